@@ -7,7 +7,7 @@ import (
 )
 
 func TestSetupEmuToken(t *testing.T) {
-	o := overflow.NewTestingEmulator().Start()
+	o := newTestingEmulator()
 	testSetupEmuToken(o, t, "user1")
 }
 
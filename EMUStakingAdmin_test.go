@@ -11,7 +11,7 @@ import (
 
 // Test Create New Farm
 func TestCreateNewFarm(t *testing.T) {
-	o := overflow.NewTestingEmulator().Start()
+	o := newTestingEmulator()
 	setupFUSDVaultWithBalance(o, "account", 1000.0)
 
 	flowAmount := 100.0
@@ -72,7 +72,7 @@ func testCreateNewFarm(o *overflow.Overflow, t *testing.T, farmID uint64) {
 
 // Test Create Reward Pool
 func TestCreateRewardPool(t *testing.T) {
-	o := overflow.NewTestingEmulator().Start()
+	o := newTestingEmulator()
 	setupFUSDVaultWithBalance(o, "account", 1000.0)
 
 	vaultIdentifier := "flowTokenVault"
@@ -113,7 +113,7 @@ func testCreateRewardPool(o *overflow.Overflow, t *testing.T,
 
 // Test update mocktimestamp
 func TestUpdateMockTimestamp(t *testing.T) {
-	o := overflow.NewTestingEmulator().Start()
+	o := newTestingEmulator()
 	setupFUSDVaultWithBalance(o, "account", 1000.0)
 	updateMockTimestamp(o, t, 100.0)
 }
@@ -128,7 +128,7 @@ func updateMockTimestamp(o *overflow.Overflow, t *testing.T, delta float64) {
 }
 
 func TestToggleMockTime(t *testing.T) {
-	o := overflow.NewTestingEmulator().Start()
+	o := newTestingEmulator()
 	setupFUSDVaultWithBalance(o, "account", 1000.0)
 	toggleMockTime(o, t)
 	updateMockTimestamp(o, t, 100.0)
@@ -8,12 +8,13 @@ import (
 
 	"github.com/bjartek/overflow/overflow"
 	"github.com/stretchr/testify/assert"
+	"swap.emudao.org/test-overflow/emuswap"
 )
 
 // j00lz todo: update tests to work with multiple accounts.
 
 func TestAddLiquidityAndStake(t *testing.T) {
-	o := overflow.NewTestingEmulator().Start()
+	o := newTestingEmulator()
 	setupFUSDVaultWithBalance(o, "account", 1000.0)
 
 	flowAmount := 100.0
@@ -107,12 +108,9 @@ func testAddLiquidityAndStake(o *overflow.Overflow, t *testing.T,
 	flowAmount float64,
 	fusdAmount float64) {
 
-	o.TransactionFromFile("/Staking/user/add_liquidity_and_stake").SignProposeAndPayAs(signer).
-		Args(o.
-			Arguments().
-			UInt64(farmID).
-			UFix64(flowAmount).
-			UFix64(fusdAmount)).
+	emuswap.NewClient(o).AddLiquidityAndStake(signer, farmID,
+		emuswap.MustLookupToken("FLOW"), emuswap.MustLookupToken("FUSD"),
+		emuswap.UFix64FromFloat(flowAmount), emuswap.UFix64FromFloat(fusdAmount)).
 		Test(t).
 		AssertSuccess().
 		AssertEmitEvent(overflow.NewTestEvent("A.f8d6e0586b0a20c7.StakingRewards.TokensStaked", map[string]interface{}{
@@ -135,7 +133,7 @@ func testAddLiquidityAndStake(o *overflow.Overflow, t *testing.T,
 }
 
 func TestStake(t *testing.T) {
-	o := overflow.NewTestingEmulator().Start()
+	o := newTestingEmulator()
 	setupFUSDVaultWithBalance(o, "account", 1000.0)
 
 	flowAmount := 100.0
@@ -279,7 +277,7 @@ func testStake(o *overflow.Overflow, t *testing.T,
 }
 
 func TestClaimRewards(t *testing.T) {
-	o := overflow.NewTestingEmulator().Start()
+	o := newTestingEmulator()
 	setupFUSDVaultWithBalance(o, "account", 1000.0)
 
 	flowAmount := 100.0
@@ -389,7 +387,7 @@ func roundFloat(x float64) float64 {
 }
 
 func TestStory2EqualStakesShareEqualRewards(t *testing.T) {
-	o := overflow.NewTestingEmulator().Start()
+	o := newTestingEmulator()
 	testSetupEmuToken(o, t, "user1")
 	setupFUSDVaultWithBalance(o, "account", 1000.0)
 	setupFUSDVaultWithBalance(o, "user1", 1000.0)
@@ -434,8 +432,8 @@ func TestStory2EqualStakesShareEqualRewards(t *testing.T) {
 
 	publicReceiver := "emuTokenReceiver"
 	storagePath := "emuTokenVault"
-	// testAddRewardReceiver(o, t, "user1", farmID, publicReceiver, storagePath)
-	testAddRewardReceiver(o, t, "user1", farmID, publicReceiver, storagePath)
+	// testAddRewardReceiver(o, t, "user1", farmID, 0, publicReceiver, storagePath)
+	testAddRewardReceiver(o, t, "user1", farmID, 0, publicReceiver, storagePath)
 
 	user1Claimed := claimRewards(o, t, "account", farmID)
 	user2Claimed := claimRewards(o, t, "user1", farmID)
@@ -446,7 +444,7 @@ func TestStory2EqualStakesShareEqualRewards(t *testing.T) {
 }
 
 func TestAddRewardReceiver(t *testing.T) {
-	o := overflow.NewTestingEmulator().Start()
+	o := newTestingEmulator()
 
 	setupFUSDVaultWithBalance(o, "account", 100.0)
 	testCreateSwapPool(o, t, "flowTokenVault", 100.0, "fusdVault", 100.0)
@@ -458,19 +456,21 @@ func TestAddRewardReceiver(t *testing.T) {
 
 	testCreateNewFarm(o, t, 0)
 	testFirstStake(o, t, signer, farmID, 1.0)
-	testAddRewardReceiver(o, t, signer, farmID, publicReceiver, storagePath)
+	testAddRewardReceiver(o, t, signer, farmID, 0, publicReceiver, storagePath)
 }
 
 func testAddRewardReceiver(o *overflow.Overflow, t *testing.T,
 	signer string,
 
 	farmID uint64,
+	rewardPoolID uint64,
 	publicReceiver string,
 	storagePath string) {
 	o.TransactionFromFile("Staking/user/add_reward_receiver").SignProposeAndPayAs(signer).
 		Args(o.
 			Arguments().
 			UInt64(farmID).
+			UInt64(rewardPoolID).
 			String(publicReceiver).
 			String(storagePath)).
 		Test(t).
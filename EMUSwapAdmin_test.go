@@ -9,7 +9,7 @@ import (
 )
 
 func TestCreateNewPoolFlowFusd(t *testing.T) {
-	o := overflow.NewTestingEmulator().Start()
+	o := newTestingEmulator()
 
 	mintFlowTokens(o, "account", 1000.0)
 	mintFlowTokens(o, "user1", 1000.0)
@@ -79,7 +79,7 @@ func TestCreateNewPoolFlowFusd(t *testing.T) {
 }
 
 func TestCreateNewPoolEmuFusd(t *testing.T) {
-	o := overflow.NewTestingEmulator().Start()
+	o := newTestingEmulator()
 
 	mintFlowTokens(o, "account", 1000.0)
 	mintFlowTokens(o, "user1", 1000.0)
@@ -147,7 +147,7 @@ func TestCreateNewPoolEmuFusd(t *testing.T) {
 }
 
 func TestCreateNewPool(t *testing.T) {
-	o := overflow.NewTestingEmulator().Start()
+	o := newTestingEmulator()
 
 	mintFlowTokens(o, "account", 1000.0)
 	mintFlowTokens(o, "user1", 1000.0)
@@ -202,7 +202,7 @@ func testCreateSwapPool(o *overflow.Overflow, t *testing.T, token1identifier str
 /*
 
 func TestTogglePoolFreeze(t *testing.T) {
-	o := overflow.NewTestingEmulator().Start()
+	o := newTestingEmulator()
 
 	mintFlowTokens(o, "account", 1000.0)
 	mintFlowTokens(o, "user1", 1000.0)
@@ -236,7 +236,7 @@ func TestTogglePoolFreeze(t *testing.T) {
 */
 
 func TestTogglePoolFreeze(t *testing.T) {
-	o := overflow.NewTestingEmulator().Start()
+	o := newTestingEmulator()
 	setupFUSDVaultWithBalance(o, "account", 1000.0)
 
 	flowAmount := 100.0
@@ -262,7 +262,7 @@ func testTogglePoolFreeze(o *overflow.Overflow, t *testing.T, poolID uint64) {
 }
 
 func TestWithdrawFees(t *testing.T) {
-	o := overflow.NewTestingEmulator().Start()
+	o := newTestingEmulator()
 	testSetupEmuToken(o, t, "user1")
 
 	mintFlowTokens(o, "account", 1000.0)
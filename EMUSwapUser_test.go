@@ -9,7 +9,7 @@ import (
 )
 
 func TestAddLiquidity(t *testing.T) {
-	o := overflow.NewTestingEmulator().Start()
+	o := newTestingEmulator()
 
 	mintFlowTokens(o, "account", 1000.0)
 	mintFlowTokens(o, "user1", 1000.0)
@@ -89,7 +89,7 @@ func addLiquidity(
 }
 
 func TestRemoveLiquidity(t *testing.T) {
-	o := overflow.NewTestingEmulator().Start()
+	o := newTestingEmulator()
 
 	mintFlowTokens(o, "account", 1000.0)
 	mintFlowTokens(o, "user1", 1000.0)
@@ -202,7 +202,7 @@ func removeLiquidity(
 }
 
 func TestSwap(t *testing.T) {
-	o := overflow.NewTestingEmulator().Start()
+	o := newTestingEmulator()
 
 	mintFlowTokens(o, "account", 1000.0)
 	mintFlowTokens(o, "user1", 1000.0)
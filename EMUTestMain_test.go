@@ -0,0 +1,23 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/bjartek/overflow/overflow"
+	"swap.emudao.org/test-overflow/internal/emutest"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(emutest.Main(m))
+}
+
+// newTestingEmulator starts the in-memory emulator with the contracts
+// deployed, recording the Cadence it executes when coverage.EnvDir is set.
+func newTestingEmulator() *overflow.Overflow {
+	o, err := emutest.Start()
+	if err != nil {
+		panic(err)
+	}
+	return o
+}
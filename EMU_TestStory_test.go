@@ -4,13 +4,12 @@ import (
 	"fmt"
 	"testing"
 
-	"github.com/bjartek/overflow/overflow"
 	"github.com/stretchr/testify/assert"
 )
 
 // This story creates 3 pools.... does a bunch of swaps and then swaps all the collected fees for EmuTokens and sends them to the xEmuToken contract
 func TestStory(t *testing.T) {
-	o := overflow.NewTestingEmulator().Start()
+	o := newTestingEmulator()
 	testSetupEmuToken(o, t, "user1")
 	setupFUSDVaultWithBalance(o, "account", 1000.0)
 	setupFUSDVaultWithBalance(o, "user1", 1000.0)
@@ -45,7 +44,7 @@ func TestStory(t *testing.T) {
 }
 
 func TestStory1(t *testing.T) {
-	o := overflow.NewTestingEmulator().Start()
+	o := newTestingEmulator()
 	testSetupEmuToken(o, t, "user1")
 	setupFUSDVaultWithBalance(o, "account", 1000.0)
 	setupFUSDVaultWithBalance(o, "user1", 1000.0)
@@ -91,8 +90,8 @@ func TestStory1(t *testing.T) {
 
 	publicReceiver := "emuTokenReceiver"
 	storagePath := "emuTokenVault"
-	// testAddRewardReceiver(o, t, "user1", farmID, publicReceiver, storagePath)
-	testAddRewardReceiver(o, t, "user1", farmID, publicReceiver, storagePath)
+	// testAddRewardReceiver(o, t, "user1", farmID, 0, publicReceiver, storagePath)
+	testAddRewardReceiver(o, t, "user1", farmID, 0, publicReceiver, storagePath)
 
 	user1Claimed := claimRewards(o, t, "account", farmID)
 	user2Claimed := claimRewards(o, t, "user1", farmID)
@@ -7,7 +7,7 @@ import (
 )
 
 func TestSetupXEmuToken(t *testing.T) {
-	o := overflow.NewTestingEmulator().Start()
+	o := newTestingEmulator()
 	testSetupXEmuToken(o, t, "user1")
 }
 
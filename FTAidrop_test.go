@@ -9,7 +9,7 @@ import (
 )
 
 func TestSetupFTAirDrop(t *testing.T) {
-	o := overflow.NewTestingEmulator().Start()
+	o := newTestingEmulator()
 	mintFlowTokens(o, "user1", 100000.0)
 	testSetupFTAirDrop(o, t, "user1")
 	testSetupFTAirDrop(o, t, "user1")
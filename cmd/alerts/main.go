@@ -0,0 +1,74 @@
+// Command alerts follows the chain and POSTs the alerts of the rules in a
+// config file to their webhooks:
+//
+//	go run ./cmd/alerts -config alerts.json
+//	go run ./cmd/alerts -network mainnet -config alerts.json -from 31000000 -dead-letters dead.jsonl
+//	go run ./cmd/alerts -config alerts.json -follow 0
+//
+// Alerts a webhook does not accept after -attempts tries are appended to
+// -dead-letters. -follow 0 syncs once and exits.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/flow-cli/pkg/flowkit/output"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/alerts"
+)
+
+func main() {
+	network := flag.String("network", "emulator", "flow.json network to read from")
+	configPath := flag.String("config", "alerts.json", "rules and webhooks")
+	deadLetters := flag.String("dead-letters", "alerts-dead.jsonl", "file the undelivered alerts are appended to")
+	from := flag.Uint64("from", 0, "first block height, default the latest")
+	follow := flag.Duration("follow", 10*time.Second, "sync interval, 0 syncs once")
+	attempts := flag.Int("attempts", alerts.DefaultOptions.Attempts, "tries per delivery")
+	flag.Parse()
+
+	if err := run(*network, *configPath, *deadLetters, *from, *follow, *attempts); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(network, configPath, deadLetters string, from uint64, follow time.Duration, attempts int) error {
+	config, err := alerts.LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	dead, err := os.OpenFile(deadLetters, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer dead.Close()
+	options := alerts.DefaultOptions
+	options.Attempts = attempts
+	options.DeadLetters = dead
+
+	o, err := overflow.NewOverflowBuilder(network, false, output.NoneLog).ExistingEmulator().StartE()
+	if err != nil {
+		return err
+	}
+	stream, err := alerts.NewStream(emuswap.NewClient(o), config, alerts.NewDispatcher(config, options), from)
+	if err != nil {
+		return err
+	}
+	for {
+		stats, err := stream.Sync()
+		if err != nil {
+			return err
+		}
+		if stats.Alerts > 0 || follow == 0 {
+			fmt.Printf("%d alerts, %d delivered, %d dead lettered, %d duplicates\n", stats.Alerts, stats.Delivered, stats.DeadLettered, stats.Duplicates)
+		}
+		if follow == 0 {
+			return nil
+		}
+		time.Sleep(follow)
+	}
+}
@@ -0,0 +1,35 @@
+// Command api serves the analytics of emuswap/api over HTTP:
+//
+//	go run ./cmd/api -addr localhost:8080
+//	curl 'localhost:8080/pools/0/depth?impact=0.01,0.05&liquidity=1'
+//	curl 'localhost:8080/accounts/f8d6e0586b0a20c7/portfolio?quote=FLOW'
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/flow-cli/pkg/flowkit/output"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/api"
+)
+
+func main() {
+	network := flag.String("network", "emulator", "flow.json network to read from")
+	addr := flag.String("addr", "localhost:8080", "address to listen on")
+	flag.Parse()
+
+	o, err := overflow.NewOverflowBuilder(*network, false, output.NoneLog).ExistingEmulator().StartE()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "serving %s on %s\n", *network, *addr)
+	if err := http.ListenAndServe(*addr, api.NewServer(emuswap.NewClient(o))); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
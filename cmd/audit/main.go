@@ -0,0 +1,100 @@
+// Command audit appends the admin actions on the EmuSwap contracts to a hash
+// chained log, or verifies one:
+//
+//	go run ./cmd/audit -log audit.jsonl
+//	go run ./cmd/audit -log audit.jsonl -follow 10s
+//	go run ./cmd/audit -log audit.jsonl -verify -head 3f1c...
+//
+// A new log starts at -from; an existing log is verified and resumed after its
+// last entry. -verify prints the number of entries and the head hash, and
+// exits with 2 when the log was tampered with or its head is not -head.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/flow-cli/pkg/flowkit/output"
+	"github.com/onflow/flow-go-sdk"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/audit"
+)
+
+func main() {
+	network := flag.String("network", "emulator", "flow.json network to read from")
+	path := flag.String("log", "audit.jsonl", "log file")
+	from := flag.Uint64("from", 0, "first block height of a new log")
+	admins := flag.String("admins", "", "comma separated admin addresses, default the contract accounts")
+	follow := flag.Duration("follow", 0, "keep syncing at this interval")
+	verify := flag.Bool("verify", false, "verify the log instead of appending to it")
+	head := flag.String("head", "", "head hash the verified log must end at")
+	flag.Parse()
+
+	if *verify {
+		if err := verifyLog(*path, *head); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			if errors.Is(err, audit.ErrTampered) {
+				os.Exit(2)
+			}
+			os.Exit(1)
+		}
+		return
+	}
+	if err := run(*network, *path, *from, *admins, *follow); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func verifyLog(path, expected string) error {
+	head, err := audit.VerifyFile(path)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d entries, head %s\n", head.Entries, head.Hash)
+	if expected != "" && expected != head.Hash {
+		return fmt.Errorf("%w: head is %s, not %s", audit.ErrTampered, head.Hash, expected)
+	}
+	return nil
+}
+
+func run(network, path string, from uint64, admins string, follow time.Duration) error {
+	options := audit.Options{StartHeight: from}
+	if admins != "" {
+		for _, field := range strings.Split(admins, ",") {
+			options.Admins = append(options.Admins, flow.HexToAddress(strings.TrimSpace(field)))
+		}
+	}
+	o, err := overflow.NewOverflowBuilder(network, false, output.NoneLog).ExistingEmulator().StartE()
+	if err != nil {
+		return err
+	}
+	log, err := audit.Open(path)
+	if err != nil {
+		return err
+	}
+	defer log.Close()
+	logger, err := audit.NewLogger(emuswap.NewClient(o), log, options)
+	if err != nil {
+		return err
+	}
+	for {
+		n, err := logger.Sync()
+		if err != nil {
+			return err
+		}
+		if n > 0 || follow == 0 {
+			head := log.Head()
+			fmt.Printf("%d entries appended, %d entries, head %s\n", n, head.Entries, head.Hash)
+		}
+		if follow == 0 {
+			return nil
+		}
+		time.Sleep(follow)
+	}
+}
@@ -0,0 +1,59 @@
+// Command bindgen regenerates emuswap/bindings_gen.go from the Cadence
+// scripts and transactions, and emuswap/events/events_gen.go from the events
+// declared by the contracts. Run it with `go generate ./emuswap` or
+//
+//	go run ./cmd/bindgen -root .
+//
+// Pass -check to fail instead of writing when a generated file is out of date.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"swap.emudao.org/test-overflow/internal/bindgen"
+)
+
+func main() {
+	root := flag.String("root", ".", "repository root containing scripts/, transactions/ and contracts/")
+	check := flag.Bool("check", false, "exit non zero if the generated file differs instead of writing it")
+	flag.Parse()
+
+	targets := []struct {
+		file     string
+		generate func(string) ([]byte, error)
+	}{
+		{bindgen.BindingsFile, bindgen.Generate},
+		{bindgen.EventsFile, bindgen.GenerateEvents},
+	}
+
+	stale := false
+	for _, target := range targets {
+		generated, err := target.generate(*root)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		path := filepath.Join(*root, target.file)
+		if *check {
+			existing, err := os.ReadFile(path)
+			if err != nil || !bytes.Equal(existing, generated) {
+				fmt.Fprintf(os.Stderr, "%s is out of date, run go generate ./emuswap\n", path)
+				stale = true
+			}
+			continue
+		}
+
+		if err := os.WriteFile(path, generated, 0644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	if stale {
+		os.Exit(1)
+	}
+}
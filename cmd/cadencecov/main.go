@@ -0,0 +1,106 @@
+// Command cadencecov reports which lines and functions of the contracts the Go
+// tests execute. It runs go test on the packages given, ./... by default,
+// recording the Cadence the in-memory emulators execute, and prints the
+// coverage of every function and contract file:
+//
+//	go run ./cmd/cadencecov
+//	go run ./cmd/cadencecov -html coverage.html -min 60 . ./emuswap
+//	go run ./cmd/cadencecov -profile coverage/
+//
+// -profile reports the profiles the tests wrote to a directory when run with
+// EMUSWAP_CADENCE_COVERAGE set to it, instead of running them. It exits with
+// 1 when the tests fail and with 2 when the lines covered are below -min
+// percent.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/onflow/flow-cli/pkg/flowkit"
+	"github.com/spf13/afero"
+	"swap.emudao.org/test-overflow/emuswap/coverage"
+)
+
+func main() {
+	profileDir := flag.String("profile", "", "directory of profiles to report instead of running the tests")
+	network := flag.String("network", "emulator", "flow.json network whose deployments the tests run")
+	contracts := flag.String("contracts", "contracts/*.cdc", "contract files to report")
+	html := flag.String("html", "", "file to write an HTML report to")
+	min := flag.Float64("min", 0, "percent of lines below which to fail")
+	flag.Parse()
+
+	testErr, report, err := run(*profileDir, *network, *contracts, *html, flag.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if testErr != nil {
+		fmt.Fprintln(os.Stderr, "tests failed:", testErr)
+		os.Exit(1)
+	}
+	if report.Percent() < *min {
+		fmt.Fprintf(os.Stderr, "coverage %.1f%% is below %.1f%%\n", report.Percent(), *min)
+		os.Exit(2)
+	}
+}
+
+func run(profileDir, network, contracts, html string, packages []string) (testErr error, report coverage.Report, err error) {
+	if profileDir == "" {
+		if profileDir, err = os.MkdirTemp("", "cadencecov"); err != nil {
+			return nil, report, err
+		}
+		defer os.RemoveAll(profileDir)
+		testErr = test(profileDir, packages)
+	}
+	profile, err := coverage.ReadProfiles(profileDir)
+	if err != nil {
+		return testErr, report, err
+	}
+	state, err := flowkit.Load([]string{"flow.json"}, &afero.Afero{Fs: afero.NewOsFs()})
+	if err != nil {
+		return testErr, report, err
+	}
+	sources, err := coverage.Sources(state, network, contracts)
+	if err != nil {
+		return testErr, report, err
+	}
+	if report, err = coverage.Analyze(profile, sources); err != nil {
+		return testErr, report, err
+	}
+	if err := report.WriteText(os.Stdout); err != nil {
+		return testErr, report, err
+	}
+	if html == "" {
+		return testErr, report, nil
+	}
+	f, err := os.Create(html)
+	if err != nil {
+		return testErr, report, err
+	}
+	if err := report.WriteHTML(f); err != nil {
+		f.Close()
+		return testErr, report, err
+	}
+	return testErr, report, f.Close()
+}
+
+// test runs go test on packages with the test output on stderr, uncached as
+// cached results write no profiles.
+func test(profileDir string, packages []string) error {
+	if len(packages) == 0 {
+		packages = []string{"./..."}
+	}
+	dir, err := filepath.Abs(profileDir)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command("go", append([]string{"test", "-count=1"}, packages...)...)
+	cmd.Env = append(os.Environ(), coverage.EnvDir+"="+dir)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
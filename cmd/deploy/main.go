@@ -0,0 +1,103 @@
+// Command deploy shows which contracts of a flow.json network differ from the
+// code on chain and, with -apply, deploys them in import order and verifies
+// the pool, fee and farm state survived the upgrade:
+//
+//	go run ./cmd/deploy -network testnet -diff
+//	go run ./cmd/deploy -network testnet -apply
+//
+// The emulator network expects a running emulator with the contracts deployed.
+// -dry-run applies the changes to a snapshot of an emulator started with
+// --persist, prints the events and state changes and restores the snapshot.
+//
+// Nothing is rolled back when the verification fails after -apply. The state
+// read before the upgrade is written to the -snapshot file to act on.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/flow-cli/pkg/flowkit/output"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/deploy"
+	"swap.emudao.org/test-overflow/emuswap/simulate"
+)
+
+func main() {
+	network := flag.String("network", "emulator", "flow.json network to deploy to")
+	diff := flag.Bool("diff", false, "print the diff of every updated contract")
+	apply := flag.Bool("apply", false, "deploy the changes and verify the state afterwards")
+	dryRun := flag.Bool("dry-run", false, "apply the changes to an emulator snapshot and restore it")
+	snapshot := flag.String("snapshot", "deploy-snapshot.json", "file the state before the upgrade is written to when the verification fails")
+	flag.Parse()
+
+	o, err := overflow.NewOverflowBuilder(*network, false, output.NoneLog).ExistingEmulator().StartE()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	changes, err := deploy.Plan(o, deploy.Options{})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	pending := 0
+	for _, change := range changes {
+		fmt.Printf("%-9s %s on %s (%s)\n", change.Status, change.Name, change.Account, change.Address)
+		if change.Status != deploy.Unchanged {
+			pending++
+		}
+		if *diff && change.Status == deploy.Updated {
+			fmt.Print(change.Diff())
+		}
+	}
+	if !(*apply || *dryRun) || pending == 0 {
+		return
+	}
+
+	if *dryRun {
+		result, err := simulate.Run(emuswap.NewClient(o), simulate.DefaultOptions, func(c *emuswap.Client) error {
+			_, err := deploy.Upgrade(c.O, deploy.Options{})
+			return err
+		})
+		if err == nil {
+			err = result.Write(os.Stdout)
+		}
+		if err == nil {
+			err = result.Err
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Printf("would deploy %d contracts, state verified\n", pending)
+		return
+	}
+
+	if _, err := deploy.Upgrade(o, deploy.Options{}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		var verifyErr *deploy.VerifyError
+		if errors.As(err, &verifyErr) {
+			if err := writeSnapshot(*snapshot, verifyErr.Before); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			} else {
+				fmt.Fprintf(os.Stderr, "the contracts stay deployed, the state before the upgrade is in %s\n", *snapshot)
+			}
+		}
+		os.Exit(1)
+	}
+	fmt.Printf("deployed %d contracts, state verified\n", pending)
+}
+
+func writeSnapshot(path string, snapshot deploy.Snapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
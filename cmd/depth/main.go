@@ -0,0 +1,80 @@
+// Command depth prints how much a pool can absorb: the largest swaps within
+// each price impact, in both directions, and how they change when liquidity is
+// added or removed:
+//
+//	go run ./cmd/depth -pool 0
+//	go run ./cmd/depth -network mainnet -pool 0 -impact 0.005,0.01 -liquidity -0.5,1 -curve
+//	go run ./cmd/depth -pool 0 -json
+//
+// Impacts are measured against the price of the first unit swapped after fees,
+// with the fees the pool charges.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/flow-cli/pkg/flowkit/output"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/depth"
+)
+
+func main() {
+	network := flag.String("network", "emulator", "flow.json network to read from")
+	poolID := flag.Uint64("pool", 0, "pool to measure")
+	impacts := flag.String("impact", "0.01,0.02,0.05", "price impacts to find the largest swap for")
+	liquidity := flag.String("liquidity", "", "liquidity changes to measure the pool after, e.g. -0.5,1 to halve and double it")
+	curve := flag.Bool("curve", false, "print the price impact curves")
+	asJSON := flag.Bool("json", false, "print JSON like the API server")
+	flag.Parse()
+
+	if err := run(*network, *poolID, *impacts, *liquidity, *curve, *asJSON); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(network string, poolID uint64, impacts, liquidity string, curve, asJSON bool) error {
+	options := depth.DefaultOptions
+	var err error
+	if options.Impacts, err = parseFloats(impacts); err != nil {
+		return err
+	}
+	if liquidity != "" {
+		if options.Liquidity, err = parseFloats(liquidity); err != nil {
+			return err
+		}
+	}
+
+	o, err := overflow.NewOverflowBuilder(network, false, output.NoneLog).ExistingEmulator().StartE()
+	if err != nil {
+		return err
+	}
+	d, err := depth.Load(emuswap.NewClient(o), poolID, options)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		out := json.NewEncoder(os.Stdout)
+		out.SetIndent("", "  ")
+		return out.Encode(d)
+	}
+	return d.Write(os.Stdout, curve)
+}
+
+func parseFloats(list string) ([]float64, error) {
+	var values []float64
+	for _, field := range strings.Split(list, ",") {
+		value, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
@@ -0,0 +1,170 @@
+// Command emission designs the DecayingEmission of a new reward pool: it
+// solves for the parameters that emit -total tokens over -duration, either in
+// -epochs epochs or with a -decay per epoch, prints the emission of every
+// epoch and the arguments of create_decaying_reward_pool.cdc:
+//
+//	go run ./cmd/emission -total 9000000 -duration 1120d -epochs 40
+//	go run ./cmd/emission -total 9000000 -duration 1120d -decay 0.1 -weights 0:1,1:0.5
+//	go run ./cmd/emission -total 1000 -duration 2000 -epochs 4 -verify -json
+//
+// -verify reads the rate the contract computes for the emission on an
+// in-memory emulator, on both sides of every epoch boundary, and exits with 2
+// on a mismatch. No reward pool is created.
+//
+// Staking sets the genesis the emission is relative to for reward pool 0
+// only, other reward pools emit at the full rate.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bjartek/overflow/overflow"
+	jsoncdc "github.com/onflow/cadence/encoding/json"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/emission"
+)
+
+func main() {
+	total := flag.String("total", "", "tokens to emit")
+	duration := flag.String("duration", "", "seconds to emit them over, or a number of days, hours or minutes such as 1120d")
+	epochs := flag.Uint64("epochs", 0, "number of epochs to solve the decay for")
+	decay := flag.String("decay", "", "decay per epoch to solve the number of epochs for")
+	storage := flag.String("storage", emuswap.MustLookupToken("EMU").StoragePath, "storage identifier of the vault funding the reward pool")
+	weights := flag.String("weights", "0:1.0", "farm weights as farmID:weight pairs")
+	nfts := flag.String("nfts", "", "comma separated access NFT type identifiers")
+	verify := flag.Bool("verify", false, "check the design against the contract on an in-memory emulator")
+	asJSON := flag.Bool("json", false, "print the design as JSON")
+	flag.Parse()
+
+	mismatches, err := run(*total, *duration, *epochs, *decay, *storage, *weights, *nfts, *verify, *asJSON)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if mismatches > 0 {
+		os.Exit(2)
+	}
+}
+
+type output struct {
+	Design    emission.Design   `json:"design"`
+	Arguments []json.RawMessage `json:"arguments"`
+	Samples   []emission.Sample `json:"samples,omitempty"`
+}
+
+func run(total, duration string, epochs uint64, decay, storage, weights, nfts string, verify, asJSON bool) (int, error) {
+	target := emission.Target{Epochs: epochs}
+	var err error
+	if target.Total, err = emuswap.ParseUFix64(total); err != nil {
+		return 0, fmt.Errorf("total: %w", err)
+	}
+	if target.Duration, err = parseSeconds(duration); err != nil {
+		return 0, fmt.Errorf("duration: %w", err)
+	}
+	if decay != "" {
+		value, err := emuswap.ParseUFix64(decay)
+		if err != nil {
+			return 0, fmt.Errorf("decay: %w", err)
+		}
+		target.Decay = &value
+	}
+	farmWeights, err := parseWeights(weights)
+	if err != nil {
+		return 0, fmt.Errorf("weights: %w", err)
+	}
+	nftPaths := []string{}
+	if nfts != "" {
+		nftPaths = strings.Split(nfts, ",")
+	}
+
+	d, err := emission.Solve(target)
+	if err != nil {
+		return 0, err
+	}
+	values, err := d.Arguments(storage, farmWeights, nftPaths)
+	if err != nil {
+		return 0, err
+	}
+	out := output{Design: d}
+	for _, value := range values {
+		data, err := jsoncdc.Encode(value)
+		if err != nil {
+			return 0, err
+		}
+		out.Arguments = append(out.Arguments, json.RawMessage(strings.TrimSpace(string(data))))
+	}
+
+	if verify {
+		o, err := overflow.NewTestingEmulator().StartE()
+		if err != nil {
+			return 0, err
+		}
+		if out.Samples, err = emission.Verify(emuswap.NewClient(o), d); err != nil {
+			return 0, err
+		}
+	}
+	mismatches := emission.Mismatches(out.Samples)
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return len(mismatches), enc.Encode(out)
+	}
+	if err := d.Write(os.Stdout); err != nil {
+		return 0, err
+	}
+	args, err := json.Marshal(out.Arguments)
+	if err != nil {
+		return 0, err
+	}
+	fmt.Printf("\nflow transactions send ./%s --args-json '%s' --signer admin-account\n", emission.Transaction, args)
+	if verify {
+		fmt.Printf("\nverified %d rates against the contract, %d mismatches\n", len(out.Samples), len(mismatches))
+		for _, sample := range mismatches {
+			fmt.Printf("MISMATCH at %s seconds: contract %s, expected %s\n", sample.Elapsed, sample.Actual, sample.Expected)
+		}
+	}
+	return len(mismatches), nil
+}
+
+// parseSeconds parses seconds, or a number followed by d, h or m.
+func parseSeconds(s string) (emuswap.UFix64, error) {
+	units := map[string]float64{"d": 86400, "h": 3600, "m": 60, "s": 1}
+	for suffix, unit := range units {
+		if strings.HasSuffix(s, suffix) {
+			value, err := strconv.ParseFloat(strings.TrimSuffix(s, suffix), 64)
+			if err != nil {
+				return 0, err
+			}
+			if value < 0 {
+				return 0, errors.New("negative duration")
+			}
+			return emuswap.UFix64FromFloat(value * unit), nil
+		}
+	}
+	return emuswap.ParseUFix64(s)
+}
+
+func parseWeights(list string) (map[uint64]emuswap.UFix64, error) {
+	weights := map[uint64]emuswap.UFix64{}
+	for _, field := range strings.Split(list, ",") {
+		id, weight, ok := strings.Cut(strings.TrimSpace(field), ":")
+		if !ok {
+			return nil, fmt.Errorf("%q is not farmID:weight", field)
+		}
+		farmID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if weights[farmID], err = emuswap.ParseUFix64(weight); err != nil {
+			return nil, err
+		}
+	}
+	return weights, nil
+}
@@ -0,0 +1,159 @@
+// Command feepolicy compares alternative LP and DAO fees for a pool before
+// they are changed with updateLPFeePercentage or updateDAOFeePercentage. It
+// replays the pool's swaps between two block heights, or a generated order
+// flow with -synthetic, under every combination of -lp and -dao fees and the
+// fees the pool charges now, then prints a recommendation:
+//
+//	go run ./cmd/feepolicy -network testnet -pool 0 -from 71000000 -out report
+//	go run ./cmd/feepolicy -pool 0 -synthetic 500 -elasticity 0,0.5,1,2 -expect 1
+//
+// The flow is replayed from the current reserves of the pool. -out receives
+// scenarios.csv, one row per fee setting and elasticity, and revenue.csv,
+// revenue against fee with one column per elasticity.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/flow-cli/pkg/flowkit/output"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/feepolicy"
+)
+
+func main() {
+	network := flag.String("network", "emulator", "flow.json network to read from")
+	poolID := flag.Uint64("pool", 0, "pool to analyse")
+	from := flag.Uint64("from", 0, "first block height to replay swaps from")
+	to := flag.Uint64("to", 0, "last block height to replay swaps from, 0 for the latest block")
+	synthetic := flag.Int("synthetic", 0, "generate this many trades instead of replaying swaps")
+	seed := flag.Int64("seed", 1, "seed of the synthetic order flow")
+	maxFraction := flag.Float64("max-fraction", 0.05, "largest synthetic trade as a fraction of the input reserve")
+	lpFees := flag.String("lp", "0.001,0.002,0.003,0.005,0.01", "candidate LP fee percentages")
+	daoFees := flag.String("dao", "0.0,0.0005,0.001,0.002", "candidate DAO fee percentages")
+	elasticities := flag.String("elasticity", "0,0.5,1,2", "volume elasticities to analyse")
+	expect := flag.Float64("expect", 1, "elasticity the recommendation is based on")
+	out := flag.String("out", "", "directory to write the CSV files to")
+	flag.Parse()
+
+	if err := run(*network, *poolID, *from, *to, *synthetic, *seed, *maxFraction, *lpFees, *daoFees, *elasticities, *expect, *out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(network string, poolID, from, to uint64, synthetic int, seed int64, maxFraction float64, lpFees, daoFees, elasticityList string, expect float64, out string) error {
+	o, err := overflow.NewOverflowBuilder(network, false, output.NoneLog).ExistingEmulator().StartE()
+	if err != nil {
+		return err
+	}
+	c := emuswap.NewClient(o)
+
+	pool, err := c.LoadPool(poolID)
+	if err != nil {
+		return err
+	}
+	// the fees the pool charges, which an admin can set apart from the
+	// contract wide ones
+	baseline := feepolicy.Fees{LP: pool.LPFeePercentage, DAO: pool.DAOFeePercentage}
+
+	var trades []feepolicy.Trade
+	if synthetic > 0 {
+		trades = feepolicy.Synthetic(pool, synthetic, maxFraction, seed)
+	} else {
+		if to == 0 {
+			block, err := o.GetLatestBlock()
+			if err != nil {
+				return err
+			}
+			to = block.Height
+		}
+		history, err := feepolicy.FetchTrades(c, poolID, baseline, from, to)
+		if err != nil {
+			return err
+		}
+		if history.Unattributed > 0 {
+			fmt.Fprintf(os.Stderr, "skipped %d Trade events whose pool could not be told\n", history.Unattributed)
+		}
+		trades = history.Trades
+	}
+	if len(trades) == 0 {
+		return fmt.Errorf("no trades for pool %d", poolID)
+	}
+
+	lps, err := parseUFix64s(lpFees)
+	if err != nil {
+		return err
+	}
+	daos, err := parseUFix64s(daoFees)
+	if err != nil {
+		return err
+	}
+	var candidates []feepolicy.Fees
+	for _, lp := range lps {
+		for _, dao := range daos {
+			candidates = append(candidates, feepolicy.Fees{LP: lp, DAO: dao})
+		}
+	}
+	var es []float64
+	for _, field := range strings.Split(elasticityList, ",") {
+		e, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return fmt.Errorf("elasticity %q: %w", field, err)
+		}
+		es = append(es, e)
+	}
+
+	analysis, err := feepolicy.Analyze(pool, trades, baseline, candidates, es)
+	if err != nil {
+		return err
+	}
+	recommendation, err := analysis.Recommend(expect)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("pool %d, %d trades, baseline %s\n", poolID, len(trades), baseline)
+	fmt.Println(recommendation)
+
+	if out == "" {
+		return nil
+	}
+	if err := os.MkdirAll(out, 0755); err != nil {
+		return err
+	}
+	for name, write := range map[string]func(*os.File) error{
+		"scenarios.csv": func(f *os.File) error { return analysis.WriteScenarios(f) },
+		"revenue.csv":   func(f *os.File) error { return analysis.WriteRevenue(f) },
+	} {
+		f, err := os.Create(filepath.Join(out, name))
+		if err != nil {
+			return err
+		}
+		err = write(f)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseUFix64s(list string) ([]emuswap.UFix64, error) {
+	var values []emuswap.UFix64
+	for _, field := range strings.Split(list, ",") {
+		value, err := emuswap.ParseUFix64(strings.TrimSpace(field))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
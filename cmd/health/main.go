@@ -0,0 +1,84 @@
+// Command health checks that the state of every EmuSwap pool adds up: the
+// reserves and LP supply in PoolMeta, the routes to the pool, and the LP
+// tokens held by collections and staked in farms against the supply.
+//
+//	go run ./cmd/health -network mainnet -history=false -holders 0x01cf0e2f2f715450
+//	go run ./cmd/health -complete -strict -json
+//
+// -history scans the LP token events from -from to -to for holders and for
+// supply changed without TokensMinted or TokensBurned events; -from must be at
+// or before the deployment of EmuSwap. It exits with 2 when there are errors,
+// or warnings as well with -strict.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/flow-cli/pkg/flowkit/output"
+	"github.com/onflow/flow-go-sdk"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/health"
+)
+
+func main() {
+	network := flag.String("network", "emulator", "flow.json network to read from")
+	holders := flag.String("holders", "", "comma separated addresses whose LP balances are read")
+	complete := flag.Bool("complete", false, "the holders hold all LP tokens, report supply nobody holds")
+	dust := flag.String("dust", health.DefaultDust.String(), "reserve and LP supply below which a pool is reported")
+	history := flag.Bool("history", true, "scan the LP token events for holders and supply drift")
+	from := flag.Uint64("from", 0, "first block height to scan")
+	to := flag.Uint64("to", 0, "last block height to scan, 0 for the latest block")
+	strict := flag.Bool("strict", false, "fail on warnings too")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	flag.Parse()
+
+	report, err := run(*network, *holders, *complete, *dust, *history, *from, *to, *asJSON)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if report.Failed(*strict) {
+		os.Exit(2)
+	}
+}
+
+func run(network, holders string, complete bool, dust string, history bool, from, to uint64, asJSON bool) (health.Report, error) {
+	options := health.Options{Complete: complete, History: history, StartHeight: from, EndHeight: to}
+	var err error
+	if options.Dust, err = emuswap.ParseUFix64(dust); err != nil {
+		return health.Report{}, fmt.Errorf("dust: %w", err)
+	}
+	if holders != "" {
+		for _, field := range strings.Split(holders, ",") {
+			options.Holders = append(options.Holders, flow.HexToAddress(strings.TrimSpace(field)))
+		}
+	}
+	o, err := overflow.NewOverflowBuilder(network, false, output.NoneLog).ExistingEmulator().StartE()
+	if err != nil {
+		return health.Report{}, err
+	}
+	c := emuswap.NewClient(o)
+
+	if history && to == 0 {
+		block, err := o.GetLatestBlock()
+		if err != nil {
+			return health.Report{}, err
+		}
+		options.EndHeight = block.Height
+	}
+	report, err := health.Check(c, options)
+	if err != nil {
+		return report, err
+	}
+	if asJSON {
+		out := json.NewEncoder(os.Stdout)
+		out.SetIndent("", "  ")
+		return report, out.Encode(report)
+	}
+	return report, report.Write(os.Stdout)
+}
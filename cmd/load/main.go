@@ -0,0 +1,107 @@
+// Command load provisions accounts on a running emulator and has them all
+// swap, add and remove liquidity, stake, unstake and claim on one pool at
+// once, then prints throughput, latency percentiles, failure reasons and the
+// invariants checked on the pool and its farm:
+//
+//	go run ./cmd/load -accounts 50 -transactions 40
+//	go run ./cmd/load -accounts 20 -duration 1m -transactions 0 -mix swap:80,claim:20
+//	go run ./cmd/load -pair EMU/FUSD -json
+//
+// The exit status is 1 when an invariant does not hold. With -dry-run the load
+// runs on a snapshot of an emulator started with --persist, which is restored
+// after; the report is followed by the events and balance changes.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/flow-cli/pkg/flowkit/output"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/load"
+	"swap.emudao.org/test-overflow/emuswap/simulate"
+)
+
+func main() {
+	options := load.DefaultOptions
+	network := flag.String("network", "emulator", "flow.json network to load")
+	flag.IntVar(&options.Accounts.Count, "accounts", options.Accounts.Count, "number of accounts sending at once")
+	flag.StringVar(&options.Accounts.Name, "name", options.Accounts.Name, "account name format")
+	flag.StringVar(&options.Accounts.Seed, "seed", options.Accounts.Seed, "account key seed format, at least 32 bytes")
+	pair := flag.String("pair", options.Token1+"/"+options.Token2, "token symbols of the pool")
+	mix := flag.String("mix", "", "op weights, e.g. swap:50,addLiquidity:15,removeLiquidity:10,stake:10,unstake:5,claim:10")
+	flag.IntVar(&options.Transactions, "transactions", options.Transactions, "transactions per account, 0 for no limit")
+	flag.DurationVar(&options.Duration, "duration", 0, "longest the load runs, 0 for no limit")
+	flag.IntVar(&options.Retries, "retries", options.Retries, "times a transaction with an expired reference block is built again")
+	flag.Int64Var(&options.Seed, "rand", options.Seed, "seed of the random ops and amounts")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	dryRun := flag.Bool("dry-run", false, "print what the load would change and restore the emulator")
+	flag.Parse()
+
+	broken, err := run(*network, options, *pair, *mix, *asJSON, *dryRun)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if broken {
+		os.Exit(1)
+	}
+}
+
+func run(network string, options load.Options, pair, mix string, asJSON, dryRun bool) (bool, error) {
+	var ok bool
+	if options.Token1, options.Token2, ok = strings.Cut(pair, "/"); !ok {
+		return false, fmt.Errorf("pair %q is not TOKEN1/TOKEN2", pair)
+	}
+	if mix != "" {
+		var err error
+		if options.Mix, err = load.ParseMix(mix); err != nil {
+			return false, err
+		}
+	}
+	o, err := overflow.NewOverflowBuilder(network, false, output.NoneLog).ExistingEmulator().StartE()
+	if err != nil {
+		return false, err
+	}
+	c := emuswap.NewClient(o)
+	if dryRun {
+		var report load.Report
+		result, err := simulate.Run(c, simulate.DefaultOptions, func(c *emuswap.Client) error {
+			var err error
+			report, err = load.Run(c, options)
+			return err
+		})
+		if err != nil {
+			return false, err
+		}
+		if result.Err != nil {
+			return false, result.Err
+		}
+		if asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return report.Broken(), encoder.Encode(struct {
+				Report load.Report     `json:"report"`
+				DryRun simulate.Result `json:"dryRun"`
+			}{report, result})
+		}
+		if err := report.Write(os.Stdout); err != nil {
+			return false, err
+		}
+		return report.Broken(), result.Write(os.Stdout)
+	}
+	report, err := load.Run(c, options)
+	if err != nil {
+		return false, err
+	}
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return report.Broken(), encoder.Encode(report)
+	}
+	return report.Broken(), report.Write(os.Stdout)
+}
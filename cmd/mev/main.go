@@ -0,0 +1,80 @@
+// Command mev scans the swaps between two block heights for sandwiches: a
+// trader swapping before and after another trader's swap in the same pool.
+// It prints every victim with the loss estimated on the pool model and an
+// alert line on stderr for each victim losing at least -min-loss:
+//
+//	go run ./cmd/mev -network mainnet -from 71000000 -to 71010000
+//	go run ./cmd/mev -window 3 -min-loss 0.5
+//
+// -window is the number of blocks a sandwich may span after its front run;
+// the emulator seals one transaction per block, so use a few blocks there.
+// It exits with 2 when there are alerts.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/flow-cli/pkg/flowkit/output"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/feepolicy"
+	"swap.emudao.org/test-overflow/emuswap/mev"
+)
+
+func main() {
+	network := flag.String("network", "emulator", "flow.json network to read from")
+	from := flag.Uint64("from", 0, "first block height to scan")
+	to := flag.Uint64("to", 0, "last block height to scan, 0 for the latest block")
+	window := flag.Uint64("window", 0, "blocks a sandwich may span after its front run")
+	minLoss := flag.String("min-loss", "0.0", "smallest victim loss to alert on, in the victim's output token")
+	flag.Parse()
+
+	alerts, err := run(*network, *from, *to, *window, *minLoss)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if alerts > 0 {
+		os.Exit(2)
+	}
+}
+
+func run(network string, from, to, window uint64, minLoss string) (int, error) {
+	threshold, err := emuswap.ParseUFix64(minLoss)
+	if err != nil {
+		return 0, fmt.Errorf("min-loss: %w", err)
+	}
+	o, err := overflow.NewOverflowBuilder(network, false, output.NoneLog).ExistingEmulator().StartE()
+	if err != nil {
+		return 0, err
+	}
+	c := emuswap.NewClient(o)
+
+	fees, err := feepolicy.BaselineFees(c)
+	if err != nil {
+		return 0, err
+	}
+	if to == 0 {
+		block, err := o.GetLatestBlock()
+		if err != nil {
+			return 0, err
+		}
+		to = block.Height
+	}
+	index, err := mev.FetchSwaps(c, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	report := mev.Scan(index, window, fees)
+	if err := report.Write(os.Stdout); err != nil {
+		return 0, err
+	}
+	alerts := report.Alerts(threshold)
+	for _, alert := range alerts {
+		fmt.Fprintln(os.Stderr, "ALERT", alert)
+	}
+	return len(alerts), nil
+}
@@ -0,0 +1,189 @@
+// Command multisig proposes, co-signs and executes admin operations through
+// the EmuMultiSig signer of a flow.json network, and lists the proposals
+// waiting for signatures:
+//
+//	go run ./cmd/multisig -network testnet list
+//	go run ./cmd/multisig -network testnet -key $KEY propose updateLPFeePercentage 0 0.005
+//	go run ./cmd/multisig -network testnet -key $KEY sign 4
+//	go run ./cmd/multisig -network testnet execute 4
+//	go run ./cmd/multisig -network testnet remove 4
+//
+// -key is the hex encoded private key of one of the multisig keys, read from
+// EMUSWAP_MULTISIG_KEY when not set. Transactions are sent by -account, any
+// account can send them. propose without a method lists the methods and
+// their arguments, its payload expires -blocks blocks after the latest. sign
+// signs the payload as proposed, printed before. remove clears an expired
+// proposal.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/flow-cli/pkg/flowkit/output"
+	"github.com/onflow/flow-go-sdk/crypto"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/multisig"
+)
+
+func main() {
+	network := flag.String("network", "emulator", "flow.json network of the multisig")
+	account := flag.String("account", "account", "flow.json account sending the transactions")
+	key := flag.String("key", os.Getenv("EMUSWAP_MULTISIG_KEY"), "hex encoded private key of a multisig key")
+	algorithm := flag.String("key-algorithm", crypto.ECDSA_P256.String(), "signature algorithm of -key")
+	blocks := flag.Uint64("blocks", 1000, "blocks a proposal can be signed and executed in")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: multisig [flags] list | propose method args... | sign txIndex | execute txIndex | remove txIndex")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	o, err := overflow.NewOverflowBuilder(*network, false, output.NoneLog).ExistingEmulator().StartE()
+	if err == nil {
+		// computing the public keys fills in the points crypto/ecdsa needs to sign
+		for _, account := range *o.State.Accounts() {
+			if k, err := account.Key().PrivateKey(); err == nil {
+				(*k).PublicKey()
+			}
+		}
+		err = run(emuswap.NewClient(o), *account, *key, *algorithm, *blocks, flag.Args())
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(c *emuswap.Client, account, key, algorithm string, blocks uint64, args []string) error {
+	command, args := args[0], args[1:]
+	switch command {
+	case "list":
+		return list(c)
+	case "propose":
+		if len(args) == 0 {
+			fmt.Println(strings.Join(multisig.Methods(), "\n"))
+			return nil
+		}
+		k, err := parseKey(key, algorithm)
+		if err != nil {
+			return err
+		}
+		action, err := multisig.ParseAction(args[0], args[1:])
+		if err != nil {
+			return err
+		}
+		payload, err := multisig.NextPayload(c, action, blocks)
+		if err != nil {
+			return err
+		}
+		tx, err := multisig.Propose(c, account, k, payload)
+		if err != nil {
+			return err
+		}
+		if err := submit(c, tx); err != nil {
+			return err
+		}
+		fmt.Printf("proposed txIndex %d, expires after block %d\n", payload.TxIndex, payload.Expiry)
+		return nil
+	}
+
+	if len(args) != 1 {
+		return fmt.Errorf("%s takes the txIndex", command)
+	}
+	txIndex, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("txIndex: %w", err)
+	}
+	switch command {
+	case "sign":
+		k, err := parseKey(key, algorithm)
+		if err != nil {
+			return err
+		}
+		payload, err := pendingPayload(c, txIndex)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("signing txIndex %d: %s%v, expires after block %d\n", payload.TxIndex, payload.Action.Method, payload.Action.Args, payload.Expiry)
+		tx, err := multisig.CoSign(c, account, k, payload)
+		if err != nil {
+			return err
+		}
+		return submit(c, tx)
+	case "execute":
+		return submit(c, multisig.Execute(c, account, txIndex))
+	case "remove":
+		return submit(c, c.MultiSigRemoveExpired(account, txIndex))
+	}
+	return fmt.Errorf("unknown command %q", command)
+}
+
+func parseKey(key, algorithm string) (multisig.Key, error) {
+	if key == "" {
+		return multisig.Key{}, errors.New("set -key or EMUSWAP_MULTISIG_KEY")
+	}
+	privateKey, err := crypto.DecodePrivateKeyHex(crypto.StringToSignatureAlgorithm(algorithm), strings.TrimPrefix(key, "0x"))
+	if err != nil {
+		return multisig.Key{}, fmt.Errorf("key: %w", err)
+	}
+	return multisig.Key{PrivateKey: privateKey}, nil
+}
+
+func pendingPayload(c *emuswap.Client, txIndex uint64) (multisig.Payload, error) {
+	pending, err := multisig.Pending(c)
+	if err != nil {
+		return multisig.Payload{}, err
+	}
+	for _, proposal := range pending {
+		if proposal.TxIndex == txIndex {
+			return multisig.PayloadOf(proposal)
+		}
+	}
+	return multisig.Payload{}, fmt.Errorf("no pending proposal %d", txIndex)
+}
+
+func submit(c *emuswap.Client, tx overflow.FlowTransactionBuilder) error {
+	result := c.Submit(tx)
+	if result.Err != nil {
+		return result.Err
+	}
+	fmt.Printf("sent %s in transaction %s\n", tx.FileName, result.Id)
+	return nil
+}
+
+func list(c *emuswap.Client) error {
+	pending, err := multisig.Pending(c)
+	if err != nil {
+		return err
+	}
+	height, err := c.MultiSigGetBlockHeight()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("block height %d\n\n", height)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "txIndex\tmethod\targuments\texpiry\tsignatures")
+	for _, proposal := range pending {
+		payload, err := multisig.PayloadOf(proposal)
+		if err != nil {
+			return err
+		}
+		expiry := strconv.FormatUint(proposal.Expiry, 10)
+		// the next transaction lands in a later block than height
+		if proposal.Expiry <= height {
+			expiry += " (expired)"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%v\t%s\t%d\n", proposal.TxIndex, proposal.Method, payload.Action.Args, expiry, len(proposal.Signers))
+	}
+	return tw.Flush()
+}
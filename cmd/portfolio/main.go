@@ -0,0 +1,73 @@
+// Command portfolio prints what an address holds across EmuSwap, valued in a
+// quote token at the pool prices:
+//
+//	go run ./cmd/portfolio -address account
+//	go run ./cmd/portfolio -network mainnet -address 0x1234567890abcdef -quote FLOW
+//	go run ./cmd/portfolio -address user1 -json
+//
+// -address is an account of flow.json or a hex address. The JSON is the schema
+// served by cmd/api.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/flow-cli/pkg/flowkit/output"
+	"github.com/onflow/flow-go-sdk"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/portfolio"
+)
+
+func main() {
+	network := flag.String("network", "emulator", "flow.json network to read from")
+	address := flag.String("address", "account", "flow.json account name or hex address to read")
+	quote := flag.String("quote", "FUSD", "symbol of the token to value the portfolio in")
+	asJSON := flag.Bool("json", false, "print JSON like the API server")
+	flag.Parse()
+
+	if err := run(*network, *address, *quote, *asJSON); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(network, address, quote string, asJSON bool) error {
+	o, err := overflow.NewOverflowBuilder(network, false, output.NoneLog).ExistingEmulator().StartE()
+	if err != nil {
+		return err
+	}
+	holder, err := resolve(o, address)
+	if err != nil {
+		return err
+	}
+	p, err := portfolio.Load(emuswap.NewClient(o), holder, quote)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		out := json.NewEncoder(os.Stdout)
+		out.SetIndent("", "  ")
+		return out.Encode(p)
+	}
+	return p.Write(os.Stdout)
+}
+
+// resolve looks address up in the flow.json accounts before reading it as hex.
+func resolve(o *overflow.Overflow, address string) (flow.Address, error) {
+	name := address
+	if o.PrependNetworkToAccountNames {
+		name = o.Network + "-" + address
+	}
+	if account, err := o.State.Accounts().ByName(name); err == nil {
+		return account.Address(), nil
+	}
+	holder := flow.HexToAddress(address)
+	if holder == flow.EmptyAddress {
+		return holder, fmt.Errorf("address %q is neither a flow.json account nor a hex address", address)
+	}
+	return holder, nil
+}
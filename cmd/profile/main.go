@@ -0,0 +1,158 @@
+// Command profile runs the profiling scenario on the in-memory emulator and
+// prints the computation and events of every transaction, checked against
+// the budgets in emuswap/profile/baselines.json. -suite does the same for
+// every transaction the Go tests run, go test on the packages given, ./... by
+// default, checked against emuswap/profile/suite.json. -sweep measures the
+// main transactions against the number of pools, reward pools and stakers:
+//
+//	go run ./cmd/profile
+//	go run ./cmd/profile -update
+//	go run ./cmd/profile -suite
+//	go run ./cmd/profile -suite -update . ./emuswap/...
+//	go run ./cmd/profile -sweep pools,rewardPools,stakers -max 4 -csv sweep.csv
+//
+// It exits non zero when a transaction exceeds its budget.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/bjartek/overflow/overflow"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/profile"
+	"swap.emudao.org/test-overflow/emuswap/profile/suite"
+)
+
+func main() {
+	baselinesFile := flag.String("baselines", "emuswap/profile/baselines.json", "baselines file")
+	update := flag.Bool("update", false, "rewrite the baselines with the recorded costs")
+	headroom := flag.Float64("headroom", 0.1, "budget above the recorded computation when updating")
+	testSuite := flag.Bool("suite", false, "profile the transactions of the Go tests instead of the scenario")
+	suiteBaselinesFile := flag.String("suite-baselines", "emuswap/profile/suite.json", "baselines file of -suite")
+	sweep := flag.String("sweep", "", "comma separated dimensions to sweep: pools, rewardPools, stakers")
+	max := flag.Int("max", 4, "largest size to sweep")
+	csvFile := flag.String("csv", "", "write the sweep to this CSV file instead of stdout")
+	flag.Parse()
+
+	var err error
+	switch {
+	case *sweep != "":
+		err = runSweeps(strings.Split(*sweep, ","), *max, *csvFile)
+	case *testSuite:
+		err = runSuite(*suiteBaselinesFile, *update, *headroom, flag.Args())
+	default:
+		err = runScenario(*baselinesFile, *update, *headroom)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func runScenario(baselinesFile string, update bool, headroom float64) error {
+	o, err := overflow.NewTestingEmulator().StartE()
+	if err != nil {
+		return err
+	}
+	var r profile.Recorder
+	if err := profile.Scenario(emuswap.NewClient(o), &r); err != nil {
+		return err
+	}
+	return report(r.Costliest(), baselinesFile, update, headroom)
+}
+
+// runSuite runs go test on packages with the test output on stderr, uncached
+// as cached results record no samples, and reports the samples.
+func runSuite(baselinesFile string, update bool, headroom float64, packages []string) error {
+	if len(packages) == 0 {
+		packages = []string{"./..."}
+	}
+	dir, err := os.MkdirTemp("", "profile")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	cmd := exec.Command("go", append([]string{"test", "-count=1"}, packages...)...)
+	cmd.Env = append(os.Environ(), suite.EnvDir+"="+dir)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("tests failed: %w", err)
+	}
+	samples, err := suite.ReadSamples(dir)
+	if err != nil {
+		return err
+	}
+	var r profile.Recorder
+	for _, sample := range samples {
+		r.Add(profile.Sample(sample))
+	}
+	return report(r.Costliest(), baselinesFile, update, headroom)
+}
+
+func report(costliest map[string]profile.Sample, baselinesFile string, update bool, headroom float64) error {
+	baselines, err := profile.LoadBaselines(baselinesFile)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(costliest))
+	for name := range costliest {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Printf("%-45s %11s %6s %6s\n", "transaction", "computation", "budget", "events")
+	for _, name := range names {
+		sample := costliest[name]
+		fmt.Printf("%-45s %11d %6d %6d\n", name, sample.Computation, baselines[name].Budget, sample.Events)
+	}
+
+	if update {
+		return baselines.Update(costliest, headroom).Save(baselinesFile)
+	}
+	violations := baselines.Check(costliest)
+	for _, violation := range violations {
+		fmt.Fprintln(os.Stderr, violation)
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("%d transactions over budget, rerun with -update if intended", len(violations))
+	}
+	return nil
+}
+
+func runSweeps(dimensions []string, max int, csvFile string) error {
+	var points []profile.Point
+	for _, dimension := range dimensions {
+		found := false
+		for _, sweep := range profile.Sweeps {
+			if sweep.Dimension != strings.TrimSpace(dimension) {
+				continue
+			}
+			found = true
+			swept, err := sweep.Run(max)
+			if err != nil {
+				return err
+			}
+			points = append(points, swept...)
+		}
+		if !found {
+			return fmt.Errorf("unknown sweep %q", dimension)
+		}
+	}
+
+	out := os.Stdout
+	if csvFile != "" {
+		f, err := os.Create(csvFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	return profile.WriteSweep(out, points)
+}
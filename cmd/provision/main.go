@@ -0,0 +1,118 @@
+// Command provision creates test accounts on a running emulator, sets them up
+// for every EmuSwap token, tops up their balances and adds them to flow.json:
+//
+//	go run ./cmd/provision -count 100 -balances FLOW:1000,FUSD:1000,EMU:100,xEMU:10
+//	go run ./cmd/provision -count 20 -name load%d -seed Load_Test_Account_seed_phrase_%06d -write ''
+//
+// Accounts are named and keyed from -name and -seed formatted with 1 to
+// -count. The default seeds are those of user1 and user2, accounts flow.json
+// already has on chain keep their keys and are only set up and funded.
+//
+// With -dry-run the accounts are provisioned on a snapshot of an emulator
+// started with --persist, which is restored after; the events and balance
+// changes are printed and flow.json is left alone.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/flow-cli/pkg/flowkit/output"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/provision"
+	"swap.emudao.org/test-overflow/emuswap/simulate"
+)
+
+func main() {
+	network := flag.String("network", "emulator", "flow.json network to provision on")
+	count := flag.Int("count", 3, "number of accounts")
+	name := flag.String("name", provision.DefaultSpec.Name, "account name format")
+	seed := flag.String("seed", provision.DefaultSpec.Seed, "key seed format, at least 32 bytes")
+	balances := flag.String("balances", "", "balances to top up to, e.g. FLOW:1000,FUSD:500")
+	batch := flag.Int("batch", provision.DefaultSpec.BatchSize, "accounts per transaction")
+	write := flag.String("write", "flow.json", "flow.json to add the accounts to, empty to leave it")
+	dryRun := flag.Bool("dry-run", false, "print what provisioning would do and restore the emulator")
+	flag.Parse()
+
+	spec := provision.Spec{Count: *count, Name: *name, Seed: *seed, BatchSize: *batch}
+	if err := run(*network, spec, *balances, *write, *dryRun); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(network string, spec provision.Spec, balances, write string, dryRun bool) error {
+	var err error
+	if spec.Balances, err = parseBalances(balances); err != nil {
+		return err
+	}
+	o, err := overflow.NewOverflowBuilder(network, false, output.NoneLog).ExistingEmulator().StartE()
+	if err != nil {
+		return err
+	}
+	c := emuswap.NewClient(o)
+	if dryRun {
+		var accounts []provision.Account
+		result, err := simulate.Run(c, simulate.DefaultOptions, func(c *emuswap.Client) error {
+			var err error
+			accounts, err = provision.Provision(c, spec)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if err := printAccounts(accounts); err != nil {
+			return err
+		}
+		if err := result.Write(os.Stdout); err != nil {
+			return err
+		}
+		return result.Err
+	}
+	accounts, err := provision.Provision(c, spec)
+	if err != nil {
+		return err
+	}
+	if err := printAccounts(accounts); err != nil {
+		return err
+	}
+	if write == "" {
+		return nil
+	}
+	return provision.WriteAccounts(write, network, accounts)
+}
+
+func printAccounts(accounts []provision.Account) error {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, account := range accounts {
+		status := "set up"
+		if account.Created {
+			status = "created"
+		}
+		fmt.Fprintf(tw, "%s\t0x%s\t%s\n", account.Name, account.Address.Hex(), status)
+	}
+	return tw.Flush()
+}
+
+func parseBalances(list string) (map[string]emuswap.UFix64, error) {
+	balances := map[string]emuswap.UFix64{}
+	if list == "" {
+		return balances, nil
+	}
+	for _, field := range strings.Split(list, ",") {
+		symbol, amount, ok := strings.Cut(strings.TrimSpace(field), ":")
+		if !ok {
+			return nil, fmt.Errorf("balance %q is not SYMBOL:AMOUNT", field)
+		}
+		value, err := emuswap.ParseUFix64(amount)
+		if err != nil {
+			return nil, fmt.Errorf("balance %q: %w", field, err)
+		}
+		balances[symbol] = value
+	}
+	return balances, nil
+}
@@ -0,0 +1,65 @@
+// Command rewards forecasts when each StakingRewards reward pool runs out
+// under its emission, and warns when a pool cannot pay the pending rewards it
+// already owes stakers.
+//
+//	go run ./cmd/rewards -network mainnet
+//	go run ./cmd/rewards -metrics :9102
+//
+// With -metrics it serves the forecast as Prometheus gauges on /metrics
+// instead of printing it. Otherwise it exits with 2 when a pool owes more
+// than it holds.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/flow-cli/pkg/flowkit/output"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/metrics"
+	"swap.emudao.org/test-overflow/emuswap/rewards"
+)
+
+func main() {
+	network := flag.String("network", "emulator", "flow.json network to read from")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	listen := flag.String("metrics", "", "serve the forecast on /metrics at this address")
+	flag.Parse()
+
+	report, err := run(*network, *asJSON, *listen)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if report.Failed() {
+		os.Exit(2)
+	}
+}
+
+func run(network string, asJSON bool, listen string) (rewards.Report, error) {
+	o, err := overflow.NewOverflowBuilder(network, false, output.NoneLog).ExistingEmulator().StartE()
+	if err != nil {
+		return rewards.Report{}, err
+	}
+	c := emuswap.NewClient(o)
+
+	if listen != "" {
+		exporter := metrics.NewExporter()
+		exporter.Register(rewards.Collector(c))
+		return rewards.Report{}, http.ListenAndServe(listen, exporter)
+	}
+	report, err := rewards.Load(c)
+	if err != nil {
+		return report, err
+	}
+	if asJSON {
+		out := json.NewEncoder(os.Stdout)
+		out.SetIndent("", "  ")
+		return report, out.Encode(report)
+	}
+	return report, report.Write(os.Stdout)
+}
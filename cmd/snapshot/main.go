@@ -0,0 +1,51 @@
+// Command snapshot writes the whole protocol state of a network to a JSON
+// document that cmd/statediff compares:
+//
+//	go run ./cmd/snapshot -network testnet -out before.json
+//	go run ./cmd/snapshot > state.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/flow-cli/pkg/flowkit/output"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/snapshot"
+)
+
+func main() {
+	network := flag.String("network", "emulator", "flow.json network to read from")
+	out := flag.String("out", "", "file to write the state to, default stdout")
+	flag.Parse()
+
+	if err := run(*network, *out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(network, out string) error {
+	o, err := overflow.NewOverflowBuilder(network, false, output.NoneLog).ExistingEmulator().StartE()
+	if err != nil {
+		return err
+	}
+	state, err := snapshot.Take(emuswap.NewClient(o))
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		return state.Write(os.Stdout)
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	if err := state.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,93 @@
+// Command statediff compares two states written by cmd/snapshot, or one with
+// the state of a network now, and prints what differs:
+//
+//	go run ./cmd/statediff before.json after.json
+//	go run ./cmd/statediff -network testnet before.json
+//	go run ./cmd/statediff -ignore 'feesCollected,pools.*.frozen' -json before.json after.json
+//
+// The network and height the states were taken at and the rewards pending
+// are left out unless -all is given. It exits with 2 when the states differ.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/flow-cli/pkg/flowkit/output"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/snapshot"
+)
+
+func main() {
+	network := flag.String("network", "emulator", "flow.json network to compare with when only one state is given")
+	ignore := flag.String("ignore", "", "comma separated paths to leave out, * matches one segment")
+	all := flag.Bool("all", false, "compare the network, height and rewards pending too")
+	asJSON := flag.Bool("json", false, "print the differences as JSON")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: statediff [flags] before.json [after.json]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 || flag.NArg() > 2 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	differences, err := run(*network, *ignore, *all, *asJSON, flag.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if len(differences) > 0 {
+		os.Exit(2)
+	}
+}
+
+func run(network, ignore string, all, asJSON bool, paths []string) ([]snapshot.Difference, error) {
+	options := snapshot.DefaultOptions
+	if all {
+		options = snapshot.Options{}
+	}
+	if ignore != "" {
+		options.Ignore = append(append([]string{}, options.Ignore...), strings.Split(ignore, ",")...)
+	}
+	before, err := snapshot.ReadFile(paths[0])
+	if err != nil {
+		return nil, err
+	}
+	var after snapshot.State
+	if len(paths) == 2 {
+		after, err = snapshot.ReadFile(paths[1])
+	} else {
+		after, err = take(network)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	differences := snapshot.Diff(before, after, options)
+	if asJSON {
+		if differences == nil {
+			differences = []snapshot.Difference{}
+		}
+		out := json.NewEncoder(os.Stdout)
+		out.SetIndent("", "  ")
+		return differences, out.Encode(differences)
+	}
+	for _, difference := range differences {
+		fmt.Println(difference)
+	}
+	return differences, nil
+}
+
+func take(network string) (snapshot.State, error) {
+	o, err := overflow.NewOverflowBuilder(network, false, output.NoneLog).ExistingEmulator().StartE()
+	if err != nil {
+		return snapshot.State{}, err
+	}
+	return snapshot.Take(emuswap.NewClient(o))
+}
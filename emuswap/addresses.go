@@ -0,0 +1,25 @@
+package emuswap
+
+import (
+	"github.com/onflow/flow-cli/pkg/flowkit"
+	"github.com/onflow/flow-go-sdk"
+)
+
+// ContractAddresses returns the address of every contract flow.json deploys
+// or aliases on network, keyed by contract name.
+func ContractAddresses(state *flowkit.State, network string) (map[string]flow.Address, error) {
+	addresses := map[string]flow.Address{}
+	for _, contract := range state.Config().Contracts.ByNetwork(network) {
+		if contract.IsAlias() {
+			addresses[contract.Name] = flow.HexToAddress(contract.Alias)
+		}
+	}
+	contracts, err := state.DeploymentContractsByNetwork(network)
+	if err != nil {
+		return nil, err
+	}
+	for _, contract := range contracts {
+		addresses[contract.Name] = contract.AccountAddress
+	}
+	return addresses, nil
+}
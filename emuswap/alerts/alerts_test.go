@@ -0,0 +1,273 @@
+package alerts
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/events"
+	"swap.emudao.org/test-overflow/internal/emutest"
+)
+
+// TestMain runs the package tests from the repository root, where flow.json
+// and the files it references resolve.
+func TestMain(m *testing.M) {
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(emutest.Main(m))
+}
+
+var ufix = emuswap.UFix64FromFloat
+
+// receiver stands in for a webhook, answering with status, 200 by default,
+// for every attempt at an alert.
+type receiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	alerts   []Alert
+	attempts map[string]int
+	status   func(alert Alert, attempt int) int
+}
+
+func newReceiver(t *testing.T) *receiver {
+	r := &receiver{attempts: map[string]int{}, status: func(Alert, int) int { return http.StatusOK }}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		var alert Alert
+		assert.Equal(t, "application/json", request.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(request.Body).Decode(&alert))
+		assert.Equal(t, alert.ID, request.Header.Get("Idempotency-Key"))
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.attempts[alert.ID]++
+		status := r.status(alert, r.attempts[alert.ID])
+		if status == http.StatusOK {
+			r.alerts = append(r.alerts, alert)
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *receiver) messages() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	messages := []string{}
+	for _, alert := range r.alerts {
+		messages = append(messages, alert.Message)
+	}
+	return messages
+}
+
+func located(height uint64, index int, event events.Event) events.Located {
+	return events.Located{Height: height, TransactionID: flow.Identifier{byte(height)}, EventIndex: index, Event: event}
+}
+
+func TestValidate(t *testing.T) {
+	valid := Rule{Name: "frozen", Kind: PoolFrozen, Webhooks: []string{"http://localhost/hook"}}
+	assert.NoError(t, Config{Rules: []Rule{valid}}.Validate())
+	for name, rule := range map[string]Rule{
+		"no name":      {Kind: PoolFrozen, Webhooks: valid.Webhooks},
+		"duplicate":    valid,
+		"kind":         {Name: "x", Kind: "pool_drained", Webhooks: valid.Webhooks},
+		"no threshold": {Name: "x", Kind: LargeTrade, Webhooks: valid.Webhooks},
+		"trade pools":  {Name: "x", Kind: LargeTrade, Pools: []uint64{0}, Token1Amount: 1, Webhooks: valid.Webhooks},
+		"no remaining": {Name: "x", Kind: RewardPoolLow, Webhooks: valid.Webhooks},
+		"no webhooks":  {Name: "x", Kind: FeeChanged},
+		"relative URL": {Name: "x", Kind: FeeChanged, Webhooks: []string{"/hook"}},
+	} {
+		assert.Error(t, Config{Rules: []Rule{valid, rule}}.Validate(), name)
+	}
+
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"rules": [
+		{"name": "whales", "kind": "large_trade", "token1Amount": "100.0", "webhooks": ["http://localhost/hook"]}
+	]}`), 0o644))
+	config, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Rule{{Name: "whales", Kind: LargeTrade, Token1Amount: ufix(100), Webhooks: []string{"http://localhost/hook"}}}, config.Rules)
+	assert.Equal(t, []events.Event{events.Trade{}}, config.Events())
+}
+
+func TestAlerts(t *testing.T) {
+	hook := []string{"http://localhost/hook"}
+	d := NewDispatcher(Config{Rules: []Rule{
+		{Name: "frozen", Kind: PoolFrozen, Webhooks: hook},
+		{Name: "fees", Kind: FeeChanged, Pools: []uint64{1}, Webhooks: hook},
+		{Name: "whales", Kind: LargeTrade, Token1Amount: ufix(100), Token2Amount: ufix(50), Webhooks: hook},
+		{Name: "low", Kind: RewardPoolLow, Remaining: ufix(10), Webhooks: hook},
+		{Name: "low emu", Kind: RewardPoolLow, Remaining: ufix(5), Tokens: []string{"EMU"}, Webhooks: hook},
+	}}, DefaultOptions)
+	claim := func(token string, remaining float64) events.Event {
+		return events.StakingRewardsRewardsClaimed{TokenType: token, AmountClaimed: ufix(1), TotalRemaining: ufix(remaining)}
+	}
+	alerts, err := d.Alerts([]events.Located{
+		located(1, 0, events.PoolIsFrozen{ID: 0, IsFrozen: false}),
+		located(1, 1, events.PoolIsFrozen{ID: 0, IsFrozen: true}),
+		located(2, 0, events.LPFeeUpdated{PoolID: 0, FeePercentage: ufix(0.01)}),
+		located(2, 1, events.DAOFeeUpdated{PoolID: 1, FeePercentage: ufix(0.01)}),
+		located(3, 0, events.Trade{Token1Amount: ufix(99), Token2Amount: ufix(49), Side: 1}),
+		located(3, 1, events.Trade{Token1Amount: ufix(1), Token2Amount: ufix(50), Side: 2}),
+		located(4, 0, claim("EMU", 11)),
+		located(4, 1, claim("EMU", 9)),
+		located(4, 2, claim("EMU", 8)),
+		located(4, 3, claim("EMU", 4)),
+		located(4, 4, claim("FUSD", 4)),
+		// back above the threshold and below again
+		located(5, 0, claim("EMU", 20)),
+		located(5, 1, claim("EMU", 1)),
+	})
+	require.NoError(t, err)
+	var got []string
+	for _, alert := range alerts {
+		got = append(got, alert.Rule+": "+alert.Message)
+	}
+	assert.Equal(t, []string{
+		"frozen: pool 0 frozen",
+		"fees: DAO fee of pool 1 set to 0.01000000",
+		"whales: swap of 1.00000000 token1 and 50.00000000 token2, token2 sold for token1",
+		"low: EMU reward pool down to 9.00000000 after a claim of 1.00000000",
+		"low emu: EMU reward pool down to 4.00000000 after a claim of 1.00000000",
+		"low: FUSD reward pool down to 4.00000000 after a claim of 1.00000000",
+		"low: EMU reward pool down to 1.00000000 after a claim of 1.00000000",
+		"low emu: EMU reward pool down to 1.00000000 after a claim of 1.00000000",
+	}, got)
+
+	frozen := alerts[0]
+	assert.Equal(t, flow.Identifier{1}.String()+"-1-frozen", frozen.ID)
+	assert.Equal(t, uint64(1), frozen.Height)
+	assert.Equal(t, "EmuSwap.PoolIsFrozen", frozen.Event)
+	assert.JSONEq(t, `{"ID": 0, "IsFrozen": true}`, string(frozen.Fields))
+}
+
+func TestHandle(t *testing.T) {
+	first, second := newReceiver(t), newReceiver(t)
+	var deadLetters bytes.Buffer
+	d := NewDispatcher(Config{Rules: []Rule{
+		{Name: "frozen", Kind: PoolFrozen, Webhooks: []string{first.URL, second.URL}},
+		{Name: "fees", Kind: FeeChanged, Webhooks: []string{first.URL}},
+	}}, Options{Attempts: 3, Backoff: time.Second, MaxBackoff: 1500 * time.Millisecond, DeadLetters: &deadLetters})
+	var waits []time.Duration
+	d.sleep = func(wait time.Duration) { waits = append(waits, wait) }
+
+	// the first webhook fails pool 1 twice, never takes pool 2 and rejects
+	// pool 3, the second takes everything
+	first.status = func(alert Alert, attempt int) int {
+		switch {
+		case alert.Message == "pool 1 frozen" && attempt <= 2:
+			return http.StatusServiceUnavailable
+		case alert.Message == "pool 2 frozen":
+			return http.StatusInternalServerError
+		case alert.Message == "pool 3 frozen":
+			return http.StatusBadRequest
+		}
+		return http.StatusOK
+	}
+	batch := []events.Located{
+		located(1, 0, events.PoolIsFrozen{ID: 1, IsFrozen: true}),
+		located(1, 1, events.LPFeeUpdated{PoolID: 1, FeePercentage: ufix(0.01)}),
+		located(2, 0, events.PoolIsFrozen{ID: 2, IsFrozen: true}),
+		located(2, 1, events.PoolIsFrozen{ID: 3, IsFrozen: true}),
+		located(3, 0, events.DAOFeeUpdated{PoolID: 3, FeePercentage: ufix(0.01)}),
+	}
+	stats, err := d.Handle(batch)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Alerts: 5, Delivered: 6, DeadLettered: 2}, stats)
+
+	// delivered in order, a retried alert holds back the ones after it
+	assert.Equal(t, []string{"pool 1 frozen", "LP fee of pool 1 set to 0.01000000", "DAO fee of pool 3 set to 0.01000000"}, first.messages())
+	assert.Equal(t, []string{"pool 1 frozen", "pool 2 frozen", "pool 3 frozen"}, second.messages())
+	// two retries each of pool 1 and pool 2, backing off up to MaxBackoff,
+	// and none of the rejected pool 3
+	assert.Equal(t, []time.Duration{time.Second, 1500 * time.Millisecond, time.Second, 1500 * time.Millisecond}, waits)
+	assert.Equal(t, 3, first.attempts[batch[0].TransactionID.String()+"-0-frozen"])
+	assert.Equal(t, 1, first.attempts[flow.Identifier{2}.String()+"-1-frozen"])
+
+	var letters []DeadLetter
+	for _, line := range bytes.Split(bytes.TrimSpace(deadLetters.Bytes()), []byte("\n")) {
+		var letter DeadLetter
+		require.NoError(t, json.Unmarshal(line, &letter))
+		letters = append(letters, letter)
+	}
+	require.Len(t, letters, 2)
+	assert.Equal(t, first.URL, letters[0].Webhook)
+	assert.Equal(t, "pool 2 frozen", letters[0].Alert.Message)
+	assert.Equal(t, 3, letters[0].Attempts)
+	assert.Contains(t, letters[0].Error, "500")
+	assert.Equal(t, "pool 3 frozen", letters[1].Alert.Message)
+	assert.Equal(t, 1, letters[1].Attempts)
+
+	// an overlapping batch delivers the new events only, dead letters
+	// included
+	stats, err = d.Handle(append(batch[2:], located(4, 0, events.PoolIsFrozen{ID: 4, IsFrozen: true})))
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Alerts: 4, Duplicates: 3, Delivered: 2}, stats)
+	assert.Equal(t, "pool 4 frozen", first.messages()[3])
+	assert.Len(t, second.messages(), 4)
+}
+
+func TestStream(t *testing.T) {
+	o, err := emutest.Start()
+	require.NoError(t, err)
+	c := emuswap.NewClient(o)
+	hook := newReceiver(t)
+	config := Config{Rules: []Rule{
+		{Name: "frozen", Kind: PoolFrozen, Webhooks: []string{hook.URL}},
+		{Name: "fees", Kind: FeeChanged, Webhooks: []string{hook.URL}},
+		{Name: "whales", Kind: LargeTrade, Token1Amount: ufix(10), Webhooks: []string{hook.URL}},
+		{Name: "low", Kind: RewardPoolLow, Remaining: ufix(40000000), Webhooks: []string{hook.URL}},
+	}}
+	require.NoError(t, config.Validate())
+	stream, err := NewStream(c, config, NewDispatcher(config, DefaultOptions), 1)
+	require.NoError(t, err)
+
+	c.DemoMintFlowTokens("account", ufix(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.FUSDSetup("account").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", ufix(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.EmuSwapAdminCreateNewPool("account", "flowTokenVault", ufix(100.0), "fusdVault", ufix(50.0)).Test(t).AssertSuccess()
+	c.EmuSwapUserSwap("account", "flowTokenVault", "fusdVault", ufix(1.0)).Test(t).AssertSuccess()
+	c.EmuSwapUserSwap("account", "flowTokenVault", "fusdVault", ufix(20.0)).Test(t).AssertSuccess()
+	c.EmuSwapAdminUpdateLPFeePercentage("account", 0, ufix(0.005)).Test(t).AssertSuccess()
+
+	stats, err := stream.Sync()
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Alerts: 2, Delivered: 2}, stats)
+
+	c.EmuSwapAdminTogglePoolFreeze("account", 0).Test(t).AssertSuccess()
+	c.EmuSwapAdminTogglePoolFreeze("account", 0).Test(t).AssertSuccess()
+	c.StakingAdminToggleMockTime("account").Test(t).AssertSuccess()
+	c.StakingAdminCreateNewFarm("account", 0).Test(t).AssertSuccess()
+	c.StakingUserStake("account", 0, ufix(1.0)).Test(t).AssertSuccess()
+	c.StakingAdminUpdateMockTimestamp("account", ufix(10.0)).Test(t).AssertSuccess()
+	c.StakingUserClaimRewards("account", 0).Test(t).AssertSuccess()
+	c.StakingAdminUpdateMockTimestamp("account", ufix(10.0)).Test(t).AssertSuccess()
+	c.StakingUserClaimRewards("account", 0).Test(t).AssertSuccess()
+
+	stats, err = stream.Sync()
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Alerts: 2, Delivered: 2}, stats)
+	// the first claim leaves less than 40000000 EMU, the second no longer
+	// crosses the threshold
+	assert.Equal(t, []string{
+		"swap of 19.94000000 token1 and 8.16241207 token2, token1 sold for token2",
+		"LP fee of pool 0 set to 0.00500000",
+		"pool 0 frozen",
+		"A.f8d6e0586b0a20c7.EmuToken.Vault reward pool down to 39999990.00000000 after a claim of 10.00000000",
+	}, hook.messages())
+
+	// nothing new
+	stats, err = stream.Sync()
+	require.NoError(t, err)
+	assert.Equal(t, Stats{}, stats)
+}
@@ -0,0 +1,268 @@
+package alerts
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/events"
+)
+
+// Options configure the delivery of a Dispatcher.
+type Options struct {
+	// Attempts is how often a delivery is tried before it is dead lettered.
+	Attempts int
+	// Backoff is the wait before the first retry, doubling for every retry
+	// up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Timeout limits every request.
+	Timeout time.Duration
+	// DeadLetters receives a DeadLetter JSON line for every alert a webhook
+	// did not accept. Nil drops them.
+	DeadLetters io.Writer
+}
+
+// DefaultOptions try 5 times over 15 seconds, with 10 second requests.
+var DefaultOptions = Options{
+	Attempts:   5,
+	Backoff:    time.Second,
+	MaxBackoff: time.Minute,
+	Timeout:    10 * time.Second,
+}
+
+// DeadLetter is an alert a webhook did not accept.
+type DeadLetter struct {
+	Webhook  string `json:"webhook"`
+	Alert    Alert  `json:"alert"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error"`
+}
+
+// Stats count what a Dispatcher did with a batch of events.
+type Stats struct {
+	Alerts       int
+	Duplicates   int
+	Delivered    int
+	DeadLettered int
+}
+
+// Dispatcher evaluates the rules over events and delivers the alerts.
+type Dispatcher struct {
+	rules   []Rule
+	options Options
+	client  *http.Client
+	sleep   func(time.Duration)
+	// sent are the IDs of the alerts handled by height, low the reward
+	// tokens below the threshold of each rule.
+	sent map[string]uint64
+	low  map[string]map[string]bool
+}
+
+// NewDispatcher returns a dispatcher for the rules of config.
+func NewDispatcher(config Config, options Options) *Dispatcher {
+	d := &Dispatcher{
+		rules:   config.Rules,
+		options: options,
+		client:  &http.Client{Timeout: options.Timeout},
+		sleep:   time.Sleep,
+		sent:    map[string]uint64{},
+		low:     map[string]map[string]bool{},
+	}
+	for _, rule := range config.Rules {
+		d.low[rule.Name] = map[string]bool{}
+	}
+	return d
+}
+
+// Alerts evaluates the rules over events in order. The alerts of one event
+// follow the order of the rules.
+func (d *Dispatcher) Alerts(located []events.Located) ([]Alert, error) {
+	var alerts []Alert
+	for _, l := range located {
+		for _, rule := range d.rules {
+			message, ok := rule.match(l.Event, d.low[rule.Name])
+			if !ok {
+				continue
+			}
+			fields, err := json.Marshal(l.Event)
+			if err != nil {
+				return nil, err
+			}
+			alerts = append(alerts, Alert{
+				ID:            fmt.Sprintf("%s-%d-%s", l.TransactionID, l.EventIndex, rule.Name),
+				Rule:          rule.Name,
+				Kind:          rule.Kind,
+				Message:       message,
+				Height:        l.Height,
+				TransactionID: l.TransactionID.String(),
+				EventIndex:    l.EventIndex,
+				Event:         l.Event.Contract() + "." + l.Event.Name(),
+				Fields:        fields,
+			})
+		}
+	}
+	return alerts, nil
+}
+
+// Handle delivers the alerts of events, which come in chain order. Alerts
+// already handled are skipped, so batches may overlap, down to the first
+// height of the batch. Every webhook gets its alerts one at a time in order,
+// an alert is only delivered after the one before was accepted or dead
+// lettered. The error is that of writing a dead letter.
+func (d *Dispatcher) Handle(located []events.Located) (Stats, error) {
+	var stats Stats
+	if len(located) == 0 {
+		return stats, nil
+	}
+	for id, height := range d.sent {
+		if height < located[0].Height {
+			delete(d.sent, id)
+		}
+	}
+	alerts, err := d.Alerts(located)
+	if err != nil {
+		return stats, err
+	}
+	webhooks := map[string][]string{}
+	for _, rule := range d.rules {
+		webhooks[rule.Name] = rule.Webhooks
+	}
+	for _, alert := range alerts {
+		stats.Alerts++
+		if _, ok := d.sent[alert.ID]; ok {
+			stats.Duplicates++
+			continue
+		}
+		d.sent[alert.ID] = alert.Height
+		body, err := json.Marshal(alert)
+		if err != nil {
+			return stats, err
+		}
+		for _, webhook := range webhooks[alert.Rule] {
+			attempts, err := d.deliver(webhook, alert.ID, body)
+			if err == nil {
+				stats.Delivered++
+				continue
+			}
+			stats.DeadLettered++
+			if err := d.deadLetter(DeadLetter{Webhook: webhook, Alert: alert, Attempts: attempts, Error: err.Error()}); err != nil {
+				return stats, err
+			}
+		}
+	}
+	return stats, nil
+}
+
+// permanent is a response retrying cannot change.
+type permanent struct{ error }
+
+// deliver POSTs body until the webhook accepts it, the attempts run out or it
+// answers with a client error other than 408 or 429.
+func (d *Dispatcher) deliver(webhook, id string, body []byte) (int, error) {
+	wait := d.options.Backoff
+	for attempt := 1; ; attempt++ {
+		err := d.post(webhook, id, body)
+		if err == nil || errors.As(err, &permanent{}) || attempt >= d.options.Attempts {
+			return attempt, err
+		}
+		d.sleep(wait)
+		if wait *= 2; wait > d.options.MaxBackoff {
+			wait = d.options.MaxBackoff
+		}
+	}
+}
+
+func (d *Dispatcher) post(webhook, id string, body []byte) error {
+	request, err := http.NewRequest(http.MethodPost, webhook, bytes.NewReader(body))
+	if err != nil {
+		return permanent{err}
+	}
+	request.Header.Set("Content-Type", "application/json")
+	// receivers can drop the alerts they see again after a lost response
+	request.Header.Set("Idempotency-Key", id)
+	response, err := d.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, response.Body)
+	switch {
+	case response.StatusCode/100 == 2:
+		return nil
+	case response.StatusCode/100 == 4 && response.StatusCode != http.StatusRequestTimeout && response.StatusCode != http.StatusTooManyRequests:
+		return permanent{fmt.Errorf("%s answered %s", webhook, response.Status)}
+	}
+	return fmt.Errorf("%s answered %s", webhook, response.Status)
+}
+
+func (d *Dispatcher) deadLetter(letter DeadLetter) error {
+	if d.options.DeadLetters == nil {
+		return nil
+	}
+	line, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+	_, err = d.options.DeadLetters.Write(append(line, '\n'))
+	return err
+}
+
+// Stream feeds a Dispatcher the events of the blocks as they come.
+type Stream struct {
+	c          *emuswap.Client
+	dispatcher *Dispatcher
+	addresses  events.Addresses
+	kinds      []events.Event
+	next       uint64
+}
+
+// NewStream returns a stream starting at startHeight, or at the latest block
+// for 0.
+func NewStream(c *emuswap.Client, config Config, dispatcher *Dispatcher, startHeight uint64) (*Stream, error) {
+	addresses, err := events.AddressesFor(c.O)
+	if err != nil {
+		return nil, err
+	}
+	s := &Stream{c: c, dispatcher: dispatcher, addresses: addresses, next: startHeight}
+	for _, kind := range config.Events() {
+		if addresses.TypeID(kind) != "" {
+			s.kinds = append(s.kinds, kind)
+		}
+	}
+	if startHeight == 0 {
+		block, err := c.O.GetLatestBlock()
+		if err != nil {
+			return nil, err
+		}
+		s.next = block.Height
+	}
+	return s, nil
+}
+
+// Sync handles the events of the blocks up to the latest one. A failed sync
+// is retried from the same height.
+func (s *Stream) Sync() (Stats, error) {
+	block, err := s.c.O.GetLatestBlock()
+	if err != nil {
+		return Stats{}, err
+	}
+	if block.Height < s.next || len(s.kinds) == 0 {
+		return Stats{}, nil
+	}
+	found, err := s.addresses.Fetch(s.c.O, s.kinds, s.next, block.Height)
+	if err != nil {
+		return Stats{}, err
+	}
+	stats, err := s.dispatcher.Handle(found)
+	if err != nil {
+		return stats, err
+	}
+	s.next = block.Height + 1
+	return stats, nil
+}
@@ -0,0 +1,210 @@
+// Package alerts turns the events of the EmuSwap contracts into alerts and
+// POSTs them as JSON to webhooks. Rules pick the events worth telling someone
+// about: a pool frozen, a fee changed, a large swap or a reward pool vault
+// running low. A Dispatcher delivers the alerts of every webhook one at a
+// time in the order of the events, retrying failed deliveries with
+// exponential backoff and writing the ones that keep failing to a dead letter
+// log. A Stream feeds it the events of new blocks.
+package alerts
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/events"
+)
+
+// Kind is the kind of a rule.
+type Kind string
+
+const (
+	PoolFrozen    Kind = "pool_frozen"     // PoolIsFrozen freezing a pool
+	FeeChanged    Kind = "fee_changed"     // LPFeeUpdated and DAOFeeUpdated
+	LargeTrade    Kind = "large_trade"     // Trade moving at least a threshold
+	RewardPoolLow Kind = "reward_pool_low" // RewardsClaimed leaving little in the vault
+)
+
+// kinds are the events each kind of rule looks at.
+var kinds = map[Kind][]events.Event{
+	PoolFrozen:    {events.PoolIsFrozen{}},
+	FeeChanged:    {events.LPFeeUpdated{}, events.DAOFeeUpdated{}},
+	LargeTrade:    {events.Trade{}},
+	RewardPoolLow: {events.StakingRewardsRewardsClaimed{}},
+}
+
+// Rule alerts its webhooks about the events of its kind.
+type Rule struct {
+	Name string `json:"name"`
+	Kind Kind   `json:"kind"`
+	// Pools limits the pool rules to these pools, empty matches every pool.
+	// LargeTrade rules match every pool, Trade events name none.
+	Pools []uint64 `json:"pools,omitempty"`
+	// Token1Amount and Token2Amount are the LargeTrade thresholds, a swap
+	// reaching either is large. Zero leaves a side out.
+	Token1Amount emuswap.UFix64 `json:"token1Amount,omitempty"`
+	Token2Amount emuswap.UFix64 `json:"token2Amount,omitempty"`
+	// Remaining is the RewardPoolLow threshold. A claim leaving less in the
+	// vault alerts once, until a claim leaves at least Remaining again.
+	Remaining emuswap.UFix64 `json:"remaining,omitempty"`
+	// Tokens limits RewardPoolLow to reward vault identifiers, empty matches
+	// every reward token.
+	Tokens   []string `json:"tokens,omitempty"`
+	Webhooks []string `json:"webhooks"`
+}
+
+// Config is the JSON configuration of a Dispatcher.
+type Config struct {
+	Rules []Rule `json:"rules"`
+}
+
+// LoadConfig reads and validates the configuration at path.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := config.Validate(); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Validate checks every rule has a unique name, a known kind, its threshold
+// and absolute webhook URLs.
+func (c Config) Validate() error {
+	names := map[string]bool{}
+	for i, rule := range c.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("rule %d has no name", i)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("rule %s: duplicate name", rule.Name)
+		}
+		names[rule.Name] = true
+		if _, ok := kinds[rule.Kind]; !ok {
+			return fmt.Errorf("rule %s: unknown kind %q", rule.Name, rule.Kind)
+		}
+		if rule.Kind == LargeTrade && rule.Token1Amount == 0 && rule.Token2Amount == 0 {
+			return fmt.Errorf("rule %s: needs token1Amount or token2Amount", rule.Name)
+		}
+		if rule.Kind == LargeTrade && len(rule.Pools) > 0 {
+			return fmt.Errorf("rule %s: Trade events name no pool to limit it to", rule.Name)
+		}
+		if rule.Kind == RewardPoolLow && rule.Remaining == 0 {
+			return fmt.Errorf("rule %s: needs remaining", rule.Name)
+		}
+		if len(rule.Webhooks) == 0 {
+			return fmt.Errorf("rule %s: no webhooks", rule.Name)
+		}
+		for _, webhook := range rule.Webhooks {
+			if u, err := url.Parse(webhook); err != nil || !u.IsAbs() {
+				return fmt.Errorf("rule %s: webhook %q is not an absolute URL", rule.Name, webhook)
+			}
+		}
+	}
+	return nil
+}
+
+// Events are the events the rules look at.
+func (c Config) Events() []events.Event {
+	seen := map[Kind]bool{}
+	var found []events.Event
+	for _, rule := range c.Rules {
+		if !seen[rule.Kind] {
+			seen[rule.Kind] = true
+			found = append(found, kinds[rule.Kind]...)
+		}
+	}
+	return found
+}
+
+// Alert is the JSON body POSTed to the webhooks.
+type Alert struct {
+	// ID is unique per rule and event, webhooks receive it again only when
+	// a delivery is retried.
+	ID      string `json:"id"`
+	Rule    string `json:"rule"`
+	Kind    Kind   `json:"kind"`
+	Message string `json:"message"`
+
+	Height        uint64 `json:"height"`
+	TransactionID string `json:"transactionID"`
+	EventIndex    int    `json:"eventIndex"`
+	// Event is the contract and event name, e.g. EmuSwap.PoolIsFrozen.
+	Event  string          `json:"event"`
+	Fields json.RawMessage `json:"fields"`
+}
+
+// match returns the message of the alert rule raises for event, or false.
+// low holds the reward tokens rule last saw below its threshold.
+func (rule Rule) match(event events.Event, low map[string]bool) (string, bool) {
+	switch e := event.(type) {
+	case events.PoolIsFrozen:
+		if rule.Kind == PoolFrozen && e.IsFrozen && rule.pool(e.ID) {
+			return fmt.Sprintf("pool %d frozen", e.ID), true
+		}
+	case events.LPFeeUpdated:
+		if rule.Kind == FeeChanged && rule.pool(e.PoolID) {
+			return fmt.Sprintf("LP fee of pool %d set to %s", e.PoolID, e.FeePercentage), true
+		}
+	case events.DAOFeeUpdated:
+		if rule.Kind == FeeChanged && rule.pool(e.PoolID) {
+			return fmt.Sprintf("DAO fee of pool %d set to %s", e.PoolID, e.FeePercentage), true
+		}
+	case events.Trade:
+		large := rule.Token1Amount > 0 && e.Token1Amount >= rule.Token1Amount ||
+			rule.Token2Amount > 0 && e.Token2Amount >= rule.Token2Amount
+		if rule.Kind == LargeTrade && large {
+			// side 1 sells token1 for token2, 2 the other way
+			sold, bought := "token1", "token2"
+			if e.Side == 2 {
+				sold, bought = bought, sold
+			}
+			return fmt.Sprintf("swap of %s token1 and %s token2, %s sold for %s", e.Token1Amount, e.Token2Amount, sold, bought), true
+		}
+	case events.StakingRewardsRewardsClaimed:
+		if rule.Kind != RewardPoolLow || !rule.token(e.TokenType) {
+			break
+		}
+		if e.TotalRemaining >= rule.Remaining {
+			delete(low, e.TokenType)
+			break
+		}
+		if !low[e.TokenType] {
+			low[e.TokenType] = true
+			return fmt.Sprintf("%s reward pool down to %s after a claim of %s", e.TokenType, e.TotalRemaining, e.AmountClaimed), true
+		}
+	}
+	return "", false
+}
+
+func (rule Rule) pool(id uint64) bool {
+	if len(rule.Pools) == 0 {
+		return true
+	}
+	for _, pool := range rule.Pools {
+		if pool == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (rule Rule) token(identifier string) bool {
+	if len(rule.Tokens) == 0 {
+		return true
+	}
+	for _, token := range rule.Tokens {
+		if token == identifier {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+// Package api serves EmuSwap analytics as JSON over HTTP:
+//
+//	GET /pools/{id}/depth?impact=0.01,0.02,0.05&liquidity=-0.5,0.5
+//	GET /accounts/{address}/portfolio?quote=FUSD
+//
+// Errors are returned as {"error": "..."} with a 4xx or 5xx status.
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/onflow/flow-go-sdk"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/depth"
+	"swap.emudao.org/test-overflow/emuswap/portfolio"
+)
+
+// Server answers requests with the scripts of one client.
+type Server struct {
+	// mu serializes requests, overflow is not safe for concurrent scripts.
+	mu  sync.Mutex
+	c   *emuswap.Client
+	mux *http.ServeMux
+}
+
+// NewServer returns a server reading the chain through c.
+func NewServer(c *emuswap.Client) *Server {
+	s := &Server{c: c, mux: http.NewServeMux()}
+	s.mux.HandleFunc("/pools/", s.pools)
+	s.mux.HandleFunc("/accounts/", s.accounts)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// statusError carries the status an error is reported with.
+type statusError struct {
+	status int
+	err    error
+}
+
+func (e statusError) Error() string { return e.err.Error() }
+
+func badRequest(format string, args ...interface{}) error {
+	return statusError{http.StatusBadRequest, fmt.Errorf(format, args...)}
+}
+
+func notFound(format string, args ...interface{}) error {
+	return statusError{http.StatusNotFound, fmt.Errorf(format, args...)}
+}
+
+// respond writes value, or err with its status.
+func respond(w http.ResponseWriter, value interface{}, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		status := http.StatusInternalServerError
+		var se statusError
+		if errors.As(err, &se) {
+			status = se.status
+		}
+		w.WriteHeader(status)
+		value = map[string]string{"error": err.Error()}
+	}
+	json.NewEncoder(w).Encode(value)
+}
+
+func allowGet(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		respond(w, nil, statusError{http.StatusMethodNotAllowed, fmt.Errorf("%s not allowed", r.Method)})
+		return false
+	}
+	return true
+}
+
+// pools routes /pools/{id}/{view}.
+func (s *Server) pools(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/pools/"), "/"), "/")
+	if len(parts) != 2 || parts[1] != "depth" {
+		respond(w, nil, notFound("no route %s", r.URL.Path))
+		return
+	}
+	poolID, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		respond(w, nil, badRequest("pool id %q", parts[0]))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkPool(poolID); err != nil {
+		respond(w, nil, err)
+		return
+	}
+	value, err := s.depth(poolID, r)
+	respond(w, value, err)
+}
+
+func (s *Server) checkPool(poolID uint64) error {
+	ids, err := s.c.GetPoolIDs()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if id == poolID {
+			return nil
+		}
+	}
+	return notFound("no pool %d", poolID)
+}
+
+func (s *Server) depth(poolID uint64, r *http.Request) (interface{}, error) {
+	options := depth.DefaultOptions
+	query := r.URL.Query()
+	var err error
+	if query.Has("impact") {
+		if options.Impacts, err = parseFloats("impact", query.Get("impact")); err != nil {
+			return nil, err
+		}
+	}
+	if query.Has("fractions") {
+		if options.Fractions, err = parseFloats("fractions", query.Get("fractions")); err != nil {
+			return nil, err
+		}
+	}
+	if query.Has("liquidity") {
+		if options.Liquidity, err = parseFloats("liquidity", query.Get("liquidity")); err != nil {
+			return nil, err
+		}
+	}
+	for _, impact := range options.Impacts {
+		if impact <= 0 || impact >= 1 {
+			return nil, badRequest("impact %v not between 0 and 1", impact)
+		}
+	}
+	for _, change := range options.Liquidity {
+		if change <= -1 {
+			return nil, badRequest("liquidity %v removes all liquidity", change)
+		}
+	}
+	return depth.Load(s.c, poolID, options)
+}
+
+// accounts routes /accounts/{address}/portfolio.
+func (s *Server) accounts(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/accounts/"), "/"), "/")
+	if len(parts) != 2 || parts[1] != "portfolio" {
+		respond(w, nil, notFound("no route %s", r.URL.Path))
+		return
+	}
+	address := flow.HexToAddress(parts[0])
+	if address == flow.EmptyAddress {
+		respond(w, nil, badRequest("address %q", parts[0]))
+		return
+	}
+	quote := r.URL.Query().Get("quote")
+	if quote == "" {
+		quote = "FUSD"
+	}
+	if _, err := emuswap.LookupToken(quote); err != nil {
+		respond(w, nil, badRequest("quote: %v", err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, err := portfolio.Load(s.c, address, quote)
+	respond(w, value, err)
+}
+
+func parseFloats(name, list string) ([]float64, error) {
+	var values []float64
+	for _, field := range strings.Split(list, ",") {
+		value, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, badRequest("%s %q", name, field)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
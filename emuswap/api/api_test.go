@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/depth"
+	"swap.emudao.org/test-overflow/emuswap/portfolio"
+	"swap.emudao.org/test-overflow/internal/emutest"
+)
+
+// TestMain runs the package tests from the repository root, where flow.json
+// and the files it references resolve.
+func TestMain(m *testing.M) {
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(emutest.Main(m))
+}
+
+var ufix = emuswap.UFix64FromFloat
+
+func get(t *testing.T, url string, value interface{}) int {
+	response, err := http.Get(url)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	assert.Equal(t, "application/json", response.Header.Get("Content-Type"))
+	require.NoError(t, json.NewDecoder(response.Body).Decode(value))
+	return response.StatusCode
+}
+
+func TestDepth(t *testing.T) {
+	o, err := emutest.Start()
+	require.NoError(t, err)
+	c := emuswap.NewClient(o)
+	c.DemoMintFlowTokens("account", ufix(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.FUSDSetup("account").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", ufix(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.EmuSwapAdminCreateNewPool("account", "flowTokenVault", ufix(100.0), "fusdVault", ufix(50.0)).Test(t).AssertSuccess()
+
+	server := httptest.NewServer(NewServer(c))
+	defer server.Close()
+
+	var served depth.PoolDepth
+	assert.Equal(t, http.StatusOK, get(t, server.URL+"/pools/0/depth?impact=0.01,0.05&liquidity=-0.5,1", &served))
+	expected, err := depth.Load(c, 0, depth.Options{
+		Impacts:   []float64{0.01, 0.05},
+		Fractions: depth.DefaultOptions.Fractions,
+		Liquidity: []float64{-0.5, 1},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, expected, served)
+
+	var defaults depth.PoolDepth
+	assert.Equal(t, http.StatusOK, get(t, server.URL+"/pools/0/depth", &defaults))
+	assert.Len(t, defaults.Current.Token1ForToken2.Levels, 3)
+	assert.Empty(t, defaults.Changes)
+
+	for path, status := range map[string]int{
+		"/pools/1/depth":              http.StatusNotFound,
+		"/pools/0/volume":             http.StatusNotFound,
+		"/pools/x/depth":              http.StatusBadRequest,
+		"/pools/0/depth?impact=2":     http.StatusBadRequest,
+		"/pools/0/depth?liquidity=-1": http.StatusBadRequest,
+	} {
+		var body map[string]string
+		assert.Equal(t, status, get(t, server.URL+path, &body), path)
+		assert.NotEmpty(t, body["error"], path)
+	}
+}
+
+func TestPortfolio(t *testing.T) {
+	o, err := emutest.Start()
+	require.NoError(t, err)
+	c := emuswap.NewClient(o)
+	c.DemoMintFlowTokens("account", ufix(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.FUSDSetup("account").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", ufix(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.EmuSwapAdminCreateNewPool("account", "flowTokenVault", ufix(100.0), "fusdVault", ufix(50.0)).Test(t).AssertSuccess()
+
+	server := httptest.NewServer(NewServer(c))
+	defer server.Close()
+
+	address := c.Address("account")
+	var served portfolio.Portfolio
+	assert.Equal(t, http.StatusOK, get(t, server.URL+"/accounts/"+address.Hex()+"/portfolio", &served))
+	expected, err := portfolio.Load(c, address, "FUSD")
+	require.NoError(t, err)
+	assert.Equal(t, expected, served)
+
+	var inFlow portfolio.Portfolio
+	assert.Equal(t, http.StatusOK, get(t, server.URL+"/accounts/0x"+address.Hex()+"/portfolio?quote=FLOW", &inFlow))
+	assert.Equal(t, 2.0, inFlow.Prices[served.Quote])
+
+	for path, status := range map[string]int{
+		"/accounts/" + address.Hex() + "/stakes":              http.StatusNotFound,
+		"/accounts/xyz/portfolio":                             http.StatusBadRequest,
+		"/accounts/" + address.Hex() + "/portfolio?quote=BTC": http.StatusBadRequest,
+	} {
+		var body map[string]string
+		assert.Equal(t, status, get(t, server.URL+path, &body), path)
+		assert.NotEmpty(t, body["error"], path)
+	}
+}
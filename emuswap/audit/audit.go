@@ -0,0 +1,462 @@
+// Package audit keeps a tamper evident log of the admin actions on the EmuSwap
+// contracts. A Logger follows the chain block by block and writes an entry for
+// every transaction signed by an admin account or emitting an admin event,
+// such as LPFeeUpdated or NewFarmCreated, with its signers, arguments, events
+// and the admin state before and after it. Entries are appended to a file of
+// JSON lines, each holding the hash of the entry before it, so altering,
+// reordering or removing an entry breaks Verify.
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/onflow/flow-go-sdk"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/events"
+)
+
+// AdminEvents are the events only admin operations emit.
+var AdminEvents = []events.Event{
+	events.LPFeeUpdated{},
+	events.DAOFeeUpdated{},
+	events.PoolIsFrozen{},
+	events.NewSwapPoolCreated{},
+	events.StakingRewardsNewFarmCreated{},
+	events.StakingRewardsRewardPoolCreated{},
+	events.StakingRewardsEmissionRateUpdated{},
+}
+
+// PoolState is what admins control of a pool.
+type PoolState struct {
+	Meta             emuswap.PoolMeta `json:"meta"`
+	Frozen           bool             `json:"frozen"`
+	LPFeePercentage  emuswap.UFix64   `json:"lpFeePercentage"`
+	DAOFeePercentage emuswap.UFix64   `json:"daoFeePercentage"`
+}
+
+// FarmState is what admins control of a farm.
+type FarmState struct {
+	FarmWeightsByID           map[uint64]emuswap.UFix64 `json:"farmWeightsByID"`
+	RewardTokensPerSecondByID map[uint64]emuswap.UFix64 `json:"rewardTokensPerSecondByID"`
+	RewardsRemainingByID      map[uint64]emuswap.UFix64 `json:"rewardsRemainingByID"`
+}
+
+// State is the admin state of the contracts. Farms whose meta cannot be read,
+// as when no reward pool weights them, are left out.
+type State struct {
+	// LPFeePercentage and DAOFeePercentage are the fees new pools start with.
+	LPFeePercentage  emuswap.UFix64 `json:"lpFeePercentage"`
+	DAOFeePercentage emuswap.UFix64 `json:"daoFeePercentage"`
+	// Treasury is the fees collected by token identifier.
+	Treasury map[string]emuswap.UFix64 `json:"treasury"`
+	Pools    map[uint64]PoolState      `json:"pools"`
+	Farms    map[uint64]FarmState      `json:"farms"`
+}
+
+// ReadState reads the admin state at the latest block.
+func ReadState(c *emuswap.Client) (State, error) {
+	var s State
+	var err error
+	if s.LPFeePercentage, err = c.GetLPFeePercentage(); err != nil {
+		return s, err
+	}
+	if s.DAOFeePercentage, err = c.GetDAOFeePercentage(); err != nil {
+		return s, err
+	}
+	if s.Treasury, err = c.ReadFeesCollected(); err != nil {
+		return s, err
+	}
+	metas, err := c.GetPoolsMeta()
+	if err != nil {
+		return s, err
+	}
+	poolIDs, err := c.GetPoolIDs()
+	if err != nil {
+		return s, err
+	}
+	frozen, err := c.GetFrozenPools()
+	if err != nil {
+		return s, err
+	}
+	fees, err := c.GetPoolFees()
+	if err != nil {
+		return s, err
+	}
+	if len(metas) != len(poolIDs) {
+		return s, fmt.Errorf("%d pool metas for %d pools", len(metas), len(poolIDs))
+	}
+	s.Pools = map[uint64]PoolState{}
+	for i, poolID := range poolIDs {
+		s.Pools[poolID] = PoolState{
+			Meta:             metas[i],
+			Frozen:           frozen[poolID],
+			LPFeePercentage:  fees[poolID]["LPFeePercentage"],
+			DAOFeePercentage: fees[poolID]["DAOFeePercentage"],
+		}
+	}
+
+	staked, err := c.StakingGetTotalStaked()
+	if err != nil {
+		return s, err
+	}
+	s.Farms = map[uint64]FarmState{}
+	for farmID := range staked {
+		meta, err := c.StakingGetFarmMeta(farmID)
+		if err != nil || meta == nil {
+			continue
+		}
+		s.Farms[farmID] = FarmState{
+			FarmWeightsByID:           meta.FarmWeightsByID,
+			RewardTokensPerSecondByID: meta.RewardTokensPerSecondByID,
+			RewardsRemainingByID:      meta.RewardsRemainingByID,
+		}
+	}
+	return s, nil
+}
+
+// subjects are the pools and farms an entry is about, nil for all of them.
+type subjects struct {
+	pools, farms map[uint64]bool
+}
+
+// only keeps the pools and farms of s that are subjects.
+func (s State) only(subjects subjects) State {
+	if subjects.pools != nil {
+		pools := map[uint64]PoolState{}
+		for id := range subjects.pools {
+			if pool, ok := s.Pools[id]; ok {
+				pools[id] = pool
+			}
+		}
+		s.Pools = pools
+	}
+	if subjects.farms != nil {
+		farms := map[uint64]FarmState{}
+		for id := range subjects.farms {
+			if farm, ok := s.Farms[id]; ok {
+				farms[id] = farm
+			}
+		}
+		s.Farms = farms
+	}
+	return s
+}
+
+// Event is an event of an entry.
+type Event struct {
+	// Type is the contract and event name, e.g. EmuSwap.LPFeeUpdated.
+	Type   string          `json:"type"`
+	Fields json.RawMessage `json:"fields"`
+}
+
+// Entry is one admin action.
+type Entry struct {
+	// Seq numbers the entries from 0, Prev is the hash of the entry before.
+	Seq           uint64 `json:"seq"`
+	Prev          string `json:"prev"`
+	Height        uint64 `json:"height"`
+	TransactionID string `json:"transactionID"`
+	// Transaction is the file under transactions/ the transaction was sent
+	// from, or the SHA-256 of its code when it matches none.
+	Transaction string         `json:"transaction"`
+	Payer       flow.Address   `json:"payer"`
+	Signers     []flow.Address `json:"signers"`
+	// Arguments are encoded as JSON-Cadence, as sent.
+	Arguments []json.RawMessage `json:"arguments"`
+	Events    []Event           `json:"events"`
+	// Error is the error of a failed transaction.
+	Error string `json:"error,omitempty"`
+	// Before and After are the state when the logger synced before and after
+	// the transaction, limited to the pools and farms its events name.
+	Before State `json:"before"`
+	After  State `json:"after"`
+}
+
+// Options configure a Logger.
+type Options struct {
+	// Admins are the accounts whose transactions are logged. Empty logs the
+	// accounts the contracts are deployed to, which hold the Admin resources.
+	Admins []flow.Address
+	// StartHeight is the first block of a new log.
+	StartHeight uint64
+}
+
+// Logger writes the admin actions on chain to a log.
+type Logger struct {
+	c         *emuswap.Client
+	log       *Log
+	addresses events.Addresses
+	admins    map[flow.Address]bool
+	admin     map[string]bool
+	names     map[string]string
+	state     State
+	next      uint64
+	// logged are the transactions in the log at height next.
+	logged map[string]bool
+}
+
+// NewLogger returns a logger appending to log. A log with entries is resumed
+// at the height of its last entry.
+func NewLogger(c *emuswap.Client, log *Log, options Options) (*Logger, error) {
+	addresses, err := events.AddressesFor(c.O)
+	if err != nil {
+		return nil, err
+	}
+	l := &Logger{c: c, log: log, addresses: addresses, admins: map[flow.Address]bool{}, admin: map[string]bool{}, next: options.StartHeight, logged: map[string]bool{}}
+	for _, admin := range options.Admins {
+		l.admins[admin] = true
+	}
+	if len(l.admins) == 0 {
+		for _, address := range addresses {
+			l.admins[address] = true
+		}
+	}
+	for _, event := range AdminEvents {
+		l.admin[event.Contract()+"."+event.Name()] = true
+	}
+	if l.names, err = transactionNames(c); err != nil {
+		return nil, err
+	}
+	if head := log.Head(); head.Last != nil {
+		l.next = head.Last.Height
+		for _, id := range head.LastBlock {
+			l.logged[id] = true
+		}
+	}
+	if l.state, err = ReadState(c); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// transactionNames maps the hash of the code of every file under
+// transactions/, with its imports resolved, to its path.
+func transactionNames(c *emuswap.Client) (map[string]string, error) {
+	names := map[string]string{}
+	err := filepath.WalkDir("transactions", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".cdc" {
+			return err
+		}
+		code, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		parsed, err := c.O.Parse(path, code, c.O.Network)
+		if err != nil {
+			return nil
+		}
+		names[codeHash([]byte(parsed))] = filepath.ToSlash(path)
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return names, nil
+	}
+	return names, err
+}
+
+func codeHash(code []byte) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(string(code))))
+	return hex.EncodeToString(sum[:])
+}
+
+// Sync logs the admin actions in the blocks up to the latest one and returns
+// the number of entries written. The state is read at the latest block when
+// Sync is called, so the entries of one sync share their Before and After;
+// sync every block to keep them apart.
+func (l *Logger) Sync() (int, error) {
+	block, err := l.c.O.GetLatestBlock()
+	if err != nil {
+		return 0, err
+	}
+	byEvents, err := l.eventTransactions(l.next, block.Height)
+	if err != nil {
+		return 0, err
+	}
+	var entries []Entry
+	var about []subjects
+	for height := l.next; height <= block.Height; height++ {
+		found, err := l.scanBlock(height, byEvents[height])
+		if err != nil {
+			return 0, err
+		}
+		for _, f := range found {
+			entries = append(entries, f.entry)
+			about = append(about, f.subjects)
+		}
+	}
+	if len(entries) == 0 {
+		l.advance(block.Height + 1)
+		return 0, nil
+	}
+
+	after, err := ReadState(l.c)
+	if err != nil {
+		return 0, err
+	}
+	for i, entry := range entries {
+		entry.Before, entry.After = l.state.only(about[i]), after.only(about[i])
+		if _, err := l.log.Append(entry); err != nil {
+			return i, err
+		}
+	}
+	l.state = after
+	l.advance(block.Height + 1)
+	return len(entries), nil
+}
+
+func (l *Logger) advance(next uint64) {
+	if next != l.next {
+		l.logged = map[string]bool{}
+	}
+	l.next = next
+}
+
+type found struct {
+	entry    Entry
+	subjects subjects
+}
+
+// eventTransactions returns the transactions emitting events of the
+// contracts by height, in order.
+func (l *Logger) eventTransactions(startHeight, endHeight uint64) (map[uint64][]flow.Identifier, error) {
+	var kinds []events.Event
+	for _, kind := range events.All {
+		if l.addresses.TypeID(kind) != "" {
+			kinds = append(kinds, kind)
+		}
+	}
+	found, err := l.addresses.Fetch(l.c.O, kinds, startHeight, endHeight)
+	if err != nil {
+		return nil, err
+	}
+	byHeight := map[uint64][]flow.Identifier{}
+	for i, f := range found {
+		if i == 0 || found[i-1].TransactionID != f.TransactionID {
+			byHeight[f.Height] = append(byHeight[f.Height], f.TransactionID)
+		}
+	}
+	return byHeight, nil
+}
+
+// scanBlock returns the admin actions in the block at height, in order. The
+// emulator lists no collections in its blocks, there the transactions are
+// found by their events alone and failed transactions are missed.
+func (l *Logger) scanBlock(height uint64, byEvents []flow.Identifier) ([]found, error) {
+	_, _, collections, err := l.c.O.Services.Blocks.GetBlock(strconv.FormatUint(height, 10), "", true)
+	if err != nil {
+		return nil, fmt.Errorf("block %d: %w", height, err)
+	}
+	var ids []flow.Identifier
+	listed := map[flow.Identifier]bool{}
+	for _, collection := range collections {
+		for _, id := range collection.TransactionIDs {
+			ids = append(ids, id)
+			listed[id] = true
+		}
+	}
+	for _, id := range byEvents {
+		if !listed[id] {
+			ids = append(ids, id)
+		}
+	}
+
+	var result []found
+	for _, id := range ids {
+		if l.logged[id.String()] {
+			continue
+		}
+		f, ok, err := l.transaction(height, id)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			result = append(result, f)
+			l.logged[id.String()] = true
+		}
+	}
+	return result, nil
+}
+
+// transaction reads a transaction and reports whether it is an admin action.
+func (l *Logger) transaction(height uint64, id flow.Identifier) (found, bool, error) {
+	tx, result, err := l.c.O.Services.Transactions.GetStatus(id, false)
+	if err != nil {
+		return found{}, false, fmt.Errorf("transaction %s: %w", id, err)
+	}
+	entry := Entry{
+		Height:        height,
+		TransactionID: id.String(),
+		Payer:         tx.Payer,
+		Signers:       append([]flow.Address{}, tx.Authorizers...),
+		Arguments:     []json.RawMessage{},
+		Events:        []Event{},
+	}
+	admin := false
+	for _, signer := range tx.Authorizers {
+		admin = admin || l.admins[signer]
+	}
+
+	about := subjects{pools: map[uint64]bool{}, farms: map[uint64]bool{}}
+	all := false
+	for _, raw := range result.Events {
+		event, err := l.addresses.Decode(raw)
+		if errors.Is(err, events.ErrUnknownEvent) {
+			continue
+		}
+		if err != nil {
+			return found{}, false, fmt.Errorf("transaction %s: %w", id, err)
+		}
+		name := event.Contract() + "." + event.Name()
+		fields, err := json.Marshal(event)
+		if err != nil {
+			return found{}, false, err
+		}
+		entry.Events = append(entry.Events, Event{Type: name, Fields: fields})
+		if !l.admin[name] {
+			continue
+		}
+		admin = true
+		switch e := event.(type) {
+		case events.LPFeeUpdated:
+			about.pools[e.PoolID] = true
+		case events.DAOFeeUpdated:
+			about.pools[e.PoolID] = true
+		case events.PoolIsFrozen:
+			about.pools[e.ID] = true
+		case events.NewSwapPoolCreated:
+			about.pools[e.PoolID] = true
+		case events.StakingRewardsNewFarmCreated:
+			about.farms[e.FarmID] = true
+		default:
+			// reward pools and emission rates change every farm
+			all = true
+		}
+	}
+	if !admin {
+		return found{}, false, nil
+	}
+	if all || len(about.pools)+len(about.farms) == 0 {
+		about = subjects{}
+	}
+
+	entry.Transaction = codeHash(tx.Script)
+	if name, ok := l.names[entry.Transaction]; ok {
+		entry.Transaction = name
+	}
+	for _, argument := range tx.Arguments {
+		entry.Arguments = append(entry.Arguments, json.RawMessage(strings.TrimSpace(string(argument))))
+	}
+	if result.Error != nil {
+		entry.Error = result.Error.Error()
+	}
+	return found{entry: entry, subjects: about}, true, nil
+}
@@ -0,0 +1,238 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/internal/emutest"
+)
+
+// TestMain runs the package tests from the repository root, where flow.json
+// and the files it references resolve.
+func TestMain(m *testing.M) {
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(emutest.Main(m))
+}
+
+var ufix = emuswap.UFix64FromFloat
+
+func writeLog(t *testing.T, n int) (string, [][]byte) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	log, err := Open(path)
+	require.NoError(t, err)
+	for i := 0; i < n; i++ {
+		_, err := log.Append(Entry{
+			Height:        uint64(10 + i),
+			TransactionID: strings.Repeat(string(rune('a'+i)), 64),
+			Transaction:   "transactions/EmuSwap/admin/update_lp_fee_percentage.cdc",
+			Signers:       []flow.Address{flow.HexToAddress("f8d6e0586b0a20c7")},
+			Arguments:     []json.RawMessage{json.RawMessage(`{"type":"UInt64","value":"0"}`)},
+			Events:        []Event{},
+			After:         State{LPFeePercentage: ufix(0.003), Pools: map[uint64]PoolState{0: {LPFeePercentage: ufix(0.0025)}}},
+		})
+		require.NoError(t, err)
+	}
+	require.NoError(t, log.Close())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return path, bytes.SplitAfter(data, []byte("\n"))[:n]
+}
+
+func verify(lines [][]byte) (Head, error) {
+	return Verify(bytes.NewReader(bytes.Join(lines, nil)))
+}
+
+func TestVerify(t *testing.T) {
+	path, lines := writeLog(t, 4)
+	head, err := VerifyFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), head.Entries)
+	assert.Equal(t, uint64(13), head.Last.Height)
+
+	// appending resumes the chain
+	log, err := Open(path)
+	require.NoError(t, err)
+	record, err := log.Append(Entry{Height: 14})
+	require.NoError(t, err)
+	require.NoError(t, log.Close())
+	resumed, err := VerifyFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), resumed.Entries)
+	assert.Equal(t, record.Hash, resumed.Hash)
+
+	edits := map[string]func(line []byte) []byte{
+		"height": func(line []byte) []byte { return bytes.Replace(line, []byte(`"height":1`), []byte(`"height":2`), 1) },
+		"signer": func(line []byte) []byte {
+			return bytes.Replace(line, []byte("f8d6e0586b0a20c7"), []byte("01cf0e2f2f715450"), 1)
+		},
+		"argument": func(line []byte) []byte {
+			return bytes.Replace(line, []byte(`"value":"0"`), []byte(`"value":"1"`), 1)
+		},
+		"state": func(line []byte) []byte {
+			return bytes.Replace(line, []byte(`"lpFeePercentage":"0.00250000"`), []byte(`"lpFeePercentage":"0.00300000"`), 1)
+		},
+		"hash":       func(line []byte) []byte { return bytes.Replace(line, []byte(`"hash":"`), []byte(`"hash":"0`), 1) },
+		"seq":        func(line []byte) []byte { return bytes.Replace(line, []byte(`"seq":`), []byte(`"seq":1`), 1) },
+		"rehashed":   rehash(func(entry []byte) []byte { return bytes.Replace(entry, []byte(`"height":1`), []byte(`"height":2`), 1) }),
+		"whitespace": func(line []byte) []byte { return bytes.Replace(line, []byte(`"height":`), []byte(`"height": `), 1) },
+	}
+	for i := range lines {
+		for name, edit := range edits {
+			tampered := append([][]byte{}, lines...)
+			tampered[i] = edit(lines[i])
+			require.NotEqual(t, lines[i], tampered[i], name)
+			head, err := verify(tampered)
+			if name == "rehashed" && i == len(lines)-1 {
+				// a rehashed last entry chains, only the head tells
+				require.NoError(t, err)
+				assert.NotEqual(t, lines[i], tampered[i])
+				continue
+			}
+			assert.True(t, errors.Is(err, ErrTampered), "%s of entry %d: %v", name, i, err)
+			broken := i
+			if name == "rehashed" {
+				// the next entry no longer follows it
+				broken++
+			}
+			assert.Equal(t, uint64(broken), head.Entries, name)
+		}
+	}
+
+	for name, tampered := range map[string][][]byte{
+		"removed":    {lines[0], lines[2], lines[3]},
+		"reordered":  {lines[0], lines[2], lines[1], lines[3]},
+		"duplicated": {lines[0], lines[1], lines[1], lines[2], lines[3]},
+		"truncated":  {lines[0], lines[1], lines[2], lines[3][:len(lines[3])-1]},
+	} {
+		_, err := verify(tampered)
+		assert.True(t, errors.Is(err, ErrTampered), name)
+	}
+
+	// a removed last entry chains, the head moves back
+	short, err := verify(lines[:3])
+	require.NoError(t, err)
+	assert.NotEqual(t, head.Hash, short.Hash)
+
+	// tampered logs are not appended to
+	require.NoError(t, os.WriteFile(path, bytes.Join([][]byte{lines[0], lines[2]}, nil), 0o644))
+	_, err = Open(path)
+	assert.True(t, errors.Is(err, ErrTampered))
+}
+
+// rehash edits the entry of a line and recomputes its hash.
+func rehash(edit func(entry []byte) []byte) func(line []byte) []byte {
+	return func(line []byte) []byte {
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			panic(err)
+		}
+		record.Entry = edit(record.Entry)
+		record.Hash = hash(record.Entry)
+		data, err := json.Marshal(record)
+		if err != nil {
+			panic(err)
+		}
+		return append(data, '\n')
+	}
+}
+
+func TestLogger(t *testing.T) {
+	o, err := emutest.Start()
+	require.NoError(t, err)
+	c := emuswap.NewClient(o)
+	flowToken, fusd := emuswap.MustLookupToken("FLOW"), emuswap.MustLookupToken("FUSD")
+	admin := c.Address("account")
+
+	c.DemoMintFlowTokens("account", ufix(1000.0), admin).Test(t).AssertSuccess()
+	c.FUSDSetup("account").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", ufix(1000.0), admin).Test(t).AssertSuccess()
+	c.DemoMintFlowTokens("account", ufix(100.0), c.Address("user1")).Test(t).AssertSuccess()
+	c.FUSDSetup("user1").Test(t).AssertSuccess()
+	block, err := o.GetLatestBlock()
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	log, err := Open(path)
+	require.NoError(t, err)
+	logger, err := NewLogger(c, log, Options{StartHeight: block.Height + 1})
+	require.NoError(t, err)
+	sync := func(expected int) []Entry {
+		before := log.Head().Entries
+		n, err := logger.Sync()
+		require.NoError(t, err)
+		require.Equal(t, expected, n)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		var entries []Entry
+		for _, line := range bytes.Split(bytes.TrimSpace(data), []byte("\n"))[before:] {
+			var record Record
+			require.NoError(t, json.Unmarshal(line, &record))
+			var entry Entry
+			require.NoError(t, json.Unmarshal(record.Entry, &entry))
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+
+	c.EmuSwapAdminCreateNewPool("account", flowToken.StoragePath, ufix(100.0), fusd.StoragePath, ufix(50.0)).Test(t).AssertSuccess()
+	created := sync(1)[0]
+	assert.Equal(t, "transactions/EmuSwap/admin/create_new_pool.cdc", created.Transaction)
+	assert.Equal(t, []flow.Address{admin}, created.Signers)
+	assert.Len(t, created.Arguments, 4)
+	assert.JSONEq(t, `{"type":"UFix64","value":"100.00000000"}`, string(created.Arguments[1]))
+	var types []string
+	for _, event := range created.Events {
+		types = append(types, event.Type)
+	}
+	assert.Contains(t, types, "EmuSwap.NewSwapPoolCreated")
+	assert.Contains(t, types, "EmuSwap.PoolIsFrozen")
+	assert.Empty(t, created.Before.Pools)
+	require.Contains(t, created.After.Pools, uint64(0))
+	assert.Equal(t, ufix(100.0), created.After.Pools[0].Meta.Token1Amount)
+	assert.False(t, created.After.Pools[0].Frozen)
+
+	// users are not logged
+	c.Swap("user1", flowToken, fusd, ufix(1.0)).Test(t).AssertSuccess()
+	sync(0)
+
+	c.EmuSwapAdminUpdateLPFeePercentage("account", 0, ufix(0.0025)).Test(t).AssertSuccess()
+	updated := sync(1)[0]
+	assert.Equal(t, "transactions/EmuSwap/admin/update_lp_fee_percentage.cdc", updated.Transaction)
+	assert.Equal(t, updated.Before.LPFeePercentage, updated.Before.Pools[0].LPFeePercentage)
+	assert.Equal(t, ufix(0.0025), updated.After.Pools[0].LPFeePercentage)
+	assert.Equal(t, []Event{{Type: "EmuSwap.LPFeeUpdated", Fields: json.RawMessage(`{"PoolID":0,"FeePercentage":"0.00250000"}`)}}, updated.Events)
+
+	// entries of one sync share the state
+	c.EmuSwapAdminTogglePoolFreeze("account", 0).Test(t).AssertSuccess()
+	c.StakingAdminCreateNewFarm("account", 0).Test(t).AssertSuccess()
+	both := sync(2)
+	assert.True(t, both[0].After.Pools[0].Frozen)
+	assert.Equal(t, "StakingRewards.NewFarmCreated", both[1].Events[0].Type)
+	assert.Empty(t, both[1].Before.Farms)
+	assert.Empty(t, both[1].After.Pools, "the farm alone is the subject")
+
+	require.NoError(t, log.Close())
+	head, err := VerifyFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), head.Entries)
+
+	// a reopened log resumes without logging twice
+	log, err = Open(path)
+	require.NoError(t, err)
+	defer log.Close()
+	logger, err = NewLogger(c, log, Options{})
+	require.NoError(t, err)
+	sync(0)
+	assert.Equal(t, head.Hash, log.Head().Hash)
+}
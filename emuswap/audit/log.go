@@ -0,0 +1,159 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Genesis is the previous hash of the first entry.
+var Genesis = strings.Repeat("0", sha256.Size*2)
+
+// ErrTampered is returned by Verify for a log that is not the chain it was
+// written as.
+var ErrTampered = errors.New("audit log tampered")
+
+// Record is one line of the log. Hash is the SHA-256 of the bytes of Entry
+// exactly as written, and every entry holds the hash of the one before it.
+type Record struct {
+	Hash  string          `json:"hash"`
+	Entry json.RawMessage `json:"entry"`
+}
+
+// Head is the end of a verified chain. Keeping the head hash elsewhere also
+// detects entries removed from the end of the log.
+type Head struct {
+	Entries uint64 `json:"entries"`
+	Hash    string `json:"hash"`
+	// Last is the last entry, nil for an empty log, and LastBlock the
+	// transactions of the entries at its height.
+	Last      *Entry   `json:"-"`
+	LastBlock []string `json:"-"`
+}
+
+func hash(entry []byte) string {
+	sum := sha256.Sum256(entry)
+	return hex.EncodeToString(sum[:])
+}
+
+func (h *Head) advance(hash string, entry Entry) {
+	if h.Last == nil || h.Last.Height != entry.Height {
+		h.LastBlock = nil
+	}
+	h.Entries++
+	h.Hash = hash
+	h.Last = &entry
+	h.LastBlock = append(h.LastBlock, entry.TransactionID)
+}
+
+// Verify reads a log and checks every hash and link. A broken chain returns
+// ErrTampered with the line it breaks at.
+func Verify(r io.Reader) (Head, error) {
+	head := Head{Hash: Genesis}
+	lines := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := lines.ReadBytes('\n')
+		if err == io.EOF {
+			if len(data) > 0 {
+				return head, fmt.Errorf("line %d: %w: no newline after the last entry", line, ErrTampered)
+			}
+			return head, nil
+		}
+		if err != nil {
+			return head, err
+		}
+		var record Record
+		if err := json.Unmarshal(data, &record); err != nil {
+			return head, fmt.Errorf("line %d: %w: %s", line, ErrTampered, err)
+		}
+		if got := hash(record.Entry); got != record.Hash {
+			return head, fmt.Errorf("line %d: %w: entry hashes to %s, not %s", line, ErrTampered, got, record.Hash)
+		}
+		var entry Entry
+		if err := json.Unmarshal(record.Entry, &entry); err != nil {
+			return head, fmt.Errorf("line %d: %w: %s", line, ErrTampered, err)
+		}
+		if entry.Seq != head.Entries {
+			return head, fmt.Errorf("line %d: %w: entry %d where %d was expected", line, ErrTampered, entry.Seq, head.Entries)
+		}
+		if entry.Prev != head.Hash {
+			return head, fmt.Errorf("line %d: %w: entry follows %s, not %s", line, ErrTampered, entry.Prev, head.Hash)
+		}
+		head.advance(record.Hash, entry)
+	}
+}
+
+// VerifyFile verifies the log at path.
+func VerifyFile(path string) (Head, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Head{}, err
+	}
+	defer f.Close()
+	return Verify(f)
+}
+
+// Log appends entries to a log file. It is only ever opened for appending.
+type Log struct {
+	f    *os.File
+	head Head
+}
+
+// Open verifies the log at path, creating it if needed, and opens it for
+// appending after its head.
+func Open(path string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	head, err := Verify(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &Log{f: f, head: head}, nil
+}
+
+// Head returns the end of the chain.
+func (l *Log) Head() Head {
+	return l.head
+}
+
+// Append chains entry to the head and writes it, synced to disk. Seq and
+// Prev are set by Append.
+func (l *Log) Append(entry Entry) (Record, error) {
+	entry.Seq, entry.Prev = l.head.Entries, l.head.Hash
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return Record{}, err
+	}
+	record := Record{Hash: hash(data), Entry: data}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return Record{}, err
+	}
+	// the entry must be written as hashed, Marshal keeps RawMessage as is
+	if !bytes.Contains(line, data) {
+		return Record{}, errors.New("audit: entry not written as hashed")
+	}
+	if _, err := l.f.Write(append(line, '\n')); err != nil {
+		return Record{}, err
+	}
+	if err := l.f.Sync(); err != nil {
+		return Record{}, err
+	}
+	l.head.advance(record.Hash, entry)
+	return record, nil
+}
+
+// Close closes the file.
+func (l *Log) Close() error {
+	return l.f.Close()
+}
@@ -0,0 +1,608 @@
+// Code generated by cmd/bindgen from scripts/ and transactions/. DO NOT EDIT.
+
+package emuswap
+
+import (
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/flow-go-sdk"
+)
+
+// DecayingEmission mirrors StakingRewards.DecayingEmission.
+type DecayingEmission struct {
+	EpochLength UFix64 `cadence:"epochLength"`
+	TotalEpochs UFix64 `cadence:"totalEpochs"`
+	Decay       UFix64 `cadence:"decay"`
+}
+
+// DropMeta mirrors FTAirdrop.DropMeta.
+type DropMeta struct {
+	ID              uint64                  `cadence:"id"`
+	TokenIdentifier string                  `cadence:"tokenIdentifier"`
+	Balance         UFix64                  `cadence:"balance"`
+	StartTime       UFix64                  `cadence:"startTime"`
+	EndTime         UFix64                  `cadence:"endTime"`
+	Address         flow.Address            `cadence:"address"`
+	Claims          map[flow.Address]UFix64 `cadence:"claims"`
+}
+
+// FarmMeta mirrors StakingRewards.FarmMeta.
+type FarmMeta struct {
+	ID                                 uint64                     `cadence:"id"`
+	Stakes                             map[flow.Address]StakeInfo `cadence:"stakes"`
+	TotalStaked                        UFix64                     `cadence:"totalStaked"`
+	LastRewardTimestamp                UFix64                     `cadence:"lastRewardTimestamp"`
+	FarmWeightsByID                    map[uint64]UFix64          `cadence:"farmWeightsByID"`
+	RewardTokensPerSecondByID          map[uint64]UFix64          `cadence:"rewardTokensPerSecondByID"`
+	TotalAccumulatedTokensPerShareByID map[uint64]UFix64          `cadence:"totalAccumulatedTokensPerShareByID"`
+	RewardsRemainingByID               map[uint64]UFix64          `cadence:"rewardsRemainingByID"`
+}
+
+// PoolMeta mirrors EmuSwap.PoolMeta.
+type PoolMeta struct {
+	Token1Amount     UFix64 `cadence:"token1Amount"`
+	Token2Amount     UFix64 `cadence:"token2Amount"`
+	Token1Identifier string `cadence:"token1Identifier"`
+	Token2Identifier string `cadence:"token2Identifier"`
+	TotalSupply      UFix64 `cadence:"totalSupply"`
+}
+
+// Proposal mirrors EmuMultiSig.Proposal.
+type Proposal struct {
+	TxIndex uint64        `cadence:"txIndex"`
+	Method  string        `cadence:"method"`
+	Args    []interface{} `cadence:"args"`
+	Expiry  uint64        `cadence:"expiry"`
+	Signers []string      `cadence:"signers"`
+}
+
+// RewardPoolMeta mirrors StakingRewards.RewardPoolMeta.
+type RewardPoolMeta struct {
+	ID                      uint64            `cadence:"id"`
+	TokenIdentifier         string            `cadence:"tokenIdentifier"`
+	Balance                 UFix64            `cadence:"balance"`
+	FarmWeightsByID         map[uint64]UFix64 `cadence:"farmWeightsByID"`
+	TotalWeight             UFix64            `cadence:"totalWeight"`
+	RewardsGenesisTimestamp UFix64            `cadence:"rewardsGenesisTimestamp"`
+	CurrentEmissionRate     UFix64            `cadence:"currentEmissionRate"`
+	Emission                *DecayingEmission `cadence:"emission"`
+	AccessNFTsAccepted      []string          `cadence:"accessNFTsAccepted"`
+}
+
+// StakeInfo mirrors StakingRewards.StakeInfo.
+type StakeInfo struct {
+	Address           flow.Address     `cadence:"address"`
+	Balance           UFix64           `cadence:"balance"`
+	RewardDebtByID    map[uint64]Fix64 `cadence:"rewardDebtByID"`
+	PendingRewards    map[uint64]Fix64 `cadence:"pendingRewards"`
+	StakedNFTs        []string         `cadence:"stakedNFTs"`
+	RewardReceiverIDs []uint64         `cadence:"rewardReceiverIDs"`
+}
+
+// VestingMeta mirrors Vesting.VestingMeta.
+type VestingMeta struct {
+	Address         flow.Address `cadence:"address"`
+	InitalBalance   UFix64       `cadence:"initalBalance"`
+	LockedFrom      UFix64       `cadence:"lockedFrom"`
+	Duration        UFix64       `cadence:"duration"`
+	Balance         UFix64       `cadence:"balance"`
+	TokensWithdrawn UFix64       `cadence:"tokensWithdrawn"`
+}
+
+// ExampleNFTGetIDs runs scripts/ExampleNFT/get_ids.cdc.
+func (c *Client) ExampleNFTGetIDs(address flow.Address) ([]uint64, error) {
+	var result []uint64
+	err := c.script("ExampleNFT/get_ids", &result, address)
+	return result, err
+}
+
+// FTAirdropCheckAvailableClaims runs scripts/FTAirdrop/checkAvailableClaims.cdc.
+func (c *Client) FTAirdropCheckAvailableClaims(address flow.Address) (interface{}, error) {
+	var result interface{}
+	err := c.script("FTAirdrop/checkAvailableClaims", &result, address)
+	return result, err
+}
+
+// FTAirdropGetDropsMeta runs scripts/FTAirdrop/get_drops_meta.cdc.
+func (c *Client) FTAirdropGetDropsMeta() (map[uint64]DropMeta, error) {
+	var result map[uint64]DropMeta
+	err := c.script("FTAirdrop/get_drops_meta", &result)
+	return result, err
+}
+
+// MultiSigGetBlockHeight runs scripts/MultiSig/get_block_height.cdc.
+func (c *Client) MultiSigGetBlockHeight() (uint64, error) {
+	var result uint64
+	err := c.script("MultiSig/get_block_height", &result)
+	return result, err
+}
+
+// MultiSigGetProposals runs scripts/MultiSig/get_proposals.cdc.
+func (c *Client) MultiSigGetProposals() (map[uint64]Proposal, error) {
+	var result map[uint64]Proposal
+	err := c.script("MultiSig/get_proposals", &result)
+	return result, err
+}
+
+// MultiSigGetTxIndex runs scripts/MultiSig/get_tx_index.cdc.
+func (c *Client) MultiSigGetTxIndex() (uint64, error) {
+	var result uint64
+	err := c.script("MultiSig/get_tx_index", &result)
+	return result, err
+}
+
+// StakingGetEmissionRates runs scripts/Staking/get_emission_rates.cdc.
+func (c *Client) StakingGetEmissionRates(epochLength UFix64, totalEpochs UFix64, decay UFix64, elapsed []UFix64) ([]UFix64, error) {
+	var result []UFix64
+	err := c.script("Staking/get_emission_rates", &result, epochLength, totalEpochs, decay, elapsed)
+	return result, err
+}
+
+// StakingGetFarmMeta runs scripts/Staking/get_farm_meta.cdc.
+func (c *Client) StakingGetFarmMeta(id uint64) (*FarmMeta, error) {
+	var result *FarmMeta
+	err := c.script("Staking/get_farm_meta", &result, id)
+	return result, err
+}
+
+// StakingGetNow runs scripts/Staking/get_now.cdc.
+func (c *Client) StakingGetNow() (UFix64, error) {
+	var result UFix64
+	err := c.script("Staking/get_now", &result)
+	return result, err
+}
+
+// StakingGetPendingRewards runs scripts/Staking/get_pending_rewards.cdc.
+func (c *Client) StakingGetPendingRewards(id uint64, address flow.Address) (map[uint64]Fix64, error) {
+	var result map[uint64]Fix64
+	err := c.script("Staking/get_pending_rewards", &result, id, address)
+	return result, err
+}
+
+// StakingGetRewardPoolsMeta runs scripts/Staking/get_reward_pools_meta.cdc.
+func (c *Client) StakingGetRewardPoolsMeta() (map[uint64]RewardPoolMeta, error) {
+	var result map[uint64]RewardPoolMeta
+	err := c.script("Staking/get_reward_pools_meta", &result)
+	return result, err
+}
+
+// StakingGetStakeMeta runs scripts/Staking/get_stake_meta.cdc.
+func (c *Client) StakingGetStakeMeta(id uint64, address flow.Address) (StakeInfo, error) {
+	var result StakeInfo
+	err := c.script("Staking/get_stake_meta", &result, id, address)
+	return result, err
+}
+
+// StakingGetTotalStaked runs scripts/Staking/get_total_staked.cdc.
+func (c *Client) StakingGetTotalStaked() (map[uint64]UFix64, error) {
+	var result map[uint64]UFix64
+	err := c.script("Staking/get_total_staked", &result)
+	return result, err
+}
+
+// StakingIsMockTime runs scripts/Staking/is_mock_time.cdc.
+func (c *Client) StakingIsMockTime() (bool, error) {
+	var result bool
+	err := c.script("Staking/is_mock_time", &result)
+	return result, err
+}
+
+// StakingReadAllStakes runs scripts/Staking/read_all_stakes.cdc.
+func (c *Client) StakingReadAllStakes() (map[uint64]map[flow.Address]StakeInfo, error) {
+	var result map[uint64]map[flow.Address]StakeInfo
+	err := c.script("Staking/read_all_stakes", &result)
+	return result, err
+}
+
+// StakingReadStakesInfo runs scripts/Staking/read_stakes_info.cdc.
+func (c *Client) StakingReadStakesInfo(id uint64) (map[flow.Address]StakeInfo, error) {
+	var result map[flow.Address]StakeInfo
+	err := c.script("Staking/read_stakes_info", &result, id)
+	return result, err
+}
+
+// VestingGetUnlockAllowance runs scripts/Vesting/getUnlockAllowance.cdc.
+func (c *Client) VestingGetUnlockAllowance(address flow.Address) (UFix64, error) {
+	var result UFix64
+	err := c.script("Vesting/getUnlockAllowance", &result, address)
+	return result, err
+}
+
+// VestingGetVestingMeta runs scripts/Vesting/get_vesting_meta.cdc.
+func (c *Client) VestingGetVestingMeta() (map[flow.Address]VestingMeta, error) {
+	var result map[flow.Address]VestingMeta
+	err := c.script("Vesting/get_vesting_meta", &result)
+	return result, err
+}
+
+// GetBalances runs scripts/get_balances.cdc.
+func (c *Client) GetBalances(addresses []flow.Address, balancePaths []string) (map[flow.Address]map[string]UFix64, error) {
+	var result map[flow.Address]map[string]UFix64
+	err := c.script("get_balances", &result, addresses, balancePaths)
+	return result, err
+}
+
+// GetDAOFeePercentage runs scripts/get_dao_fee_percentage.cdc.
+func (c *Client) GetDAOFeePercentage() (UFix64, error) {
+	var result UFix64
+	err := c.script("get_dao_fee_percentage", &result)
+	return result, err
+}
+
+// GetFrozenPools runs scripts/get_frozen_pools.cdc.
+func (c *Client) GetFrozenPools() (map[uint64]bool, error) {
+	var result map[uint64]bool
+	err := c.script("get_frozen_pools", &result)
+	return result, err
+}
+
+// GetLPBalances runs scripts/get_lp_balances.cdc.
+func (c *Client) GetLPBalances(addresses []flow.Address) (map[flow.Address]map[uint64]UFix64, error) {
+	var result map[flow.Address]map[uint64]UFix64
+	err := c.script("get_lp_balances", &result, addresses)
+	return result, err
+}
+
+// GetLPFeePercentage runs scripts/get_lp_fee_percentage.cdc.
+func (c *Client) GetLPFeePercentage() (UFix64, error) {
+	var result UFix64
+	err := c.script("get_lp_fee_percentage", &result)
+	return result, err
+}
+
+// GetPoolFees runs scripts/get_pool_fees.cdc.
+func (c *Client) GetPoolFees() (map[uint64]map[string]UFix64, error) {
+	var result map[uint64]map[string]UFix64
+	err := c.script("get_pool_fees", &result)
+	return result, err
+}
+
+// GetPoolIDFromTokenIDs runs scripts/get_pool_id_from_token_ids.cdc.
+func (c *Client) GetPoolIDFromTokenIDs(token1 string, token2 string) (*uint64, error) {
+	var result *uint64
+	err := c.script("get_pool_id_from_token_ids", &result, token1, token2)
+	return result, err
+}
+
+// GetPoolIDs runs scripts/get_pool_ids.cdc.
+func (c *Client) GetPoolIDs() ([]uint64, error) {
+	var result []uint64
+	err := c.script("get_pool_ids", &result)
+	return result, err
+}
+
+// GetPoolMeta runs scripts/get_pool_meta.cdc.
+func (c *Client) GetPoolMeta(poolID uint64) (PoolMeta, error) {
+	var result PoolMeta
+	err := c.script("get_pool_meta", &result, poolID)
+	return result, err
+}
+
+// GetPoolsMeta runs scripts/get_pools_meta.cdc.
+func (c *Client) GetPoolsMeta() ([]PoolMeta, error) {
+	var result []PoolMeta
+	err := c.script("get_pools_meta", &result)
+	return result, err
+}
+
+// GetPortfolio runs scripts/get_portfolio.cdc.
+func (c *Client) GetPortfolio(address flow.Address, balancePaths []string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := c.script("get_portfolio", &result, address, balancePaths)
+	return result, err
+}
+
+// GetSwapsAvailable runs scripts/get_swaps_available.cdc.
+func (c *Client) GetSwapsAvailable(tokenIdentifier string) (map[string]uint64, error) {
+	var result map[string]uint64
+	err := c.script("get_swaps_available", &result, tokenIdentifier)
+	return result, err
+}
+
+// PoolGetQuoteAToExactB runs scripts/pool/get_quote_a_to_exact_b.cdc.
+func (c *Client) PoolGetQuoteAToExactB(poolID uint64, amount UFix64) (UFix64, error) {
+	var result UFix64
+	err := c.script("pool/get_quote_a_to_exact_b", &result, poolID, amount)
+	return result, err
+}
+
+// PoolGetQuoteBToExactA runs scripts/pool/get_quote_b_to_exact_a.cdc.
+func (c *Client) PoolGetQuoteBToExactA(poolID uint64, amount UFix64) (UFix64, error) {
+	var result UFix64
+	err := c.script("pool/get_quote_b_to_exact_a", &result, poolID, amount)
+	return result, err
+}
+
+// PoolGetQuoteExactAToB runs scripts/pool/get_quote_exact_a_to_b.cdc.
+func (c *Client) PoolGetQuoteExactAToB(poolID uint64, amount UFix64) (UFix64, error) {
+	var result UFix64
+	err := c.script("pool/get_quote_exact_a_to_b", &result, poolID, amount)
+	return result, err
+}
+
+// PoolGetQuoteExactBToA runs scripts/pool/get_quote_exact_b_to_a.cdc.
+func (c *Client) PoolGetQuoteExactBToA(poolID uint64, amount UFix64) (UFix64, error) {
+	var result UFix64
+	err := c.script("pool/get_quote_exact_b_to_a", &result, poolID, amount)
+	return result, err
+}
+
+// PoolGetQuotes runs scripts/pool/get_quotes.cdc.
+func (c *Client) PoolGetQuotes(poolID uint64, amount UFix64) (map[string]UFix64, error) {
+	var result map[string]UFix64
+	err := c.script("pool/get_quotes", &result, poolID, amount)
+	return result, err
+}
+
+// ReadFeesCollected runs scripts/read_fees_collected.cdc.
+func (c *Client) ReadFeesCollected() (map[string]UFix64, error) {
+	var result map[string]UFix64
+	err := c.script("read_fees_collected", &result)
+	return result, err
+}
+
+// XEmuGetPool runs scripts/xEmu/get_pool.cdc.
+func (c *Client) XEmuGetPool() (map[string]UFix64, error) {
+	var result map[string]UFix64
+	err := c.script("xEmu/get_pool", &result)
+	return result, err
+}
+
+// EmuSwapAdminCreateNewPool builds transactions/EmuSwap/admin/create_new_pool.cdc signed by signer.
+func (c *Client) EmuSwapAdminCreateNewPool(signer string, token1Storage string, token1Amount UFix64, token2Storage string, token2Amount UFix64) overflow.FlowTransactionBuilder {
+	return c.transaction("EmuSwap/admin/create_new_pool", signer, token1Storage, token1Amount, token2Storage, token2Amount)
+}
+
+// EmuSwapAdminCreateNewPoolEMUFUSD builds transactions/EmuSwap/admin/create_new_pool_EMU_FUSD.cdc signed by signer.
+func (c *Client) EmuSwapAdminCreateNewPoolEMUFUSD(signer string, token1Amount UFix64, token2Amount UFix64) overflow.FlowTransactionBuilder {
+	return c.transaction("EmuSwap/admin/create_new_pool_EMU_FUSD", signer, token1Amount, token2Amount)
+}
+
+// EmuSwapAdminCreateNewPoolFLOWFUSD builds transactions/EmuSwap/admin/create_new_pool_FLOW_FUSD.cdc signed by signer.
+func (c *Client) EmuSwapAdminCreateNewPoolFLOWFUSD(signer string, token1Amount UFix64, token2Amount UFix64) overflow.FlowTransactionBuilder {
+	return c.transaction("EmuSwap/admin/create_new_pool_FLOW_FUSD", signer, token1Amount, token2Amount)
+}
+
+// EmuSwapAdminTogglePoolFreeze builds transactions/EmuSwap/admin/toggle_pool_freeze.cdc signed by signer.
+func (c *Client) EmuSwapAdminTogglePoolFreeze(signer string, id uint64) overflow.FlowTransactionBuilder {
+	return c.transaction("EmuSwap/admin/toggle_pool_freeze", signer, id)
+}
+
+// EmuSwapAdminUpdateDAOFeePercentage builds transactions/EmuSwap/admin/update_dao_fee_percentage.cdc signed by signer.
+func (c *Client) EmuSwapAdminUpdateDAOFeePercentage(signer string, id uint64, feePercentage UFix64) overflow.FlowTransactionBuilder {
+	return c.transaction("EmuSwap/admin/update_dao_fee_percentage", signer, id, feePercentage)
+}
+
+// EmuSwapAdminUpdateLPFeePercentage builds transactions/EmuSwap/admin/update_lp_fee_percentage.cdc signed by signer.
+func (c *Client) EmuSwapAdminUpdateLPFeePercentage(signer string, id uint64, feePercentage UFix64) overflow.FlowTransactionBuilder {
+	return c.transaction("EmuSwap/admin/update_lp_fee_percentage", signer, id, feePercentage)
+}
+
+// EmuSwapAdminWithdrawFees builds transactions/EmuSwap/admin/withdraw_fees.cdc signed by signer.
+func (c *Client) EmuSwapAdminWithdrawFees(signer string) overflow.FlowTransactionBuilder {
+	return c.transaction("EmuSwap/admin/withdraw_fees", signer)
+}
+
+// EmuSwapUserAddLiquidity builds transactions/EmuSwap/user/add_liquidity.cdc signed by signer.
+func (c *Client) EmuSwapUserAddLiquidity(signer string, token1Path string, token1Amount UFix64, token2Path string, token2Amount UFix64) overflow.FlowTransactionBuilder {
+	return c.transaction("EmuSwap/user/add_liquidity", signer, token1Path, token1Amount, token2Path, token2Amount)
+}
+
+// EmuSwapUserRemoveLiquidity builds transactions/EmuSwap/user/remove_liquidity.cdc signed by signer.
+func (c *Client) EmuSwapUserRemoveLiquidity(signer string, amount UFix64, storageIdentifierA string, storageIdentifierB string) overflow.FlowTransactionBuilder {
+	return c.transaction("EmuSwap/user/remove_liquidity", signer, amount, storageIdentifierA, storageIdentifierB)
+}
+
+// EmuSwapUserSwap builds transactions/EmuSwap/user/swap.cdc signed by signer.
+func (c *Client) EmuSwapUserSwap(signer string, fromTokenStorageIdentifier string, toTokenStorageIdentifier string, amount UFix64) overflow.FlowTransactionBuilder {
+	return c.transaction("EmuSwap/user/swap", signer, fromTokenStorageIdentifier, toTokenStorageIdentifier, amount)
+}
+
+// EmuTokenSetup builds transactions/EmuToken/setup.cdc signed by signer.
+func (c *Client) EmuTokenSetup(signer string) overflow.FlowTransactionBuilder {
+	return c.transaction("EmuToken/setup", signer)
+}
+
+// EmuTokenTransfer builds transactions/EmuToken/transfer.cdc signed by signer.
+func (c *Client) EmuTokenTransfer(signer string, amount UFix64, to flow.Address) overflow.FlowTransactionBuilder {
+	return c.transaction("EmuToken/transfer", signer, amount, to)
+}
+
+// ExampleNFTMint builds transactions/ExampleNFT/mint.cdc signed by signer.
+func (c *Client) ExampleNFTMint(signer string, recipient flow.Address) overflow.FlowTransactionBuilder {
+	return c.transaction("ExampleNFT/mint", signer, recipient)
+}
+
+// ExampleNFTSetup builds transactions/ExampleNFT/setup.cdc signed by signer.
+func (c *Client) ExampleNFTSetup(signer string) overflow.FlowTransactionBuilder {
+	return c.transaction("ExampleNFT/setup", signer)
+}
+
+// FTAirdropClaimDrop builds transactions/FTAirdrop/claimDrop.cdc signed by signer.
+func (c *Client) FTAirdropClaimDrop(signer string, id uint64, ftReceiverIdentifier string) overflow.FlowTransactionBuilder {
+	return c.transaction("FTAirdrop/claimDrop", signer, id, ftReceiverIdentifier)
+}
+
+// FTAirdropCreateDrop builds transactions/FTAirdrop/createDrop.cdc signed by signer.
+func (c *Client) FTAirdropCreateDrop(signer string, amount UFix64) overflow.FlowTransactionBuilder {
+	return c.transaction("FTAirdrop/createDrop", signer, amount)
+}
+
+// FUSDSetup builds transactions/FUSD/setup.cdc signed by signer.
+func (c *Client) FUSDSetup(signer string) overflow.FlowTransactionBuilder {
+	return c.transaction("FUSD/setup", signer)
+}
+
+// FUSDTransfer builds transactions/FUSD/transfer.cdc signed by signer.
+func (c *Client) FUSDTransfer(signer string, amount UFix64, to flow.Address) overflow.FlowTransactionBuilder {
+	return c.transaction("FUSD/transfer", signer, amount, to)
+}
+
+// MultiSigAdminSetup builds transactions/MultiSig/admin/setup.cdc signed by signer.
+func (c *Client) MultiSigAdminSetup(signer string, publicKeys []string, weights []UFix64, signatureAlgorithms []uint8) overflow.FlowTransactionBuilder {
+	return c.transaction("MultiSig/admin/setup", signer, publicKeys, weights, signatureAlgorithms)
+}
+
+// MultiSigExecute builds transactions/MultiSig/execute.cdc signed by signer.
+func (c *Client) MultiSigExecute(signer string, txIndex uint64) overflow.FlowTransactionBuilder {
+	return c.transaction("MultiSig/execute", signer, txIndex)
+}
+
+// MultiSigPropose builds transactions/MultiSig/propose.cdc signed by signer.
+func (c *Client) MultiSigPropose(signer string, txIndex uint64, method string, args []interface{}, publicKey string, signature string) overflow.FlowTransactionBuilder {
+	return c.transaction("MultiSig/propose", signer, txIndex, method, args, publicKey, signature)
+}
+
+// MultiSigRemoveExpired builds transactions/MultiSig/remove_expired.cdc signed by signer.
+func (c *Client) MultiSigRemoveExpired(signer string, txIndex uint64) overflow.FlowTransactionBuilder {
+	return c.transaction("MultiSig/remove_expired", signer, txIndex)
+}
+
+// MultiSigSign builds transactions/MultiSig/sign.cdc signed by signer.
+func (c *Client) MultiSigSign(signer string, txIndex uint64, publicKey string, signature string) overflow.FlowTransactionBuilder {
+	return c.transaction("MultiSig/sign", signer, txIndex, publicKey, signature)
+}
+
+// StakingAdminAddAccessNFT builds transactions/Staking/admin/add_access_nft.cdc signed by signer.
+func (c *Client) StakingAdminAddAccessNFT(signer string, rewardPoolID uint64, nftIdentifier string) overflow.FlowTransactionBuilder {
+	return c.transaction("Staking/admin/add_access_nft", signer, rewardPoolID, nftIdentifier)
+}
+
+// StakingAdminCreateDecayingRewardPool builds transactions/Staking/admin/create_decaying_reward_pool.cdc signed by signer.
+func (c *Client) StakingAdminCreateDecayingRewardPool(signer string, storageID string, amount UFix64, epochLength UFix64, totalEpochs UFix64, decay UFix64, farmWeightsByID map[uint64]UFix64, nftPaths []string) overflow.FlowTransactionBuilder {
+	return c.transaction("Staking/admin/create_decaying_reward_pool", signer, storageID, amount, epochLength, totalEpochs, decay, farmWeightsByID, nftPaths)
+}
+
+// StakingAdminCreateNewFarm builds transactions/Staking/admin/create_new_farm.cdc signed by signer.
+func (c *Client) StakingAdminCreateNewFarm(signer string, poolID uint64) overflow.FlowTransactionBuilder {
+	return c.transaction("Staking/admin/create_new_farm", signer, poolID)
+}
+
+// StakingAdminCreateRewardPool builds transactions/Staking/admin/create_reward_pool.cdc signed by signer.
+func (c *Client) StakingAdminCreateRewardPool(signer string, storageID string, amount UFix64, nftPaths []string) overflow.FlowTransactionBuilder {
+	return c.transaction("Staking/admin/create_reward_pool", signer, storageID, amount, nftPaths)
+}
+
+// StakingAdminCreateRewardPoolFusd builds transactions/Staking/admin/create_reward_pool_fusd.cdc signed by signer.
+func (c *Client) StakingAdminCreateRewardPoolFusd(signer string, amount UFix64) overflow.FlowTransactionBuilder {
+	return c.transaction("Staking/admin/create_reward_pool_fusd", signer, amount)
+}
+
+// StakingAdminRemoveAccessNFT builds transactions/Staking/admin/remove_access_nft.cdc signed by signer.
+func (c *Client) StakingAdminRemoveAccessNFT(signer string, rewardPoolID uint64, index uint64) overflow.FlowTransactionBuilder {
+	return c.transaction("Staking/admin/remove_access_nft", signer, rewardPoolID, index)
+}
+
+// StakingAdminToggleMockTime builds transactions/Staking/admin/toggle_mock_time.cdc signed by signer.
+func (c *Client) StakingAdminToggleMockTime(signer string) overflow.FlowTransactionBuilder {
+	return c.transaction("Staking/admin/toggle_mock_time", signer)
+}
+
+// StakingAdminUpdateMockTimestamp builds transactions/Staking/admin/update_mock_timestamp.cdc signed by signer.
+func (c *Client) StakingAdminUpdateMockTimestamp(signer string, delta UFix64) overflow.FlowTransactionBuilder {
+	return c.transaction("Staking/admin/update_mock_timestamp", signer, delta)
+}
+
+// StakingUserAddRewardReceiver builds transactions/Staking/user/add_reward_receiver.cdc signed by signer.
+func (c *Client) StakingUserAddRewardReceiver(signer string, farmID uint64, rewardPoolID uint64, ftReceiverCap string, vaultPath string) overflow.FlowTransactionBuilder {
+	return c.transaction("Staking/user/add_reward_receiver", signer, farmID, rewardPoolID, ftReceiverCap, vaultPath)
+}
+
+// StakingUserClaimRewards builds transactions/Staking/user/claim_rewards.cdc signed by signer.
+func (c *Client) StakingUserClaimRewards(signer string, farmID uint64) overflow.FlowTransactionBuilder {
+	return c.transaction("Staking/user/claim_rewards", signer, farmID)
+}
+
+// StakingUserStake builds transactions/Staking/user/stake.cdc signed by signer.
+func (c *Client) StakingUserStake(signer string, farmID uint64, amount UFix64) overflow.FlowTransactionBuilder {
+	return c.transaction("Staking/user/stake", signer, farmID, amount)
+}
+
+// StakingUserStakeNFT builds transactions/Staking/user/stake_nft.cdc signed by signer.
+func (c *Client) StakingUserStakeNFT(signer string, farmID uint64, nftStorageID string, nftID uint64) overflow.FlowTransactionBuilder {
+	return c.transaction("Staking/user/stake_nft", signer, farmID, nftStorageID, nftID)
+}
+
+// StakingUserStakeWithNFTs builds transactions/Staking/user/stake_with_nfts.cdc signed by signer.
+func (c *Client) StakingUserStakeWithNFTs(signer string, farmID uint64, amount UFix64, nftStorageID string, nftPublicID string, nftIDs []uint64) overflow.FlowTransactionBuilder {
+	return c.transaction("Staking/user/stake_with_nfts", signer, farmID, amount, nftStorageID, nftPublicID, nftIDs)
+}
+
+// StakingUserUnstake builds transactions/Staking/user/unstake.cdc signed by signer.
+func (c *Client) StakingUserUnstake(signer string, farmID uint64, amount UFix64) overflow.FlowTransactionBuilder {
+	return c.transaction("Staking/user/unstake", signer, farmID, amount)
+}
+
+// StakingUserUnstakeNFT builds transactions/Staking/user/unstake_nft.cdc signed by signer.
+func (c *Client) StakingUserUnstakeNFT(signer string, farmID uint64, nftIdentifier string, nftStorageID string) overflow.FlowTransactionBuilder {
+	return c.transaction("Staking/user/unstake_nft", signer, farmID, nftIdentifier, nftStorageID)
+}
+
+// USDCCreateVault builds transactions/USDC/create_vault.cdc signed by signer.
+func (c *Client) USDCCreateVault(signer string, multiSigPubKeys []string, multiSigKeyWeights []UFix64, multiSigAlgos []uint8) overflow.FlowTransactionBuilder {
+	return c.transaction("USDC/create_vault", signer, multiSigPubKeys, multiSigKeyWeights, multiSigAlgos)
+}
+
+// VestingWithdraw builds transactions/Vesting/withdraw.cdc signed by signer.
+func (c *Client) VestingWithdraw(signer string) overflow.FlowTransactionBuilder {
+	return c.transaction("Vesting/withdraw", signer)
+}
+
+// VestingWithdrawAmount builds transactions/Vesting/withdrawAmount.cdc signed by signer.
+func (c *Client) VestingWithdrawAmount(signer string, amount UFix64) overflow.FlowTransactionBuilder {
+	return c.transaction("Vesting/withdrawAmount", signer, amount)
+}
+
+// AddProposalKeys builds transactions/add_proposal_keys.cdc signed by signer.
+func (c *Client) AddProposalKeys(signer string, publicKey string, signatureAlgorithm uint8, hashAlgorithm uint8, count uint64) overflow.FlowTransactionBuilder {
+	return c.transaction("add_proposal_keys", signer, publicKey, signatureAlgorithm, hashAlgorithm, count)
+}
+
+// DemoCreateAccounts builds transactions/demo/create_accounts.cdc signed by signer.
+func (c *Client) DemoCreateAccounts(signer string, publicKeys []string) overflow.FlowTransactionBuilder {
+	return c.transaction("demo/create_accounts", signer, publicKeys)
+}
+
+// DemoFundAccounts builds transactions/demo/fund_accounts.cdc signed by signer.
+func (c *Client) DemoFundAccounts(signer string, addresses []flow.Address, flowAmounts []UFix64, fusdAmounts []UFix64, emuAmounts []UFix64, xEmuEmuAmounts []UFix64) overflow.FlowTransactionBuilder {
+	return c.transaction("demo/fund_accounts", signer, addresses, flowAmounts, fusdAmounts, emuAmounts, xEmuEmuAmounts)
+}
+
+// DemoMintFUSD builds transactions/demo/mintFUSD.cdc signed by signer.
+func (c *Client) DemoMintFUSD(signer string, amount UFix64, recipientAddress flow.Address) overflow.FlowTransactionBuilder {
+	return c.transaction("demo/mintFUSD", signer, amount, recipientAddress)
+}
+
+// DemoMintFlowTokens builds transactions/demo/mintFlowTokens.cdc signed by signer.
+func (c *Client) DemoMintFlowTokens(signer string, amount UFix64, recipientAddress flow.Address) overflow.FlowTransactionBuilder {
+	return c.transaction("demo/mintFlowTokens", signer, amount, recipientAddress)
+}
+
+// DemoSetupAccount builds transactions/demo/setup_account.cdc signed by signer.
+func (c *Client) DemoSetupAccount(signer string) overflow.FlowTransactionBuilder {
+	return c.transaction("demo/setup_account", signer)
+}
+
+// Tick builds transactions/tick.cdc signed by signer.
+func (c *Client) Tick(signer string) overflow.FlowTransactionBuilder {
+	return c.transaction("tick", signer)
+}
+
+// XEmuEnterPool builds transactions/xEmu/enterPool.cdc signed by signer.
+func (c *Client) XEmuEnterPool(signer string, amount UFix64) overflow.FlowTransactionBuilder {
+	return c.transaction("xEmu/enterPool", signer, amount)
+}
+
+// XEmuExitPool builds transactions/xEmu/exitPool.cdc signed by signer.
+func (c *Client) XEmuExitPool(signer string, amount UFix64) overflow.FlowTransactionBuilder {
+	return c.transaction("xEmu/exitPool", signer, amount)
+}
+
+// XEmuSetup builds transactions/xEmu/setup.cdc signed by signer.
+func (c *Client) XEmuSetup(signer string) overflow.FlowTransactionBuilder {
+	return c.transaction("xEmu/setup", signer)
+}
+
+// XEmuTransfer builds transactions/xEmu/transfer.cdc signed by signer.
+func (c *Client) XEmuTransfer(signer string, amount UFix64, to flow.Address) overflow.FlowTransactionBuilder {
+	return c.transaction("xEmu/transfer", signer, amount, to)
+}
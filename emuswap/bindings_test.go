@@ -0,0 +1,25 @@
+package emuswap
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"swap.emudao.org/test-overflow/internal/bindgen"
+)
+
+// TestBindingsUpToDate fails when a script, transaction or event signature
+// changed without regenerating the Go code.
+func TestBindingsUpToDate(t *testing.T) {
+	generated, err := bindgen.Generate(".")
+	assert.NoError(t, err)
+	existing, err := os.ReadFile(bindgen.BindingsFile)
+	assert.NoError(t, err)
+	assert.Equal(t, string(generated), string(existing), "bindings_gen.go is out of date, run go generate ./emuswap")
+
+	generated, err = bindgen.GenerateEvents(".")
+	assert.NoError(t, err)
+	existing, err = os.ReadFile(bindgen.EventsFile)
+	assert.NoError(t, err)
+	assert.Equal(t, string(generated), string(existing), "events_gen.go is out of date, run go generate ./emuswap")
+}
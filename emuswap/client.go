@@ -0,0 +1,175 @@
+// Package emuswap holds typed Go access to the EmuSwap contracts. The script and
+// transaction bindings in bindings_gen.go are generated from the Cadence files
+// in scripts/ and transactions/ by cmd/bindgen.
+package emuswap
+
+//go:generate go run ../cmd/bindgen -root ..
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-cli/pkg/flowkit"
+	"github.com/onflow/flow-go-sdk"
+)
+
+// Client wraps an overflow instance with the generated bindings.
+type Client struct {
+	O *overflow.Overflow
+	// OnSubmit is called with the result of every transaction Submit and
+	// SubmitWithSequence send, from the goroutine sending it, when set.
+	OnSubmit func(*overflow.OverflowResult)
+}
+
+// NewClient wraps o.
+func NewClient(o *overflow.Overflow) *Client {
+	return &Client{O: o}
+}
+
+// Address looks up the address of a flow.json account, network prefix excluded.
+func (c *Client) Address(account string) flow.Address {
+	return c.O.Account(account).Address()
+}
+
+func (c *Client) transaction(path string, signer string, args ...interface{}) overflow.FlowTransactionBuilder {
+	return c.O.TransactionFromFile(path).
+		SignProposeAndPayAs(signer).
+		ArgsV(encodeArgs(path, args))
+}
+
+// Submit sends tx and waits for its result like tx.Send, which resets and
+// reads the log of the in-memory emulator shared by every transaction of the
+// Overflow. Submit leaves the log alone, so transactions can be submitted from
+// many goroutines, and the result has no emulator log or computation used.
+//
+// The sequence number of the proposal key is read from the chain when the
+// transaction is built. Transactions of one signer must not be in flight at
+// the same time, or they propose with the same number.
+func (c *Client) Submit(tx overflow.FlowTransactionBuilder) *overflow.OverflowResult {
+	return c.submit(tx, nil)
+}
+
+// SubmitWithSequence is Submit proposing at sequenceNumber rather than the
+// sequence number the proposal key has on chain, for callers tracking the
+// sequence numbers of their keys. The main signer proposes with the key it is
+// configured with.
+func (c *Client) SubmitWithSequence(tx overflow.FlowTransactionBuilder, sequenceNumber uint64) *overflow.OverflowResult {
+	return c.submit(tx, &sequenceNumber)
+}
+
+func (c *Client) submit(tx overflow.FlowTransactionBuilder, sequenceNumber *uint64) *overflow.OverflowResult {
+	result := c.send(tx, sequenceNumber)
+	if c.OnSubmit != nil {
+		c.OnSubmit(result)
+	}
+	return result
+}
+
+func (c *Client) send(tx overflow.FlowTransactionBuilder, sequenceNumber *uint64) *overflow.OverflowResult {
+	result := &overflow.OverflowResult{}
+	if tx.MainSigner == nil {
+		result.Err = fmt.Errorf("%s: no signer", tx.FileName)
+		return result
+	}
+	codeFileName := fmt.Sprintf("%s/%s.cdc", tx.BasePath, tx.FileName)
+	code := []byte(tx.Content)
+	if tx.Content == "" {
+		var err error
+		if code, err = c.O.State.ReaderWriter().ReadFile(codeFileName); err != nil {
+			result.Err = err
+			return result
+		}
+	}
+
+	// the main signer signs last, as the payer
+	signers := append(append([]*flowkit.Account{}, tx.PayloadSigners...), tx.MainSigner)
+	authorizers := make([]flow.Address, len(signers))
+	for i, signer := range signers {
+		authorizers[i] = signer.Address()
+	}
+	built, err := c.O.Services.Transactions.Build(
+		tx.MainSigner.Address(),
+		authorizers,
+		tx.MainSigner.Address(),
+		tx.MainSigner.Key().Index(),
+		code,
+		codeFileName,
+		tx.GasLimit,
+		tx.Arguments,
+		c.O.Network,
+		true,
+	)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if sequenceNumber != nil {
+		built.FlowTransaction().SetProposalKey(tx.MainSigner.Address(), tx.MainSigner.Key().Index(), *sequenceNumber)
+	}
+	for _, signer := range signers {
+		if err := built.SetSigner(signer); err != nil {
+			result.Err = err
+			return result
+		}
+		if built, err = built.Sign(); err != nil {
+			result.Err = err
+			return result
+		}
+	}
+	result.Id = built.FlowTransaction().ID()
+
+	payload := []byte(fmt.Sprintf("%x", built.FlowTransaction().Encode()))
+	sent, status, err := c.O.Services.Transactions.SendSigned(payload, true)
+	result.Transaction = sent
+	switch {
+	case err != nil:
+		result.Err = Classify(err)
+	case status.Error != nil:
+		result.Err = Classify(status.Error)
+	default:
+		result.RawEvents = status.Events
+	}
+	return result
+}
+
+// IsExpired reports whether err is the rejection of a transaction whose
+// reference block expired before it was sent, ErrExpired. Nothing was
+// executed and the sequence number of the proposal key is unchanged, so the
+// transaction can be built again.
+func IsExpired(err error) bool {
+	return errors.Is(Classify(err), ErrExpired)
+}
+
+// IsSequenceMismatch reports whether err is the failure of a transaction
+// proposed with another sequence number than its proposal key has,
+// ErrSequenceMismatch.
+func IsSequenceMismatch(err error) bool {
+	return errors.Is(Classify(err), ErrSequenceMismatch)
+}
+
+func (c *Client) script(path string, result interface{}, args ...interface{}) error {
+	value, err := c.O.ScriptFromFile(path).ArgsV(encodeArgs(path, args)).RunReturns()
+	if err != nil {
+		return Classify(err)
+	}
+	if err := Decode(value, result); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// encodeArgs panics on failure since the generated signatures only allow
+// types Encode understands.
+func encodeArgs(path string, args []interface{}) []cadence.Value {
+	values := make([]cadence.Value, len(args))
+	for i, arg := range args {
+		value, err := Encode(arg)
+		if err != nil {
+			panic(fmt.Sprintf("%s: %v", path, err))
+		}
+		values[i] = value
+	}
+	return values
+}
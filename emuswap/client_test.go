@@ -0,0 +1,78 @@
+package emuswap
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"swap.emudao.org/test-overflow/internal/emutest"
+)
+
+// TestMain runs the package tests from the repository root, where flow.json
+// and the files it references resolve.
+func TestMain(m *testing.M) {
+	if err := os.Chdir(".."); err != nil {
+		panic(err)
+	}
+	os.Exit(emutest.Main(m))
+}
+
+func newTestClient(t *testing.T) *Client {
+	o, err := emutest.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewClient(o)
+}
+
+func TestClientCreatePoolAndQuote(t *testing.T) {
+	c := newTestClient(t)
+
+	c.DemoMintFlowTokens("account", UFix64FromFloat(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.FUSDSetup("account").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", UFix64FromFloat(1000.0), c.Address("account")).Test(t).AssertSuccess()
+
+	c.EmuSwapAdminCreateNewPool("account", "flowTokenVault", UFix64FromFloat(100.0), "fusdVault", UFix64FromFloat(50.0)).
+		Test(t).
+		AssertSuccess()
+
+	ids, err := c.GetPoolIDs()
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{0}, ids)
+
+	meta, err := c.GetPoolMeta(0)
+	assert.NoError(t, err)
+	assert.Equal(t, PoolMeta{
+		Token1Amount:     UFix64FromFloat(100.0),
+		Token2Amount:     UFix64FromFloat(50.0),
+		Token1Identifier: "A.0ae53cb6e3f42a79.FlowToken.Vault",
+		Token2Identifier: "A.f8d6e0586b0a20c7.FUSD.Vault",
+		TotalSupply:      UFix64FromFloat(1.0),
+	}, meta)
+
+	poolID, err := c.GetPoolIDFromTokenIDs("A.0ae53cb6e3f42a79.FlowToken.Vault", "A.f8d6e0586b0a20c7.FUSD.Vault")
+	assert.NoError(t, err)
+	if assert.NotNil(t, poolID) {
+		assert.Equal(t, uint64(0), *poolID)
+	}
+
+	quotes, err := c.PoolGetQuotes(0, UFix64FromFloat(1.0))
+	assert.NoError(t, err)
+	quote, err := c.PoolGetQuoteExactAToB(0, UFix64FromFloat(1.0))
+	assert.NoError(t, err)
+	assert.Equal(t, quotes["exact A for B"], quote)
+}
+
+func TestClientSubmit(t *testing.T) {
+	c := newTestClient(t)
+
+	// templated transactions are sent like the ones read from files
+	result := c.Submit(c.DemoMintFlowTokens("account", UFix64FromFloat(10.0), c.Address("user1")))
+	assert.NoError(t, result.Err)
+	assert.NotEmpty(t, result.RawEvents)
+	assert.Equal(t, result.Id, result.Transaction.ID())
+
+	result = c.Submit(c.Swap("user1", MustLookupToken("FLOW"), MustLookupToken("FUSD"), UFix64FromFloat(1.0)))
+	assert.ErrorContains(t, result.Err, "Can't find swap pool")
+	assert.Empty(t, result.RawEvents)
+}
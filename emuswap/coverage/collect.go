@@ -0,0 +1,205 @@
+// Package coverage records which lines of Cadence the in-memory emulator
+// executes and reports the coverage of the contracts per function and per
+// line, as text or HTML.
+//
+// The Cadence runtime counts the statements it executes per line when given a
+// coverage report, which the emulator does not expose. Attach sets one on the
+// runtime of an emulator started by overflow. Go tests record their coverage
+// when EMUSWAP_CADENCE_COVERAGE names a directory: Collect, called by the test
+// helper starting an emulator, attaches to it, and Run, called from TestMain,
+// writes what the test binary recorded to a profile in the directory.
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(coverage.Run(m))
+//	}
+//
+// cmd/cadencecov runs go test with the variable set and reports the profiles.
+package coverage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime"
+	"swap.emudao.org/test-overflow/internal/emulator"
+)
+
+// EnvDir is the environment variable naming the directory Run writes profiles
+// to. A relative path is relative to the directory the tests run in, the
+// repository root for the tests of this module.
+const EnvDir = "EMUSWAP_CADENCE_COVERAGE"
+
+// ErrNotEmulator is returned by Attach for an Overflow not running the
+// in-memory emulator.
+var ErrNotEmulator = errors.New("coverage: not an in-memory emulator")
+
+// Profile is the number of statements executed per line, by location ID,
+// e.g. A.f8d6e0586b0a20c7.EmuSwap for a contract.
+type Profile map[string]map[int]int
+
+// Add adds the hits of other to p.
+func (p Profile) Add(other Profile) {
+	for location, lines := range other {
+		if p[location] == nil {
+			p[location] = map[int]int{}
+		}
+		for line, hits := range lines {
+			p[location][line] += hits
+		}
+	}
+}
+
+// Recorder records the lines the runtime of one emulator executes.
+type Recorder struct {
+	mu     sync.Mutex
+	report *runtime.CoverageReport
+}
+
+// Profile is what was executed so far.
+func (r *Recorder) Profile() Profile {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	profile := Profile{}
+	for location, coverage := range r.report.Coverage {
+		lines := map[int]int{}
+		for line, hits := range coverage.LineHits {
+			lines[line] = hits
+		}
+		profile[string(location)] = lines
+	}
+	return profile
+}
+
+// recordingRuntime serializes scripts, which the emulator runs concurrently,
+// and transactions, as the coverage report is not safe for concurrent use.
+type recordingRuntime struct {
+	runtime.Runtime
+	recorder *Recorder
+}
+
+func (r recordingRuntime) Unwrap() runtime.Runtime { return r.Runtime }
+
+func (r recordingRuntime) ExecuteScript(script runtime.Script, context runtime.Context) (cadence.Value, error) {
+	r.recorder.mu.Lock()
+	defer r.recorder.mu.Unlock()
+	return r.Runtime.ExecuteScript(script, context)
+}
+
+func (r recordingRuntime) ExecuteTransaction(script runtime.Script, context runtime.Context) error {
+	r.recorder.mu.Lock()
+	defer r.recorder.mu.Unlock()
+	return r.Runtime.ExecuteTransaction(script, context)
+}
+
+// Attach records what the emulator of o executes from now on. Attaching to
+// an emulator again returns the recorder it already has.
+func Attach(o *overflow.Overflow) (*Recorder, error) {
+	vm, err := emulator.VirtualMachine(o)
+	if err != nil {
+		return nil, err
+	}
+	for rt := vm.Runtime; rt != nil; rt = emulator.Unwrap(rt) {
+		if attached, ok := rt.(recordingRuntime); ok {
+			return attached.recorder, nil
+		}
+	}
+	r := &Recorder{report: runtime.NewCoverageReport()}
+	vm.Runtime.SetCoverageReport(r.report)
+	vm.Runtime = recordingRuntime{Runtime: vm.Runtime, recorder: r}
+	return r, nil
+}
+
+var collected struct {
+	sync.Mutex
+	recorders []*Recorder
+}
+
+// Collect attaches to the emulator of o for Run when EnvDir is set, and does
+// nothing otherwise or when o runs no in-memory emulator.
+func Collect(o *overflow.Overflow) error {
+	if os.Getenv(EnvDir) == "" {
+		return nil
+	}
+	r, err := Attach(o)
+	if errors.Is(err, ErrNotEmulator) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	collected.Lock()
+	defer collected.Unlock()
+	for _, c := range collected.recorders {
+		if c == r {
+			return nil
+		}
+	}
+	collected.recorders = append(collected.recorders, r)
+	return nil
+}
+
+// Run runs the tests and, when EnvDir is set, writes the profile of the
+// emulators Collect attached to to a new file in the directory. It returns
+// the exit code for os.Exit.
+func Run(m interface{ Run() int }) int {
+	code := m.Run()
+	dir := os.Getenv(EnvDir)
+	if dir == "" {
+		return code
+	}
+	profile := Profile{}
+	collected.Lock()
+	for _, r := range collected.recorders {
+		profile.Add(r.Profile())
+	}
+	collected.Unlock()
+	if err := profile.write(dir); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if code == 0 {
+			code = 1
+		}
+	}
+	return code
+}
+
+func (p Profile) write(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, "cadence-*.json")
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(p); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadProfiles adds up the profiles Run wrote to dir.
+func ReadProfiles(dir string) (Profile, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "cadence-*.json"))
+	if err != nil {
+		return nil, err
+	}
+	profile := Profile{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var p Profile
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		profile.Add(p)
+	}
+	return profile, nil
+}
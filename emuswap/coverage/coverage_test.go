@@ -0,0 +1,120 @@
+package coverage_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"swap.emudao.org/test-overflow/emuswap"
+	. "swap.emudao.org/test-overflow/emuswap/coverage"
+)
+
+// TestMain runs the package tests from the repository root, where flow.json
+// and the files it references resolve.
+func TestMain(m *testing.M) {
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(Run(m))
+}
+
+var ufix = emuswap.UFix64FromFloat
+
+func function(t *testing.T, f File, name string) Function {
+	for _, function := range f.Functions {
+		if function.Name == name {
+			return function
+		}
+	}
+	t.Fatalf("no function %s in %s", name, f.Path)
+	return Function{}
+}
+
+func TestAttach(t *testing.T) {
+	o, err := overflow.NewTestingEmulator().StartE()
+	require.NoError(t, err)
+	r, err := Attach(o)
+	require.NoError(t, err)
+	again, err := Attach(o)
+	require.NoError(t, err)
+	assert.Same(t, r, again)
+
+	c := emuswap.NewClient(o)
+	c.FUSDSetup("account").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", ufix(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.EmuSwapAdminCreateNewPool("account", "flowTokenVault", ufix(100.0), "fusdVault", ufix(50.0)).Test(t).AssertSuccess()
+	c.EmuSwapUserSwap("account", "flowTokenVault", "fusdVault", ufix(10.0)).Test(t).AssertSuccess()
+	_, err = c.GetPoolsMeta()
+	require.NoError(t, err)
+
+	profile := r.Profile()
+	require.Contains(t, profile, "A.f8d6e0586b0a20c7.EmuSwap")
+	sources, err := Sources(o.State, "emulator", "contracts/*.cdc")
+	require.NoError(t, err)
+	report, err := Analyze(profile, sources)
+	require.NoError(t, err)
+
+	files := map[string]File{}
+	for _, f := range report.Files {
+		files[filepath.ToSlash(f.Path)] = f
+	}
+	emuSwap := files["contracts/EmuSwap.cdc"]
+	assert.Equal(t, "A.f8d6e0586b0a20c7.EmuSwap", emuSwap.Location)
+	swap := function(t, emuSwap, "EmuSwap.Pool.swapToken1ForToken2")
+	assert.Equal(t, 242, swap.Line)
+	assert.NotZero(t, swap.Lines)
+	assert.NotZero(t, swap.Covered)
+	quote := function(t, emuSwap, "EmuSwap.Pool.quoteSwapToken2ForExactToken1")
+	assert.NotZero(t, quote.Lines)
+	assert.Zero(t, quote.Covered)
+	// the contracts were deployed before attaching
+	assert.Zero(t, function(t, emuSwap, "EmuSwap.init").Covered)
+	covered, lines := emuSwap.Covered()
+	assert.Greater(t, covered, 0)
+	assert.Less(t, covered, lines)
+	assert.Empty(t, files["contracts/NFTAirdrop.cdc"].Location)
+	covered, _ = files["contracts/NFTAirdrop.cdc"].Covered()
+	assert.Zero(t, covered)
+
+	var text strings.Builder
+	require.NoError(t, report.WriteText(&text))
+	assert.Regexp(t, `(?m)^contracts/EmuSwap.cdc:218: +EmuSwap.Pool.quoteSwapToken2ForExactToken1 +0.0%$`, text.String())
+	assert.Regexp(t, `(?m)^contracts/EmuSwap.cdc:242: +EmuSwap.Pool.swapToken1ForToken2 +100.0%$`, text.String())
+	assert.Contains(t, text.String(), "not deployed")
+	assert.Contains(t, text.String(), "total:")
+	var html strings.Builder
+	require.NoError(t, report.WriteHTML(&html))
+	assert.Contains(t, html.String(), `<a href="#f1-218">EmuSwap.Pool.quoteSwapToken2ForExactToken1</a>`)
+	assert.Contains(t, html.String(), `class="missed"`)
+	assert.Contains(t, html.String(), `class="hit"`)
+}
+
+type tests func() int
+
+func (run tests) Run() int { return run() }
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(EnvDir, dir)
+	code := Run(tests(func() int {
+		o, err := overflow.NewTestingEmulator().StartE()
+		require.NoError(t, err)
+		require.NoError(t, Collect(o))
+		require.NoError(t, Collect(o))
+		c := emuswap.NewClient(o)
+		c.FUSDSetup("user1").Test(t).AssertSuccess()
+		return 3
+	}))
+	assert.Equal(t, 3, code)
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+	assert.Len(t, paths, 1)
+	profile, err := ReadProfiles(dir)
+	require.NoError(t, err)
+	assert.Contains(t, profile, "A.f8d6e0586b0a20c7.FUSD")
+}
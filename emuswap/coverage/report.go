@@ -0,0 +1,304 @@
+package coverage
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/onflow/cadence/runtime/ast"
+	"github.com/onflow/cadence/runtime/parser2"
+	"github.com/onflow/flow-cli/pkg/flowkit"
+)
+
+// Source is a contract file and the location ID of the contract deployed
+// from it, empty when the network does not deploy it.
+type Source struct {
+	Path     string
+	Location string
+}
+
+// Sources are the files matching pattern, e.g. contracts/*.cdc, with the
+// contracts the flow.json network deploys from them.
+func Sources(state *flowkit.State, network, pattern string) ([]Source, error) {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	contracts, err := state.DeploymentContractsByNetwork(network)
+	if err != nil {
+		return nil, err
+	}
+	locations := map[string]string{}
+	for _, contract := range contracts {
+		locations[filepath.Clean(contract.Source)] = fmt.Sprintf("A.%s.%s", contract.AccountAddress.Hex(), contract.Name)
+	}
+	sources := make([]Source, len(paths))
+	for i, path := range paths {
+		sources[i] = Source{Path: path, Location: locations[filepath.Clean(path)]}
+	}
+	return sources, nil
+}
+
+// Function is the coverage of a function, initializer or destructor. Lines
+// are the lines with statements or conditions, Covered those executed.
+type Function struct {
+	// Name is qualified by the declarations around the function, e.g.
+	// EmuSwap.Pool.donateLiquidity.
+	Name    string `json:"name"`
+	Line    int    `json:"line"`
+	Lines   int    `json:"lines"`
+	Covered int    `json:"covered"`
+}
+
+// Percent is the part of the lines covered, 100 for a function without any.
+func (f Function) Percent() float64 {
+	return percent(f.Covered, f.Lines)
+}
+
+// File is the coverage of a contract file.
+type File struct {
+	Path     string `json:"path"`
+	Location string `json:"location,omitempty"`
+	// Hits are the times the statements of each line with statements or
+	// conditions were executed.
+	Hits      map[int]int `json:"hits"`
+	Functions []Function  `json:"functions"`
+	lines     []string
+}
+
+// Covered is the number of lines executed out of the lines with statements.
+func (f File) Covered() (covered, lines int) {
+	for _, hits := range f.Hits {
+		if hits > 0 {
+			covered++
+		}
+	}
+	return covered, len(f.Hits)
+}
+
+// Percent is the part of the lines covered.
+func (f File) Percent() float64 {
+	return percent(f.Covered())
+}
+
+// Report is the coverage of the contract files.
+type Report struct {
+	Files []File `json:"files"`
+}
+
+// Covered is the number of lines executed out of the lines with statements
+// in all files.
+func (r Report) Covered() (covered, lines int) {
+	for _, f := range r.Files {
+		c, l := f.Covered()
+		covered += c
+		lines += l
+	}
+	return covered, lines
+}
+
+// Percent is the part of the lines covered in all files.
+func (r Report) Percent() float64 {
+	return percent(r.Covered())
+}
+
+func percent(covered, lines int) float64 {
+	if lines == 0 {
+		return 100
+	}
+	return 100 * float64(covered) / float64(lines)
+}
+
+// Analyze parses the sources and counts the hits of profile on the lines of
+// their functions. Hits on other lines, as of code a test deployed in place
+// of the source, are left out.
+func Analyze(profile Profile, sources []Source) (Report, error) {
+	report := Report{Files: make([]File, len(sources))}
+	for i, source := range sources {
+		code, err := os.ReadFile(source.Path)
+		if err != nil {
+			return report, err
+		}
+		program, err := parser2.ParseProgram(string(code), nil)
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", source.Path, err)
+		}
+		f := File{Path: source.Path, Location: source.Location, Hits: map[int]int{}, lines: strings.Split(string(code), "\n")}
+		hits := profile[source.Location]
+		for _, function := range functions(program) {
+			function.Lines = len(function.lines)
+			for line := range function.lines {
+				f.Hits[line] = hits[line]
+				if hits[line] > 0 {
+					function.Covered++
+				}
+			}
+			f.Functions = append(f.Functions, function.Function)
+		}
+		report.Files[i] = f
+	}
+	return report, nil
+}
+
+type function struct {
+	Function
+	lines map[int]bool
+}
+
+// functions are the functions of the declarations in program, in the order
+// they are declared.
+func functions(program *ast.Program) []function {
+	var all []function
+	var members func(prefix string, m *ast.Members)
+	members = func(prefix string, m *ast.Members) {
+		for _, declaration := range m.Declarations() {
+			switch d := declaration.(type) {
+			case *ast.FunctionDeclaration:
+				all = append(all, newFunction(prefix+d.Identifier.Identifier, d))
+			case *ast.SpecialFunctionDeclaration:
+				all = append(all, newFunction(prefix+d.Kind.Keywords(), d.FunctionDeclaration))
+			case *ast.CompositeDeclaration:
+				members(prefix+d.Identifier.Identifier+".", d.Members)
+			case *ast.InterfaceDeclaration:
+				members(prefix+d.Identifier.Identifier+".", d.Members)
+			}
+		}
+	}
+	for _, declaration := range program.Declarations() {
+		switch d := declaration.(type) {
+		case *ast.FunctionDeclaration:
+			all = append(all, newFunction(d.Identifier.Identifier, d))
+		case *ast.CompositeDeclaration:
+			members(d.Identifier.Identifier+".", d.Members)
+		case *ast.InterfaceDeclaration:
+			members(d.Identifier.Identifier+".", d.Members)
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Line < all[j].Line })
+	return all
+}
+
+// newFunction finds the lines the runtime counts in d: those its statements
+// start on and those of its conditions, which it runs as statements.
+func newFunction(name string, d *ast.FunctionDeclaration) function {
+	f := function{Function: Function{Name: name, Line: d.StartPos.Line}, lines: map[int]bool{}}
+	block := d.FunctionBlock
+	if block == nil {
+		return f
+	}
+	for _, conditions := range []*ast.Conditions{block.PreConditions, block.PostConditions} {
+		if conditions != nil {
+			for _, condition := range *conditions {
+				f.lines[condition.Test.StartPosition().Line] = true
+			}
+		}
+	}
+	if block.Block != nil {
+		ast.Inspect(block.Block, func(element ast.Element) bool {
+			if statement, ok := element.(ast.Statement); ok {
+				f.lines[statement.StartPosition().Line] = true
+			}
+			return true
+		})
+	}
+	return f
+}
+
+// WriteText prints the coverage of every function, file and in total, like
+// go tool cover -func.
+func (r Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, f := range r.Files {
+		for _, function := range f.Functions {
+			if function.Lines == 0 {
+				continue
+			}
+			fmt.Fprintf(tw, "%s:%d:\t%s\t%.1f%%\n", f.Path, function.Line, function.Name, function.Percent())
+		}
+	}
+	for _, f := range r.Files {
+		covered, lines := f.Covered()
+		note := ""
+		if f.Location == "" {
+			note = ", not deployed"
+		}
+		fmt.Fprintf(tw, "%s\t(%d/%d lines%s)\t%.1f%%\n", f.Path, covered, lines, note, f.Percent())
+	}
+	covered, lines := r.Covered()
+	fmt.Fprintf(tw, "total:\t(%d/%d lines)\t%.1f%%\n", covered, lines, r.Percent())
+	return tw.Flush()
+}
+
+var page = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Cadence coverage</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table.functions td { padding: 0 1em 0 0; }
+pre { line-height: 1.3; }
+.hit { background: #d6f5d6; }
+.missed { background: #f8d0d0; }
+.n, .count { color: #888; display: inline-block; width: 4em; text-align: right; margin-right: 1em; }
+</style>
+</head>
+<body>
+<h1>Cadence coverage {{printf "%.1f" .Percent}}%</h1>
+<ul>
+{{range $i, $f := .Files}}<li><a href="#f{{$i}}">{{.Path}}</a> {{printf "%.1f" .Percent}}%{{if not .Location}} (not deployed){{end}}</li>
+{{end}}</ul>
+{{range $i, $f := .Files}}
+<h2 id="f{{$i}}">{{$f.Path}} {{printf "%.1f" $f.Percent}}%</h2>
+<table class="functions">
+{{range $f.Functions}}{{if .Lines}}<tr><td><a href="#f{{$i}}-{{.Line}}">{{.Name}}</a></td><td>{{printf "%.1f" .Percent}}%</td><td>{{.Covered}}/{{.Lines}}</td></tr>
+{{end}}{{end}}</table>
+<pre>{{range $f.Lines}}<span id="f{{$i}}-{{.Number}}" class="{{.Class}}"><span class="n">{{.Number}}</span><span class="count">{{.Count}}</span>{{.Text}}</span>
+{{end}}</pre>
+{{end}}
+</body>
+</html>
+`))
+
+type htmlLine struct {
+	Number int
+	Count  string
+	Class  string
+	Text   string
+}
+
+type htmlFile struct {
+	File
+	Lines []htmlLine
+}
+
+// WriteHTML writes a page with the functions of every file and its source,
+// the lines executed and missed highlighted.
+func (r Report) WriteHTML(w io.Writer) error {
+	var data struct {
+		Files   []htmlFile
+		Percent float64
+	}
+	data.Percent = r.Percent()
+	for _, f := range r.Files {
+		h := htmlFile{File: f}
+		for i, text := range f.lines {
+			line := htmlLine{Number: i + 1, Text: text}
+			if hits, ok := f.Hits[i+1]; ok {
+				line.Count = fmt.Sprint(hits)
+				line.Class = "missed"
+				if hits > 0 {
+					line.Class = "hit"
+				}
+			}
+			h.Lines = append(h.Lines, line)
+		}
+		data.Files = append(data.Files, h)
+	}
+	return page.Execute(w, data)
+}
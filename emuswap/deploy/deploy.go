@@ -0,0 +1,218 @@
+// Package deploy plans and applies contract deployments and upgrades for one
+// network of flow.json. Contracts are ordered by their imports, compared with
+// the code already on chain, and upgrades are checked to leave the pool,
+// fee and farm state untouched.
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-cli/pkg/flowkit/contracts"
+	"github.com/onflow/flow-go-sdk"
+	"swap.emudao.org/test-overflow/emuswap"
+)
+
+// Status tells what applying a change does to a contract.
+type Status int
+
+const (
+	Unchanged Status = iota
+	New
+	Updated
+)
+
+func (s Status) String() string {
+	switch s {
+	case New:
+		return "new"
+	case Updated:
+		return "updated"
+	default:
+		return "unchanged"
+	}
+}
+
+// Change is one contract of the deployment, in dependency order.
+type Change struct {
+	Name    string
+	Account string // flow.json account deploying the contract
+	Address flow.Address
+	Status  Status
+	// Code is the local source with imports resolved to addresses
+	Code string
+	// Deployed is the code currently on chain, empty for new contracts
+	Deployed string
+	Args     []cadence.Value
+}
+
+// Diff returns a line diff from the deployed to the local code.
+func (c Change) Diff() string {
+	return Diff(c.Deployed, c.Code)
+}
+
+// Options adjust a plan. Sources replaces the source of contracts by name,
+// which is how tests simulate an upgrade without touching contracts/.
+type Options struct {
+	Sources map[string]string
+}
+
+// ErrStateChanged is returned by Upgrade, as a *VerifyError, when the
+// verification finds the state read after the upgrade differs from the state
+// before.
+var ErrStateChanged = errors.New("state changed during upgrade")
+
+// VerifyError is the failure of the verification after the changes of an
+// upgrade were applied. Nothing is rolled back: Before is the state to
+// restore, After the state found, or Err the failure to read it.
+type VerifyError struct {
+	Before      Snapshot
+	After       Snapshot
+	Differences []string
+	Err         error
+}
+
+func (e *VerifyError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("reading state after upgrade: %v", e.Err)
+	}
+	return fmt.Sprintf("%v:\n%s", ErrStateChanged, strings.Join(e.Differences, "\n"))
+}
+
+func (e *VerifyError) Unwrap() error {
+	if e.Err != nil {
+		return e.Err
+	}
+	return ErrStateChanged
+}
+
+// Plan resolves the contracts deployed on the network o is connected to and
+// compares each with the code on chain.
+func Plan(o *overflow.Overflow, options Options) ([]Change, error) {
+	deployments, err := o.State.DeploymentContractsByNetwork(o.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]string{}
+	for _, contract := range deployments {
+		names[contract.Source] = contract.Name
+	}
+	loader := overrideLoader{
+		Loader:  contracts.FilesystemLoader{Reader: o.State.ReaderWriter()},
+		names:   names,
+		sources: options.Sources,
+	}
+	processor := contracts.NewPreprocessor(loader, o.State.AliasesForNetwork(o.Network))
+	for _, contract := range deployments {
+		err := processor.AddContractSource(contract.Name, contract.Source, contract.AccountAddress, contract.AccountName, contract.Args)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", contract.Name, err)
+		}
+	}
+	if err := processor.ResolveImports(); err != nil {
+		return nil, err
+	}
+	ordered, err := processor.ContractDeploymentOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := map[flow.Address]*flow.Account{}
+	changes := make([]Change, 0, len(ordered))
+	for _, contract := range ordered {
+		account, ok := accounts[contract.Target()]
+		if !ok {
+			account, err = o.Services.Accounts.Get(contract.Target())
+			if err != nil {
+				return nil, fmt.Errorf("fetching account %s: %w", contract.Target(), err)
+			}
+			accounts[contract.Target()] = account
+		}
+
+		change := Change{
+			Name:    contract.Name(),
+			Account: contract.AccountName(),
+			Address: contract.Target(),
+			Code:    contract.TranspiledCode(),
+			Args:    contract.Args(),
+		}
+		deployed, exists := account.Contracts[change.Name]
+		switch {
+		case !exists:
+			change.Status = New
+		case string(deployed) != change.Code:
+			change.Status = Updated
+			change.Deployed = string(deployed)
+		default:
+			change.Deployed = string(deployed)
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// Apply deploys the new and updated contracts in order, stopping at the
+// first failure.
+func Apply(o *overflow.Overflow, changes []Change) error {
+	for _, change := range changes {
+		if change.Status == Unchanged {
+			continue
+		}
+		if change.Status == New && len(change.Args) > 0 {
+			return fmt.Errorf("%s takes init arguments, deploy it with flow project deploy", change.Name)
+		}
+		account, err := o.State.Accounts().ByName(change.Account)
+		if err != nil {
+			return err
+		}
+		if _, err := o.Services.Accounts.AddContract(account, change.Name, []byte(change.Code), change.Status == Updated); err != nil {
+			return fmt.Errorf("%s %s: %w", change.Status, change.Name, err)
+		}
+	}
+	return nil
+}
+
+// Upgrade plans, applies and verifies the deployment. The state is read
+// before and after applying the changes. When it cannot be read afterwards
+// or differs, the changes stay applied and a *VerifyError holds both states.
+func Upgrade(o *overflow.Overflow, options Options) ([]Change, error) {
+	changes, err := Plan(o, options)
+	if err != nil {
+		return nil, err
+	}
+	client := emuswap.NewClient(o)
+	before, err := TakeSnapshot(client)
+	if err != nil {
+		return changes, fmt.Errorf("reading state before upgrade: %w", err)
+	}
+	if err := Apply(o, changes); err != nil {
+		return changes, err
+	}
+	after, err := TakeSnapshot(client)
+	if err != nil {
+		return changes, &VerifyError{Before: before, Err: err}
+	}
+	if differences := before.Compare(after); len(differences) > 0 {
+		return changes, &VerifyError{Before: before, After: after, Differences: differences}
+	}
+	return changes, nil
+}
+
+// overrideLoader serves Options.Sources in place of the files flow.json
+// points at.
+type overrideLoader struct {
+	contracts.Loader
+	names   map[string]string // source path to contract name
+	sources map[string]string
+}
+
+func (l overrideLoader) Load(source string) ([]byte, error) {
+	if code, ok := l.sources[l.names[source]]; ok {
+		return []byte(code), nil
+	}
+	return l.Loader.Load(source)
+}
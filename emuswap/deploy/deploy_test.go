@@ -0,0 +1,190 @@
+package deploy
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/internal/emutest"
+)
+
+// TestMain runs the package tests from the repository root, where flow.json
+// and the files it references resolve.
+func TestMain(m *testing.M) {
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(emutest.Main(m))
+}
+
+var ufix = emuswap.UFix64FromFloat
+
+func indexOf(changes []Change, name string) int {
+	for i, change := range changes {
+		if change.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// upgradedEmuSwap returns EmuSwap.cdc with extra inserted before the closing
+// brace of the contract.
+func upgradedEmuSwap(t *testing.T, extra string) string {
+	code, err := os.ReadFile("contracts/EmuSwap.cdc")
+	require.NoError(t, err)
+	source := strings.TrimRight(string(code), " \t\r\n")
+	return strings.TrimSuffix(source, "}") + extra + "\n}\n"
+}
+
+func TestUpgradeEmuSwap(t *testing.T) {
+	o, err := emutest.Start()
+	require.NoError(t, err)
+	c := emuswap.NewClient(o)
+	flowToken, fusd := emuswap.MustLookupToken("FLOW"), emuswap.MustLookupToken("FUSD")
+
+	// state to preserve: a pool with fees and a farm with a stake
+	c.DemoMintFlowTokens("account", ufix(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.FUSDSetup("account").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", ufix(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.EmuSwapAdminCreateNewPool("account", "flowTokenVault", ufix(100.0), "fusdVault", ufix(150.0)).Test(t).AssertSuccess()
+	c.Swap("account", flowToken, fusd, ufix(10.0)).Test(t).AssertSuccess()
+	c.StakingAdminCreateNewFarm("account", 0).Test(t).AssertSuccess()
+	c.AddLiquidityAndStake("account", 0, flowToken, fusd, ufix(10.0), ufix(10.0)).Test(t).AssertSuccess()
+
+	changes, err := Plan(o, Options{})
+	require.NoError(t, err)
+	for _, change := range changes {
+		assert.Equal(t, Unchanged, change.Status, change.Name)
+	}
+	assert.Less(t, indexOf(changes, "FungibleTokens"), indexOf(changes, "EmuSwap"))
+	assert.Less(t, indexOf(changes, "EmuSwap"), indexOf(changes, "StakingRewards"))
+
+	before, err := TakeSnapshot(c)
+	require.NoError(t, err)
+	assert.Len(t, before.Pools, 1)
+	assert.Len(t, before.Fees, 1)
+	assert.Len(t, before.PoolFees[0], 2)
+	assert.Contains(t, before.Frozen, uint64(0))
+	assert.Len(t, before.Stakes[0], 1)
+
+	options := Options{Sources: map[string]string{
+		"EmuSwap": upgradedEmuSwap(t, "\n    pub fun getVersion(): String {\n        return \"2\"\n    }"),
+	}}
+	changes, err = Upgrade(o, options)
+	require.NoError(t, err)
+	for _, change := range changes {
+		if change.Name == "EmuSwap" {
+			assert.Equal(t, Updated, change.Status)
+			assert.Contains(t, change.Diff(), "+    pub fun getVersion(): String {")
+		} else {
+			assert.Equal(t, Unchanged, change.Status, change.Name)
+		}
+	}
+
+	// the chain now runs the upgraded code
+	changes, err = Plan(o, options)
+	require.NoError(t, err)
+	assert.Equal(t, Unchanged, changes[indexOf(changes, "EmuSwap")].Status)
+	version, err := o.Script("import EmuSwap from 0xf8d6e0586b0a20c7\npub fun main(): String { return EmuSwap.getVersion() }").RunReturns()
+	require.NoError(t, err)
+	assert.Equal(t, "2", version.ToGoValue())
+
+	// and swaps keep working on the upgraded contract
+	c.Swap("account", fusd, flowToken, ufix(1.0)).Test(t).AssertSuccess()
+
+	// a rejected upgrade, here a field without initialization, leaves the deployed code in place
+	_, err = Upgrade(o, Options{Sources: map[string]string{
+		"EmuSwap": upgradedEmuSwap(t, "\n    pub let extra: UInt64"),
+	}})
+	assert.ErrorContains(t, err, "updated EmuSwap")
+	changes, err = Plan(o, options)
+	require.NoError(t, err)
+	assert.Equal(t, Unchanged, changes[indexOf(changes, "EmuSwap")].Status)
+
+	// a deployment from before the fee and frozen getters of Pool is read
+	// with the contract wide fees and upgraded to the current code
+	code, err := os.ReadFile("contracts/EmuSwap.cdc")
+	require.NoError(t, err)
+	start := strings.Index(string(code), "        // Is Pool Frozen")
+	end := strings.Index(string(code), "        ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////\n        // Function for Quotes")
+	require.Less(t, 0, start)
+	require.Less(t, start, end)
+	changes, err = Plan(o, Options{Sources: map[string]string{"EmuSwap": string(code[:start]) + string(code[end:])}})
+	require.NoError(t, err)
+	require.NoError(t, Apply(o, changes))
+	old, err := TakeSnapshot(c)
+	require.NoError(t, err)
+	assert.True(t, old.ContractWideFees)
+	assert.Nil(t, old.Frozen)
+	assert.Equal(t, before.PoolFees, old.PoolFees)
+	_, err = Upgrade(o, Options{})
+	require.NoError(t, err)
+}
+
+func TestSnapshotCompare(t *testing.T) {
+	address := flow.HexToAddress("179b6b1cb6755e31")
+	snapshot := func(reserve, fees, staked float64) Snapshot {
+		return Snapshot{
+			PoolFees: map[uint64]map[string]emuswap.UFix64{0: {"LPFeePercentage": ufix(0.0025), "DAOFeePercentage": ufix(0.0005)}},
+			Frozen:   map[uint64]bool{0: false},
+			Pools: map[uint64]emuswap.PoolMeta{0: {
+				Token1Amount:     ufix(reserve),
+				Token2Amount:     ufix(50.0),
+				Token1Identifier: "A.0ae53cb6e3f42a79.FlowToken.Vault",
+				Token2Identifier: "A.f8d6e0586b0a20c7.FUSD.Vault",
+				TotalSupply:      ufix(1.0),
+			}},
+			Fees: map[string]emuswap.UFix64{"A.0ae53cb6e3f42a79.FlowToken.Vault": ufix(fees)},
+			Stakes: map[uint64]map[flow.Address]emuswap.StakeInfo{0: {address: {
+				Address:        address,
+				Balance:        ufix(staked),
+				RewardDebtByID: map[uint64]emuswap.Fix64{0: 0},
+				PendingRewards: map[uint64]emuswap.Fix64{0: emuswap.Fix64FromFloat(staked)},
+			}}},
+		}
+	}
+
+	assert.Empty(t, snapshot(100.0, 0.5, 1.0).Compare(snapshot(100.0, 0.5, 1.0)))
+	assert.Equal(t, []string{
+		"farm 0: stake of 179b6b1cb6755e31 1.00000000 is now 2.00000000",
+		"fees A.0ae53cb6e3f42a79.FlowToken.Vault: 0.50000000 is now 0.00000000",
+		"pool 0: token1 reserve 100.00000000 is now 99.00000000",
+	}, snapshot(100.0, 0.5, 1.0).Compare(snapshot(99.0, 0.0, 2.0)))
+	reset := snapshot(100.0, 0.5, 1.0)
+	reset.PoolFees[0]["LPFeePercentage"] = 0
+	reset.Frozen[0] = true
+	assert.Equal(t, []string{
+		"pool 0: LPFeePercentage 0.00250000 is now 0.00000000",
+		"pool 0: frozen false is now true",
+	}, snapshot(100.0, 0.5, 1.0).Compare(reset))
+	assert.Equal(t, []string{"pool 0: missing"}, snapshot(100.0, 0.5, 1.0).Compare(Snapshot{
+		Fees:   snapshot(100.0, 0.5, 1.0).Fees,
+		Stakes: snapshot(100.0, 0.5, 1.0).Stakes,
+	}))
+
+	// snapshots of deployments without the getters of Pool
+	old := snapshot(100.0, 0.5, 1.0)
+	old.ContractWideFees = true
+	old.Frozen = nil
+	assert.Empty(t, old.Compare(reset)[1:])
+	assert.Equal(t, "pool 0: LPFeePercentage 0.00250000 (contract wide) is now 0.00000000", old.Compare(reset)[0])
+
+	err := &VerifyError{Before: old, After: reset, Differences: old.Compare(reset)}
+	assert.ErrorIs(t, err, ErrStateChanged)
+	assert.EqualError(t, err, "state changed during upgrade:\npool 0: LPFeePercentage 0.00250000 (contract wide) is now 0.00000000")
+	data, jsonErr := json.Marshal(err.Before)
+	require.NoError(t, jsonErr)
+	assert.Contains(t, string(data), `"Stakes":{"0":{"179b6b1cb6755e31":{`)
+	assert.Contains(t, string(data), `"ContractWideFees":true`)
+}
+
+func TestDiff(t *testing.T) {
+	assert.Empty(t, Diff("a\nb", "a\nb"))
+	assert.Equal(t, "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n", Diff("a\nb\nc", "a\nB\nc"))
+}
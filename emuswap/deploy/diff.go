@@ -0,0 +1,96 @@
+package deploy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// Diff returns a unified style line diff from a to b, empty when they are
+// equal.
+func Diff(a, b string) string {
+	if a == b {
+		return ""
+	}
+	from, to := strings.Split(a, "\n"), strings.Split(b, "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of from[i:] and to[j:]
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	type line struct {
+		op   byte // ' ', '-' or '+'
+		text string
+		// line numbers in from and to, 1 based
+		fromLine, toLine int
+	}
+	var lines []line
+	i, j := 0, 0
+	for i < len(from) || j < len(to) {
+		switch {
+		case i < len(from) && j < len(to) && from[i] == to[j]:
+			lines = append(lines, line{' ', from[i], i + 1, j + 1})
+			i++
+			j++
+		case i < len(from) && (j == len(to) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, line{'-', from[i], i + 1, j + 1})
+			i++
+		default:
+			lines = append(lines, line{'+', to[j], i + 1, j + 1})
+			j++
+		}
+	}
+
+	var out strings.Builder
+	for start := 0; start < len(lines); {
+		if lines[start].op == ' ' {
+			start++
+			continue
+		}
+		// grow the hunk until diffContext*2 unchanged lines separate it from the next change
+		end := start
+		for k := start; k < len(lines) && k-end <= diffContext*2; k++ {
+			if lines[k].op != ' ' {
+				end = k
+			}
+		}
+		first, last := start-diffContext, end+diffContext
+		if first < 0 {
+			first = 0
+		}
+		if last > len(lines)-1 {
+			last = len(lines) - 1
+		}
+
+		var fromCount, toCount int
+		for _, l := range lines[first : last+1] {
+			if l.op != '+' {
+				fromCount++
+			}
+			if l.op != '-' {
+				toCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", lines[first].fromLine, fromCount, lines[first].toLine, toCount)
+		for _, l := range lines[first : last+1] {
+			fmt.Fprintf(&out, "%c%s\n", l.op, l.text)
+		}
+		start = last + 1
+	}
+	return out.String()
+}
@@ -0,0 +1,193 @@
+package deploy
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/onflow/flow-go-sdk"
+	"swap.emudao.org/test-overflow/emuswap"
+)
+
+// Snapshot is the state an upgrade must preserve: pool reserves and LP
+// supplies, the fee percentages and frozen flags of every pool, collected
+// fees and the stakes of every farm.
+type Snapshot struct {
+	Pools map[uint64]emuswap.PoolMeta
+	// PoolFees are the LPFeePercentage and DAOFeePercentage of every pool.
+	PoolFees map[uint64]map[string]emuswap.UFix64
+	// ContractWideFees is set when PoolFees are the contract wide fees,
+	// read from a deployment without the fee getters of Pool.
+	ContractWideFees bool
+	// Frozen is nil when read from a deployment without Pool.isPoolFrozen.
+	Frozen map[uint64]bool
+	Fees   map[string]emuswap.UFix64
+	Stakes map[uint64]map[flow.Address]emuswap.StakeInfo
+}
+
+// MarshalJSON writes the stakes keyed by the hex addresses, which JSON
+// object keys can hold.
+func (s Snapshot) MarshalJSON() ([]byte, error) {
+	type snapshot Snapshot
+	stakes := map[uint64]map[string]emuswap.StakeInfo{}
+	for farmID, byAddress := range s.Stakes {
+		stakes[farmID] = map[string]emuswap.StakeInfo{}
+		for address, stake := range byAddress {
+			stakes[farmID][address.Hex()] = stake
+		}
+	}
+	return json.Marshal(struct {
+		snapshot
+		Stakes map[uint64]map[string]emuswap.StakeInfo
+	}{snapshot(s), stakes})
+}
+
+// missingMember tells whether err is the failure of a script using a
+// function the deployed contracts do not have yet.
+func missingMember(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "has no member")
+}
+
+// TakeSnapshot reads the state through the script bindings. The pool fees
+// and frozen flags need getters of Pool that older deployments lack. Their
+// pools are read with the contract wide fees, which pools are created with,
+// and without frozen flags.
+func TakeSnapshot(c *emuswap.Client) (Snapshot, error) {
+	snapshot := Snapshot{Pools: map[uint64]emuswap.PoolMeta{}}
+	ids, err := c.GetPoolIDs()
+	if err != nil {
+		return snapshot, err
+	}
+	for _, id := range ids {
+		meta, err := c.GetPoolMeta(id)
+		if err != nil {
+			return snapshot, fmt.Errorf("pool %d: %w", id, err)
+		}
+		snapshot.Pools[id] = meta
+	}
+	snapshot.PoolFees, err = c.GetPoolFees()
+	if missingMember(err) {
+		snapshot.PoolFees, err = contractWideFees(c, ids)
+		snapshot.ContractWideFees = true
+	}
+	if err != nil {
+		return snapshot, err
+	}
+	if snapshot.Frozen, err = c.GetFrozenPools(); missingMember(err) {
+		snapshot.Frozen, err = nil, nil
+	}
+	if err != nil {
+		return snapshot, err
+	}
+	if snapshot.Fees, err = c.ReadFeesCollected(); err != nil {
+		return snapshot, err
+	}
+	if snapshot.Stakes, err = c.StakingReadAllStakes(); err != nil {
+		return snapshot, err
+	}
+	return snapshot, nil
+}
+
+// contractWideFees are the contract wide fees for every pool in ids.
+func contractWideFees(c *emuswap.Client, ids []uint64) (map[uint64]map[string]emuswap.UFix64, error) {
+	lpFee, err := c.GetLPFeePercentage()
+	if err != nil {
+		return nil, err
+	}
+	daoFee, err := c.GetDAOFeePercentage()
+	if err != nil {
+		return nil, err
+	}
+	fees := map[uint64]map[string]emuswap.UFix64{}
+	for _, id := range ids {
+		fees[id] = map[string]emuswap.UFix64{"LPFeePercentage": lpFee, "DAOFeePercentage": daoFee}
+	}
+	return fees, nil
+}
+
+// Compare lists every difference between s and after, sorted. Pending
+// rewards are left out since they grow with the block timestamp, and frozen
+// flags when either snapshot lacks them.
+func (s Snapshot) Compare(after Snapshot) []string {
+	var differences []string
+	report := func(format string, args ...interface{}) {
+		differences = append(differences, fmt.Sprintf(format, args...))
+	}
+
+	for id, before := range s.Pools {
+		now, ok := after.Pools[id]
+		switch {
+		case !ok:
+			report("pool %d: missing", id)
+		case before.Token1Identifier != now.Token1Identifier || before.Token2Identifier != now.Token2Identifier:
+			report("pool %d: pair %s/%s is now %s/%s", id, before.Token1Identifier, before.Token2Identifier, now.Token1Identifier, now.Token2Identifier)
+		default:
+			if before.Token1Amount != now.Token1Amount {
+				report("pool %d: token1 reserve %s is now %s", id, before.Token1Amount, now.Token1Amount)
+			}
+			if before.Token2Amount != now.Token2Amount {
+				report("pool %d: token2 reserve %s is now %s", id, before.Token2Amount, now.Token2Amount)
+			}
+			if before.TotalSupply != now.TotalSupply {
+				report("pool %d: LP supply %s is now %s", id, before.TotalSupply, now.TotalSupply)
+			}
+			source := ""
+			if s.ContractWideFees {
+				source = " (contract wide)"
+			}
+			for name, fee := range s.PoolFees[id] {
+				if got, ok := after.PoolFees[id][name]; !ok || got != fee {
+					report("pool %d: %s %s%s is now %s", id, name, fee, source, got)
+				}
+			}
+			if s.Frozen != nil && after.Frozen != nil && s.Frozen[id] != after.Frozen[id] {
+				report("pool %d: frozen %t is now %t", id, s.Frozen[id], after.Frozen[id])
+			}
+		}
+	}
+	for id := range after.Pools {
+		if _, ok := s.Pools[id]; !ok {
+			report("pool %d: appeared", id)
+		}
+	}
+
+	for identifier, before := range s.Fees {
+		if now := after.Fees[identifier]; before != now {
+			report("fees %s: %s is now %s", identifier, before, now)
+		}
+	}
+	for identifier, now := range after.Fees {
+		if _, ok := s.Fees[identifier]; !ok {
+			report("fees %s: appeared with %s", identifier, now)
+		}
+	}
+
+	for farmID, stakes := range s.Stakes {
+		for address, before := range stakes {
+			now, ok := after.Stakes[farmID][address]
+			if !ok {
+				report("farm %d: stake of %s missing", farmID, address)
+				continue
+			}
+			if before.Balance != now.Balance {
+				report("farm %d: stake of %s %s is now %s", farmID, address, before.Balance, now.Balance)
+			}
+			for poolID, debt := range before.RewardDebtByID {
+				if now.RewardDebtByID[poolID] != debt {
+					report("farm %d: reward debt of %s in reward pool %d %s is now %s", farmID, address, poolID, debt, now.RewardDebtByID[poolID])
+				}
+			}
+		}
+	}
+	for farmID, stakes := range after.Stakes {
+		for address := range stakes {
+			if _, ok := s.Stakes[farmID][address]; !ok {
+				report("farm %d: stake of %s appeared", farmID, address)
+			}
+		}
+	}
+
+	sort.Strings(differences)
+	return differences
+}
@@ -0,0 +1,303 @@
+// Package depth measures how much a pool can absorb: the price impact of swaps
+// of every size, the largest swap that stays within an impact, and how both
+// change when liquidity is added or removed. Everything is computed on
+// emuswap.Pool, so the amounts are what the contract would pay out.
+package depth
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"swap.emudao.org/test-overflow/emuswap"
+)
+
+// Point is the outcome of a swap of one size.
+type Point struct {
+	AmountIn  emuswap.UFix64 `json:"amountIn"`
+	AmountOut emuswap.UFix64 `json:"amountOut"`
+	// Price is the output per input the trader gets, fees included.
+	Price float64 `json:"price"`
+	// Impact is how much worse Price is than the marginal price, 0.01 for 1%.
+	Impact float64 `json:"impact"`
+}
+
+// Marginal is the output per input of the first unit swapped, after fees.
+func Marginal(pool emuswap.Pool, side emuswap.Side) float64 {
+	in, out := pool.Token1Amount, pool.Token2Amount
+	if side == emuswap.Token2ForToken1 {
+		in, out = out, in
+	}
+	if in == 0 {
+		return 0
+	}
+	fees := pool.LPFeePercentage.Float64() + pool.DAOFeePercentage.Float64()
+	return out.Float64() / in.Float64() * (1 - fees)
+}
+
+// Quote swaps amount on a copy of pool.
+func Quote(pool emuswap.Pool, side emuswap.Side, amount emuswap.UFix64) (Point, error) {
+	marginal := Marginal(pool, side)
+	result, err := pool.Swap(side, amount)
+	if err != nil {
+		return Point{}, err
+	}
+	price := result.AmountOut.Float64() / amount.Float64()
+	return Point{AmountIn: amount, AmountOut: result.AmountOut, Price: price, Impact: 1 - price/marginal}, nil
+}
+
+// Curve quotes swaps of the given fractions of the input reserve. Swaps too
+// small to pay out anything are left out.
+func Curve(pool emuswap.Pool, side emuswap.Side, fractions []float64) ([]Point, error) {
+	in := pool.Token1Amount
+	if side == emuswap.Token2ForToken1 {
+		in = pool.Token2Amount
+	}
+	points := []Point{}
+	for _, fraction := range fractions {
+		amount := emuswap.UFix64FromFloat(in.Float64() * fraction)
+		if amount == 0 {
+			continue
+		}
+		point, err := Quote(pool, side, amount)
+		if errors.Is(err, emuswap.ErrAmountTooSmall) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s %v of reserve: %w", side, fraction, err)
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// MaxAmountIn finds the largest input whose impact is at most impact. The
+// zero Point is returned when even the smallest swap that pays out exceeds it.
+func MaxAmountIn(pool emuswap.Pool, side emuswap.Side, impact float64) (Point, error) {
+	if impact <= 0 || impact >= 1 {
+		return Point{}, fmt.Errorf("impact %v not between 0 and 1", impact)
+	}
+	in := pool.Token1Amount
+	if side == emuswap.Token2ForToken1 {
+		in = pool.Token2Amount
+	}
+	if in == 0 {
+		return Point{}, emuswap.ErrEmptyVault
+	}
+	// on the curve alone impact = priced / (in + priced), twice that bound
+	// leaves room for truncation
+	net := 1 - pool.LPFeePercentage.Float64() - pool.DAOFeePercentage.Float64()
+	bound := 2 * impact * in.Float64() / (net * (1 - impact))
+	hi := emuswap.MaxUFix64 - in
+	if bound < hi.Float64() {
+		hi = emuswap.UFix64FromFloat(bound) + 1
+	}
+
+	within := func(amount emuswap.UFix64) (Point, bool, error) {
+		point, err := Quote(pool, side, amount)
+		if errors.Is(err, emuswap.ErrAmountTooSmall) {
+			return Point{}, true, nil
+		}
+		if err != nil {
+			return Point{}, false, err
+		}
+		return point, point.Impact <= impact, nil
+	}
+	var lo emuswap.UFix64
+	for lo < hi {
+		mid := lo + (hi-lo+1)/2
+		_, ok, err := within(mid)
+		if err != nil {
+			return Point{}, err
+		}
+		if ok {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	if lo == 0 {
+		return Point{}, nil
+	}
+	point, _, err := within(lo)
+	return point, err
+}
+
+// WithLiquidity returns pool after a change of liquidity, a share of the
+// current reserves: 0.5 adds half of them in proportion, like addLiquidity
+// without leftovers, and -0.5 removes half, like removeLiquidity.
+func WithLiquidity(pool emuswap.Pool, change float64) (emuswap.Pool, error) {
+	if change <= -1 {
+		return emuswap.Pool{}, fmt.Errorf("cannot remove all liquidity (change %v)", change)
+	}
+	pool.Token1Amount = emuswap.UFix64FromFloat(pool.Token1Amount.Float64() * (1 + change))
+	pool.Token2Amount = emuswap.UFix64FromFloat(pool.Token2Amount.Float64() * (1 + change))
+	return pool, nil
+}
+
+// Level is the largest swap within an impact.
+type Level struct {
+	MaxImpact float64 `json:"maxImpact"`
+	Point
+}
+
+// SideDepth is the depth of a pool in one direction.
+type SideDepth struct {
+	Marginal float64 `json:"marginal"`
+	Levels   []Level `json:"levels"`
+	Curve    []Point `json:"curve"`
+}
+
+// Report is the depth of a pool in both directions.
+type Report struct {
+	Token1Amount     emuswap.UFix64 `json:"token1Amount"`
+	Token2Amount     emuswap.UFix64 `json:"token2Amount"`
+	LPFeePercentage  emuswap.UFix64 `json:"lpFeePercentage"`
+	DAOFeePercentage emuswap.UFix64 `json:"daoFeePercentage"`
+	// Price is the amount of token2 per token1, fees excluded.
+	Price           float64   `json:"price"`
+	Token1ForToken2 SideDepth `json:"token1ForToken2"`
+	Token2ForToken1 SideDepth `json:"token2ForToken1"`
+}
+
+// Side returns the depth of side.
+func (r Report) Side(side emuswap.Side) SideDepth {
+	if side == emuswap.Token2ForToken1 {
+		return r.Token2ForToken1
+	}
+	return r.Token1ForToken2
+}
+
+// Options choose what is measured.
+type Options struct {
+	// Impacts are the levels to find the largest swap for.
+	Impacts []float64
+	// Fractions of the input reserve are the points of the curves.
+	Fractions []float64
+	// Liquidity are the changes of liquidity to measure the pool after.
+	Liquidity []float64
+}
+
+// DefaultOptions measure 1%, 2% and 5% impact, the curve from 0.1% to the
+// whole input reserve and no liquidity changes.
+var DefaultOptions = Options{
+	Impacts:   []float64{0.01, 0.02, 0.05},
+	Fractions: []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+}
+
+// Analyze measures pool in both directions.
+func Analyze(pool emuswap.Pool, options Options) (Report, error) {
+	report := Report{
+		Token1Amount:     pool.Token1Amount,
+		Token2Amount:     pool.Token2Amount,
+		LPFeePercentage:  pool.LPFeePercentage,
+		DAOFeePercentage: pool.DAOFeePercentage,
+		Price:            pool.Price(),
+	}
+	impacts := append([]float64(nil), options.Impacts...)
+	sort.Float64s(impacts)
+	for _, side := range []emuswap.Side{emuswap.Token1ForToken2, emuswap.Token2ForToken1} {
+		depth := SideDepth{Marginal: Marginal(pool, side), Levels: []Level{}}
+		for _, impact := range impacts {
+			point, err := MaxAmountIn(pool, side, impact)
+			if err != nil {
+				return Report{}, fmt.Errorf("%s at %v impact: %w", side, impact, err)
+			}
+			depth.Levels = append(depth.Levels, Level{MaxImpact: impact, Point: point})
+		}
+		curve, err := Curve(pool, side, options.Fractions)
+		if err != nil {
+			return Report{}, err
+		}
+		depth.Curve = curve
+		if side == emuswap.Token1ForToken2 {
+			report.Token1ForToken2 = depth
+		} else {
+			report.Token2ForToken1 = depth
+		}
+	}
+	return report, nil
+}
+
+// Change is the depth of a pool after a change of liquidity.
+type Change struct {
+	Liquidity float64 `json:"liquidity"`
+	Report    Report  `json:"report"`
+}
+
+// PoolDepth is the depth of an on-chain pool now and after the liquidity
+// changes of the options.
+type PoolDepth struct {
+	PoolID           uint64   `json:"poolID"`
+	Token1Identifier string   `json:"token1Identifier"`
+	Token2Identifier string   `json:"token2Identifier"`
+	Current          Report   `json:"current"`
+	Changes          []Change `json:"changes"`
+}
+
+// Load measures the pool poolID with the fees it charges.
+func Load(c *emuswap.Client, poolID uint64, options Options) (PoolDepth, error) {
+	meta, err := c.GetPoolMeta(poolID)
+	if err != nil {
+		return PoolDepth{}, err
+	}
+	pool, err := c.LoadPool(poolID)
+	if err != nil {
+		return PoolDepth{}, err
+	}
+	current, err := Analyze(pool, options)
+	if err != nil {
+		return PoolDepth{}, err
+	}
+	depth := PoolDepth{
+		PoolID:           poolID,
+		Token1Identifier: meta.Token1Identifier,
+		Token2Identifier: meta.Token2Identifier,
+		Current:          current,
+		Changes:          []Change{},
+	}
+	for _, change := range options.Liquidity {
+		changed, err := WithLiquidity(pool, change)
+		if err != nil {
+			return PoolDepth{}, err
+		}
+		report, err := Analyze(changed, options)
+		if err != nil {
+			return PoolDepth{}, fmt.Errorf("liquidity %+v: %w", change, err)
+		}
+		depth.Changes = append(depth.Changes, Change{Liquidity: change, Report: report})
+	}
+	return depth, nil
+}
+
+// Write prints the levels of the current pool and of every liquidity change,
+// with the curves when curves is set.
+func (d PoolDepth) Write(w io.Writer, curves bool) error {
+	fmt.Fprintf(w, "pool %d %s/%s\n", d.PoolID, d.Token1Identifier, d.Token2Identifier)
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	write := func(label string, r Report) {
+		fmt.Fprintf(tw, "%s: reserves %s/%s, fees %s+%s, price %.8f\n", label, r.Token1Amount, r.Token2Amount, r.LPFeePercentage, r.DAOFeePercentage, r.Price)
+		fmt.Fprintln(tw, "side\tmax impact\tamount in\tamount out\timpact")
+		for _, side := range []emuswap.Side{emuswap.Token1ForToken2, emuswap.Token2ForToken1} {
+			for _, level := range r.Side(side).Levels {
+				fmt.Fprintf(tw, "%s\t%.2f%%\t%s\t%s\t%.4f%%\n", side, level.MaxImpact*100, level.AmountIn, level.AmountOut, level.Impact*100)
+			}
+		}
+		if !curves {
+			return
+		}
+		fmt.Fprintln(tw, "side\tamount in\tamount out\tprice\timpact")
+		for _, side := range []emuswap.Side{emuswap.Token1ForToken2, emuswap.Token2ForToken1} {
+			for _, point := range r.Side(side).Curve {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%.8f\t%.4f%%\n", side, point.AmountIn, point.AmountOut, point.Price, point.Impact*100)
+			}
+		}
+	}
+	write("current", d.Current)
+	for _, change := range d.Changes {
+		write(fmt.Sprintf("liquidity %+.0f%%", change.Liquidity*100), change.Report)
+	}
+	return tw.Flush()
+}
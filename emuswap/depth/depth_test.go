@@ -0,0 +1,140 @@
+package depth
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/internal/emutest"
+)
+
+// TestMain runs the package tests from the repository root, where flow.json
+// and the files it references resolve.
+func TestMain(m *testing.M) {
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(emutest.Main(m))
+}
+
+var ufix = emuswap.UFix64FromFloat
+
+func TestMaxAmountIn(t *testing.T) {
+	pool := emuswap.Pool{Token1Amount: ufix(10000.0), Token2Amount: ufix(5000.0), LPFeePercentage: ufix(0.003)}
+	for _, side := range []emuswap.Side{emuswap.Token1ForToken2, emuswap.Token2ForToken1} {
+		var previous emuswap.UFix64
+		for _, impact := range []float64{0.001, 0.01, 0.05, 0.5} {
+			point, err := MaxAmountIn(pool, side, impact)
+			require.NoError(t, err)
+			assert.LessOrEqual(t, point.Impact, impact)
+			assert.Greater(t, point.AmountIn, previous)
+			previous = point.AmountIn
+
+			// one more unit goes over
+			over, err := Quote(pool, side, point.AmountIn+1)
+			require.NoError(t, err)
+			assert.Greater(t, over.Impact, impact)
+		}
+	}
+
+	// the curve alone gives impact = priced / (reserve + priced)
+	point, err := MaxAmountIn(pool, emuswap.Token1ForToken2, 0.01)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.01*10000/0.99/0.997, point.AmountIn.Float64(), 1e-3)
+
+	_, err = MaxAmountIn(pool, emuswap.Token1ForToken2, 1)
+	assert.Error(t, err)
+
+	curve, err := Curve(pool, emuswap.Token2ForToken1, DefaultOptions.Fractions)
+	require.NoError(t, err)
+	require.Len(t, curve, len(DefaultOptions.Fractions))
+	for i := 1; i < len(curve); i++ {
+		assert.Greater(t, curve[i].Impact, curve[i-1].Impact)
+		assert.Less(t, curve[i].Price, curve[i-1].Price)
+	}
+	// swapping the whole reserve nearly halves the price
+	assert.InDelta(t, 0.997/1.997, curve[len(curve)-1].Impact, 1e-6)
+
+	doubled, err := WithLiquidity(pool, 1)
+	require.NoError(t, err)
+	deeper, err := MaxAmountIn(doubled, emuswap.Token1ForToken2, 0.01)
+	require.NoError(t, err)
+	assert.InDelta(t, 2*point.AmountIn.Float64(), deeper.AmountIn.Float64(), 1e-6)
+	_, err = WithLiquidity(pool, -1)
+	assert.Error(t, err)
+}
+
+// TestAgainstEmulator checks the curves against the quote scripts and the
+// levels against swaps and added liquidity on chain.
+func TestAgainstEmulator(t *testing.T) {
+	o, err := emutest.Start()
+	require.NoError(t, err)
+	c := emuswap.NewClient(o)
+	flowToken, fusd := emuswap.MustLookupToken("FLOW"), emuswap.MustLookupToken("FUSD")
+
+	c.DemoMintFlowTokens("account", ufix(10000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.FUSDSetup("account").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", ufix(10000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.EmuSwapAdminCreateNewPool("account", flowToken.StoragePath, ufix(1000.0), fusd.StoragePath, ufix(500.0)).Test(t).AssertSuccess()
+	// fees of the pool apart from the contract wide ones
+	c.EmuSwapAdminUpdateLPFeePercentage("account", 0, ufix(0.004)).Test(t).AssertSuccess()
+	c.EmuSwapAdminUpdateDAOFeePercentage("account", 0, ufix(0.001)).Test(t).AssertSuccess()
+
+	options := DefaultOptions
+	options.Liquidity = []float64{0.5}
+	d, err := Load(c, 0, options)
+	require.NoError(t, err)
+	assert.Equal(t, "A.0ae53cb6e3f42a79.FlowToken.Vault", d.Token1Identifier)
+	require.Len(t, d.Changes, 1)
+
+	pool, err := c.LoadPool(0)
+	require.NoError(t, err)
+	assert.Equal(t, ufix(0.004), pool.LPFeePercentage)
+	assert.Equal(t, ufix(0.001), pool.DAOFeePercentage)
+	net := emuswap.UFix64(emuswap.UFix64Factor) - pool.LPFeePercentage - pool.DAOFeePercentage
+	for _, point := range d.Current.Token1ForToken2.Curve {
+		priced, err := point.AmountIn.Mul(net)
+		require.NoError(t, err)
+		quote, err := c.PoolGetQuoteExactAToB(0, priced)
+		require.NoError(t, err)
+		assert.Equal(t, quote, point.AmountOut, point.AmountIn.String())
+	}
+	for _, point := range d.Current.Token2ForToken1.Curve {
+		priced, err := point.AmountIn.Mul(net)
+		require.NoError(t, err)
+		quotes, err := c.PoolGetQuotes(0, priced)
+		require.NoError(t, err)
+		assert.Equal(t, quotes["exact B for A"], point.AmountOut, point.AmountIn.String())
+	}
+
+	// the 1% level swaps on chain for what it says
+	level := d.Current.Token1ForToken2.Levels[0]
+	assert.Equal(t, 0.01, level.MaxImpact)
+	c.Swap("account", flowToken, fusd, level.AmountIn).Test(t).AssertSuccess()
+	meta, err := c.GetPoolMeta(0)
+	require.NoError(t, err)
+	assert.Equal(t, d.Current.Token2Amount-level.AmountOut, meta.Token2Amount)
+	c.Swap("account", fusd, flowToken, level.AmountOut).Test(t).AssertSuccess()
+
+	// adding half of the reserves gives the predicted depth
+	meta, err = c.GetPoolMeta(0)
+	require.NoError(t, err)
+	predicted, err := Load(c, 0, options)
+	require.NoError(t, err)
+	half1, half2 := meta.Token1Amount/2, meta.Token2Amount/2
+	c.AddLiquidity("account", flowToken, fusd, half1, half2).Test(t).AssertSuccess()
+	added, err := Load(c, 0, DefaultOptions)
+	require.NoError(t, err)
+	assert.InDelta(t, predicted.Changes[0].Report.Token1Amount.Float64(), added.Current.Token1Amount.Float64(), 2e-8)
+	for i, level := range added.Current.Token1ForToken2.Levels {
+		assert.InDelta(t, predicted.Changes[0].Report.Token1ForToken2.Levels[i].AmountIn.Float64(), level.AmountIn.Float64(), 1e-6)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, d.Write(&buf, true))
+	assert.Contains(t, buf.String(), "liquidity +50%")
+	assert.Contains(t, buf.String(), "1.00%")
+}
@@ -0,0 +1,313 @@
+// Package emission designs the DecayingEmission of a StakingRewards reward
+// pool. A reward pool emits rate tokens per second, split between its farms
+// by weight, where the rate is 1.0 in the first epoch and decays by decay in
+// each epoch after:
+//
+//	epoch = (now - genesis) / epochLength   // not rounded, 8 decimals
+//	rate  = (1 - decay)^(ceil(epoch) - 1)   // 1.0 up to the end of epoch 1
+//
+// so epoch n ends at genesis + n * epochLength, give or take the truncation
+// of epoch, and its rate holds until then. Once epoch passes totalEpochs the
+// rate returns to 1.0 and stays there until the vault runs dry. The full rate
+// is 1 token per second whatever the vault holds, so a schedule can emit at
+// most its duration in tokens.
+//
+// Design solves the decay for a number of epochs, or the number of epochs for
+// a decay, so the schedule emits a target total over a duration. Verify checks
+// a design against the rate the contract computes, only reading the chain.
+//
+// The emission of a reward pool is relative to its genesis timestamp, which
+// staking sets for reward pool 0 only, at its first stake. Other reward pools
+// keep a genesis of 0.0, which reads as now, and emit at the full rate.
+package emission
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"text/tabwriter"
+
+	"github.com/onflow/cadence"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/rewards"
+)
+
+// Transaction creates a reward pool with a DecayingEmission.
+const Transaction = "transactions/Staking/admin/create_decaying_reward_pool.cdc"
+
+// maxEpochs bounds the epochs searched for a decay.
+const maxEpochs = 10000
+
+var one = emuswap.UFix64(emuswap.UFix64Factor)
+
+// Target is the schedule to design. Exactly one of Epochs and Decay is set,
+// the other is solved for.
+type Target struct {
+	// Total is the tokens emitted over Duration seconds.
+	Total    emuswap.UFix64  `json:"total"`
+	Duration emuswap.UFix64  `json:"duration"`
+	Epochs   uint64          `json:"epochs,omitempty"`
+	Decay    *emuswap.UFix64 `json:"decay,omitempty"`
+}
+
+// Epoch is one row of the emission table. Start and End are seconds after
+// genesis, Emitted is the tokens the pool emits in the epoch at full stake.
+type Epoch struct {
+	Epoch      uint64         `json:"epoch"`
+	Start      emuswap.UFix64 `json:"start"`
+	End        emuswap.UFix64 `json:"end"`
+	Rate       emuswap.UFix64 `json:"rate"`
+	Emitted    emuswap.UFix64 `json:"emitted"`
+	Cumulative emuswap.UFix64 `json:"cumulative"`
+}
+
+// Design is a solved schedule. Total is what it emits, which differs from the
+// target by the rounding of the parameters to UFix64.
+type Design struct {
+	Target   Target                   `json:"target"`
+	Emission emuswap.DecayingEmission `json:"emission"`
+	Epochs   []Epoch                  `json:"epochs"`
+	Total    emuswap.UFix64           `json:"total"`
+	Warnings []string                 `json:"warnings"`
+}
+
+// Table returns the epochs of emission and the tokens emitted over all of
+// them, computed with the truncating arithmetic of the contract.
+func Table(emission emuswap.DecayingEmission) ([]Epoch, emuswap.UFix64, error) {
+	factor, err := one.Sub(emission.Decay)
+	if err != nil {
+		return nil, 0, err
+	}
+	end, err := emission.TotalEpochs.Mul(emission.EpochLength)
+	if err != nil {
+		return nil, 0, err
+	}
+	var epochs []Epoch
+	var total emuswap.UFix64
+	rate := one
+	for n := uint64(1); emuswap.UFix64(n-1)*one < emission.TotalEpochs; n++ {
+		if n > 1 {
+			if rate, err = rate.Mul(factor); err != nil {
+				return nil, 0, err
+			}
+		}
+		start := emission.EpochLength * emuswap.UFix64(n-1)
+		epoch := Epoch{Epoch: n, Start: start, End: start + emission.EpochLength, Rate: rate}
+		// a fractional last epoch ends with totalEpochs
+		if epoch.End > end {
+			epoch.End = end
+		}
+		if epoch.Emitted, err = (epoch.End - epoch.Start).Mul(rate); err != nil {
+			return nil, 0, err
+		}
+		if total, err = total.Add(epoch.Emitted); err != nil {
+			return nil, 0, err
+		}
+		epoch.Cumulative = total
+		epochs = append(epochs, epoch)
+	}
+	return epochs, total, nil
+}
+
+// Solve designs the schedule of target.
+func Solve(target Target) (Design, error) {
+	d := Design{Target: target, Warnings: []string{}}
+	switch {
+	case target.Total == 0 || target.Duration == 0:
+		return d, errors.New("total and duration must be positive")
+	case (target.Epochs == 0) == (target.Decay == nil):
+		return d, errors.New("set either the epochs or the decay")
+	case target.Total > target.Duration:
+		return d, fmt.Errorf("%s tokens in %s seconds is more than the 1 token per second a reward pool emits at most", target.Total, target.Duration)
+	}
+
+	var err error
+	if target.Decay != nil {
+		d.Emission, err = solveEpochs(target)
+	} else {
+		d.Emission, err = solveDecay(target)
+	}
+	if err != nil {
+		return d, err
+	}
+	if d.Epochs, d.Total, err = Table(d.Emission); err != nil {
+		return d, err
+	}
+
+	if d.Total != target.Total {
+		diff := d.Total.Float64() - target.Total.Float64()
+		d.Warnings = append(d.Warnings, fmt.Sprintf("emits %s, %+.8f from the target", d.Total, diff))
+	}
+	d.Warnings = append(d.Warnings, fmt.Sprintf("after epoch %d the rate returns to 1 token per second until the vault runs dry, fund the pool with %s for it to end with the schedule", len(d.Epochs), d.Total))
+	return d, nil
+}
+
+// solveDecay finds the decay that emits the total in the given epochs.
+func solveDecay(target Target) (emuswap.DecayingEmission, error) {
+	n := target.Epochs
+	emission := emuswap.DecayingEmission{TotalEpochs: emuswap.UFix64(n) * one}
+	var err error
+	if emission.EpochLength, err = target.Duration.Div(emission.TotalEpochs); err != nil {
+		return emission, err
+	}
+	// without decay everything is emitted, with a decay of 1 only the first epoch
+	if target.Total < emission.EpochLength {
+		least := uint64(math.Ceil(target.Duration.Float64() / target.Total.Float64()))
+		return emission, fmt.Errorf("the first epoch alone emits %s, use at least %d epochs", emission.EpochLength, least)
+	}
+	if n == 1 {
+		return emission, nil
+	}
+
+	length, total := emission.EpochLength.Float64(), target.Total.Float64()
+	sum := func(f float64) float64 {
+		return length * (1 - math.Pow(f, float64(n))) / (1 - f)
+	}
+	lo, hi := 0.0, 1.0
+	for i := 0; i < 100; i++ {
+		if mid := (lo + hi) / 2; sum(mid) < total {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	// the table truncates every rate, try the decays next to the float one
+	guess := int64(emuswap.UFix64FromFloat(1 - lo))
+	best, bestDiff := emission, math.Inf(1)
+	for delta := int64(-8); delta <= 8; delta++ {
+		decay := guess + delta
+		if decay < 0 || decay > emuswap.UFix64Factor {
+			continue
+		}
+		candidate := emission
+		candidate.Decay = emuswap.UFix64(decay)
+		_, emitted, err := Table(candidate)
+		if err != nil {
+			return emission, err
+		}
+		if diff := math.Abs(emitted.Float64() - total); diff < bestDiff {
+			best, bestDiff = candidate, diff
+		}
+	}
+	return best, nil
+}
+
+// solveEpochs finds the number of epochs in which the decay emits closest to
+// the total.
+func solveEpochs(target Target) (emuswap.DecayingEmission, error) {
+	best, bestDiff := emuswap.DecayingEmission{}, math.Inf(1)
+	if *target.Decay > one {
+		return best, fmt.Errorf("decay %s is above 1", *target.Decay)
+	}
+	// the emission falls with every epoch the duration is split in
+	for n := uint64(1); n <= maxEpochs; n++ {
+		emission := emuswap.DecayingEmission{TotalEpochs: emuswap.UFix64(n) * one, Decay: *target.Decay}
+		var err error
+		if emission.EpochLength, err = target.Duration.Div(emission.TotalEpochs); err != nil {
+			return best, err
+		}
+		_, emitted, err := Table(emission)
+		if err != nil {
+			return best, err
+		}
+		diff := emitted.Float64() - target.Total.Float64()
+		if math.Abs(diff) < bestDiff {
+			best, bestDiff = emission, math.Abs(diff)
+		}
+		if diff <= 0 {
+			return best, nil
+		}
+	}
+	return best, fmt.Errorf("decay %s emits more than %s in %d epochs", *target.Decay, target.Total, maxEpochs)
+}
+
+// Arguments returns the arguments of Transaction creating the reward pool of
+// the design, funded with its total from the vault at storageID.
+func (d Design) Arguments(storageID string, farmWeightsByID map[uint64]emuswap.UFix64, nftPaths []string) ([]cadence.Value, error) {
+	if nftPaths == nil {
+		nftPaths = []string{}
+	}
+	args := []interface{}{storageID, d.Total, d.Emission.EpochLength, d.Emission.TotalEpochs, d.Emission.Decay, farmWeightsByID, nftPaths}
+	values := make([]cadence.Value, len(args))
+	for i, arg := range args {
+		value, err := emuswap.Encode(arg)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// Sample is the rate of a reward pool at a time after its genesis.
+type Sample struct {
+	Elapsed  emuswap.UFix64 `json:"elapsed"`
+	Expected emuswap.UFix64 `json:"expected"`
+	Actual   emuswap.UFix64 `json:"actual"`
+}
+
+// Verify reads the rate of the emission of the design on both sides of every
+// epoch boundary and after the last from DecayingEmission.getCurrentEmissionRate,
+// through a script that creates no reward pool. The elapsed seconds are taken
+// back from StakingRewards.now(), which needs mock time off or past them.
+func Verify(c *emuswap.Client, d Design) ([]Sample, error) {
+	var at []emuswap.UFix64
+	for _, epoch := range d.Epochs {
+		at = append(at, epoch.Start+one, epoch.End)
+	}
+	at = append(at, d.Epochs[len(d.Epochs)-1].End+one)
+
+	e := d.Emission
+	rates, err := c.StakingGetEmissionRates(e.EpochLength, e.TotalEpochs, e.Decay, at)
+	if err != nil {
+		return nil, err
+	}
+	if len(rates) != len(at) {
+		return nil, fmt.Errorf("read %d rates for %d times", len(rates), len(at))
+	}
+	samples := make([]Sample, len(at))
+	for i, t := range at {
+		// any genesis but 0.0, which the contract reads as now
+		expected, err := rewards.Rate(e, one, one+t)
+		if err != nil {
+			return nil, err
+		}
+		samples[i] = Sample{Elapsed: t, Expected: expected, Actual: rates[i]}
+	}
+	return samples, nil
+}
+
+// Mismatches returns the samples at which the contract rate is not the
+// expected one.
+func Mismatches(samples []Sample) []Sample {
+	var mismatches []Sample
+	for _, sample := range samples {
+		if sample.Actual != sample.Expected {
+			mismatches = append(mismatches, sample)
+		}
+	}
+	return mismatches
+}
+
+// Write prints the parameters, the emission table and the warnings.
+func (d Design) Write(w io.Writer) error {
+	e := d.Emission
+	if _, err := fmt.Fprintf(w, "epochLength %s (%.2f days), totalEpochs %s, decay %s\n\n", e.EpochLength, e.EpochLength.Float64()/86400, e.TotalEpochs, e.Decay); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "epoch\tstart (days)\tend (days)\trate/s\temitted\tcumulative\t")
+	for _, epoch := range d.Epochs {
+		fmt.Fprintf(tw, "%d\t%.2f\t%.2f\t%s\t%s\t%s\t\n", epoch.Epoch, epoch.Start.Float64()/86400, epoch.End.Float64()/86400, epoch.Rate, epoch.Emitted, epoch.Cumulative)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, warning := range d.Warnings {
+		if _, err := fmt.Fprintf(w, "WARNING %s\n", warning); err != nil {
+			return err
+		}
+	}
+	return nil
+}
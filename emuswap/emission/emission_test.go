@@ -0,0 +1,164 @@
+package emission
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/rewards"
+	"swap.emudao.org/test-overflow/internal/emutest"
+)
+
+// TestMain runs the package tests from the repository root, where flow.json
+// and the files it references resolve.
+func TestMain(m *testing.M) {
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(emutest.Main(m))
+}
+
+var ufix = emuswap.UFix64FromFloat
+
+// emu is the emission of reward pool 0, set up by the contract.
+var emu = emuswap.DecayingEmission{EpochLength: ufix(28 * 24 * 3600), TotalEpochs: ufix(40), Decay: ufix(0.05388176)}
+
+func TestTable(t *testing.T) {
+	emission := emuswap.DecayingEmission{EpochLength: ufix(100), TotalEpochs: ufix(3), Decay: ufix(0.5)}
+	epochs, total, err := Table(emission)
+	require.NoError(t, err)
+	assert.Equal(t, []Epoch{
+		{Epoch: 1, Start: 0, End: ufix(100), Rate: ufix(1), Emitted: ufix(100), Cumulative: ufix(100)},
+		{Epoch: 2, Start: ufix(100), End: ufix(200), Rate: ufix(0.5), Emitted: ufix(50), Cumulative: ufix(150)},
+		{Epoch: 3, Start: ufix(200), End: ufix(300), Rate: ufix(0.25), Emitted: ufix(25), Cumulative: ufix(175)},
+	}, epochs)
+	assert.Equal(t, ufix(175), total)
+
+	// a fractional last epoch is cut short
+	emission.TotalEpochs = ufix(2.5)
+	epochs, total, err = Table(emission)
+	require.NoError(t, err)
+	require.Len(t, epochs, 3)
+	assert.Equal(t, ufix(250), epochs[2].End)
+	assert.Equal(t, ufix(162.5), total)
+
+	// the rates are the ones the contract computes within each epoch
+	epochs, _, err = Table(emu)
+	require.NoError(t, err)
+	require.Len(t, epochs, 40)
+	genesis := ufix(1000)
+	for _, epoch := range epochs {
+		for _, at := range []emuswap.UFix64{epoch.Start + ufix(1), epoch.End} {
+			rate, err := rewards.Rate(emu, genesis, genesis+at)
+			require.NoError(t, err)
+			assert.Equal(t, epoch.Rate, rate, "epoch %d at %s", epoch.Epoch, at)
+		}
+	}
+}
+
+func TestSolve(t *testing.T) {
+	_, emitted, err := Table(emu)
+	require.NoError(t, err)
+	duration := emu.EpochLength * 40
+
+	// the decay of reward pool 0 is found from its total
+	d, err := Solve(Target{Total: emitted, Duration: duration, Epochs: 40})
+	require.NoError(t, err)
+	assert.Equal(t, emu, d.Emission)
+	assert.Equal(t, emitted, d.Total)
+	assert.Len(t, d.Warnings, 1, "only the rate after the last epoch")
+
+	// and its epochs from the decay
+	decay := emu.Decay
+	d, err = Solve(Target{Total: emitted, Duration: duration, Decay: &decay})
+	require.NoError(t, err)
+	assert.Equal(t, emu, d.Emission)
+
+	// an unreachable total gets the closest schedule
+	d, err = Solve(Target{Total: ufix(1000), Duration: ufix(2000), Epochs: 4})
+	require.NoError(t, err)
+	assert.Equal(t, ufix(500), d.Emission.EpochLength)
+	assert.InDelta(t, 1000, d.Total.Float64(), 0.0001)
+	assert.Contains(t, d.Warnings[0], "from the target")
+
+	// no decay emits one token per second throughout
+	zero := emuswap.UFix64(0)
+	d, err = Solve(Target{Total: ufix(2000), Duration: ufix(2000), Decay: &zero})
+	require.NoError(t, err)
+	assert.Equal(t, ufix(2000), d.Total)
+
+	for name, target := range map[string]Target{
+		"above 1 token per second": {Total: ufix(2001), Duration: ufix(2000), Epochs: 4},
+		"too few epochs":           {Total: ufix(100), Duration: ufix(2000), Epochs: 4},
+		"both set":                 {Total: ufix(1000), Duration: ufix(2000), Epochs: 4, Decay: &decay},
+		"neither set":              {Total: ufix(1000), Duration: ufix(2000)},
+		"empty":                    {Duration: ufix(2000), Epochs: 4},
+	} {
+		_, err := Solve(target)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestArguments(t *testing.T) {
+	d, err := Solve(Target{Total: ufix(1000), Duration: ufix(2000), Epochs: 4})
+	require.NoError(t, err)
+	args, err := d.Arguments("emuTokenVault", map[uint64]emuswap.UFix64{0: ufix(1)}, nil)
+	require.NoError(t, err)
+	require.Len(t, args, 7)
+	assert.Equal(t, `"emuTokenVault"`, args[0].String())
+	assert.Equal(t, d.Total.String(), args[1].String())
+	assert.Equal(t, "500.00000000", args[2].String())
+	assert.Equal(t, "4.00000000", args[3].String())
+	assert.Equal(t, "{0: 1.00000000}", args[5].String())
+	assert.Equal(t, "[]", args[6].String())
+}
+
+func TestVerify(t *testing.T) {
+	o, err := emutest.Start()
+	require.NoError(t, err)
+	c := emuswap.NewClient(o)
+
+	d, err := Solve(Target{Total: ufix(20 * 24 * 3600), Duration: ufix(30 * 24 * 3600), Epochs: 5})
+	require.NoError(t, err)
+	before, err := c.StakingGetRewardPoolsMeta()
+	require.NoError(t, err)
+	samples, err := Verify(c, d)
+	require.NoError(t, err)
+	require.Len(t, samples, 11)
+	assert.Empty(t, Mismatches(samples))
+	for i, epoch := range d.Epochs {
+		assert.Equal(t, epoch.Rate, samples[2*i].Actual, "start of epoch %d", epoch.Epoch)
+		assert.Equal(t, epoch.Rate, samples[2*i+1].Actual, "end of epoch %d", epoch.Epoch)
+	}
+	assert.Equal(t, ufix(1), samples[10].Actual, "full rate after the last epoch")
+
+	after, err := c.StakingGetRewardPoolsMeta()
+	require.NoError(t, err)
+	assert.Equal(t, before, after, "no reward pool is created")
+	mocked, err := c.StakingIsMockTime()
+	require.NoError(t, err)
+	assert.False(t, mocked)
+
+	// mock time must be past the times sampled
+	c.StakingAdminToggleMockTime("account").Test(t).AssertSuccess()
+	mocked, err = c.StakingIsMockTime()
+	require.NoError(t, err)
+	assert.True(t, mocked)
+	_, err = Verify(c, d)
+	assert.Error(t, err)
+	c.StakingAdminUpdateMockTimestamp("account", ufix(31*24*3600)).Test(t).AssertSuccess()
+	samples, err = Verify(c, d)
+	require.NoError(t, err)
+	assert.Empty(t, Mismatches(samples))
+
+	// the transaction creates the pool of the design, which keeps a genesis of 0.0
+	e := d.Emission
+	c.StakingAdminCreateDecayingRewardPool("account", emuswap.MustLookupToken("EMU").StoragePath, d.Total, e.EpochLength, e.TotalEpochs, e.Decay, map[uint64]emuswap.UFix64{}, []string{}).Test(t).AssertSuccess()
+	pools, err := c.StakingGetRewardPoolsMeta()
+	require.NoError(t, err)
+	require.Contains(t, pools, uint64(1))
+	assert.Equal(t, d.Emission, *pools[1].Emission)
+	assert.Equal(t, ufix(0), pools[1].RewardsGenesisTimestamp)
+}
@@ -0,0 +1,141 @@
+package emuswap
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Errors of the pre-conditions, assertions and panics of EmuSwap and
+// StakingRewards. Classify turns the error of a failed transaction or script
+// into an *Error matching one of them with errors.Is. The Pool model returns
+// ErrEmptyVault, ErrAmountTooSmall and ErrInsufficientToken where EmuSwap.Pool
+// would fail.
+var (
+	ErrPoolFrozen           = errors.New("pool is frozen")
+	ErrEmptyVault           = errors.New("empty token vault")
+	ErrAmountTooSmall       = errors.New("exchanged amount too small")
+	ErrInsufficientToken    = errors.New("not enough tokens in the pool")
+	ErrRemoveAllLiquidity   = errors.New("cannot remove all liquidity")
+	ErrInsufficientStake    = errors.New("not enough LP tokens staked")
+	ErrWrongStakeController = errors.New("stake controller of another farm")
+)
+
+// Errors of transactions rejected without being executed.
+var (
+	// ErrExpired is the rejection of a transaction whose reference block
+	// expired before it was sent. The sequence number of the proposal key is
+	// unchanged, so the transaction can be built again. The in-memory emulator
+	// expires a reference block as soon as the next block is committed.
+	ErrExpired = errors.New("transaction expired")
+	// ErrSequenceMismatch is the failure of a transaction proposed with
+	// another sequence number than its proposal key has.
+	ErrSequenceMismatch = errors.New("sequence number mismatch")
+)
+
+// Error is a failed transaction or script classified by Classify.
+type Error struct {
+	// Kind is one of the errors above.
+	Kind error
+	// Message is the message of the failed pre-condition, assertion or
+	// panic, e.g. "EmuSwap is frozen".
+	Message string
+	// Contract and Line locate the failure, e.g. EmuSwap line 244. Contract
+	// is empty when the failure is not in a contract.
+	Contract string
+	Line     int
+	// Token is the token the pool has too little of for ErrInsufficientToken,
+	// 1 or 2.
+	Token int
+	// Requested and Available are the LP tokens an unstake asked for and the
+	// stake held for ErrInsufficientStake.
+	Requested UFix64
+	Available UFix64
+
+	err error
+}
+
+// Error is the error of the transaction or script, unchanged.
+func (e *Error) Error() string {
+	return e.err.Error()
+}
+
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// contractErrors map the start of a contract message to its error. The
+// messages are the ones in contracts/EmuSwap.cdc and contracts/StakingRewards.cdc.
+var contractErrors = []struct {
+	message string
+	kind    error
+}{
+	{"EmuSwap is frozen", ErrPoolFrozen},
+	{"Empty token vault", ErrEmptyVault},
+	{"Exchanged amount too small", ErrAmountTooSmall},
+	{"Not enough Token1 in the pool", ErrInsufficientToken},
+	{"Not enough Token2 in the pool", ErrInsufficientToken},
+	{"Cannot remove all liquidity", ErrRemoveAllLiquidity},
+	{"Insufficient LP Tokens available to withdraw.", ErrInsufficientStake},
+	{"Incorrect Stake controller for this Farm!", ErrWrongStakeController},
+}
+
+var (
+	// cadenceError is the first error of a Cadence runtime error, e.g.
+	// "error: pre-condition failed: EmuSwap is frozen", and its location,
+	// e.g. "--> f8d6e0586b0a20c7.EmuSwap:244:16"
+	cadenceError = regexp.MustCompile(`(?m)^error: (?:pre-condition failed: |post-condition failed: |assertion failed: |panic: )?(.*)$(?:\n\s*--> (?:[0-9a-f]+\.(\w+)|[0-9a-f]+):(\d+):\d+)?`)
+	// unstakeAmounts are the amounts appended to the message of
+	// ErrInsufficientStake
+	unstakeAmounts = regexp.MustCompile(`^\S.*\. (\d+\.\d+) (\d+\.\d+)$`)
+)
+
+// Classify returns err as an *Error when it is the failure of a contract
+// assertion listed above, or a rejection for an expired reference block or a
+// sequence number mismatch. Other errors, and errors classified already, are
+// returned unchanged.
+//
+//	if errors.Is(emuswap.Classify(result.Err), emuswap.ErrPoolFrozen) {
+func Classify(err error) error {
+	var classified *Error
+	if err == nil || errors.As(err, &classified) {
+		return err
+	}
+	text := err.Error()
+	switch {
+	case strings.Contains(text, "transaction is expired"):
+		return &Error{Kind: ErrExpired, Message: text, err: err}
+	case strings.Contains(text, "sequence number"):
+		return &Error{Kind: ErrSequenceMismatch, Message: text, err: err}
+	}
+
+	match := cadenceError.FindStringSubmatch(text)
+	if match == nil {
+		return err
+	}
+	e := &Error{Message: strings.TrimSpace(match[1]), Contract: match[2], err: err}
+	e.Line, _ = strconv.Atoi(match[3])
+	for _, c := range contractErrors {
+		if strings.HasPrefix(e.Message, c.message) {
+			e.Kind = c.kind
+			break
+		}
+	}
+	switch {
+	case e.Kind == nil:
+		return err
+	case strings.HasPrefix(e.Message, "Not enough Token"):
+		e.Token = int(e.Message[len("Not enough Token")] - '0')
+	case e.Kind == ErrInsufficientStake:
+		if amounts := unstakeAmounts.FindStringSubmatch(e.Message); amounts != nil {
+			e.Requested, _ = ParseUFix64(amounts[1])
+			e.Available, _ = ParseUFix64(amounts[2])
+		}
+	}
+	return e
+}
@@ -0,0 +1,132 @@
+package emuswap
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyUnknown(t *testing.T) {
+	assert.Nil(t, Classify(nil))
+	other := errors.New("error: pre-condition failed: Pools are not initalized!")
+	assert.Equal(t, other, Classify(other))
+	assert.ErrorIs(t, Classify(errors.New("transaction is expired: ref_height=41 final_height=43")), ErrExpired)
+
+	err := errors.New("[Error Code: 1006] invalid proposal key: sequence number mismatch")
+	classified := Classify(err)
+	assert.ErrorIs(t, classified, ErrSequenceMismatch)
+	assert.ErrorIs(t, classified, err)
+	assert.Equal(t, err.Error(), classified.Error())
+	assert.True(t, IsSequenceMismatch(err))
+	assert.False(t, IsExpired(err))
+	wrapped := fmt.Errorf("sending: %w", classified)
+	assert.Equal(t, wrapped, Classify(wrapped))
+}
+
+func TestClassify(t *testing.T) {
+	c := newTestClient(t)
+	c.FUSDSetup("account").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", UFix64FromFloat(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.EmuSwapAdminCreateNewPool("account", "flowTokenVault", UFix64FromFloat(100.0), "fusdVault", UFix64FromFloat(50.0)).Test(t).AssertSuccess()
+	c.EmuSwapAdminCreateNewPoolEMUFUSD("account", UFix64FromFloat(100.0), UFix64FromFloat(50.0)).Test(t).AssertSuccess()
+	c.StakingAdminCreateNewFarm("account", 0).Test(t).AssertSuccess()
+	c.StakingAdminCreateNewFarm("account", 1).Test(t).AssertSuccess()
+	c.StakingUserStake("account", 0, UFix64FromFloat(0.5)).Test(t).AssertSuccess()
+
+	addresses, err := ContractAddresses(c.O.State, c.O.Network)
+	require.NoError(t, err)
+	// no transaction passes the stake controller of one farm to another
+	unstakeFromOtherFarm := c.O.Transaction(fmt.Sprintf(`
+		import StakingRewards from 0x%s
+		transaction {
+			prepare(signer: AuthAccount) {
+				let collection = signer.borrow<&StakingRewards.StakeControllerCollection>(from: StakingRewards.CollectionStoragePath)!
+				StakingRewards.borrowFarm(id: 1)!.unstake(amount: 0.1, stakeControllerRef: collection.borrow(id: 0)!)
+			}
+		}`, addresses["StakingRewards"].Hex())).SignProposeAndPayAs("account")
+
+	toggleFreeze := func() { c.EmuSwapAdminTogglePoolFreeze("account", 0).Test(t).AssertSuccess() }
+	quote := func(f func(uint64, UFix64) (UFix64, error), amount float64) func() error {
+		return func() error {
+			_, err := f(0, UFix64FromFloat(amount))
+			return err
+		}
+	}
+	for _, test := range []struct {
+		name  string
+		setup func()
+		fail  func() error
+		want  Error
+	}{
+		{
+			name:  "frozen",
+			setup: toggleFreeze,
+			fail: func() error {
+				return c.Submit(c.EmuSwapUserSwap("account", "flowTokenVault", "fusdVault", UFix64FromFloat(1.0))).Err
+			},
+			want: Error{Kind: ErrPoolFrozen, Message: "EmuSwap is frozen", Contract: "EmuSwap"},
+		},
+		{
+			name: "empty vault",
+			// thaws the pool, the frozen check comes first
+			setup: toggleFreeze,
+			fail:  func() error { return c.Submit(c.EmuSwapUserSwap("account", "fusdVault", "flowTokenVault", 0)).Err },
+			want:  Error{Kind: ErrEmptyVault, Message: "Empty token vault", Contract: "EmuSwap"},
+		},
+		{
+			name: "amount too small",
+			fail: func() error { return c.Submit(c.EmuSwapUserSwap("account", "flowTokenVault", "fusdVault", 1)).Err },
+			want: Error{Kind: ErrAmountTooSmall, Message: "Exchanged amount too small", Contract: "EmuSwap"},
+		},
+		{
+			name: "not enough token2",
+			fail: quote(c.PoolGetQuoteAToExactB, 50.0),
+			want: Error{Kind: ErrInsufficientToken, Message: "Not enough Token2 in the pool", Contract: "EmuSwap", Token: 2},
+		},
+		{
+			name: "not enough token1",
+			fail: quote(c.PoolGetQuoteBToExactA, 100.0),
+			want: Error{Kind: ErrInsufficientToken, Message: "Not enough Token1 in the pool", Contract: "EmuSwap", Token: 1},
+		},
+		{
+			name: "remove all liquidity",
+			fail: func() error {
+				return c.Submit(c.EmuSwapUserRemoveLiquidity("account", UFix64FromFloat(1.0), "emuTokenVault", "fusdVault")).Err
+			},
+			want: Error{Kind: ErrRemoveAllLiquidity, Message: "Cannot remove all liquidity", Contract: "EmuSwap"},
+		},
+		{
+			name: "insufficient stake",
+			fail: func() error { return c.Submit(c.StakingUserUnstake("account", 0, UFix64FromFloat(0.6))).Err },
+			want: Error{
+				Kind:      ErrInsufficientStake,
+				Message:   "Insufficient LP Tokens available to withdraw. 0.60000000 0.50000000",
+				Contract:  "StakingRewards",
+				Requested: UFix64FromFloat(0.6),
+				Available: UFix64FromFloat(0.5),
+			},
+		},
+		{
+			name: "wrong stake controller",
+			fail: func() error { return c.Submit(unstakeFromOtherFarm).Err },
+			want: Error{Kind: ErrWrongStakeController, Message: "Incorrect Stake controller for this Farm!", Contract: "StakingRewards"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if test.setup != nil {
+				test.setup()
+			}
+			err := test.fail()
+			var classified *Error
+			require.True(t, errors.As(err, &classified), "%v", err)
+			assert.ErrorIs(t, err, test.want.Kind)
+			// the location moves with any edit of the contract
+			assert.Positive(t, classified.Line)
+			test.want.err, test.want.Line = classified.err, classified.Line
+			assert.Equal(t, &test.want, classified)
+		})
+	}
+}
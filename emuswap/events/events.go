@@ -0,0 +1,170 @@
+// Package events holds Go structs for the events of the EmuSwap contracts and
+// decoders from flow-go-sdk events. The structs in events_gen.go are generated
+// by cmd/bindgen. EmuSwap events keep their Cadence name, events of the other
+// contracts are prefixed with the contract, e.g. StakingRewardsTokensStaked.
+package events
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/flow-cli/pkg/flowkit"
+	"github.com/onflow/flow-go-sdk"
+	"swap.emudao.org/test-overflow/emuswap"
+)
+
+// Event is implemented by every generated event struct.
+type Event interface {
+	// Contract is the name of the declaring contract.
+	Contract() string
+	// Name is the Cadence event name.
+	Name() string
+}
+
+// ErrUnknownEvent is returned when decoding an event that was not emitted by
+// one of the contracts at the decoder's addresses.
+var ErrUnknownEvent = errors.New("not an EmuSwap contract event")
+
+// Addresses maps contract names to the address they are deployed at on one network.
+type Addresses map[string]flow.Address
+
+// Emulator are the addresses flow.json deploys to on the emulator.
+var Emulator = Addresses{
+	"EmuSwap":        flow.HexToAddress("f8d6e0586b0a20c7"),
+	"StakingRewards": flow.HexToAddress("f8d6e0586b0a20c7"),
+	"FTAirdrop":      flow.HexToAddress("f8d6e0586b0a20c7"),
+	"xEmuToken":      flow.HexToAddress("f8d6e0586b0a20c7"),
+	"EmuToken":       flow.HexToAddress("f8d6e0586b0a20c7"),
+	"EmuMultiSig":    flow.HexToAddress("f8d6e0586b0a20c7"),
+}
+
+// AddressesForNetwork collects contract addresses from the deployments and
+// aliases configured for network.
+func AddressesForNetwork(state *flowkit.State, network string) (Addresses, error) {
+	addresses, err := emuswap.ContractAddresses(state, network)
+	if err != nil {
+		return nil, err
+	}
+	return Addresses(addresses), nil
+}
+
+// AddressesFor returns the addresses for the network o is connected to.
+func AddressesFor(o *overflow.Overflow) (Addresses, error) {
+	return AddressesForNetwork(o.State, o.Network)
+}
+
+// TypeID returns the fully qualified event type, e.g.
+// A.f8d6e0586b0a20c7.EmuSwap.Trade. It is empty when the contract of the
+// event has no known address.
+func (a Addresses) TypeID(event Event) string {
+	address, ok := a[event.Contract()]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("A.%s.%s.%s", address.Hex(), event.Contract(), event.Name())
+}
+
+// Decode converts a flow event into its typed struct. Events from other
+// contracts, or from the right contract at another address, return
+// ErrUnknownEvent.
+func (a Addresses) Decode(event flow.Event) (Event, error) {
+	parts := strings.Split(event.Type, ".")
+	if len(parts) != 4 || parts[0] != "A" {
+		return nil, ErrUnknownEvent
+	}
+	contract, name := parts[2], parts[3]
+	address, ok := a[contract]
+	if !ok || address != flow.HexToAddress(parts[1]) {
+		return nil, ErrUnknownEvent
+	}
+	decode, ok := decoders[contract+"."+name]
+	if !ok {
+		return nil, ErrUnknownEvent
+	}
+	decoded, err := decode(event.Value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", event.Type, err)
+	}
+	return decoded, nil
+}
+
+// DecodeAll decodes the events of our contracts and skips all others, keeping
+// the original order.
+func (a Addresses) DecodeAll(flowEvents []flow.Event) ([]Event, error) {
+	var decoded []Event
+	for _, event := range flowEvents {
+		e, err := a.Decode(event)
+		if errors.Is(err, ErrUnknownEvent) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		decoded = append(decoded, e)
+	}
+	return decoded, nil
+}
+
+// Filter returns the events of type T, e.g. Filter[Trade](decoded).
+func Filter[T Event](decoded []Event) []T {
+	var result []T
+	for _, event := range decoded {
+		if e, ok := event.(T); ok {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// Located is a decoded event and where it was emitted.
+type Located struct {
+	Height           uint64
+	TransactionID    flow.Identifier
+	TransactionIndex int
+	EventIndex       int
+	Event            Event
+}
+
+// Fetch returns the events of the given kinds between two block heights,
+// decoded and in the order they were emitted.
+func (a Addresses) Fetch(o *overflow.Overflow, kinds []Event, startHeight, endHeight uint64) ([]Located, error) {
+	types := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		types = append(types, a.TypeID(kind))
+	}
+	blocks, err := o.Services.Events.Get(types, startHeight, endHeight, 250, 1)
+	if err != nil {
+		return nil, err
+	}
+	var found []Located
+	for _, block := range blocks {
+		for _, event := range block.Events {
+			decoded, err := a.Decode(event)
+			if err != nil {
+				return nil, err
+			}
+			found = append(found, Located{
+				Height:           block.Height,
+				TransactionID:    event.TransactionID,
+				TransactionIndex: event.TransactionIndex,
+				EventIndex:       event.EventIndex,
+				Event:            decoded,
+			})
+		}
+	}
+	// events come grouped by type, restore the chain order
+	sort.SliceStable(found, func(i, j int) bool {
+		a, b := found[i], found[j]
+		if a.Height != b.Height {
+			return a.Height < b.Height
+		}
+		if a.TransactionIndex != b.TransactionIndex {
+			return a.TransactionIndex < b.TransactionIndex
+		}
+		return a.EventIndex < b.EventIndex
+	})
+	return found, nil
+}
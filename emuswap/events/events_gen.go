@@ -0,0 +1,638 @@
+// Code generated by cmd/bindgen from contracts/. DO NOT EDIT.
+
+package events
+
+import (
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+	"swap.emudao.org/test-overflow/emuswap"
+)
+
+// ContractInitialized mirrors EmuSwap.ContractInitialized.
+type ContractInitialized struct{}
+
+func (ContractInitialized) Contract() string { return "EmuSwap" }
+func (ContractInitialized) Name() string     { return "ContractInitialized" }
+
+// TokensInitialized mirrors EmuSwap.TokensInitialized.
+type TokensInitialized struct {
+	TokenID uint64 `cadence:"tokenID"`
+}
+
+func (TokensInitialized) Contract() string { return "EmuSwap" }
+func (TokensInitialized) Name() string     { return "TokensInitialized" }
+
+// TokensWithdrawn mirrors EmuSwap.TokensWithdrawn.
+type TokensWithdrawn struct {
+	TokenID uint64         `cadence:"tokenID"`
+	Amount  emuswap.UFix64 `cadence:"amount"`
+	From    *flow.Address  `cadence:"from"`
+}
+
+func (TokensWithdrawn) Contract() string { return "EmuSwap" }
+func (TokensWithdrawn) Name() string     { return "TokensWithdrawn" }
+
+// TokensDeposited mirrors EmuSwap.TokensDeposited.
+type TokensDeposited struct {
+	TokenID uint64         `cadence:"tokenID"`
+	Amount  emuswap.UFix64 `cadence:"amount"`
+	To      *flow.Address  `cadence:"to"`
+}
+
+func (TokensDeposited) Contract() string { return "EmuSwap" }
+func (TokensDeposited) Name() string     { return "TokensDeposited" }
+
+// TokensMinted mirrors EmuSwap.TokensMinted.
+type TokensMinted struct {
+	TokenID uint64         `cadence:"tokenID"`
+	Amount  emuswap.UFix64 `cadence:"amount"`
+}
+
+func (TokensMinted) Contract() string { return "EmuSwap" }
+func (TokensMinted) Name() string     { return "TokensMinted" }
+
+// TokensBurned mirrors EmuSwap.TokensBurned.
+type TokensBurned struct {
+	TokenID uint64         `cadence:"tokenID"`
+	Amount  emuswap.UFix64 `cadence:"amount"`
+}
+
+func (TokensBurned) Contract() string { return "EmuSwap" }
+func (TokensBurned) Name() string     { return "TokensBurned" }
+
+// LPFeeUpdated mirrors EmuSwap.LPFeeUpdated.
+type LPFeeUpdated struct {
+	PoolID        uint64         `cadence:"poolID"`
+	FeePercentage emuswap.UFix64 `cadence:"feePercentage"`
+}
+
+func (LPFeeUpdated) Contract() string { return "EmuSwap" }
+func (LPFeeUpdated) Name() string     { return "LPFeeUpdated" }
+
+// DAOFeeUpdated mirrors EmuSwap.DAOFeeUpdated.
+type DAOFeeUpdated struct {
+	PoolID        uint64         `cadence:"poolID"`
+	FeePercentage emuswap.UFix64 `cadence:"feePercentage"`
+}
+
+func (DAOFeeUpdated) Contract() string { return "EmuSwap" }
+func (DAOFeeUpdated) Name() string     { return "DAOFeeUpdated" }
+
+// Trade mirrors EmuSwap.Trade.
+type Trade struct {
+	Token1Amount emuswap.UFix64 `cadence:"token1Amount"`
+	Token2Amount emuswap.UFix64 `cadence:"token2Amount"`
+	Side         uint8          `cadence:"side"`
+}
+
+func (Trade) Contract() string { return "EmuSwap" }
+func (Trade) Name() string     { return "Trade" }
+
+// Swap mirrors EmuSwap.Swap.
+type Swap struct {
+	Token1Amount emuswap.UFix64 `cadence:"token1Amount"`
+	Token2Amount emuswap.UFix64 `cadence:"token2Amount"`
+	PoolID       uint64         `cadence:"poolID"`
+	Direction    uint8          `cadence:"direction"`
+}
+
+func (Swap) Contract() string { return "EmuSwap" }
+func (Swap) Name() string     { return "Swap" }
+
+// NewSwapPoolCreated mirrors EmuSwap.NewSwapPoolCreated.
+type NewSwapPoolCreated struct {
+	PoolID uint64 `cadence:"poolID"`
+	TokenA string `cadence:"tokenA"`
+	TokenB string `cadence:"tokenB"`
+}
+
+func (NewSwapPoolCreated) Contract() string { return "EmuSwap" }
+func (NewSwapPoolCreated) Name() string     { return "NewSwapPoolCreated" }
+
+// PoolIsFrozen mirrors EmuSwap.PoolIsFrozen.
+type PoolIsFrozen struct {
+	ID       uint64 `cadence:"id"`
+	IsFrozen bool   `cadence:"isFrozen"`
+}
+
+func (PoolIsFrozen) Contract() string { return "EmuSwap" }
+func (PoolIsFrozen) Name() string     { return "PoolIsFrozen" }
+
+// FeesDeposited mirrors EmuSwap.FeesDeposited.
+type FeesDeposited struct {
+	TokenIdentifier string         `cadence:"tokenIdentifier"`
+	Amount          emuswap.UFix64 `cadence:"amount"`
+}
+
+func (FeesDeposited) Contract() string { return "EmuSwap" }
+func (FeesDeposited) Name() string     { return "FeesDeposited" }
+
+// StakingRewardsNewFarmCreated mirrors StakingRewards.NewFarmCreated.
+type StakingRewardsNewFarmCreated struct {
+	FarmID uint64 `cadence:"farmID"`
+}
+
+func (StakingRewardsNewFarmCreated) Contract() string { return "StakingRewards" }
+func (StakingRewardsNewFarmCreated) Name() string     { return "NewFarmCreated" }
+
+// StakingRewardsEmissionRateUpdated mirrors StakingRewards.EmissionRateUpdated.
+type StakingRewardsEmissionRateUpdated struct {
+	NewRate emuswap.UFix64 `cadence:"newRate"`
+}
+
+func (StakingRewardsEmissionRateUpdated) Contract() string { return "StakingRewards" }
+func (StakingRewardsEmissionRateUpdated) Name() string     { return "EmissionRateUpdated" }
+
+// StakingRewardsRewardPoolCreated mirrors StakingRewards.RewardPoolCreated.
+type StakingRewardsRewardPoolCreated struct {
+	ID uint64 `cadence:"id"`
+}
+
+func (StakingRewardsRewardPoolCreated) Contract() string { return "StakingRewards" }
+func (StakingRewardsRewardPoolCreated) Name() string     { return "RewardPoolCreated" }
+
+// StakingRewardsStakingControllerDeposited mirrors StakingRewards.StakingControllerDeposited.
+type StakingRewardsStakingControllerDeposited struct {
+	To     flow.Address `cadence:"to"`
+	FarmID uint64       `cadence:"farmID"`
+}
+
+func (StakingRewardsStakingControllerDeposited) Contract() string { return "StakingRewards" }
+func (StakingRewardsStakingControllerDeposited) Name() string     { return "StakingControllerDeposited" }
+
+// StakingRewardsTokensStaked mirrors StakingRewards.TokensStaked.
+type StakingRewardsTokensStaked struct {
+	Address      flow.Address   `cadence:"address"`
+	PoolID       uint64         `cadence:"poolID"`
+	AmountStaked emuswap.UFix64 `cadence:"amountStaked"`
+	TotalStaked  emuswap.UFix64 `cadence:"totalStaked"`
+}
+
+func (StakingRewardsTokensStaked) Contract() string { return "StakingRewards" }
+func (StakingRewardsTokensStaked) Name() string     { return "TokensStaked" }
+
+// StakingRewardsTokensUnstaked mirrors StakingRewards.TokensUnstaked.
+type StakingRewardsTokensUnstaked struct {
+	Address        flow.Address   `cadence:"address"`
+	AmountUnstaked emuswap.UFix64 `cadence:"amountUnstaked"`
+	TotalStaked    emuswap.UFix64 `cadence:"totalStaked"`
+}
+
+func (StakingRewardsTokensUnstaked) Contract() string { return "StakingRewards" }
+func (StakingRewardsTokensUnstaked) Name() string     { return "TokensUnstaked" }
+
+// StakingRewardsRewardsClaimed mirrors StakingRewards.RewardsClaimed.
+type StakingRewardsRewardsClaimed struct {
+	Address        flow.Address   `cadence:"address"`
+	TokenType      string         `cadence:"tokenType"`
+	AmountClaimed  emuswap.UFix64 `cadence:"amountClaimed"`
+	RewardDebt     emuswap.Fix64  `cadence:"rewardDebt"`
+	TotalRemaining emuswap.UFix64 `cadence:"totalRemaining"`
+}
+
+func (StakingRewardsRewardsClaimed) Contract() string { return "StakingRewards" }
+func (StakingRewardsRewardsClaimed) Name() string     { return "RewardsClaimed" }
+
+// FTAirdropDropCreated mirrors FTAirdrop.DropCreated.
+type FTAirdropDropCreated struct {
+	ID      uint64         `cadence:"id"`
+	Address flow.Address   `cadence:"address"`
+	Amount  emuswap.UFix64 `cadence:"amount"`
+}
+
+func (FTAirdropDropCreated) Contract() string { return "FTAirdrop" }
+func (FTAirdropDropCreated) Name() string     { return "DropCreated" }
+
+// FTAirdropDropClaimed mirrors FTAirdrop.DropClaimed.
+type FTAirdropDropClaimed struct {
+	ID      uint64         `cadence:"id"`
+	Address flow.Address   `cadence:"address"`
+	Amount  emuswap.UFix64 `cadence:"amount"`
+}
+
+func (FTAirdropDropClaimed) Contract() string { return "FTAirdrop" }
+func (FTAirdropDropClaimed) Name() string     { return "DropClaimed" }
+
+// FTAirdropDropDestroyed mirrors FTAirdrop.DropDestroyed.
+type FTAirdropDropDestroyed struct {
+	ID uint64 `cadence:"id"`
+}
+
+func (FTAirdropDropDestroyed) Contract() string { return "FTAirdrop" }
+func (FTAirdropDropDestroyed) Name() string     { return "DropDestroyed" }
+
+// XEmuTokenTokensInitialized mirrors xEmuToken.TokensInitialized.
+type XEmuTokenTokensInitialized struct {
+	InitialSupply emuswap.UFix64 `cadence:"initialSupply"`
+}
+
+func (XEmuTokenTokensInitialized) Contract() string { return "xEmuToken" }
+func (XEmuTokenTokensInitialized) Name() string     { return "TokensInitialized" }
+
+// XEmuTokenTokensWithdrawn mirrors xEmuToken.TokensWithdrawn.
+type XEmuTokenTokensWithdrawn struct {
+	Amount emuswap.UFix64 `cadence:"amount"`
+	From   *flow.Address  `cadence:"from"`
+}
+
+func (XEmuTokenTokensWithdrawn) Contract() string { return "xEmuToken" }
+func (XEmuTokenTokensWithdrawn) Name() string     { return "TokensWithdrawn" }
+
+// XEmuTokenTokensDeposited mirrors xEmuToken.TokensDeposited.
+type XEmuTokenTokensDeposited struct {
+	Amount emuswap.UFix64 `cadence:"amount"`
+	To     *flow.Address  `cadence:"to"`
+}
+
+func (XEmuTokenTokensDeposited) Contract() string { return "xEmuToken" }
+func (XEmuTokenTokensDeposited) Name() string     { return "TokensDeposited" }
+
+// XEmuTokenTokensMinted mirrors xEmuToken.TokensMinted.
+type XEmuTokenTokensMinted struct {
+	Amount emuswap.UFix64 `cadence:"amount"`
+}
+
+func (XEmuTokenTokensMinted) Contract() string { return "xEmuToken" }
+func (XEmuTokenTokensMinted) Name() string     { return "TokensMinted" }
+
+// XEmuTokenTokensBurned mirrors xEmuToken.TokensBurned.
+type XEmuTokenTokensBurned struct {
+	Amount emuswap.UFix64 `cadence:"amount"`
+}
+
+func (XEmuTokenTokensBurned) Contract() string { return "xEmuToken" }
+func (XEmuTokenTokensBurned) Name() string     { return "TokensBurned" }
+
+// XEmuTokenMinterCreated mirrors xEmuToken.MinterCreated.
+type XEmuTokenMinterCreated struct {
+	AllowedAmount emuswap.UFix64 `cadence:"allowedAmount"`
+}
+
+func (XEmuTokenMinterCreated) Contract() string { return "xEmuToken" }
+func (XEmuTokenMinterCreated) Name() string     { return "MinterCreated" }
+
+// XEmuTokenBurnerCreated mirrors xEmuToken.BurnerCreated.
+type XEmuTokenBurnerCreated struct{}
+
+func (XEmuTokenBurnerCreated) Contract() string { return "xEmuToken" }
+func (XEmuTokenBurnerCreated) Name() string     { return "BurnerCreated" }
+
+// XEmuTokenFeesReceived mirrors xEmuToken.FeesReceived.
+type XEmuTokenFeesReceived struct {
+	Amount emuswap.UFix64 `cadence:"amount"`
+}
+
+func (XEmuTokenFeesReceived) Contract() string { return "xEmuToken" }
+func (XEmuTokenFeesReceived) Name() string     { return "FeesReceived" }
+
+// EmuTokenTokensInitialized mirrors EmuToken.TokensInitialized.
+type EmuTokenTokensInitialized struct {
+	InitialSupply emuswap.UFix64 `cadence:"initialSupply"`
+}
+
+func (EmuTokenTokensInitialized) Contract() string { return "EmuToken" }
+func (EmuTokenTokensInitialized) Name() string     { return "TokensInitialized" }
+
+// EmuTokenTokensWithdrawn mirrors EmuToken.TokensWithdrawn.
+type EmuTokenTokensWithdrawn struct {
+	Amount emuswap.UFix64 `cadence:"amount"`
+	From   *flow.Address  `cadence:"from"`
+}
+
+func (EmuTokenTokensWithdrawn) Contract() string { return "EmuToken" }
+func (EmuTokenTokensWithdrawn) Name() string     { return "TokensWithdrawn" }
+
+// EmuTokenTokensDeposited mirrors EmuToken.TokensDeposited.
+type EmuTokenTokensDeposited struct {
+	Amount emuswap.UFix64 `cadence:"amount"`
+	To     *flow.Address  `cadence:"to"`
+}
+
+func (EmuTokenTokensDeposited) Contract() string { return "EmuToken" }
+func (EmuTokenTokensDeposited) Name() string     { return "TokensDeposited" }
+
+// EmuTokenTokensMinted mirrors EmuToken.TokensMinted.
+type EmuTokenTokensMinted struct {
+	Amount emuswap.UFix64 `cadence:"amount"`
+}
+
+func (EmuTokenTokensMinted) Contract() string { return "EmuToken" }
+func (EmuTokenTokensMinted) Name() string     { return "TokensMinted" }
+
+// EmuTokenTokensBurned mirrors EmuToken.TokensBurned.
+type EmuTokenTokensBurned struct {
+	Amount emuswap.UFix64 `cadence:"amount"`
+}
+
+func (EmuTokenTokensBurned) Contract() string { return "EmuToken" }
+func (EmuTokenTokensBurned) Name() string     { return "TokensBurned" }
+
+// EmuTokenMinterCreated mirrors EmuToken.MinterCreated.
+type EmuTokenMinterCreated struct {
+	AllowedAmount emuswap.UFix64 `cadence:"allowedAmount"`
+}
+
+func (EmuTokenMinterCreated) Contract() string { return "EmuToken" }
+func (EmuTokenMinterCreated) Name() string     { return "MinterCreated" }
+
+// EmuTokenBurnerCreated mirrors EmuToken.BurnerCreated.
+type EmuTokenBurnerCreated struct{}
+
+func (EmuTokenBurnerCreated) Contract() string { return "EmuToken" }
+func (EmuTokenBurnerCreated) Name() string     { return "BurnerCreated" }
+
+// EmuMultiSigProposalAdded mirrors EmuMultiSig.ProposalAdded.
+type EmuMultiSigProposalAdded struct {
+	TxIndex uint64 `cadence:"txIndex"`
+	Method  string `cadence:"method"`
+	Expiry  uint64 `cadence:"expiry"`
+}
+
+func (EmuMultiSigProposalAdded) Contract() string { return "EmuMultiSig" }
+func (EmuMultiSigProposalAdded) Name() string     { return "ProposalAdded" }
+
+// EmuMultiSigProposalSigned mirrors EmuMultiSig.ProposalSigned.
+type EmuMultiSigProposalSigned struct {
+	TxIndex   uint64 `cadence:"txIndex"`
+	PublicKey string `cadence:"publicKey"`
+}
+
+func (EmuMultiSigProposalSigned) Contract() string { return "EmuMultiSig" }
+func (EmuMultiSigProposalSigned) Name() string     { return "ProposalSigned" }
+
+// EmuMultiSigProposalExecuted mirrors EmuMultiSig.ProposalExecuted.
+type EmuMultiSigProposalExecuted struct {
+	TxIndex uint64 `cadence:"txIndex"`
+	Method  string `cadence:"method"`
+}
+
+func (EmuMultiSigProposalExecuted) Contract() string { return "EmuMultiSig" }
+func (EmuMultiSigProposalExecuted) Name() string     { return "ProposalExecuted" }
+
+// EmuMultiSigProposalRemoved mirrors EmuMultiSig.ProposalRemoved.
+type EmuMultiSigProposalRemoved struct {
+	TxIndex uint64 `cadence:"txIndex"`
+}
+
+func (EmuMultiSigProposalRemoved) Contract() string { return "EmuMultiSig" }
+func (EmuMultiSigProposalRemoved) Name() string     { return "ProposalRemoved" }
+
+// All holds the zero value of every event, in declaration order.
+var All = []Event{
+	ContractInitialized{},
+	TokensInitialized{},
+	TokensWithdrawn{},
+	TokensDeposited{},
+	TokensMinted{},
+	TokensBurned{},
+	LPFeeUpdated{},
+	DAOFeeUpdated{},
+	Trade{},
+	Swap{},
+	NewSwapPoolCreated{},
+	PoolIsFrozen{},
+	FeesDeposited{},
+	StakingRewardsNewFarmCreated{},
+	StakingRewardsEmissionRateUpdated{},
+	StakingRewardsRewardPoolCreated{},
+	StakingRewardsStakingControllerDeposited{},
+	StakingRewardsTokensStaked{},
+	StakingRewardsTokensUnstaked{},
+	StakingRewardsRewardsClaimed{},
+	FTAirdropDropCreated{},
+	FTAirdropDropClaimed{},
+	FTAirdropDropDestroyed{},
+	XEmuTokenTokensInitialized{},
+	XEmuTokenTokensWithdrawn{},
+	XEmuTokenTokensDeposited{},
+	XEmuTokenTokensMinted{},
+	XEmuTokenTokensBurned{},
+	XEmuTokenMinterCreated{},
+	XEmuTokenBurnerCreated{},
+	XEmuTokenFeesReceived{},
+	EmuTokenTokensInitialized{},
+	EmuTokenTokensWithdrawn{},
+	EmuTokenTokensDeposited{},
+	EmuTokenTokensMinted{},
+	EmuTokenTokensBurned{},
+	EmuTokenMinterCreated{},
+	EmuTokenBurnerCreated{},
+	EmuMultiSigProposalAdded{},
+	EmuMultiSigProposalSigned{},
+	EmuMultiSigProposalExecuted{},
+	EmuMultiSigProposalRemoved{},
+}
+
+// decoders is keyed by Contract.Name.
+var decoders = map[string]func(cadence.Event) (Event, error){
+	"EmuSwap.ContractInitialized": func(value cadence.Event) (Event, error) {
+		var event ContractInitialized
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"EmuSwap.TokensInitialized": func(value cadence.Event) (Event, error) {
+		var event TokensInitialized
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"EmuSwap.TokensWithdrawn": func(value cadence.Event) (Event, error) {
+		var event TokensWithdrawn
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"EmuSwap.TokensDeposited": func(value cadence.Event) (Event, error) {
+		var event TokensDeposited
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"EmuSwap.TokensMinted": func(value cadence.Event) (Event, error) {
+		var event TokensMinted
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"EmuSwap.TokensBurned": func(value cadence.Event) (Event, error) {
+		var event TokensBurned
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"EmuSwap.LPFeeUpdated": func(value cadence.Event) (Event, error) {
+		var event LPFeeUpdated
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"EmuSwap.DAOFeeUpdated": func(value cadence.Event) (Event, error) {
+		var event DAOFeeUpdated
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"EmuSwap.Trade": func(value cadence.Event) (Event, error) {
+		var event Trade
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"EmuSwap.Swap": func(value cadence.Event) (Event, error) {
+		var event Swap
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"EmuSwap.NewSwapPoolCreated": func(value cadence.Event) (Event, error) {
+		var event NewSwapPoolCreated
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"EmuSwap.PoolIsFrozen": func(value cadence.Event) (Event, error) {
+		var event PoolIsFrozen
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"EmuSwap.FeesDeposited": func(value cadence.Event) (Event, error) {
+		var event FeesDeposited
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"StakingRewards.NewFarmCreated": func(value cadence.Event) (Event, error) {
+		var event StakingRewardsNewFarmCreated
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"StakingRewards.EmissionRateUpdated": func(value cadence.Event) (Event, error) {
+		var event StakingRewardsEmissionRateUpdated
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"StakingRewards.RewardPoolCreated": func(value cadence.Event) (Event, error) {
+		var event StakingRewardsRewardPoolCreated
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"StakingRewards.StakingControllerDeposited": func(value cadence.Event) (Event, error) {
+		var event StakingRewardsStakingControllerDeposited
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"StakingRewards.TokensStaked": func(value cadence.Event) (Event, error) {
+		var event StakingRewardsTokensStaked
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"StakingRewards.TokensUnstaked": func(value cadence.Event) (Event, error) {
+		var event StakingRewardsTokensUnstaked
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"StakingRewards.RewardsClaimed": func(value cadence.Event) (Event, error) {
+		var event StakingRewardsRewardsClaimed
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"FTAirdrop.DropCreated": func(value cadence.Event) (Event, error) {
+		var event FTAirdropDropCreated
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"FTAirdrop.DropClaimed": func(value cadence.Event) (Event, error) {
+		var event FTAirdropDropClaimed
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"FTAirdrop.DropDestroyed": func(value cadence.Event) (Event, error) {
+		var event FTAirdropDropDestroyed
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"xEmuToken.TokensInitialized": func(value cadence.Event) (Event, error) {
+		var event XEmuTokenTokensInitialized
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"xEmuToken.TokensWithdrawn": func(value cadence.Event) (Event, error) {
+		var event XEmuTokenTokensWithdrawn
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"xEmuToken.TokensDeposited": func(value cadence.Event) (Event, error) {
+		var event XEmuTokenTokensDeposited
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"xEmuToken.TokensMinted": func(value cadence.Event) (Event, error) {
+		var event XEmuTokenTokensMinted
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"xEmuToken.TokensBurned": func(value cadence.Event) (Event, error) {
+		var event XEmuTokenTokensBurned
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"xEmuToken.MinterCreated": func(value cadence.Event) (Event, error) {
+		var event XEmuTokenMinterCreated
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"xEmuToken.BurnerCreated": func(value cadence.Event) (Event, error) {
+		var event XEmuTokenBurnerCreated
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"xEmuToken.FeesReceived": func(value cadence.Event) (Event, error) {
+		var event XEmuTokenFeesReceived
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"EmuToken.TokensInitialized": func(value cadence.Event) (Event, error) {
+		var event EmuTokenTokensInitialized
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"EmuToken.TokensWithdrawn": func(value cadence.Event) (Event, error) {
+		var event EmuTokenTokensWithdrawn
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"EmuToken.TokensDeposited": func(value cadence.Event) (Event, error) {
+		var event EmuTokenTokensDeposited
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"EmuToken.TokensMinted": func(value cadence.Event) (Event, error) {
+		var event EmuTokenTokensMinted
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"EmuToken.TokensBurned": func(value cadence.Event) (Event, error) {
+		var event EmuTokenTokensBurned
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"EmuToken.MinterCreated": func(value cadence.Event) (Event, error) {
+		var event EmuTokenMinterCreated
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"EmuToken.BurnerCreated": func(value cadence.Event) (Event, error) {
+		var event EmuTokenBurnerCreated
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"EmuMultiSig.ProposalAdded": func(value cadence.Event) (Event, error) {
+		var event EmuMultiSigProposalAdded
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"EmuMultiSig.ProposalSigned": func(value cadence.Event) (Event, error) {
+		var event EmuMultiSigProposalSigned
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"EmuMultiSig.ProposalExecuted": func(value cadence.Event) (Event, error) {
+		var event EmuMultiSigProposalExecuted
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+	"EmuMultiSig.ProposalRemoved": func(value cadence.Event) (Event, error) {
+		var event EmuMultiSigProposalRemoved
+		err := emuswap.Decode(value, &event)
+		return event, err
+	},
+}
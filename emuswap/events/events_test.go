@@ -0,0 +1,72 @@
+package events
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/internal/emutest"
+)
+
+// TestMain runs the package tests from the repository root, where flow.json
+// and the files it references resolve.
+func TestMain(m *testing.M) {
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(emutest.Main(m))
+}
+
+func TestTypeID(t *testing.T) {
+	assert.Equal(t, "A.f8d6e0586b0a20c7.EmuSwap.Trade", Emulator.TypeID(Trade{}))
+	assert.Equal(t, "A.f8d6e0586b0a20c7.StakingRewards.TokensStaked", Emulator.TypeID(StakingRewardsTokensStaked{}))
+	assert.Equal(t, "", Addresses{}.TypeID(Trade{}))
+}
+
+func TestDecodeSwapEvents(t *testing.T) {
+	o, err := emutest.Start()
+	require.NoError(t, err)
+	c := emuswap.NewClient(o)
+	ufix := emuswap.UFix64FromFloat
+
+	addresses, err := AddressesFor(o)
+	assert.NoError(t, err)
+	for contract, address := range Emulator {
+		assert.Equal(t, address, addresses[contract], contract)
+	}
+
+	c.DemoMintFlowTokens("account", ufix(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.FUSDSetup("account").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", ufix(1000.0), c.Address("account")).Test(t).AssertSuccess()
+
+	created := c.EmuSwapAdminCreateNewPool("account", "flowTokenVault", ufix(100.0), "fusdVault", ufix(50.0)).Send()
+	assert.NoError(t, created.Err)
+	decoded, err := addresses.DecodeAll(created.RawEvents)
+	assert.NoError(t, err)
+	assert.Equal(t, []NewSwapPoolCreated{{
+		PoolID: 0,
+		TokenA: "A.0ae53cb6e3f42a79.FlowToken",
+		TokenB: "A.f8d6e0586b0a20c7.FUSD",
+	}}, Filter[NewSwapPoolCreated](decoded))
+	assert.Equal(t, []PoolIsFrozen{{ID: 0, IsFrozen: false}}, Filter[PoolIsFrozen](decoded))
+
+	// 0.3% of the input goes to LP and DAO fees before pricing
+	token1Amount := ufix(0.997)
+	token2Amount, err := c.PoolGetQuoteExactAToB(0, token1Amount)
+	assert.NoError(t, err)
+
+	swapped := c.EmuSwapUserSwap("account", "flowTokenVault", "fusdVault", ufix(1.0)).Send()
+	assert.NoError(t, swapped.Err)
+	decoded, err = addresses.DecodeAll(swapped.RawEvents)
+	assert.NoError(t, err)
+	assert.Equal(t, []Trade{{Token1Amount: token1Amount, Token2Amount: token2Amount, Side: 1}}, Filter[Trade](decoded))
+	assert.Equal(t, []FeesDeposited{{
+		TokenIdentifier: "A.0ae53cb6e3f42a79.FlowToken.Vault",
+		Amount:          ufix(0.0005),
+	}}, Filter[FeesDeposited](decoded))
+
+	// FlowToken and FUSD events are not ours and get skipped
+	assert.Len(t, decoded, 2)
+}
@@ -0,0 +1,63 @@
+package feepolicy
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 8, 64)
+}
+
+func formatElasticity(e float64) string {
+	return strconv.FormatFloat(e, 'g', -1, 64)
+}
+
+// WriteScenarios writes one row per scenario, values in token1 at the price
+// before the flow.
+func (a Analysis) WriteScenarios(w io.Writer) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{
+		"lpFee", "daoFee", "totalFee", "elasticity", "volumeFactor", "trades", "failed",
+		"volumeToken1", "volumeToken2", "lpIncomeToken1", "lpIncomeToken2",
+		"daoRevenueToken1", "daoRevenueToken2", "lpIncome", "daoRevenue", "revenue", "traderCost",
+	})
+	price := a.Price()
+	for _, s := range a.Scenarios {
+		out.Write([]string{
+			s.Fees.LP.String(), s.Fees.DAO.String(), s.Fees.Total().String(),
+			formatElasticity(s.Elasticity), formatFloat(s.VolumeFactor),
+			strconv.Itoa(s.Trades), strconv.Itoa(s.Failed),
+			s.Volume.Token1.String(), s.Volume.Token2.String(),
+			s.LPIncome.Token1.String(), s.LPIncome.Token2.String(),
+			s.DAORevenue.Token1.String(), s.DAORevenue.Token2.String(),
+			formatFloat(s.LPIncome.Value(price)), formatFloat(s.DAORevenue.Value(price)),
+			formatFloat(s.Revenue(price)), formatFloat(s.TraderCost),
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// WriteRevenue writes the revenue of every fee setting, one column per
+// elasticity, ready to be charted as revenue against fee.
+func (a Analysis) WriteRevenue(w io.Writer) error {
+	out := csv.NewWriter(w)
+	header := []string{"lpFee", "daoFee", "totalFee"}
+	for _, e := range a.Elasticities {
+		header = append(header, "revenue@"+formatElasticity(e))
+	}
+	out.Write(header)
+	price := a.Price()
+	for _, fees := range a.Candidates {
+		row := []string{fees.LP.String(), fees.DAO.String(), fees.Total().String()}
+		for _, e := range a.Elasticities {
+			s, _ := a.Lookup(fees, e)
+			row = append(row, formatFloat(s.Revenue(price)))
+		}
+		out.Write(row)
+	}
+	out.Flush()
+	return out.Error()
+}
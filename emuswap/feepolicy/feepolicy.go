@@ -0,0 +1,258 @@
+// Package feepolicy estimates what alternative LP and DAO fees would have done
+// to a pool. An order flow, replayed from Trade events or generated, is run
+// through the emuswap.Pool model under every candidate fee setting and the
+// income of liquidity providers, the DAO revenue swept with storeFees and the
+// cost to traders are compared against the current fees.
+package feepolicy
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"swap.emudao.org/test-overflow/emuswap"
+)
+
+// Fees is a pair of LP and DAO fee percentages.
+type Fees struct {
+	LP  emuswap.UFix64
+	DAO emuswap.UFix64
+}
+
+// BaselineFees reads the contract wide fees from get_lp_fee_percentage and
+// get_dao_fee_percentage.
+func BaselineFees(c *emuswap.Client) (Fees, error) {
+	lp, err := c.GetLPFeePercentage()
+	if err != nil {
+		return Fees{}, err
+	}
+	dao, err := c.GetDAOFeePercentage()
+	if err != nil {
+		return Fees{}, err
+	}
+	return Fees{LP: lp, DAO: dao}, nil
+}
+
+func (f Fees) String() string {
+	return fmt.Sprintf("lp %s dao %s", f.LP, f.DAO)
+}
+
+// Total is the share of every input the trader pays in fees.
+func (f Fees) Total() emuswap.UFix64 {
+	return f.LP + f.DAO
+}
+
+// netPercentage is the share of the input priced on the curve.
+func (f Fees) netPercentage() (emuswap.UFix64, error) {
+	if f.LP+f.DAO >= emuswap.UFix64Factor || f.LP+f.DAO < f.LP {
+		return 0, fmt.Errorf("fees %s take the whole input", f)
+	}
+	return emuswap.UFix64Factor - f.LP - f.DAO, nil
+}
+
+// Amounts are totals per pool token.
+type Amounts struct {
+	Token1 emuswap.UFix64
+	Token2 emuswap.UFix64
+}
+
+func (a *Amounts) add(side emuswap.Side, amount emuswap.UFix64) {
+	if side == emuswap.Token1ForToken2 {
+		a.Token1 += amount
+	} else {
+		a.Token2 += amount
+	}
+}
+
+// Value converts the amounts to token1 at price, token2 per token1.
+func (a Amounts) Value(price float64) float64 {
+	if price == 0 {
+		return a.Token1.Float64()
+	}
+	return a.Token1.Float64() + a.Token2.Float64()/price
+}
+
+// Scenario is the outcome of an order flow under one fee setting and volume
+// elasticity.
+type Scenario struct {
+	Fees       Fees
+	Elasticity float64
+	// VolumeFactor scales every trade of the flow, see Analyze.
+	VolumeFactor float64
+	Trades       int
+	// Failed counts trades the contract would have rejected.
+	Failed     int
+	Volume     Amounts
+	LPIncome   Amounts
+	DAORevenue Amounts
+	// TraderCost is what traders paid in fees and price impact: the value of
+	// their input less the value of their output, in token1 at the price
+	// before each trade.
+	TraderCost float64
+}
+
+// Revenue is the value of the LP income and DAO revenue together, in token1
+// at price.
+func (s Scenario) Revenue(price float64) float64 {
+	return s.LPIncome.Value(price) + s.DAORevenue.Value(price)
+}
+
+// Analysis holds the scenarios of every fee setting and elasticity.
+type Analysis struct {
+	Pool         emuswap.Pool
+	Baseline     Fees
+	Candidates   []Fees
+	Elasticities []float64
+	// Scenarios are ordered by candidate, then elasticity. The baseline is
+	// always the first candidate.
+	Scenarios []Scenario
+}
+
+// Price is the token2 per token1 price the values are computed at.
+func (a Analysis) Price() float64 {
+	return a.Pool.Price()
+}
+
+// Analyze replays trades from pool under the baseline and every candidate fee
+// setting. Elasticity models traders reacting to fees: every trade is scaled
+// by (candidate total fee / baseline total fee) ^ -elasticity, so an
+// elasticity of 0 keeps the flow as it is and 1 halves the volume when fees
+// double.
+func Analyze(pool emuswap.Pool, trades []Trade, baseline Fees, candidates []Fees, elasticities []float64) (Analysis, error) {
+	if len(elasticities) == 0 {
+		elasticities = []float64{0}
+	}
+	all := []Fees{baseline}
+	for _, candidate := range candidates {
+		if candidate != baseline {
+			all = append(all, candidate)
+		}
+	}
+	analysis := Analysis{Pool: pool, Baseline: baseline, Candidates: all, Elasticities: elasticities}
+	for _, fees := range all {
+		if _, err := fees.netPercentage(); err != nil {
+			return Analysis{}, err
+		}
+		for _, elasticity := range elasticities {
+			factor := 1.0
+			if baseline.Total() > 0 && fees.Total() > 0 {
+				factor = math.Pow(fees.Total().Float64()/baseline.Total().Float64(), -elasticity)
+			}
+			analysis.Scenarios = append(analysis.Scenarios, replay(pool, trades, fees, elasticity, factor))
+		}
+	}
+	return analysis, nil
+}
+
+func replay(pool emuswap.Pool, trades []Trade, fees Fees, elasticity, factor float64) Scenario {
+	pool.LPFeePercentage, pool.DAOFeePercentage = fees.LP, fees.DAO
+	scenario := Scenario{Fees: fees, Elasticity: elasticity, VolumeFactor: factor}
+	for _, trade := range trades {
+		amount := trade.AmountIn
+		if factor != 1 {
+			amount = emuswap.UFix64FromFloat(amount.Float64() * factor)
+		}
+		price := pool.Price()
+		result, err := pool.Swap(trade.Side, amount)
+		scenario.Trades++
+		if err != nil {
+			scenario.Failed++
+			continue
+		}
+		scenario.Volume.add(trade.Side, result.AmountIn)
+		scenario.LPIncome.add(trade.Side, result.LPFee())
+		scenario.DAORevenue.add(trade.Side, result.DAOFee)
+
+		// cost in token1 at the price before the trade
+		var in, out float64
+		if trade.Side == emuswap.Token1ForToken2 {
+			in, out = result.AmountIn.Float64(), result.AmountOut.Float64()/price
+		} else {
+			in, out = result.AmountIn.Float64()/price, result.AmountOut.Float64()
+		}
+		scenario.TraderCost += in - out
+	}
+	return scenario
+}
+
+// Lookup returns the scenario of fees at elasticity.
+func (a Analysis) Lookup(fees Fees, elasticity float64) (Scenario, bool) {
+	for _, scenario := range a.Scenarios {
+		if scenario.Fees == fees && scenario.Elasticity == elasticity {
+			return scenario, true
+		}
+	}
+	return Scenario{}, false
+}
+
+// Recommendation is the candidate earning the most at the expected elasticity.
+type Recommendation struct {
+	Fees       Fees
+	Elasticity float64
+	Revenue    float64
+	// BaselineRevenue is the revenue of the current fees at the same elasticity.
+	BaselineRevenue float64
+	TraderCost      float64
+	// BaselineTraderCost is the trader cost of the current fees.
+	BaselineTraderCost float64
+	// Robust is set when the candidate earns at least the baseline revenue
+	// at every analysed elasticity.
+	Robust bool
+}
+
+// Change is the relative revenue change against the baseline.
+func (r Recommendation) Change() float64 {
+	if r.BaselineRevenue == 0 {
+		return 0
+	}
+	return r.Revenue/r.BaselineRevenue - 1
+}
+
+func (r Recommendation) String() string {
+	robust := "not at every elasticity"
+	if r.Robust {
+		robust = "at every elasticity"
+	}
+	return fmt.Sprintf("set %s: revenue %.8f vs %.8f (%+.1f%%) at elasticity %g, trader cost %.8f vs %.8f, at least baseline revenue %s",
+		r.Fees, r.Revenue, r.BaselineRevenue, 100*r.Change(), r.Elasticity, r.TraderCost, r.BaselineTraderCost, robust)
+}
+
+// Recommend picks the candidate with the highest revenue at elasticity, the
+// lower total fee winning ties so traders are not charged for nothing.
+func (a Analysis) Recommend(elasticity float64) (Recommendation, error) {
+	baseline, ok := a.Lookup(a.Baseline, elasticity)
+	if !ok {
+		return Recommendation{}, fmt.Errorf("elasticity %g was not analysed", elasticity)
+	}
+	price := a.Price()
+
+	candidates := append([]Fees(nil), a.Candidates...)
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Total() < candidates[j].Total() })
+
+	var best Scenario
+	found := false
+	for _, fees := range candidates {
+		scenario, _ := a.Lookup(fees, elasticity)
+		if !found || scenario.Revenue(price) > best.Revenue(price) {
+			best, found = scenario, true
+		}
+	}
+
+	robust := true
+	for _, e := range a.Elasticities {
+		candidate, _ := a.Lookup(best.Fees, e)
+		base, _ := a.Lookup(a.Baseline, e)
+		if candidate.Revenue(price) < base.Revenue(price) {
+			robust = false
+		}
+	}
+	return Recommendation{
+		Fees:               best.Fees,
+		Elasticity:         elasticity,
+		Revenue:            best.Revenue(price),
+		BaselineRevenue:    baseline.Revenue(price),
+		TraderCost:         best.TraderCost,
+		BaselineTraderCost: baseline.TraderCost,
+		Robust:             robust,
+	}, nil
+}
@@ -0,0 +1,182 @@
+package feepolicy
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/internal/emutest"
+)
+
+// TestMain runs the package tests from the repository root, where flow.json
+// and the files it references resolve.
+func TestMain(m *testing.M) {
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(emutest.Main(m))
+}
+
+var ufix = emuswap.UFix64FromFloat
+
+func testPool() emuswap.Pool {
+	return emuswap.Pool{Token1Amount: ufix(10000.0), Token2Amount: ufix(5000.0)}
+}
+
+func TestGrossAmount(t *testing.T) {
+	for _, fees := range []Fees{{}, {LP: ufix(0.003)}, {LP: ufix(0.0025), DAO: ufix(0.0005)}, {LP: ufix(0.3), DAO: ufix(0.3)}} {
+		for _, amount := range []emuswap.UFix64{1, 7, ufix(0.1), ufix(123.45678901), ufix(99999.0)} {
+			pool := testPool()
+			pool.LPFeePercentage, pool.DAOFeePercentage = fees.LP, fees.DAO
+			result, err := pool.Swap(emuswap.Token1ForToken2, amount)
+			if err != nil {
+				continue
+			}
+			gross, err := GrossAmount(result.Priced, fees)
+			require.NoError(t, err)
+			assert.LessOrEqual(t, gross, amount, "%s %s", fees, amount)
+
+			// the recovered amount prices the same on the curve
+			replayed := testPool()
+			replayed.LPFeePercentage, replayed.DAOFeePercentage = fees.LP, fees.DAO
+			again, err := replayed.Swap(emuswap.Token1ForToken2, gross)
+			require.NoError(t, err)
+			assert.Equal(t, result.Priced, again.Priced)
+			assert.Equal(t, result.AmountOut, again.AmountOut)
+		}
+	}
+
+	_, err := GrossAmount(ufix(1.0), Fees{LP: ufix(0.5), DAO: ufix(0.5)})
+	assert.Error(t, err)
+}
+
+func TestAnalyze(t *testing.T) {
+	pool := testPool()
+	trades := Synthetic(pool, 200, 0.02, 7)
+	assert.Equal(t, trades, Synthetic(pool, 200, 0.02, 7))
+
+	baseline := Fees{LP: ufix(0.003)}
+	candidates := []Fees{{}, {LP: ufix(0.003)}, {LP: ufix(0.006)}, {LP: ufix(0.003), DAO: ufix(0.001)}}
+	analysis, err := Analyze(pool, trades, baseline, candidates, []float64{0, 2})
+	require.NoError(t, err)
+
+	// the baseline comes first and is not repeated
+	assert.Equal(t, []Fees{baseline, {}, {LP: ufix(0.006)}, {LP: ufix(0.003), DAO: ufix(0.001)}}, analysis.Candidates)
+	assert.Len(t, analysis.Scenarios, 8)
+
+	price := analysis.Price()
+	free, _ := analysis.Lookup(Fees{}, 0)
+	assert.Zero(t, free.Revenue(price))
+	assert.Equal(t, 1.0, free.VolumeFactor)
+
+	current, _ := analysis.Lookup(baseline, 0)
+	double, _ := analysis.Lookup(Fees{LP: ufix(0.006)}, 0)
+	assert.Zero(t, current.DAORevenue)
+	assert.Greater(t, double.Revenue(price), current.Revenue(price))
+	assert.Greater(t, double.TraderCost, current.TraderCost)
+	assert.Greater(t, current.TraderCost, free.TraderCost)
+
+	withDAO, _ := analysis.Lookup(Fees{LP: ufix(0.003), DAO: ufix(0.001)}, 0)
+	assert.NotZero(t, withDAO.DAORevenue.Token1)
+	assert.NotZero(t, withDAO.DAORevenue.Token2)
+
+	// traders leaving at high elasticity make doubling the fee a loss
+	elastic, _ := analysis.Lookup(Fees{LP: ufix(0.006)}, 2)
+	assert.InDelta(t, 0.25, elastic.VolumeFactor, 1e-9)
+	assert.Less(t, elastic.Revenue(price), current.Revenue(price))
+
+	recommendation, err := analysis.Recommend(0)
+	require.NoError(t, err)
+	assert.Equal(t, Fees{LP: ufix(0.006)}, recommendation.Fees)
+	assert.False(t, recommendation.Robust)
+	assert.Greater(t, recommendation.Change(), 0.0)
+
+	recommendation, err = analysis.Recommend(2)
+	require.NoError(t, err)
+	assert.NotEqual(t, Fees{LP: ufix(0.006)}, recommendation.Fees)
+
+	_, err = analysis.Recommend(1)
+	assert.Error(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, analysis.WriteRevenue(&buf))
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lpFee", "daoFee", "totalFee", "revenue@0", "revenue@2"}, rows[0])
+	assert.Len(t, rows, 5)
+
+	buf.Reset()
+	require.NoError(t, analysis.WriteScenarios(&buf))
+	rows, err = csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	assert.Len(t, rows, 9)
+}
+
+func TestFetchTrades(t *testing.T) {
+	o, err := emutest.Start()
+	require.NoError(t, err)
+	c := emuswap.NewClient(o)
+	flowToken, fusd, emu := emuswap.MustLookupToken("FLOW"), emuswap.MustLookupToken("FUSD"), emuswap.MustLookupToken("EMU")
+
+	c.DemoMintFlowTokens("account", ufix(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.FUSDSetup("account").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", ufix(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.EmuSwapAdminCreateNewPool("account", "flowTokenVault", ufix(100.0), "fusdVault", ufix(50.0)).Test(t).AssertSuccess()
+	// the swaps of another pool are told apart
+	c.EmuSwapAdminCreateNewPool("account", emu.StoragePath, ufix(100.0), fusd.StoragePath, ufix(50.0)).Test(t).AssertSuccess()
+
+	baseline, err := BaselineFees(c)
+	require.NoError(t, err)
+	start, err := c.LoadPool(0)
+	require.NoError(t, err)
+	otherStart, err := c.LoadPool(1)
+	require.NoError(t, err)
+	block, err := o.GetLatestBlock()
+	require.NoError(t, err)
+
+	c.Swap("account", flowToken, fusd, ufix(10.0)).Test(t).AssertSuccess()
+	c.Swap("account", fusd, emu, ufix(1.0)).Test(t).AssertSuccess()
+	c.Swap("account", fusd, flowToken, ufix(2.5)).Test(t).AssertSuccess()
+	c.Swap("account", flowToken, fusd, ufix(0.12345678)).Test(t).AssertSuccess()
+
+	end, err := o.GetLatestBlock()
+	require.NoError(t, err)
+	history, err := FetchTrades(c, 0, baseline, block.Height, end.Height)
+	require.NoError(t, err)
+	assert.Zero(t, history.Unattributed)
+	require.Len(t, history.Trades, 3)
+	assert.Equal(t, Trade{Side: emuswap.Token1ForToken2, AmountIn: ufix(10.0)}, history.Trades[0])
+	assert.Equal(t, emuswap.Token2ForToken1, history.Trades[1].Side)
+	other, err := FetchTrades(c, 1, baseline, block.Height, end.Height)
+	require.NoError(t, err)
+	assert.Zero(t, other.Unattributed)
+	require.Len(t, other.Trades, 1)
+	assert.Equal(t, Trade{Side: emuswap.Token2ForToken1, AmountIn: ufix(1.0)}, other.Trades[0])
+
+	// replaying the recovered flow at the current fees ends where the chain did
+	analysis, err := Analyze(start, history.Trades, baseline, nil, nil)
+	require.NoError(t, err)
+	replayed := start
+	for _, trade := range history.Trades {
+		_, err := replayed.Swap(trade.Side, trade.AmountIn)
+		require.NoError(t, err)
+	}
+	meta, err := c.GetPoolMeta(0)
+	require.NoError(t, err)
+	assert.InDelta(t, meta.Token1Amount.Float64(), replayed.Token1Amount.Float64(), 1e-7)
+	assert.InDelta(t, meta.Token2Amount.Float64(), replayed.Token2Amount.Float64(), 1e-7)
+
+	// the fees are collected by token, FUSD from both pools
+	otherAnalysis, err := Analyze(otherStart, other.Trades, baseline, nil, nil)
+	require.NoError(t, err)
+	collected, err := c.ReadFeesCollected()
+	require.NoError(t, err)
+	scenario, _ := analysis.Lookup(baseline, 0)
+	otherScenario, _ := otherAnalysis.Lookup(baseline, 0)
+	assert.InDelta(t, collected["A.0ae53cb6e3f42a79.FlowToken.Vault"].Float64(), scenario.DAORevenue.Token1.Float64(), 1e-7)
+	assert.InDelta(t, collected["A.f8d6e0586b0a20c7.FUSD.Vault"].Float64(), scenario.DAORevenue.Token2.Float64()+otherScenario.DAORevenue.Token2.Float64(), 1e-7)
+}
@@ -0,0 +1,208 @@
+package feepolicy
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/onflow/flow-go-sdk"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/events"
+)
+
+// Trade is one swap of an order flow, the amount the trader paid in.
+type Trade struct {
+	Side     emuswap.Side
+	AmountIn emuswap.UFix64
+}
+
+// GrossAmount recovers the amount a trader paid from the priced amount of a
+// Trade event, given the fees of the pool at the time. Truncation
+// makes several amounts price the same, the smallest is returned.
+func GrossAmount(priced emuswap.UFix64, fees Fees) (emuswap.UFix64, error) {
+	net, err := fees.netPercentage()
+	if err != nil {
+		return 0, err
+	}
+	gross, err := priced.Div(net)
+	if err != nil {
+		return 0, err
+	}
+	for gross > 0 {
+		previous, err := (gross - 1).Mul(net)
+		if err != nil || previous < priced {
+			break
+		}
+		gross--
+	}
+	for {
+		value, err := gross.Mul(net)
+		if err != nil {
+			return 0, err
+		}
+		if value >= priced {
+			return gross, nil
+		}
+		gross++
+	}
+}
+
+// TradeFromEvent converts the amounts of a Trade event back to the
+// trade that produced it.
+func TradeFromEvent(side emuswap.Side, token1Amount, token2Amount emuswap.UFix64, fees Fees) (Trade, error) {
+	priced := token1Amount
+	if side == emuswap.Token2ForToken1 {
+		priced = token2Amount
+	} else if side != emuswap.Token1ForToken2 {
+		return Trade{}, fmt.Errorf("invalid swap side %d", side)
+	}
+	amount, err := GrossAmount(priced, fees)
+	if err != nil {
+		return Trade{}, err
+	}
+	return Trade{Side: side, AmountIn: amount}, nil
+}
+
+// History is the order flow of a pool recovered from the chain.
+type History struct {
+	Trades []Trade
+	// Unattributed counts Trade events whose pool could not be told.
+	Unattributed int
+}
+
+// FetchTrades collects the swaps of poolID between two block heights from
+// their Trade events, see FetchPoolTrades.
+func FetchTrades(c *emuswap.Client, poolID uint64, fees Fees, startHeight, endHeight uint64) (History, error) {
+	found, unattributed, err := FetchPoolTrades(c, startHeight, endHeight)
+	if err != nil {
+		return History{}, err
+	}
+	history := History{Unattributed: unattributed}
+	for _, f := range found {
+		if f.PoolID != poolID {
+			continue
+		}
+		e := f.Event.(events.Trade)
+		trade, err := TradeFromEvent(emuswap.Side(e.Side), e.Token1Amount, e.Token2Amount, fees)
+		if err != nil {
+			return History{}, err
+		}
+		history.Trades = append(history.Trades, trade)
+	}
+	return history, nil
+}
+
+// PoolTrade is a Trade event and the pool that emitted it.
+type PoolTrade struct {
+	events.Located
+	PoolID uint64
+	// Trader is the first authorizer of the transaction, its payer when the
+	// transaction has no authorizers.
+	Trader flow.Address
+}
+
+// FetchPoolTrades collects the Trade events between two block heights, in
+// chain order, with the pool of each. Trade events name no pool, but a swap
+// deposits its input into the pool right before the event and withdraws the
+// output right after it. The tokens of those two events are the pair of the
+// pool, and a pair has one pool. Trade events without them are counted as
+// unattributed.
+func FetchPoolTrades(c *emuswap.Client, startHeight, endHeight uint64) (trades []PoolTrade, unattributed int, err error) {
+	addresses, err := events.AddressesFor(c.O)
+	if err != nil {
+		return nil, 0, err
+	}
+	poolIDs, err := c.GetPoolIDs()
+	if err != nil {
+		return nil, 0, err
+	}
+	metas, err := c.GetPoolsMeta()
+	if err != nil {
+		return nil, 0, err
+	}
+	pools := map[[2]string]uint64{}
+	for i, meta := range metas {
+		pools[[2]string{tokenContract(meta.Token1Identifier), tokenContract(meta.Token2Identifier)}] = poolIDs[i]
+	}
+	found, err := addresses.Fetch(c.O, []events.Event{events.Trade{}}, startHeight, endHeight)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	type transaction struct {
+		trader flow.Address
+		events map[int]flow.Event
+	}
+	transactions := map[flow.Identifier]transaction{}
+	for _, located := range found {
+		tx, ok := transactions[located.TransactionID]
+		if !ok {
+			sent, result, err := c.O.Services.Transactions.GetStatus(located.TransactionID, false)
+			if err != nil {
+				return nil, 0, fmt.Errorf("transaction %s: %w", located.TransactionID, err)
+			}
+			tx = transaction{trader: sent.Payer, events: map[int]flow.Event{}}
+			if len(sent.Authorizers) > 0 {
+				tx.trader = sent.Authorizers[0]
+			}
+			for _, event := range result.Events {
+				tx.events[event.EventIndex] = event
+			}
+			transactions[located.TransactionID] = tx
+		}
+
+		in, deposited := tokenEvent(tx.events[located.EventIndex-1], "TokensDeposited")
+		out, withdrawn := tokenEvent(tx.events[located.EventIndex+1], "TokensWithdrawn")
+		pair := [2]string{in, out}
+		if emuswap.Side(located.Event.(events.Trade).Side) == emuswap.Token2ForToken1 {
+			pair = [2]string{out, in}
+		}
+		poolID, known := pools[pair]
+		if !deposited || !withdrawn || !known {
+			unattributed++
+			continue
+		}
+		trades = append(trades, PoolTrade{Located: located, PoolID: poolID, Trader: tx.trader})
+	}
+	return trades, unattributed, nil
+}
+
+// tokenContract is the contract of a token type identifier, A.address.Name
+// of A.address.Name.Vault.
+func tokenContract(identifier string) string {
+	if i := strings.LastIndex(identifier, "."); i >= 0 {
+		return identifier[:i]
+	}
+	return identifier
+}
+
+// tokenEvent returns the contract of event when it is the named event of a
+// token contract.
+func tokenEvent(event flow.Event, name string) (string, bool) {
+	contract := strings.TrimSuffix(event.Type, "."+name)
+	return contract, contract != event.Type
+}
+
+// Synthetic generates n trades in random directions, each paying in up to
+// maxFraction of the reserve of its input token. The same seed gives the same
+// order flow.
+func Synthetic(pool emuswap.Pool, n int, maxFraction float64, seed int64) []Trade {
+	r := rand.New(rand.NewSource(seed))
+	trades := make([]Trade, 0, n)
+	for len(trades) < n {
+		side := emuswap.Token1ForToken2
+		reserve := pool.Token1Amount
+		if r.Intn(2) == 1 {
+			side = emuswap.Token2ForToken1
+			reserve = pool.Token2Amount
+		}
+		// mostly small trades with a few large ones
+		fraction := maxFraction * r.Float64() * r.Float64()
+		amount := emuswap.UFix64FromFloat(reserve.Float64() * fraction)
+		if amount == 0 {
+			continue
+		}
+		trades = append(trades, Trade{Side: side, AmountIn: amount})
+	}
+	return trades
+}
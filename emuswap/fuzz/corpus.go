@@ -0,0 +1,134 @@
+package fuzz
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Corpus saves inputs to a seed corpus directory, e.g.
+// emuswap/fuzz/testdata/fuzz/FuzzSwap, one for every kind of result. Coverage of the Go
+// code the fuzzing engine keeps inputs for says little about the Cadence the
+// inputs run, the outcomes tell more.
+type Corpus struct {
+	Dir string
+}
+
+// Save writes args, the arguments of the fuzz target in order, as the input
+// of the kind of r unless an input of that kind was saved already. The file is
+// named after r.Key, so saving from several fuzzing processes writes it once.
+// It reports whether it wrote the file.
+func (c Corpus) Save(r Result, args ...interface{}) (bool, error) {
+	data, err := marshal(args)
+	if err != nil {
+		return false, err
+	}
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return false, err
+	}
+	path := filepath.Join(c.Dir, fmt.Sprintf("%x", sha256.Sum256([]byte(r.Key())))[:16])
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return false, err
+	}
+	return true, f.Close()
+}
+
+// Load returns the arguments of the inputs saved in the directory, none when
+// it does not exist, for testing.F.Add.
+func (c Corpus) Load() ([][]interface{}, error) {
+	entries, err := os.ReadDir(c.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var inputs [][]interface{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(c.Dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		args, err := unmarshal(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		inputs = append(inputs, args)
+	}
+	return inputs, nil
+}
+
+// marshal encodes args as a corpus file, like the fuzzing engine does.
+func marshal(args []interface{}) ([]byte, error) {
+	var b strings.Builder
+	b.WriteString("go test fuzz v1\n")
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case bool, int64, uint64:
+			fmt.Fprintf(&b, "%T(%v)\n", v, v)
+		case uint8:
+			fmt.Fprintf(&b, "byte(%q)\n", v)
+		case string:
+			fmt.Fprintf(&b, "string(%q)\n", v)
+		default:
+			return nil, fmt.Errorf("fuzz: cannot save a %T", arg)
+		}
+	}
+	return []byte(b.String()), nil
+}
+
+// unmarshal decodes a corpus file of the types marshal encodes.
+func unmarshal(data []byte) ([]interface{}, error) {
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if lines[0] != "go test fuzz v1" {
+		return nil, errors.New("fuzz: not a corpus file")
+	}
+	var args []interface{}
+	for _, line := range lines[1:] {
+		typ, value, ok := strings.Cut(line, "(")
+		if !ok || !strings.HasSuffix(value, ")") {
+			return nil, fmt.Errorf("fuzz: malformed line %q", line)
+		}
+		value = strings.TrimSuffix(value, ")")
+		var arg interface{}
+		var err error
+		switch typ {
+		case "bool":
+			arg, err = strconv.ParseBool(value)
+		case "int64":
+			arg, err = strconv.ParseInt(value, 10, 64)
+		case "uint64":
+			arg, err = strconv.ParseUint(value, 10, 64)
+		case "byte":
+			var char string
+			if char, err = strconv.Unquote(value); err == nil {
+				arg = uint8([]rune(char)[0])
+			}
+		case "string":
+			arg, err = strconv.Unquote(value)
+		default:
+			err = fmt.Errorf("fuzz: cannot load a %s", typ)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", line, err)
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
@@ -0,0 +1,435 @@
+// Package fuzz sends boundary values as the amounts of swaps, liquidity adds
+// and removes, stakes and unstakes, vesting withdrawals and airdrop drops and
+// claims, and classifies what every transaction did: it succeeded, it
+// reverted as expected, or it broke an invariant.
+//
+// Amounts are relative to the state before each transaction, e.g. one unit of
+// 0.00000001 above the reserve a swap pays out, so edges stay edges while the
+// state moves: zero, the smallest UFix64, amounts whose DAO fee truncates to
+// zero, the reserves, the LP supply, the balances, the stake, the vesting
+// allowance and the largest UFix64. After every transaction the harness
+// checks that
+//
+//   - it failed where the contracts must fail and succeeded where they must
+//     succeed, amounts the state before leaves undecided pass either way,
+//   - a failed transaction changed nothing,
+//   - a successful one moved what the contracts compute, a swap what
+//     emuswap.Pool models, and every token and LP token it moved is accounted
+//     for,
+//   - the stakes of the farm add up, sqrt(reserve1*reserve2) per LP token did
+//     not drop and health.Check finds no errors.
+//
+// The Fuzz tests of the package drive a Harness with testing.F:
+//
+//	go test ./emuswap/fuzz -run '^$' -fuzz FuzzSwap -fuzztime 1m -save
+//
+// With -save, the inputs of outcomes no saved input had are written to the
+// seed corpus, which go test runs from then on. The corpus is the package's
+// testdata/fuzz/FuzzSwap. Like every test of the module the tests run from
+// the repository root, so the targets add the corpus inputs themselves, and
+// go test writes the inputs of failures to testdata/fuzz of the root, to be
+// moved to the corpus once fixed.
+package fuzz
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/flow-go-sdk"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/health"
+	"swap.emudao.org/test-overflow/emuswap/snapshot"
+)
+
+// Op is a kind of transaction the harness sends.
+type Op string
+
+const (
+	Swap            Op = "swap"
+	AddLiquidity    Op = "addLiquidity"
+	RemoveLiquidity Op = "removeLiquidity"
+	Stake           Op = "stake"
+	Unstake         Op = "unstake"
+	VestingWithdraw Op = "vestingWithdraw"
+	CreateDrop      Op = "createDrop"
+	ClaimDrop       Op = "claimDrop"
+)
+
+// Outcome is what a transaction did.
+type Outcome uint8
+
+const (
+	// Success is a transaction that succeeded and broke no invariant.
+	Success Outcome = iota + 1
+	// Revert is a transaction that failed, as the contracts may for its
+	// arguments, and changed nothing.
+	Revert
+	// Breach is a transaction that broke an invariant, whether it succeeded
+	// or failed.
+	Breach
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case Success:
+		return "success"
+	case Revert:
+		return "revert"
+	case Breach:
+		return "breach"
+	}
+	return fmt.Sprintf("Outcome(%d)", uint8(o))
+}
+
+// The invariants a transaction is checked against.
+const (
+	// InvariantExpected is broken by a transaction failing where the
+	// contracts must succeed, or succeeding where they must fail.
+	InvariantExpected = "expected"
+	// InvariantReverted is broken by a failed transaction changing the
+	// protocol state or the holdings of the accounts.
+	InvariantReverted = "reverted"
+	// InvariantModel is broken by a successful transaction moving other
+	// amounts than the contracts compute.
+	InvariantModel = "model"
+	// InvariantTokens is broken by pool tokens going missing or appearing
+	// between the pool, the fees collected and the trader.
+	InvariantTokens = "tokens"
+	// InvariantSupply is broken by LP supply minted or burned that the
+	// holders and the farm do not account for.
+	InvariantSupply = "lpSupply"
+	InvariantFarm   = "farmStaked"
+	InvariantValue  = "lpValue"
+	InvariantHealth = "health"
+)
+
+// Invariant is an invariant a transaction broke.
+type Invariant struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+// Result is a transaction sent by the harness.
+type Result struct {
+	Op Op `json:"op"`
+	// Args are the arguments sent, amounts with the base they were chosen
+	// from, e.g. token1->token2 0.00001999 (feeTruncation).
+	Args    string  `json:"args"`
+	Outcome Outcome `json:"outcome"`
+	// Err is the failure of the transaction, classified by emuswap.Classify.
+	Err error `json:"-"`
+	// Breaches are the invariants broken, for Breach.
+	Breaches []Invariant `json:"breaches,omitempty"`
+}
+
+func (r Result) String() string {
+	s := fmt.Sprintf("%s(%s): %s", r.Op, r.Args, r.Outcome)
+	if r.Err != nil {
+		s += ": " + failure(r.Err)
+	}
+	for _, b := range r.Breaches {
+		s += "\n\t" + b.Name + ": " + b.Message
+	}
+	return s
+}
+
+// Key tells results of a different kind apart: the op, the outcome, the
+// cause of a failure with its numbers as N and the invariants broken.
+func (r Result) Key() string {
+	key := fmt.Sprintf("%s %s", r.Op, r.Outcome)
+	if r.Err != nil {
+		key += ": " + numbers.ReplaceAllString(failure(r.Err), "N")
+	}
+	for _, b := range r.Breaches {
+		key += " " + b.Name
+	}
+	return key
+}
+
+// numbers are replaced in keys, so amounts do not tell failures of the same
+// cause apart.
+var numbers = regexp.MustCompile(`\b\d+(\.\d+)?\b`)
+
+// failure is the first line of the Cadence error, or else of err.
+func failure(err error) string {
+	lines := strings.Split(err.Error(), "\n")
+	first := strings.TrimSpace(lines[0])
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "error: ") {
+			return strings.TrimPrefix(line, "error: ")
+		}
+	}
+	return first
+}
+
+// Harness sends transactions to an emulator set up by New, one at a time,
+// and checks each of them. Nobody else may send transactions to the emulator
+// meanwhile.
+type Harness struct {
+	c *emuswap.Client
+	// PoolID is the FLOW/FUSD pool the harness trades in and the ID of its
+	// farm.
+	PoolID uint64
+	// Trader swaps, adds and removes liquidity and stakes. Admin deployed
+	// the contracts, so vests EMU, and creates and claims the airdrops.
+	Trader, Admin  string
+	token1, token2 emuswap.Token
+	trader, admin  flow.Address
+}
+
+// New sets up the emulator of c, which must have no pools yet: Admin creates
+// a FLOW/FUSD pool of 100 FLOW and 50 FUSD with a farm, Trader is given 10000
+// FLOW and FUSD each and adds 1000 FLOW and 500 FUSD of liquidity, then Admin
+// removes the 1 LP token the pool started with. Trader holds the whole LP
+// supply of 10 then and stakes none.
+func New(c *emuswap.Client) (*Harness, error) {
+	h := &Harness{
+		c:      c,
+		Trader: "user1",
+		Admin:  "account",
+		token1: emuswap.MustLookupToken("FLOW"),
+		token2: emuswap.MustLookupToken("FUSD"),
+	}
+	h.trader, h.admin = c.Address(h.Trader), c.Address(h.Admin)
+	ids, err := c.GetPoolIDs()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) != 0 {
+		return nil, errors.New("fuzz: the emulator has pools already")
+	}
+
+	ufix := emuswap.UFix64FromFloat
+	setup := []overflow.FlowTransactionBuilder{
+		c.FUSDSetup(h.Admin),
+		c.DemoMintFUSD(h.Admin, ufix(1000), h.admin),
+		c.EmuSwapAdminCreateNewPool(h.Admin, h.token1.StoragePath, ufix(100), h.token2.StoragePath, ufix(50)),
+		c.StakingAdminCreateNewFarm(h.Admin, h.PoolID),
+		c.DemoSetupAccount(h.Trader),
+		c.DemoFundAccounts(h.Admin, []flow.Address{h.trader}, []emuswap.UFix64{ufix(10000)}, []emuswap.UFix64{ufix(10000)}, []emuswap.UFix64{0}, []emuswap.UFix64{0}),
+		c.AddLiquidity(h.Trader, h.token1, h.token2, ufix(1000), ufix(500)),
+		c.RemoveLiquidity(h.Admin, h.token1, h.token2, ufix(1)),
+	}
+	for _, tx := range setup {
+		if result := c.Submit(tx); result.Err != nil {
+			return nil, fmt.Errorf("fuzz: %s: %w", tx.FileName, result.Err)
+		}
+	}
+	return h, nil
+}
+
+// state is what a transaction is checked against before and after.
+type state struct {
+	snapshot.State
+	trader, admin emuswap.Holdings
+}
+
+func (h *Harness) read() (state, error) {
+	var s state
+	var err error
+	if s.State, err = snapshot.Take(h.c); err != nil {
+		return s, err
+	}
+	if s.trader, err = h.c.ReadHoldings(h.trader); err != nil {
+		return s, err
+	}
+	s.admin, err = h.c.ReadHoldings(h.admin)
+	return s, err
+}
+
+// expectation is whether the contracts must fail or succeed for the
+// arguments of a transaction, and why. The zero expectation allows either.
+type expectation struct {
+	outcome Outcome
+	why     string
+}
+
+func mustRevert(format string, args ...interface{}) expectation {
+	return expectation{outcome: Revert, why: fmt.Sprintf(format, args...)}
+}
+
+func mustSucceed(format string, args ...interface{}) expectation {
+	return expectation{outcome: Success, why: fmt.Sprintf(format, args...)}
+}
+
+// breach records an invariant broken.
+type breach func(name, format string, args ...interface{})
+
+// run sends tx, reads the state after it and checks it: with succeeded when
+// it succeeds, besides the checks every successful transaction gets.
+func (h *Harness) run(r Result, tx overflow.FlowTransactionBuilder, before state, expect expectation, succeeded func(after state, broke breach)) (Result, error) {
+	sent := h.c.Submit(tx)
+	if errors.Is(sent.Err, emuswap.ErrExpired) || errors.Is(sent.Err, emuswap.ErrSequenceMismatch) {
+		return r, sent.Err
+	}
+	after, err := h.read()
+	if err != nil {
+		return r, err
+	}
+	r.Err = sent.Err
+	broke := func(name, format string, args ...interface{}) {
+		r.Breaches = append(r.Breaches, Invariant{Name: name, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch {
+	case r.Err != nil && expect.outcome == Success:
+		broke(InvariantExpected, "failed although %s", expect.why)
+	case r.Err == nil && expect.outcome == Revert:
+		broke(InvariantExpected, "succeeded although %s", expect.why)
+	}
+	if r.Err != nil {
+		h.unchanged(before, after, broke)
+	} else {
+		succeeded(after, broke)
+		h.accounted(before, after, broke)
+		if err := h.healthy(broke); err != nil {
+			return r, err
+		}
+	}
+
+	r.Outcome = Success
+	switch {
+	case len(r.Breaches) > 0:
+		r.Outcome = Breach
+	case r.Err != nil:
+		r.Outcome = Revert
+	}
+	return r, nil
+}
+
+// Base is the value an Amount is relative to, read before each transaction.
+type Base uint8
+
+const (
+	// Absolute amounts are the offset alone.
+	Absolute Base = iota
+	// Max is the largest UFix64.
+	Max
+	// ReserveIn and ReserveOut are the reserves of the tokens a swap takes
+	// and pays out, token1 and token2 for the other transactions.
+	ReserveIn
+	ReserveOut
+	// Supply is the LP token supply of the pool.
+	Supply
+	// Balance is what the signer holds of what the transaction takes: the
+	// input of a swap, token1 or token2 of a liquidity add, LP tokens for a
+	// remove or a stake, FLOW for a drop. A vesting withdrawal takes the
+	// vesting balance.
+	Balance
+	// LPBalance and Staked are the LP tokens of the trader and its stake.
+	LPBalance
+	Staked
+	// Allowance is the vesting unlock allowance of the admin.
+	Allowance
+	// FeeTruncation is the largest amount whose DAO fee truncates to zero.
+	FeeTruncation
+
+	bases
+)
+
+var baseNames = [bases]string{"", "max", "reserveIn", "reserveOut", "supply", "balance", "lpBalance", "staked", "allowance", "feeTruncation"}
+
+// Amount is an amount relative to the state before a transaction, Offset
+// units of 0.00000001 from the value of Base. Bases past FeeTruncation wrap
+// around, so any byte is a base.
+type Amount struct {
+	Base   Base
+	Offset int64
+}
+
+func (a Amount) String() string {
+	offset := emuswap.UFix64(a.Offset).String()
+	if a.Offset < 0 {
+		offset = "-" + emuswap.UFix64(-uint64(a.Offset)).String()
+	}
+	name := baseNames[a.Base%bases]
+	switch {
+	case name == "":
+		return offset
+	case a.Offset == 0:
+		return name
+	case a.Offset > 0:
+		return name + "+" + offset
+	}
+	return name + offset
+}
+
+// value resolves a against the values of the bases, clamped to the range of
+// UFix64.
+func (a Amount) value(values map[Base]emuswap.UFix64) emuswap.UFix64 {
+	base := values[a.Base%bases]
+	if a.Offset < 0 {
+		// -uint64 is the magnitude of the offset, math.MinInt64 included
+		if magnitude := emuswap.UFix64(-uint64(a.Offset)); magnitude < base {
+			return base - magnitude
+		}
+		return 0
+	}
+	if offset := emuswap.UFix64(a.Offset); offset <= emuswap.MaxUFix64-base {
+		return base + offset
+	}
+	return emuswap.MaxUFix64
+}
+
+// describe is the value of a with a when it is relative.
+func describe(a Amount, value emuswap.UFix64) string {
+	if a.Base%bases == Absolute {
+		return value.String()
+	}
+	return fmt.Sprintf("%s (%s)", value, a)
+}
+
+// values are the values of the bases in s, with balance the signer's balance
+// of the token taken.
+func (h *Harness) values(s state, side emuswap.Side, balance emuswap.UFix64) map[Base]emuswap.UFix64 {
+	pool := s.Pools[h.PoolID]
+	in, out := pool.Token1Amount, pool.Token2Amount
+	if side == emuswap.Token2ForToken1 {
+		in, out = out, in
+	}
+	// amount * fee truncates to zero below one unit of 0.00000001
+	truncation := emuswap.MaxUFix64
+	if pool.DAOFeePercentage > 0 {
+		truncation = (emuswap.UFix64Factor - 1) / pool.DAOFeePercentage
+	}
+	return map[Base]emuswap.UFix64{
+		Max:           emuswap.MaxUFix64,
+		ReserveIn:     in,
+		ReserveOut:    out,
+		Supply:        pool.TotalSupply,
+		Balance:       balance,
+		LPBalance:     s.trader.LPBalances[h.PoolID],
+		Staked:        s.trader.Stakes[h.PoolID].Balance,
+		Allowance:     s.admin.VestingAllowance,
+		FeeTruncation: truncation,
+	}
+}
+
+// sortedKeys are the keys of m in order.
+func sortedKeys(m map[uint64]snapshot.Airdrop) []uint64 {
+	keys := make([]uint64, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// healthy reports the errors health.Check finds in the pool.
+func (h *Harness) healthy(broke breach) error {
+	report, err := health.Check(h.c, health.Options{Holders: []flow.Address{h.trader, h.admin}, Dust: health.DefaultDust})
+	if err != nil {
+		return err
+	}
+	for _, f := range report.Findings {
+		if f.PoolID == h.PoolID && f.Severity == health.Error {
+			broke(InvariantHealth, "%s: %s", f.Check, f.Message)
+		}
+	}
+	return nil
+}
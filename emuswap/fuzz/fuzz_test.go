@@ -0,0 +1,288 @@
+package fuzz
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/internal/emutest"
+)
+
+var save = flag.Bool("save", false, "save the inputs of kinds of results not saved before to the seed corpus")
+
+// corpusDir is the seed corpus of the package, emuswap/fuzz/testdata/fuzz.
+// The fuzz targets add its inputs themselves, as go test would look for them
+// under the repository root the tests run in.
+var corpusDir string
+
+// TestMain runs the package tests from the repository root, where flow.json
+// and the files it references resolve. Fuzzing workers start there already,
+// in the directory the fuzzing process changed to.
+func TestMain(m *testing.M) {
+	root, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "flow.json")); err != nil {
+		root = filepath.Join(root, "..", "..")
+	}
+	corpusDir = filepath.Join(root, "emuswap", "fuzz", "testdata", "fuzz")
+	if err := os.Chdir(root); err != nil {
+		panic(err)
+	}
+	os.Exit(emutest.Main(m))
+}
+
+// units are amounts of 0.00000001, as Amount offsets are.
+func units(f float64) int64 {
+	return int64(emuswap.UFix64FromFloat(f))
+}
+
+// harness starts the emulator of a fuzz target and adds the inputs of its
+// seed corpus.
+func harness(f *testing.F) *Harness {
+	inputs, err := Corpus{Dir: filepath.Join(corpusDir, f.Name())}.Load()
+	require.NoError(f, err)
+	for _, args := range inputs {
+		f.Add(args...)
+	}
+	o, err := emutest.Start()
+	require.NoError(f, err)
+	h, err := New(emuswap.NewClient(o))
+	require.NoError(f, err)
+	return h
+}
+
+// check fails on a breach and, with -save, saves args as the input of r when
+// no input of its kind was saved.
+func check(t *testing.T, r Result, err error, args ...interface{}) {
+	t.Helper()
+	require.NoError(t, err)
+	t.Log(r)
+	if *save {
+		name, _, _ := strings.Cut(t.Name(), "/")
+		_, err := Corpus{Dir: filepath.Join(corpusDir, name)}.Save(r, args...)
+		require.NoError(t, err)
+	}
+	if r.Outcome == Breach {
+		t.Error(r)
+	}
+}
+
+func FuzzSwap(f *testing.F) {
+	h := harness(f)
+	for _, seed := range []struct {
+		token2 bool
+		base   Base
+		offset int64
+	}{
+		{false, Absolute, 0},
+		{false, Absolute, 1},
+		{false, FeeTruncation, 0},
+		{true, FeeTruncation, 1},
+		{false, Absolute, units(10)},
+		{true, ReserveOut, -1},
+		{true, ReserveIn, 0},
+		{false, ReserveOut, 0},
+		{false, Balance, 1},
+		{true, Max, 0},
+		{false, Max, -1},
+		{true, Balance, 0},
+	} {
+		f.Add(seed.token2, uint8(seed.base), seed.offset)
+	}
+	f.Fuzz(func(t *testing.T, token2 bool, base uint8, offset int64) {
+		side := emuswap.Token1ForToken2
+		if token2 {
+			side = emuswap.Token2ForToken1
+		}
+		r, err := h.Swap(side, Amount{Base: Base(base), Offset: offset})
+		check(t, r, err, token2, base, offset)
+	})
+}
+
+func FuzzLiquidity(f *testing.F) {
+	h := harness(f)
+	for _, seed := range []struct {
+		remove  bool
+		base1   Base
+		offset1 int64
+		base2   Base
+		offset2 int64
+	}{
+		{false, Absolute, 0, Absolute, units(1)},
+		{false, Absolute, 1, Absolute, 1},
+		{false, Absolute, units(10), Absolute, units(5)},
+		{false, ReserveIn, 0, ReserveOut, 0},
+		{false, Balance, 1, Absolute, units(1)},
+		{false, Max, 0, Absolute, 1},
+		{true, Absolute, 0, Absolute, 0},
+		{true, Absolute, 1, Absolute, 0},
+		{true, Supply, 0, Absolute, 0},
+		{true, Supply, -1, Absolute, 0},
+		{true, LPBalance, 1, Absolute, 0},
+		{true, LPBalance, 0, Absolute, 0},
+		{false, Balance, 0, Balance, 0},
+	} {
+		f.Add(seed.remove, uint8(seed.base1), seed.offset1, uint8(seed.base2), seed.offset2)
+	}
+	f.Fuzz(func(t *testing.T, remove bool, base1 uint8, offset1 int64, base2 uint8, offset2 int64) {
+		amount1, amount2 := Amount{Base: Base(base1), Offset: offset1}, Amount{Base: Base(base2), Offset: offset2}
+		r, err := h.AddLiquidity(amount1, amount2)
+		check(t, r, err, remove, base1, offset1, base2, offset2)
+		if remove {
+			r, err = h.RemoveLiquidity(amount1)
+			check(t, r, err, remove, base1, offset1, base2, offset2)
+		}
+	})
+}
+
+func FuzzStake(f *testing.F) {
+	h := harness(f)
+	for _, seed := range []struct {
+		unstake bool
+		base    Base
+		offset  int64
+	}{
+		{true, Absolute, 0},
+		{false, Absolute, 0},
+		{false, Absolute, 1},
+		{false, LPBalance, 1},
+		{true, Absolute, 0},
+		{true, Staked, 1},
+		{true, Staked, 0},
+		{true, Absolute, 1},
+		{false, Max, 0},
+		{false, LPBalance, 0},
+		{true, Max, 0},
+	} {
+		f.Add(seed.unstake, uint8(seed.base), seed.offset)
+	}
+	f.Fuzz(func(t *testing.T, unstake bool, base uint8, offset int64) {
+		amount := Amount{Base: Base(base), Offset: offset}
+		r, err := h.Stake(amount)
+		check(t, r, err, unstake, base, offset)
+		if unstake {
+			r, err = h.Unstake(amount)
+			check(t, r, err, unstake, base, offset)
+		}
+	})
+}
+
+func FuzzVestingWithdraw(f *testing.F) {
+	h := harness(f)
+	for _, seed := range []struct {
+		base   Base
+		offset int64
+	}{
+		{Absolute, 0},
+		{Absolute, 1},
+		{Allowance, 0},
+		{Allowance, 1},
+		{Balance, 0},
+		{Balance, 1},
+		{Max, 0},
+	} {
+		f.Add(uint8(seed.base), seed.offset)
+	}
+	f.Fuzz(func(t *testing.T, base uint8, offset int64) {
+		r, err := h.VestingWithdraw(Amount{Base: Base(base), Offset: offset})
+		check(t, r, err, base, offset)
+	})
+}
+
+func FuzzAirdrop(f *testing.F) {
+	h := harness(f)
+	for _, seed := range []struct {
+		base     Base
+		offset   int64
+		back     uint8
+		receiver string
+	}{
+		{Absolute, units(10), 0, "flowTokenReceiver"},
+		{Absolute, units(10) - 1, 0, "flowTokenReceiver"},
+		{Absolute, 0, 1, "flowTokenReceiver"},
+		{Absolute, units(100), 0, "fusdReceiver"},
+		{Absolute, units(100), 0, ""},
+		{Absolute, units(100), 0, "no such receiver"},
+		{Absolute, units(100), 200, "flowTokenReceiver"},
+		{Max, 0, 0, "flowTokenReceiver"},
+		{Absolute, units(100), 1, "flowTokenReceiver"},
+		{Balance, 1, 0, "flowTokenReceiver"},
+	} {
+		f.Add(uint8(seed.base), seed.offset, seed.back, seed.receiver)
+	}
+	f.Fuzz(func(t *testing.T, base uint8, offset int64, back uint8, receiver string) {
+		r, err := h.CreateDrop(Amount{Base: Base(base), Offset: offset})
+		check(t, r, err, base, offset, back, receiver)
+		r, err = h.ClaimDrop(uint64(back), receiver)
+		check(t, r, err, base, offset, back, receiver)
+	})
+}
+
+func TestAmount(t *testing.T) {
+	values := map[Base]emuswap.UFix64{Max: emuswap.MaxUFix64, ReserveIn: ufix(10)}
+	for _, c := range []struct {
+		amount Amount
+		value  emuswap.UFix64
+		text   string
+	}{
+		{Amount{Absolute, 0}, 0, "0.00000000"},
+		{Amount{Absolute, units(1.5)}, ufix(1.5), "1.50000000"},
+		{Amount{Absolute, -1}, 0, "-0.00000001"},
+		{Amount{ReserveIn, 1}, ufix(10) + 1, "reserveIn+0.00000001"},
+		{Amount{ReserveIn, -units(20)}, 0, "reserveIn-20.00000000"},
+		{Amount{ReserveIn + bases, 0}, ufix(10), "reserveIn"},
+		{Amount{Max, 1}, emuswap.MaxUFix64, "max+0.00000001"},
+		{Amount{Max, -1 << 63}, emuswap.MaxUFix64 - 1<<63, "max-92233720368.54775808"},
+		{Amount{Staked, 0}, 0, "staked"},
+	} {
+		assert.Equal(t, c.value, c.amount.value(values), c.text)
+		assert.Equal(t, c.text, c.amount.String())
+	}
+}
+
+var ufix = emuswap.UFix64FromFloat
+
+func TestCorpus(t *testing.T) {
+	corpus := Corpus{Dir: filepath.Join(t.TempDir(), "FuzzAirdrop")}
+	r := Result{Op: ClaimDrop, Outcome: Revert, Err: assert.AnError}
+	saved, err := corpus.Save(r, uint8(2), int64(-1), true, "flowTokenReceiver")
+	require.NoError(t, err)
+	assert.True(t, saved)
+	// the amounts differ, the kind does not
+	saved, err = corpus.Save(Result{Op: ClaimDrop, Outcome: Revert, Err: assert.AnError}, uint8(3), int64(7), true, "")
+	require.NoError(t, err)
+	assert.False(t, saved)
+	saved, err = corpus.Save(Result{Op: ClaimDrop, Outcome: Success}, uint8(3), int64(7), false, "")
+	require.NoError(t, err)
+	assert.True(t, saved)
+	_, err = corpus.Save(r, 1.5)
+	assert.Error(t, err)
+
+	paths, err := filepath.Glob(filepath.Join(corpus.Dir, "*"))
+	require.NoError(t, err)
+	require.Len(t, paths, 2)
+	var files []string
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		files = append(files, string(data))
+	}
+	assert.Contains(t, files, "go test fuzz v1\nbyte('\\x02')\nint64(-1)\nbool(true)\nstring(\"flowTokenReceiver\")\n")
+
+	inputs, err := corpus.Load()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, [][]interface{}{
+		{uint8(2), int64(-1), true, "flowTokenReceiver"},
+		{uint8(3), int64(7), false, ""},
+	}, inputs)
+	inputs, err = Corpus{Dir: filepath.Join(t.TempDir(), "FuzzSwap")}.Load()
+	require.NoError(t, err)
+	assert.Empty(t, inputs)
+}
@@ -0,0 +1,96 @@
+package fuzz
+
+import (
+	"math"
+	"strings"
+
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/snapshot"
+)
+
+// unchanged reports what a failed transaction changed of the protocol state
+// and of what the trader and the admin hold.
+func (h *Harness) unchanged(before, after state, broke breach) {
+	var changed []string
+	for _, d := range snapshot.Diff(before.State, after.State, snapshot.DefaultOptions) {
+		changed = append(changed, d.String())
+	}
+	for _, holder := range []struct {
+		name          string
+		before, after emuswap.Holdings
+	}{{h.Trader, before.trader, after.trader}, {h.Admin, before.admin, after.admin}} {
+		for path, balance := range holder.before.Balances {
+			if holder.after.Balances[path] != balance {
+				changed = append(changed, holder.name+" "+path+": "+balance.String()+" -> "+holder.after.Balances[path].String())
+			}
+		}
+		for id, balance := range holder.before.LPBalances {
+			if holder.after.LPBalances[id] != balance {
+				changed = append(changed, holder.name+" LP balance: "+balance.String()+" -> "+holder.after.LPBalances[id].String())
+			}
+		}
+	}
+	if len(changed) > 0 {
+		broke(InvariantReverted, "%s", strings.Join(changed, ", "))
+	}
+}
+
+// accounted reports what a successful transaction moved that is not
+// accounted for: pool tokens between the pool, the fees collected and the
+// trader, LP tokens between the supply, the holders and the farm, and stakes
+// between the farm total and the stakes. It reports a drop of
+// sqrt(reserve1*reserve2) per LP token, which fees and truncation only raise.
+func (h *Harness) accounted(before, after state, broke breach) {
+	pool, got := before.Pools[h.PoolID], after.Pools[h.PoolID]
+	for _, token := range []struct {
+		token           emuswap.Token
+		identifier      string
+		reserve, stored emuswap.UFix64
+	}{{h.token1, pool.Token1Identifier, pool.Token1Amount, got.Token1Amount}, {h.token2, pool.Token2Identifier, pool.Token2Amount, got.Token2Amount}} {
+		held, holds := before.trader.Balances[token.token.BalancePath], after.trader.Balances[token.token.BalancePath]
+		fees, collected := before.FeesCollected[token.identifier], after.FeesCollected[token.identifier]
+		// in units, signed, as any of them may have gone down
+		moved := delta(token.reserve, token.stored) + delta(fees, collected) + delta(held, holds)
+		if moved != 0 {
+			broke(InvariantTokens, "%s: the pool went from %s to %s, the fees from %s to %s and the trader from %s to %s",
+				token.token.Symbol, token.reserve, token.stored, fees, collected, held, holds)
+		}
+	}
+
+	holders := func(s state) emuswap.UFix64 {
+		return s.trader.LPBalances[h.PoolID] + s.admin.LPBalances[h.PoolID] + h.totalStaked(s)
+	}
+	if delta(pool.TotalSupply, got.TotalSupply) != delta(holders(before), holders(after)) {
+		broke(InvariantSupply, "the supply went from %s to %s, what the holders and the farm hold from %s to %s", pool.TotalSupply, got.TotalSupply, holders(before), holders(after))
+	}
+
+	farm := after.Farms[h.PoolID]
+	var staked emuswap.UFix64
+	for _, stake := range farm.Stakes {
+		staked += stake.Balance
+	}
+	if total := h.totalStaked(after); total != staked {
+		broke(InvariantFarm, "total %s, the stakes sum to %s", total, staked)
+	}
+
+	value := func(p snapshot.Pool) float64 {
+		return math.Sqrt(p.Token1Amount.Float64()*p.Token2Amount.Float64()) / p.TotalSupply.Float64()
+	}
+	if was, is := value(pool), value(got); is < was*(1-1e-12) {
+		broke(InvariantValue, "sqrt(reserve1*reserve2) per LP token went from %.8f to %.8f", was, is)
+	}
+}
+
+// totalStaked is the total staked in the farm of the pool, zero when its meta
+// is unreadable.
+func (h *Harness) totalStaked(s state) emuswap.UFix64 {
+	if meta := s.Farms[h.PoolID].Meta; meta != nil {
+		return meta.TotalStaked
+	}
+	return 0
+}
+
+// delta is b-a in units of 0.00000001.
+func delta(a, b emuswap.UFix64) int64 {
+	return int64(b) - int64(a)
+}
@@ -0,0 +1,408 @@
+package fuzz
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/snapshot"
+)
+
+// precision is EmuSwap.PRECISION, the factor liquidity shares are scaled by.
+var precision = emuswap.UFix64FromFloat(10000)
+
+var (
+	errNoLiquidity   = errors.New("the share rounds to zero")
+	errNothingMinted = errors.New("nothing is minted")
+	errOverflow      = errors.New("the reserve overflows")
+	errClaimsBalance = errors.New("the claims exceed the balance")
+)
+
+// storageReserve is the FLOW an account keeps, as much as the emulator
+// charges for its storage at least. Whether spending FLOW below it fails
+// depends on the storage the account uses, so either is expected then.
+var storageReserve = emuswap.UFix64FromFloat(0.001)
+
+// spendsStorage reports whether spending value of token from balance leaves
+// less than storageReserve FLOW.
+func spendsStorage(token emuswap.Token, balance, value emuswap.UFix64) bool {
+	return token.Symbol == "FLOW" && balance-value < storageReserve
+}
+
+// model is the pool before a transaction as emuswap.Pool.
+func (h *Harness) model(s state) emuswap.Pool {
+	pool := s.Pools[h.PoolID]
+	return emuswap.Pool{
+		Token1Amount:     pool.Token1Amount,
+		Token2Amount:     pool.Token2Amount,
+		LPFeePercentage:  pool.LPFeePercentage,
+		DAOFeePercentage: pool.DAOFeePercentage,
+	}
+}
+
+// Swap swaps amount of token1 for token2, or of token2 for token1. It must
+// fail where emuswap.Pool fails and move what it computes otherwise.
+func (h *Harness) Swap(side emuswap.Side, amount Amount) (Result, error) {
+	before, err := h.read()
+	if err != nil {
+		return Result{}, err
+	}
+	from, to := h.token1, h.token2
+	if side == emuswap.Token2ForToken1 {
+		from, to = to, from
+	}
+	balance := before.trader.Balances[from.BalancePath]
+	value := amount.value(h.values(before, side, balance))
+	r := Result{Op: Swap, Args: fmt.Sprintf("%s %s", side, describe(amount, value))}
+
+	pool := h.model(before)
+	swap, modelErr := pool.Swap(side, value)
+	var expect expectation
+	switch {
+	case value > balance:
+		expect = mustRevert("the trader holds %s", balance)
+	case modelErr != nil:
+		expect = mustRevert("the model fails: %v", modelErr)
+	case spendsStorage(from, balance, value):
+		// either
+	default:
+		expect = mustSucceed("the model pays out %s", swap.AmountOut)
+	}
+	return h.run(r, h.c.Swap(h.Trader, from, to, value), before, expect, func(after state, broke breach) {
+		got := after.Pools[h.PoolID]
+		if got.Token1Amount != pool.Token1Amount || got.Token2Amount != pool.Token2Amount {
+			broke(InvariantModel, "reserves %s and %s, the model has %s and %s", got.Token1Amount, got.Token2Amount, pool.Token1Amount, pool.Token2Amount)
+		}
+		identifier := got.Token1Identifier
+		if side == emuswap.Token2ForToken1 {
+			identifier = got.Token2Identifier
+		}
+		if fee := after.FeesCollected[identifier] - before.FeesCollected[identifier]; fee != swap.DAOFee {
+			broke(InvariantModel, "DAO fee %s, the model takes %s", fee, swap.DAOFee)
+		}
+	})
+}
+
+// share is amount*PRECISION/total, the share of total EmuSwap computes for
+// amount.
+func share(amount, total emuswap.UFix64) (emuswap.UFix64, error) {
+	scaled, err := amount.Mul(precision)
+	if err != nil {
+		return 0, err
+	}
+	return scaled.Div(total)
+}
+
+// portion is the part of total for a share computed by share.
+func portion(total, share emuswap.UFix64) (emuswap.UFix64, error) {
+	scaled, err := total.Mul(share)
+	if err != nil {
+		return 0, err
+	}
+	return scaled.Div(precision)
+}
+
+// mint is the LP supply EmuSwap.Pool.addLiquidity mints for amount1 of
+// token1 and amount2 of token2: the smaller share of the reserves they are.
+func mint(s snapshot.Pool, amount1, amount2 emuswap.UFix64) (emuswap.UFix64, error) {
+	if amount1 == 0 || amount2 == 0 {
+		return 0, emuswap.ErrEmptyVault
+	}
+	share1, err := share(amount1, s.Token1Amount)
+	if err != nil {
+		return 0, err
+	}
+	share2, err := share(amount2, s.Token2Amount)
+	if err != nil {
+		return 0, err
+	}
+	if share2 < share1 {
+		share1 = share2
+	}
+	if share1 == 0 {
+		return 0, errNoLiquidity
+	}
+	if _, err := s.Token1Amount.Add(amount1); err != nil {
+		return 0, errOverflow
+	}
+	if _, err := s.Token2Amount.Add(amount2); err != nil {
+		return 0, errOverflow
+	}
+	minted, err := portion(s.TotalSupply, share1)
+	if err == nil && minted == 0 {
+		err = errNothingMinted
+	}
+	return minted, err
+}
+
+// burn is what EmuSwap.Pool.removeLiquidity pays out for amount LP tokens.
+func burn(s snapshot.Pool, amount emuswap.UFix64) (amount1, amount2 emuswap.UFix64, err error) {
+	switch {
+	case amount == 0:
+		return 0, 0, emuswap.ErrEmptyVault
+	case amount >= s.TotalSupply:
+		return 0, 0, emuswap.ErrRemoveAllLiquidity
+	}
+	liquidity, err := share(amount, s.TotalSupply)
+	if err != nil {
+		return 0, 0, err
+	}
+	if liquidity == 0 {
+		return 0, 0, errNoLiquidity
+	}
+	if amount1, err = portion(s.Token1Amount, liquidity); err != nil {
+		return 0, 0, err
+	}
+	amount2, err = portion(s.Token2Amount, liquidity)
+	return amount1, amount2, err
+}
+
+// AddLiquidity adds amount1 of token1 and amount2 of token2. Balance is the
+// trader's balance of the token of each amount.
+func (h *Harness) AddLiquidity(amount1, amount2 Amount) (Result, error) {
+	before, err := h.read()
+	if err != nil {
+		return Result{}, err
+	}
+	balance1, balance2 := before.trader.Balances[h.token1.BalancePath], before.trader.Balances[h.token2.BalancePath]
+	value1 := amount1.value(h.values(before, emuswap.Token1ForToken2, balance1))
+	value2 := amount2.value(h.values(before, emuswap.Token1ForToken2, balance2))
+	r := Result{Op: AddLiquidity, Args: describe(amount1, value1) + ", " + describe(amount2, value2)}
+
+	pool := before.Pools[h.PoolID]
+	minted, modelErr := mint(pool, value1, value2)
+	var expect expectation
+	switch {
+	case value1 > balance1 || value2 > balance2:
+		expect = mustRevert("the trader holds %s and %s", balance1, balance2)
+	case modelErr != nil:
+		expect = mustRevert("the model fails: %v", modelErr)
+	case spendsStorage(h.token1, balance1, value1):
+		// either
+	default:
+		expect = mustSucceed("the model mints %s", minted)
+	}
+	return h.run(r, h.c.AddLiquidity(h.Trader, h.token1, h.token2, value1, value2), before, expect, func(after state, broke breach) {
+		got := after.Pools[h.PoolID]
+		if got.Token1Amount != pool.Token1Amount+value1 || got.Token2Amount != pool.Token2Amount+value2 {
+			broke(InvariantModel, "reserves %s and %s after adding %s and %s to %s and %s", got.Token1Amount, got.Token2Amount, value1, value2, pool.Token1Amount, pool.Token2Amount)
+		}
+		if got.TotalSupply != pool.TotalSupply+minted {
+			broke(InvariantModel, "minted %s, the model mints %s", got.TotalSupply-pool.TotalSupply, minted)
+		}
+	})
+}
+
+// RemoveLiquidity removes amount LP tokens, e.g. the whole supply.
+func (h *Harness) RemoveLiquidity(amount Amount) (Result, error) {
+	before, err := h.read()
+	if err != nil {
+		return Result{}, err
+	}
+	held := before.trader.LPBalances[h.PoolID]
+	value := amount.value(h.values(before, emuswap.Token1ForToken2, held))
+	r := Result{Op: RemoveLiquidity, Args: describe(amount, value)}
+
+	pool := before.Pools[h.PoolID]
+	amount1, amount2, modelErr := burn(pool, value)
+	var expect expectation
+	switch {
+	case value > held:
+		expect = mustRevert("the trader holds %s LP tokens", held)
+	case modelErr != nil:
+		expect = mustRevert("the model fails: %v", modelErr)
+	default:
+		expect = mustSucceed("the model pays out %s and %s", amount1, amount2)
+	}
+	return h.run(r, h.c.RemoveLiquidity(h.Trader, h.token1, h.token2, value), before, expect, func(after state, broke breach) {
+		got := after.Pools[h.PoolID]
+		if got.Token1Amount != pool.Token1Amount-amount1 || got.Token2Amount != pool.Token2Amount-amount2 {
+			broke(InvariantModel, "paid out %s and %s, the model pays out %s and %s", pool.Token1Amount-got.Token1Amount, pool.Token2Amount-got.Token2Amount, amount1, amount2)
+		}
+		if got.TotalSupply != pool.TotalSupply-value {
+			broke(InvariantModel, "burned %s of %s", pool.TotalSupply-got.TotalSupply, value)
+		}
+	})
+}
+
+// Stake stakes amount of the trader's LP tokens in the farm of the pool.
+func (h *Harness) Stake(amount Amount) (Result, error) {
+	return h.stake(Stake, amount)
+}
+
+// Unstake unstakes amount of the trader's stake.
+func (h *Harness) Unstake(amount Amount) (Result, error) {
+	return h.stake(Unstake, amount)
+}
+
+func (h *Harness) stake(op Op, amount Amount) (Result, error) {
+	before, err := h.read()
+	if err != nil {
+		return Result{}, err
+	}
+	held := before.trader.LPBalances[h.PoolID]
+	stake, hasStake := before.trader.Stakes[h.PoolID]
+	staked, available := stake.Balance, held
+	if op == Unstake {
+		available = staked
+	}
+	value := amount.value(h.values(before, emuswap.Token1ForToken2, held))
+	r := Result{Op: op, Args: describe(amount, value)}
+
+	var expect expectation
+	switch {
+	case op == Unstake && !hasStake:
+		expect = mustRevert("the trader has no stake controller")
+	case value > available:
+		expect = mustRevert("%s is available", available)
+	default:
+		expect = mustSucceed("%s is available", available)
+	}
+	tx := h.c.StakingUserStake(h.Trader, h.PoolID, value)
+	wantHeld, wantStaked := held-value, staked+value
+	if op == Unstake {
+		tx = h.c.StakingUserUnstake(h.Trader, h.PoolID, value)
+		wantHeld, wantStaked = held+value, staked-value
+	}
+	return h.run(r, tx, before, expect, func(after state, broke breach) {
+		gotHeld, gotStaked := after.trader.LPBalances[h.PoolID], after.trader.Stakes[h.PoolID].Balance
+		if gotHeld != wantHeld || gotStaked != wantStaked {
+			broke(InvariantModel, "the trader holds %s and stakes %s, %s and %s before", gotHeld, gotStaked, held, staked)
+		}
+	})
+}
+
+// VestingWithdraw withdraws amount of the admin's vested EMU. It must
+// succeed up to the allowance before, which only grows, and fail above the
+// EMU left.
+func (h *Harness) VestingWithdraw(amount Amount) (Result, error) {
+	before, err := h.read()
+	if err != nil {
+		return Result{}, err
+	}
+	emu := emuswap.MustLookupToken("EMU").BalancePath
+	vesting := before.Vesting[h.admin.Hex()]
+	value := amount.value(h.values(before, emuswap.Token1ForToken2, vesting.Balance))
+	r := Result{Op: VestingWithdraw, Args: describe(amount, value)}
+
+	allowance := before.admin.VestingAllowance
+	var expect expectation
+	switch {
+	case value == 0:
+		expect = mustRevert("nothing is withdrawn")
+	case value > vesting.Balance:
+		expect = mustRevert("%s is left", vesting.Balance)
+	case value <= allowance:
+		expect = mustSucceed("the allowance is %s", allowance)
+	}
+	return h.run(r, h.c.VestingWithdrawAmount(h.Admin, value), before, expect, func(after state, broke breach) {
+		got := after.Vesting[h.admin.Hex()]
+		if got.Balance != vesting.Balance-value || got.TokensWithdrawn != vesting.TokensWithdrawn+value {
+			broke(InvariantModel, "%s left and %s withdrawn, %s and %s before", got.Balance, got.TokensWithdrawn, vesting.Balance, vesting.TokensWithdrawn)
+		}
+		if received := after.admin.Balances[emu] - before.admin.Balances[emu]; received != value {
+			broke(InvariantModel, "the admin received %s EMU", received)
+		}
+	})
+}
+
+// CreateDrop creates an airdrop of amount FLOW of the admin, which the admin
+// can claim 10 FLOW of, as transactions/FTAirdrop/createDrop.cdc does.
+func (h *Harness) CreateDrop(amount Amount) (Result, error) {
+	before, err := h.read()
+	if err != nil {
+		return Result{}, err
+	}
+	flowBalance := emuswap.MustLookupToken("FLOW").BalancePath
+	balance := before.admin.Balances[flowBalance]
+	value := amount.value(h.values(before, emuswap.Token1ForToken2, balance))
+	r := Result{Op: CreateDrop, Args: describe(amount, value)}
+
+	claim := emuswap.UFix64FromFloat(10)
+	var expect expectation
+	switch {
+	case value > balance:
+		expect = mustRevert("the admin holds %s", balance)
+	case value < claim:
+		expect = mustRevert("%v: %s of %s", errClaimsBalance, claim, value)
+	case spendsStorage(emuswap.MustLookupToken("FLOW"), balance, value):
+		// either
+	default:
+		expect = mustSucceed("the admin holds %s", balance)
+	}
+	return h.run(r, h.c.FTAirdropCreateDrop(h.Admin, value), before, expect, func(after state, broke breach) {
+		var created []uint64
+		for id := range after.Airdrops {
+			if _, ok := before.Airdrops[id]; !ok {
+				created = append(created, id)
+			}
+		}
+		if len(created) != 1 {
+			broke(InvariantModel, "%d drops created", len(created))
+			return
+		}
+		drop := after.Airdrops[created[0]]
+		if drop.Balance != value || len(drop.Claims) != 1 || drop.Claims[h.admin.Hex()] != claim {
+			broke(InvariantModel, "drop %d holds %s with claims %v", created[0], drop.Balance, drop.Claims)
+		}
+		if paid := balance - after.admin.Balances[flowBalance]; paid != value {
+			broke(InvariantModel, "the admin paid %s", paid)
+		}
+	})
+}
+
+// ClaimDrop claims the admin's claim on the drop created back drops before
+// the latest one, depositing it to the FungibleToken.Receiver the admin links
+// at /public/receiver. Going back past the first drop wraps around to IDs no
+// drop has. The claim must succeed when there is one and receiver takes the
+// token of the drop, and fail otherwise.
+func (h *Harness) ClaimDrop(back uint64, receiver string) (Result, error) {
+	before, err := h.read()
+	if err != nil {
+		return Result{}, err
+	}
+	var id uint64
+	if ids := sortedKeys(before.Airdrops); len(ids) > 0 {
+		id = ids[len(ids)-1]
+	}
+	id -= back
+	r := Result{Op: ClaimDrop, Args: fmt.Sprintf("%d, %q", id, receiver)}
+
+	drop, exists := before.Airdrops[id]
+	claim, claimable := drop.Claims[h.admin.Hex()]
+	token, err := dropToken(drop.TokenIdentifier)
+	var expect expectation
+	switch {
+	case !exists:
+		expect = mustRevert("there is no drop %d", id)
+	case !claimable:
+		expect = mustRevert("the admin has no claim")
+	case err != nil:
+		return r, err
+	case receiver != token.ReceiverPath:
+		expect = mustRevert("%s receives %s", token.ReceiverPath, token.Symbol)
+	default:
+		expect = mustSucceed("the admin can claim %s", claim)
+	}
+	return h.run(r, h.c.FTAirdropClaimDrop(h.Admin, id, receiver), before, expect, func(after state, broke breach) {
+		got := after.Airdrops[id]
+		if _, ok := got.Claims[h.admin.Hex()]; ok || got.Balance != drop.Balance-claim {
+			broke(InvariantModel, "drop %d holds %s with claims %v, %s before", id, got.Balance, got.Claims, drop.Balance)
+		}
+		if received := after.admin.Balances[token.BalancePath] - before.admin.Balances[token.BalancePath]; received != claim {
+			broke(InvariantModel, "the admin received %s %s", received, token.Symbol)
+		}
+	})
+}
+
+// dropToken is the registered token of a vault type identifier, e.g.
+// A.0ae53cb6e3f42a79.FlowToken.Vault.
+func dropToken(identifier string) (emuswap.Token, error) {
+	for _, token := range emuswap.Tokens {
+		if strings.HasSuffix(identifier, "."+token.Contract+".Vault") {
+			return token, nil
+		}
+	}
+	return emuswap.Token{}, fmt.Errorf("fuzz: drop of unknown token %s", identifier)
+}
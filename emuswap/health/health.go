@@ -0,0 +1,306 @@
+// Package health checks that the state of the EmuSwap pools adds up. For every
+// pool it reads PoolMeta, the frozen flag, the farm staking its LP tokens and
+// the LP balances of known holders, and flags frozen pools, dust reserves,
+// routes that do not lead to the pool, LP tokens held beyond the supply and,
+// when the events since deployment are scanned, supply that changed without
+// TokensMinted or TokensBurned events, as when an LP TokenVault is destroyed
+// outside of removeLiquidity. PoolMeta reads its reserves from the balances of
+// the pool vaults, so the reserves checked are the tokens the pool holds.
+package health
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/onflow/flow-go-sdk"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/events"
+)
+
+// Severity orders findings, errors make the report fail.
+type Severity string
+
+const (
+	Warning Severity = "warning"
+	Error   Severity = "error"
+)
+
+// Finding is one problem with a pool.
+type Finding struct {
+	PoolID   uint64   `json:"poolID"`
+	Check    string   `json:"check"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("pool %d %s %s: %s", f.PoolID, f.Severity, f.Check, f.Message)
+}
+
+// The checks findings are reported under.
+const (
+	CheckUnreadable = "unreadable"
+	CheckFrozen     = "frozen"
+	CheckDust       = "dust"
+	CheckRoute      = "route"
+	CheckHoldings   = "holdings"
+	CheckSupply     = "supplyDrift"
+)
+
+// Options configure a check.
+type Options struct {
+	// Holders are addresses whose LP balances are read, in addition to the
+	// ones found in the events.
+	Holders []flow.Address
+	// Complete states that the holders are every address holding LP tokens,
+	// so supply nobody holds is reported too.
+	Complete bool
+	// Dust is the reserve and LP supply below which a pool is reported.
+	Dust emuswap.UFix64
+	// History scans the LP token events from StartHeight to EndHeight for
+	// holders and the minted and burned supply. StartHeight must be at or
+	// before the deployment of EmuSwap for the supply check.
+	History     bool
+	StartHeight uint64
+	EndHeight   uint64
+}
+
+// DefaultDust is the dust threshold of the CLI.
+var DefaultDust = emuswap.UFix64FromFloat(0.0001)
+
+// Pool is the state read for one pool.
+type Pool struct {
+	PoolID uint64            `json:"poolID"`
+	Meta   *emuswap.PoolMeta `json:"meta"`
+	Frozen bool              `json:"frozen"`
+	// Held is the LP supply in the collections of the holders, Staked the
+	// supply staked in the farm of the pool.
+	Held   emuswap.UFix64 `json:"held"`
+	Staked emuswap.UFix64 `json:"staked"`
+	// Holders counts the holders with a balance.
+	Holders int `json:"holders"`
+	// Minted and Burned are summed from the events when History is set.
+	Minted emuswap.UFix64 `json:"minted"`
+	Burned emuswap.UFix64 `json:"burned"`
+}
+
+// Report is the outcome of a check.
+type Report struct {
+	Pools    []Pool    `json:"pools"`
+	Findings []Finding `json:"findings"`
+}
+
+// Failed reports whether there are errors, or warnings as well when strict.
+func (r Report) Failed(strict bool) bool {
+	for _, f := range r.Findings {
+		if f.Severity == Error || strict {
+			return true
+		}
+	}
+	return false
+}
+
+// supply is what the LP events say about the supply of every pool.
+type supply struct {
+	minted, burned map[uint64]emuswap.UFix64
+	holders        map[flow.Address]bool
+}
+
+// scan sums the minted and burned LP tokens and collects the addresses LP
+// tokens moved to and from. A vault deposited into a collection that holds
+// none of its pool yet emits no TokensDeposited, so the authorizers of the
+// transactions minting LP tokens are collected as well.
+func scan(c *emuswap.Client, startHeight, endHeight uint64) (supply, error) {
+	s := supply{minted: map[uint64]emuswap.UFix64{}, burned: map[uint64]emuswap.UFix64{}, holders: map[flow.Address]bool{}}
+	addresses, err := events.AddressesFor(c.O)
+	if err != nil {
+		return s, err
+	}
+	found, err := addresses.Fetch(c.O, []events.Event{events.TokensMinted{}, events.TokensBurned{}, events.TokensDeposited{}, events.TokensWithdrawn{}}, startHeight, endHeight)
+	if err != nil {
+		return s, err
+	}
+	seen := map[flow.Identifier]bool{}
+	for _, f := range found {
+		switch e := f.Event.(type) {
+		case events.TokensMinted:
+			s.minted[e.TokenID] += e.Amount
+			if seen[f.TransactionID] {
+				continue
+			}
+			seen[f.TransactionID] = true
+			tx, _, err := c.O.Services.Transactions.GetStatus(f.TransactionID, false)
+			if err != nil {
+				return s, fmt.Errorf("transaction %s: %w", f.TransactionID, err)
+			}
+			for _, authorizer := range tx.Authorizers {
+				s.holders[authorizer] = true
+			}
+		case events.TokensBurned:
+			s.burned[e.TokenID] += e.Amount
+		case events.TokensDeposited:
+			if e.To != nil {
+				s.holders[*e.To] = true
+			}
+		case events.TokensWithdrawn:
+			if e.From != nil {
+				s.holders[*e.From] = true
+			}
+		}
+	}
+	return s, nil
+}
+
+// Check reads every pool and reports what does not add up.
+func Check(c *emuswap.Client, options Options) (Report, error) {
+	report := Report{Pools: []Pool{}, Findings: []Finding{}}
+	poolIDs, err := c.GetPoolIDs()
+	if err != nil {
+		return report, err
+	}
+	sort.Slice(poolIDs, func(i, j int) bool { return poolIDs[i] < poolIDs[j] })
+	frozen, err := c.GetFrozenPools()
+	if err != nil {
+		return report, err
+	}
+	staked, err := c.StakingGetTotalStaked()
+	if err != nil {
+		return report, err
+	}
+
+	holders := map[flow.Address]bool{}
+	for _, holder := range options.Holders {
+		holders[holder] = true
+	}
+	var history supply
+	if options.History {
+		if history, err = scan(c, options.StartHeight, options.EndHeight); err != nil {
+			return report, err
+		}
+		for holder := range history.holders {
+			holders[holder] = true
+		}
+	}
+	addresses := make([]flow.Address, 0, len(holders))
+	for holder := range holders {
+		addresses = append(addresses, holder)
+	}
+	sort.Slice(addresses, func(i, j int) bool { return addresses[i].Hex() < addresses[j].Hex() })
+	balances, err := c.GetLPBalances(addresses)
+	if err != nil {
+		return report, err
+	}
+
+	for _, poolID := range poolIDs {
+		pool := Pool{PoolID: poolID, Frozen: frozen[poolID], Staked: staked[poolID], Minted: history.minted[poolID], Burned: history.burned[poolID]}
+		for _, held := range balances {
+			if balance := held[poolID]; balance > 0 {
+				pool.Held += balance
+				pool.Holders++
+			}
+		}
+		findings := checkPool(c, &pool, options)
+		report.Pools = append(report.Pools, pool)
+		report.Findings = append(report.Findings, findings...)
+	}
+	return report, nil
+}
+
+func checkPool(c *emuswap.Client, pool *Pool, options Options) []Finding {
+	var findings []Finding
+	add := func(check string, severity Severity, format string, args ...interface{}) {
+		findings = append(findings, Finding{PoolID: pool.PoolID, Check: check, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if pool.Frozen {
+		add(CheckFrozen, Warning, "swaps are rejected")
+	}
+	meta, err := c.GetPoolMeta(pool.PoolID)
+	if err != nil {
+		add(CheckUnreadable, Error, "get_pool_meta: %s", firstLine(err))
+		return findings
+	}
+	pool.Meta = &meta
+
+	switch {
+	case meta.TotalSupply == 0:
+		add(CheckDust, Error, "no LP supply, the reserves cannot be withdrawn")
+	case meta.TotalSupply < options.Dust:
+		add(CheckDust, Warning, "LP supply %s below %s", meta.TotalSupply, options.Dust)
+	}
+	for i, reserve := range []emuswap.UFix64{meta.Token1Amount, meta.Token2Amount} {
+		switch {
+		case reserve == 0:
+			add(CheckDust, Error, "token%d reserve is empty", i+1)
+		case reserve < options.Dust:
+			add(CheckDust, Warning, "token%d reserve %s below %s", i+1, reserve, options.Dust)
+		}
+	}
+
+	// the routes drop the .Vault suffix of the vault identifiers
+	token1, token2 := strings.TrimSuffix(meta.Token1Identifier, ".Vault"), strings.TrimSuffix(meta.Token2Identifier, ".Vault")
+	routed, err := c.GetPoolIDFromTokenIDs(token1, token2)
+	switch {
+	case err != nil:
+		add(CheckRoute, Error, "get_pool_id_from_token_ids: %s", firstLine(err))
+	case routed == nil:
+		add(CheckRoute, Error, "no route for %s/%s", token1, token2)
+	case *routed != pool.PoolID:
+		add(CheckRoute, Error, "%s/%s routes to pool %d", token1, token2, *routed)
+	}
+
+	accounted := pool.Held + pool.Staked
+	switch {
+	case accounted > meta.TotalSupply:
+		add(CheckHoldings, Error, "%s LP held and staked exceeds the supply of %s", accounted, meta.TotalSupply)
+	case options.Complete && accounted < meta.TotalSupply:
+		add(CheckHoldings, Error, "%s of the %s LP supply is held by nobody", meta.TotalSupply-accounted, meta.TotalSupply)
+	}
+
+	if options.History {
+		expected := int64(pool.Minted) - int64(pool.Burned)
+		if drift := int64(meta.TotalSupply) - expected; drift != 0 {
+			add(CheckSupply, Error, "supply %s, minted %s and burned %s in events, drift %s",
+				meta.TotalSupply, pool.Minted, pool.Burned, emuswap.Fix64(drift))
+		}
+	}
+	return findings
+}
+
+// firstLine keeps the Cadence error message out of the stack trace.
+func firstLine(err error) string {
+	message := err.Error()
+	if i := strings.Index(message, "\n"); i >= 0 {
+		message = message[:i]
+	}
+	return message
+}
+
+// Write prints the pools and the findings.
+func (r Report) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "pool\tfrozen\ttoken1\ttoken2\tsupply\theld\tstaked\tholders")
+	for _, pool := range r.Pools {
+		if pool.Meta == nil {
+			fmt.Fprintf(tw, "%d\t%t\t-\t-\t-\t%s\t%s\t%d\n", pool.PoolID, pool.Frozen, pool.Held, pool.Staked, pool.Holders)
+			continue
+		}
+		fmt.Fprintf(tw, "%d\t%t\t%s\t%s\t%s\t%s\t%s\t%d\n", pool.PoolID, pool.Frozen, pool.Meta.Token1Amount, pool.Meta.Token2Amount, pool.Meta.TotalSupply, pool.Held, pool.Staked, pool.Holders)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(r.Findings) == 0 {
+		_, err := fmt.Fprintln(w, "no findings")
+		return err
+	}
+	for _, f := range r.Findings {
+		if _, err := fmt.Fprintln(w, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package health
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/internal/emutest"
+)
+
+// TestMain runs the package tests from the repository root, where flow.json
+// and the files it references resolve.
+func TestMain(m *testing.M) {
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(emutest.Main(m))
+}
+
+var ufix = emuswap.UFix64FromFloat
+
+// moveLP withdraws amount LP tokens of pool 0 from the signer's collection and
+// either destroys them, which lowers the supply without a TokensBurned event,
+// or keeps them in storage outside the collection.
+const moveLP = `
+import FungibleTokens from 0xf8d6e0586b0a20c7
+import EmuSwap from 0xf8d6e0586b0a20c7
+
+transaction(amount: UFix64, destroyTokens: Bool) {
+  prepare(signer: AuthAccount) {
+    let collection = signer.borrow<&EmuSwap.Collection>(from: EmuSwap.LPTokensStoragePath)!
+    let tokens <- collection.borrowVault(id: 0).withdraw(amount: amount)
+    if destroyTokens {
+      destroy tokens
+    } else {
+      signer.save(<- tokens, to: /storage/hiddenLP)
+    }
+  }
+}
+`
+
+func checks(report Report) map[string]Severity {
+	found := map[string]Severity{}
+	for _, f := range report.Findings {
+		found[f.Check] = f.Severity
+	}
+	return found
+}
+
+func TestCheck(t *testing.T) {
+	o, err := emutest.Start()
+	require.NoError(t, err)
+	c := emuswap.NewClient(o)
+	flowToken, fusd, emu := emuswap.MustLookupToken("FLOW"), emuswap.MustLookupToken("FUSD"), emuswap.MustLookupToken("EMU")
+
+	c.DemoMintFlowTokens("account", ufix(10000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.FUSDSetup("account").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", ufix(10000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.DemoMintFlowTokens("account", ufix(1000.0), c.Address("user1")).Test(t).AssertSuccess()
+	c.FUSDSetup("user1").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", ufix(1000.0), c.Address("user1")).Test(t).AssertSuccess()
+
+	c.EmuSwapAdminCreateNewPool("account", flowToken.StoragePath, ufix(1000.0), fusd.StoragePath, ufix(500.0)).Test(t).AssertSuccess()
+	c.StakingAdminCreateNewFarm("account", 0).Test(t).AssertSuccess()
+	c.StakingAdminCreateRewardPool("account", emu.StoragePath, ufix(1000.0), []string{}).Test(t).AssertSuccess()
+	c.AddLiquidity("user1", flowToken, fusd, ufix(100.0), ufix(50.0)).Test(t).AssertSuccess()
+	c.AddLiquidityAndStake("user1", 0, flowToken, fusd, ufix(100.0), ufix(50.0)).Test(t).AssertSuccess()
+
+	options := Options{
+		Holders:  []flow.Address{c.Address("account"), c.Address("user1")},
+		Complete: true,
+		Dust:     DefaultDust,
+		History:  true,
+	}
+	latest := func() uint64 {
+		block, err := o.GetLatestBlock()
+		require.NoError(t, err)
+		return block.Height
+	}
+
+	options.EndHeight = latest()
+	report, err := Check(c, options)
+	require.NoError(t, err)
+	assert.Empty(t, report.Findings)
+	assert.False(t, report.Failed(true))
+	require.Len(t, report.Pools, 1)
+	pool := report.Pools[0]
+	assert.Equal(t, pool.Meta.TotalSupply, pool.Held+pool.Staked)
+	assert.Equal(t, pool.Meta.TotalSupply, pool.Minted-pool.Burned)
+	assert.NotZero(t, pool.Staked)
+	assert.Equal(t, 2, pool.Holders)
+
+	// the events find the holders on their own
+	report, err = Check(c, Options{Complete: true, Dust: DefaultDust, History: true, EndHeight: options.EndHeight})
+	require.NoError(t, err)
+	assert.Empty(t, report.Findings)
+
+	// a frozen pool and a dust pool warn
+	c.EmuSwapAdminTogglePoolFreeze("account", 0).Test(t).AssertSuccess()
+	c.EmuSwapAdminCreateNewPool("account", flowToken.StoragePath, ufix(0.00001), emu.StoragePath, ufix(1.0)).Test(t).AssertSuccess()
+	options.EndHeight = latest()
+	report, err = Check(c, options)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Severity{CheckFrozen: Warning, CheckDust: Warning}, checks(report))
+	assert.False(t, report.Failed(false))
+	assert.True(t, report.Failed(true))
+	c.EmuSwapAdminTogglePoolFreeze("account", 0).Test(t).AssertSuccess()
+	options.Dust = ufix(0.000001)
+
+	// LP tokens kept outside the collection are held by nobody we know of
+	o.Transaction(moveLP).SignProposeAndPayAs("user1").Args(o.Arguments().UFix64(0.05).Boolean(false)).Test(t).AssertSuccess()
+	options.EndHeight = latest()
+	report, err = Check(c, options)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Severity{CheckHoldings: Error}, checks(report))
+	assert.True(t, report.Failed(false))
+
+	// destroying LP tokens lowers the supply without a TokensBurned event
+	o.Transaction(moveLP).SignProposeAndPayAs("user1").Args(o.Arguments().UFix64(0.02).Boolean(true)).Test(t).AssertSuccess()
+	options.EndHeight = latest()
+	options.Complete = false
+	report, err = Check(c, options)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Severity{CheckSupply: Error}, checks(report))
+	assert.Contains(t, report.Findings[0].Message, "drift -0.02000000")
+
+	var buf bytes.Buffer
+	require.NoError(t, report.Write(&buf))
+	assert.Contains(t, buf.String(), "pool 0 error supplyDrift")
+}
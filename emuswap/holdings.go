@@ -0,0 +1,39 @@
+package emuswap
+
+import "github.com/onflow/flow-go-sdk"
+
+// Holdings is what scripts/get_portfolio.cdc reads for an address, with the
+// pools and reward pool tokens needed to value it.
+type Holdings struct {
+	// Balances by the public balance path identifier they are linked at,
+	// paths without a vault are left out.
+	Balances   map[string]UFix64 `cadence:"balances"`
+	LPBalances map[uint64]UFix64 `cadence:"lpBalances"`
+	// Stakes by farm ID.
+	Stakes map[uint64]StakeInfo `cadence:"stakes"`
+	// VestingAllowance is the EMU Vesting lets the address withdraw now.
+	VestingAllowance UFix64              `cadence:"vestingAllowance"`
+	AirdropClaims    []AirdropClaim      `cadence:"airdropClaims"`
+	Pools            map[uint64]PoolMeta `cadence:"pools"`
+	RewardPoolTokens map[uint64]string   `cadence:"rewardPoolTokens"`
+	EmuPerXEmu       UFix64              `cadence:"emuPerXEmu"`
+}
+
+// AirdropClaim is an FTAirdrop drop the address can claim.
+type AirdropClaim struct {
+	ID              uint64 `cadence:"id"`
+	Amount          UFix64 `cadence:"amount"`
+	TokenIdentifier string `cadence:"type"`
+}
+
+// ReadHoldings reads the holdings of address in one script, the wallet
+// balances of the registered tokens included.
+func (c *Client) ReadHoldings(address flow.Address) (Holdings, error) {
+	paths := make([]string, len(Tokens))
+	for i, token := range Tokens {
+		paths[i] = token.BalancePath
+	}
+	var holdings Holdings
+	err := c.script("get_portfolio", &holdings, address, paths)
+	return holdings, err
+}
@@ -0,0 +1,262 @@
+// Package keypool lets goroutines send transactions of one account at the
+// same time, as keepers and the fee sweeper all signing as the admin account
+// do. Flow orders the transactions of a proposal key by its sequence number,
+// so an account proposing with a single key has one transaction in flight.
+// Provision adds copies of the key an account is configured with, each with a
+// sequence number of its own, and a Pool leases them to goroutines. The pool
+// tracks the sequence numbers itself and reads a key's from the chain again
+// when a transaction fails on a mismatch.
+package keypool
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/flow-cli/pkg/flowkit"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+	"swap.emudao.org/test-overflow/emuswap"
+)
+
+// account is the configuration and on chain state of the account name.
+type account struct {
+	configured *flowkit.Account
+	privateKey crypto.PrivateKey
+	onChain    *flow.Account
+}
+
+func load(c *emuswap.Client, name string) (account, error) {
+	configured := c.O.Account(name)
+	key, err := configured.Key().PrivateKey()
+	if err != nil {
+		return account{}, fmt.Errorf("%s: the key pool signs with a private key in flow.json: %w", name, err)
+	}
+	onChain, err := c.O.Services.Accounts.Get(configured.Address())
+	if err != nil {
+		return account{}, err
+	}
+	return account{configured: configured, privateKey: *key, onChain: onChain}, nil
+}
+
+// poolKeys are the keys of the account the pool proposes with: full weight
+// copies of the configured key, except the configured one itself, which is
+// left to the transactions sent the usual way.
+func (a account) poolKeys() []*flow.AccountKey {
+	var keys []*flow.AccountKey
+	for _, key := range a.onChain.Keys {
+		if key.Revoked || key.Weight < flow.AccountKeyWeightThreshold || key.Index == a.configured.Key().Index() {
+			continue
+		}
+		if key.PublicKey.Equals(a.privateKey.PublicKey()) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Provision makes sure the account name has count keys for a Pool, adding the
+// missing copies of its configured key in one transaction signed by it.
+func Provision(c *emuswap.Client, name string, count int) error {
+	a, err := load(c, name)
+	if err != nil {
+		return err
+	}
+	missing := count - len(a.poolKeys())
+	if missing <= 0 {
+		return nil
+	}
+	configuredKey := a.onChain.Keys[a.configured.Key().Index()]
+	signatureAlgorithm, err := cadenceSignatureAlgorithm(configuredKey.SigAlgo)
+	if err != nil {
+		return err
+	}
+	hashAlgorithm, err := cadenceHashAlgorithm(configuredKey.HashAlgo)
+	if err != nil {
+		return err
+	}
+	publicKey := hex.EncodeToString(a.privateKey.PublicKey().Encode())
+	if err := c.Submit(c.AddProposalKeys(name, publicKey, signatureAlgorithm, hashAlgorithm, uint64(missing))).Err; err != nil {
+		return fmt.Errorf("adding %d keys to %s: %w", missing, name, err)
+	}
+	return nil
+}
+
+// cadenceSignatureAlgorithm is the raw value of the Cadence
+// SignatureAlgorithm, which numbers the algorithms differently than
+// flow-go-sdk.
+func cadenceSignatureAlgorithm(algorithm crypto.SignatureAlgorithm) (uint8, error) {
+	switch algorithm {
+	case crypto.ECDSA_P256:
+		return 1, nil
+	case crypto.ECDSA_secp256k1:
+		return 2, nil
+	}
+	return 0, fmt.Errorf("no proposal keys for %s keys", algorithm)
+}
+
+// cadenceHashAlgorithm is the raw value of the Cadence HashAlgorithm.
+func cadenceHashAlgorithm(algorithm crypto.HashAlgorithm) (uint8, error) {
+	switch algorithm {
+	case crypto.SHA2_256:
+		return 1, nil
+	case crypto.SHA3_256:
+		return 3, nil
+	}
+	return 0, fmt.Errorf("no proposal keys hashing with %s", algorithm)
+}
+
+// Key is a proposal key leased from a Pool.
+type Key struct {
+	Index int
+	// SequenceNumber is the number the next transaction proposes with.
+	SequenceNumber uint64
+	// signer is the account signing with the key
+	signer *flowkit.Account
+	// stale is set when the sequence number must be read from the chain
+	// before the key is used again
+	stale bool
+}
+
+// Options configure a Pool.
+type Options struct {
+	// Retries is how often a transaction is built again when its reference
+	// block expired before it was sent, or it failed on the sequence number.
+	Retries int
+}
+
+// DefaultOptions retry a transaction three times.
+var DefaultOptions = Options{Retries: 3}
+
+// Stats count what a Pool did.
+type Stats struct {
+	Sent    int `json:"sent"`
+	Retries int `json:"retries"`
+	// Resyncs counts the sequence numbers read from the chain after the
+	// pool was created.
+	Resyncs int `json:"resyncs"`
+}
+
+// Pool leases the proposal keys of one account.
+type Pool struct {
+	c       *emuswap.Client
+	address flow.Address
+	options Options
+	free    chan *Key
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// New creates a pool of the keys Provision added to the account name, with the
+// sequence numbers they have on chain.
+func New(c *emuswap.Client, name string, options Options) (*Pool, error) {
+	a, err := load(c, name)
+	if err != nil {
+		return nil, err
+	}
+	keys := a.poolKeys()
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s has no proposal keys, see Provision", name)
+	}
+	p := &Pool{c: c, address: a.configured.Address(), options: options, free: make(chan *Key, len(keys))}
+	for _, key := range keys {
+		signer := &flowkit.Account{}
+		signer.SetName(a.configured.Name())
+		signer.SetAddress(a.configured.Address())
+		signer.SetKey(flowkit.NewHexAccountKeyFromPrivateKey(key.Index, key.HashAlgo, a.privateKey))
+		p.free <- &Key{Index: key.Index, SequenceNumber: key.SequenceNumber, signer: signer}
+	}
+	return p, nil
+}
+
+// Size is the number of keys in the pool.
+func (p *Pool) Size() int {
+	return cap(p.free)
+}
+
+// Lease takes a key out of the pool, waiting for one to be released when all
+// are leased. The key is the caller's until it is released.
+func (p *Pool) Lease() *Key {
+	return <-p.free
+}
+
+// Release puts a leased key back.
+func (p *Pool) Release(k *Key) {
+	p.free <- k
+}
+
+// Send leases a key, sends tx with it and releases it.
+func (p *Pool) Send(tx overflow.FlowTransactionBuilder) *overflow.OverflowResult {
+	k := p.Lease()
+	defer p.Release(k)
+	return p.SendWith(k, tx)
+}
+
+// SendWith sends tx proposed, paid and signed by the account with the leased
+// key k, whichever signer tx was built for. Transactions rejected for an
+// expired reference block, and ones failing on the sequence number after it
+// was read again, are built again up to Retries times.
+func (p *Pool) SendWith(k *Key, tx overflow.FlowTransactionBuilder) *overflow.OverflowResult {
+	tx.MainSigner = k.signer
+	for attempt := 0; ; attempt++ {
+		if k.stale {
+			if err := p.resync(k); err != nil {
+				return &overflow.OverflowResult{Err: err}
+			}
+		}
+		result := p.c.SubmitWithSequence(tx, k.SequenceNumber)
+		switch {
+		case result.Err == nil:
+			k.SequenceNumber++
+			p.count(func(s *Stats) { s.Sent++ })
+			return result
+		case emuswap.IsSequenceMismatch(result.Err):
+			k.stale = true
+		case emuswap.IsExpired(result.Err):
+			// not executed, the sequence number is unchanged
+		case result.Transaction != nil:
+			// executed and failed, which uses up the sequence number
+			k.SequenceNumber++
+			p.count(func(s *Stats) { s.Sent++ })
+			return result
+		default:
+			// whether it was executed is unknown
+			k.stale = true
+			return result
+		}
+		if attempt == p.options.Retries {
+			return result
+		}
+		p.count(func(s *Stats) { s.Retries++ })
+	}
+}
+
+// resync reads the sequence number of k from the chain.
+func (p *Pool) resync(k *Key) error {
+	onChain, err := p.c.O.Services.Accounts.Get(p.address)
+	if err != nil {
+		return err
+	}
+	if k.Index >= len(onChain.Keys) {
+		return fmt.Errorf("%s has no key %d", p.address, k.Index)
+	}
+	k.SequenceNumber = onChain.Keys[k.Index].SequenceNumber
+	k.stale = false
+	p.count(func(s *Stats) { s.Resyncs++ })
+	return nil
+}
+
+func (p *Pool) count(update func(*Stats)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	update(&p.stats)
+}
+
+// Stats returns what the pool did so far.
+func (p *Pool) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stats
+}
@@ -0,0 +1,114 @@
+package keypool
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/internal/emutest"
+)
+
+// TestMain runs the package tests from the repository root, where flow.json
+// and the files it references resolve.
+func TestMain(m *testing.M) {
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(emutest.Main(m))
+}
+
+var ufix = emuswap.UFix64FromFloat
+
+func newClient(t *testing.T) *emuswap.Client {
+	o, err := emutest.Start()
+	require.NoError(t, err)
+	return emuswap.NewClient(o)
+}
+
+func TestProvision(t *testing.T) {
+	c := newClient(t)
+	_, err := New(c, "account", DefaultOptions)
+	assert.EqualError(t, err, "account has no proposal keys, see Provision")
+
+	require.NoError(t, Provision(c, "account", 3))
+	require.NoError(t, Provision(c, "account", 2))
+	onChain, err := c.O.Services.Accounts.Get(c.Address("account"))
+	require.NoError(t, err)
+	require.Len(t, onChain.Keys, 4)
+	for _, key := range onChain.Keys[1:] {
+		assert.Equal(t, onChain.Keys[0].PublicKey, key.PublicKey)
+		assert.Equal(t, 1000, key.Weight)
+	}
+
+	require.NoError(t, Provision(c, "account", 5))
+	p, err := New(c, "account", DefaultOptions)
+	require.NoError(t, err)
+	assert.Equal(t, 5, p.Size())
+	for i := 0; i < p.Size(); i++ {
+		// the configured key 0 is left out
+		assert.Equal(t, i+1, p.Lease().Index)
+	}
+}
+
+func TestPool(t *testing.T) {
+	c := newClient(t)
+	c.FUSDSetup("account").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", ufix(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.EmuSwapAdminCreateNewPool("account", "flowTokenVault", ufix(100.0), "fusdVault", ufix(50.0)).Test(t).AssertSuccess()
+
+	require.NoError(t, Provision(c, "account", 10))
+	// the in-memory emulator expires the reference block of every transaction
+	// built while another one was sealed
+	p, err := New(c, "account", Options{Retries: 100})
+	require.NoError(t, err)
+
+	fees := map[emuswap.UFix64]bool{}
+	results := make([]*overflow.OverflowResult, 100)
+	var wg sync.WaitGroup
+	for i := range results {
+		fee := ufix(0.001) + emuswap.UFix64(i)
+		fees[fee] = true
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = p.Send(c.EmuSwapAdminUpdateLPFeePercentage("account", 0, fee))
+		}(i)
+	}
+	wg.Wait()
+	for i, result := range results {
+		assert.NoError(t, result.Err, i)
+	}
+	stats := p.Stats()
+	assert.Equal(t, 100, stats.Sent)
+	assert.Zero(t, stats.Resyncs)
+
+	// every transaction used up one sequence number of a pool key
+	onChain, err := c.O.Services.Accounts.Get(c.Address("account"))
+	require.NoError(t, err)
+	var used uint64
+	for _, key := range onChain.Keys[1:] {
+		used += key.SequenceNumber
+	}
+	assert.Equal(t, uint64(100), used)
+	poolFees, err := c.GetPoolFees()
+	require.NoError(t, err)
+	assert.True(t, fees[poolFees[0]["LPFeePercentage"]], poolFees[0])
+
+	// a sequence number gone stale is read again
+	k := p.Lease()
+	k.SequenceNumber += 3
+	result := p.SendWith(k, c.EmuSwapAdminUpdateLPFeePercentage("account", 0, ufix(0.003)))
+	assert.NoError(t, result.Err)
+	assert.Equal(t, 1, p.Stats().Resyncs)
+	onChain, err = c.O.Services.Accounts.Get(c.Address("account"))
+	require.NoError(t, err)
+	assert.Equal(t, onChain.Keys[k.Index].SequenceNumber, k.SequenceNumber)
+	p.Release(k)
+
+	// the configured key still sends the usual way
+	c.EmuSwapAdminUpdateLPFeePercentage("account", 0, ufix(0.003)).Test(t).AssertSuccess()
+}
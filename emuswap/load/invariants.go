@@ -0,0 +1,138 @@
+package load
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/onflow/flow-go-sdk"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/health"
+	"swap.emudao.org/test-overflow/emuswap/provision"
+)
+
+// The invariants checked after a run.
+const (
+	InvariantSequence = "sequenceNumbers"
+	InvariantSupply   = "lpSupply"
+	InvariantAccounts = "accounts"
+	InvariantFarm     = "farmStaked"
+	InvariantValue    = "lpValue"
+	InvariantHealth   = "health"
+)
+
+// Invariant is the outcome of one check.
+type Invariant struct {
+	Name    string `json:"name"`
+	Held    bool   `json:"held"`
+	Message string `json:"message"`
+}
+
+// state is what the invariants compare before and after a run.
+type state struct {
+	meta emuswap.PoolMeta
+	// farm is the farm of the pool, nil without one
+	farm       *emuswap.FarmMeta
+	lp, stakes map[flow.Address]emuswap.UFix64
+}
+
+func read(c *emuswap.Client, poolID uint64, accounts []provision.Account) (state, error) {
+	s := state{lp: map[flow.Address]emuswap.UFix64{}, stakes: map[flow.Address]emuswap.UFix64{}}
+	var err error
+	if s.meta, err = c.GetPoolMeta(poolID); err != nil {
+		return s, err
+	}
+	if s.farm, err = c.StakingGetFarmMeta(poolID); err != nil {
+		return s, err
+	}
+	addresses := make([]flow.Address, len(accounts))
+	for i, account := range accounts {
+		addresses[i] = account.Address
+	}
+	balances, err := c.GetLPBalances(addresses)
+	if err != nil {
+		return s, err
+	}
+	for _, address := range addresses {
+		s.lp[address] = balances[address][poolID]
+		if s.farm != nil {
+			s.stakes[address] = s.farm.Stakes[address].Balance
+		}
+	}
+	return s, nil
+}
+
+// check compares the state after the run with the state before and what the
+// sealed transactions of the workers say happened in between.
+func check(c *emuswap.Client, poolID uint64, workers []*worker, before, after state, results [][]result) ([]Invariant, error) {
+	var invariants []Invariant
+	add := func(name string, held bool, format string, args ...interface{}) {
+		invariants = append(invariants, Invariant{Name: name, Held: held, Message: fmt.Sprintf(format, args...)})
+	}
+
+	var minted, burned, staked, unstaked emuswap.UFix64
+	sequence := 0
+	for _, sent := range results {
+		for _, r := range sent {
+			minted, burned, staked, unstaked = minted+r.minted, burned+r.burned, staked+r.staked, unstaked+r.unstaked
+			if emuswap.IsSequenceMismatch(r.err) {
+				sequence++
+			}
+		}
+	}
+	add(InvariantSequence, sequence == 0, "%d transactions failed on the sequence number of their proposal key", sequence)
+
+	expected := before.meta.TotalSupply + minted - burned
+	add(InvariantSupply, after.meta.TotalSupply == expected, "supply %s, %s before, %s minted and %s burned", after.meta.TotalSupply, before.meta.TotalSupply, minted, burned)
+
+	mismatched := 0
+	first := ""
+	for _, w := range workers {
+		address := w.account.Address
+		if w.lp == after.lp[address] && w.stake == after.stakes[address] {
+			continue
+		}
+		if mismatched == 0 {
+			first = fmt.Sprintf(", %s holds %s and stakes %s where its events say %s and %s", w.account.Name, after.lp[address], after.stakes[address], w.lp, w.stake)
+		}
+		mismatched++
+	}
+	add(InvariantAccounts, mismatched == 0, "%d of %d accounts hold other LP tokens than their transactions moved%s", mismatched, len(workers), first)
+
+	if after.farm != nil {
+		var sum emuswap.UFix64
+		for _, stake := range after.farm.Stakes {
+			sum += stake.Balance
+		}
+		var previous emuswap.UFix64
+		if before.farm != nil {
+			previous = before.farm.TotalStaked
+		}
+		held := after.farm.TotalStaked == sum && after.farm.TotalStaked == previous+staked-unstaked
+		add(InvariantFarm, held, "total %s, stakes sum to %s, %s before, %s staked and %s unstaked", after.farm.TotalStaked, sum, previous, staked, unstaked)
+	}
+
+	// fees and truncation only ever leave tokens in the pool
+	value := func(meta emuswap.PoolMeta) float64 {
+		return math.Sqrt(meta.Token1Amount.Float64()*meta.Token2Amount.Float64()) / meta.TotalSupply.Float64()
+	}
+	was, is := value(before.meta), value(after.meta)
+	add(InvariantValue, is >= was*(1-1e-12), "sqrt(reserve1*reserve2) per LP token went from %.8f to %.8f", was, is)
+
+	holders := make([]flow.Address, len(workers))
+	for i, w := range workers {
+		holders[i] = w.account.Address
+	}
+	report, err := health.Check(c, health.Options{Holders: holders, Dust: health.DefaultDust})
+	if err != nil {
+		return invariants, err
+	}
+	var findings []string
+	for _, f := range report.Findings {
+		if f.PoolID == poolID && f.Severity == health.Error {
+			findings = append(findings, f.Check+": "+f.Message)
+		}
+	}
+	add(InvariantHealth, len(findings) == 0, "%d errors%s", len(findings), strings.Join(append([]string{""}, findings...), ", "))
+	return invariants, nil
+}
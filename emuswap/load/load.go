@@ -0,0 +1,382 @@
+// Package load runs many accounts against one EmuSwap pool and its farm at
+// once. Every account submits a random mix of swaps, liquidity adds and
+// removes, stakes, unstakes and reward claims while the latency and outcome
+// of every transaction is recorded, and the pool, the farm and the accounts
+// are checked to add up once the load stops.
+//
+// Each account has one transaction in flight at a time. The sequence number
+// of its proposal key is read when a transaction is built, see
+// emuswap.Client.Submit, so transactions of an account never propose with the
+// same number while the accounts run in parallel.
+//
+// The invariants assume nobody else sends transactions touching the pool or
+// the farm during a run.
+package load
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bjartek/overflow/overflow"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/events"
+	"swap.emudao.org/test-overflow/emuswap/provision"
+)
+
+// Op is a kind of transaction the load is made of.
+type Op string
+
+const (
+	Swap            Op = "swap"
+	AddLiquidity    Op = "addLiquidity"
+	RemoveLiquidity Op = "removeLiquidity"
+	Stake           Op = "stake"
+	Unstake         Op = "unstake"
+	Claim           Op = "claim"
+)
+
+// Ops lists every op in the order they are reported.
+var Ops = []Op{Swap, AddLiquidity, RemoveLiquidity, Stake, Unstake, Claim}
+
+// Mix weighs how often each op is picked.
+type Mix map[Op]int
+
+// DefaultMix is mostly swaps.
+var DefaultMix = Mix{Swap: 50, AddLiquidity: 15, RemoveLiquidity: 10, Stake: 10, Unstake: 5, Claim: 10}
+
+// ParseMix reads a mix like swap:50,claim:10.
+func ParseMix(list string) (Mix, error) {
+	mix := Mix{}
+	for _, field := range strings.Split(list, ",") {
+		name, weight, ok := strings.Cut(strings.TrimSpace(field), ":")
+		if !ok {
+			return nil, fmt.Errorf("mix %q is not OP:WEIGHT", field)
+		}
+		w, err := strconv.Atoi(weight)
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("mix %q: weight must be a whole number", field)
+		}
+		mix[Op(name)] = w
+	}
+	return mix, mix.validate()
+}
+
+func (m Mix) validate() error {
+	total := 0
+	for op, weight := range m {
+		known := false
+		for _, o := range Ops {
+			known = known || o == op
+		}
+		if !known {
+			return fmt.Errorf("unknown op %q", op)
+		}
+		total += weight
+	}
+	if total == 0 {
+		return fmt.Errorf("the mix has no weight")
+	}
+	return nil
+}
+
+// pick draws an op by weight.
+func (m Mix) pick(r *rand.Rand) Op {
+	total := 0
+	for _, op := range Ops {
+		total += m[op]
+	}
+	n := r.Intn(total)
+	for _, op := range Ops {
+		if n < m[op] {
+			return op
+		}
+		n -= m[op]
+	}
+	panic("unreachable")
+}
+
+// Options configure a run.
+type Options struct {
+	// Accounts are provisioned before the load starts, one worker each.
+	Accounts provision.Spec
+	// Token1 and Token2 are the symbols of the pool, the farm staked in is
+	// the farm of the pool.
+	Token1, Token2 string
+	Mix            Mix
+	// Transactions is the number each account sends, Duration the longest
+	// the load runs. Zero is no limit, but one of them must be set.
+	Transactions int
+	Duration     time.Duration
+	// Retries is how often a transaction rejected for an expired reference
+	// block is built again before it counts as failed.
+	Retries int
+	// Seed seeds the ops and amounts of the accounts, account i draws from
+	// Seed+i.
+	Seed int64
+}
+
+// DefaultOptions run 10 accounts of 20 transactions on the FLOW/FUSD pool.
+var DefaultOptions = Options{
+	Accounts: provision.Spec{
+		Count:     10,
+		Name:      "load%d",
+		Seed:      "Load_Test_Account_seed_phrase_%06d",
+		Balances:  map[string]emuswap.UFix64{"FLOW": emuswap.UFix64FromFloat(10000), "FUSD": emuswap.UFix64FromFloat(10000)},
+		BatchSize: provision.DefaultSpec.BatchSize,
+	},
+	Token1:       "FLOW",
+	Token2:       "FUSD",
+	Mix:          DefaultMix,
+	Transactions: 20,
+	Retries:      3,
+	Seed:         1,
+}
+
+// result is one transaction sent.
+type result struct {
+	op      Op
+	latency time.Duration
+	retries int
+	err     error
+	// what the events of a sealed transaction moved, in LP tokens of the pool
+	minted, burned, staked, unstaked emuswap.UFix64
+}
+
+// target is the pool and farm under load.
+type target struct {
+	poolID         uint64
+	token1, token2 emuswap.Token
+	addresses      events.Addresses
+}
+
+// Run provisions the accounts, runs the load until every account sent its
+// transactions or the duration is up, and checks the invariants.
+func Run(c *emuswap.Client, options Options) (Report, error) {
+	if options.Transactions <= 0 && options.Duration <= 0 {
+		return Report{}, fmt.Errorf("neither a number of transactions nor a duration is set")
+	}
+	if err := options.Mix.validate(); err != nil {
+		return Report{}, err
+	}
+	t, err := find(c, options.Token1, options.Token2)
+	if err != nil {
+		return Report{}, err
+	}
+	accounts, err := provision.Provision(c, options.Accounts)
+	if err != nil {
+		return Report{}, err
+	}
+	before, err := read(c, t.poolID, accounts)
+	if err != nil {
+		return Report{}, err
+	}
+
+	var deadline time.Time
+	if options.Duration > 0 {
+		deadline = time.Now().Add(options.Duration)
+	}
+	workers := make([]*worker, len(accounts))
+	results := make([][]result, len(accounts))
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := range accounts {
+		w := &worker{
+			c:       c,
+			target:  t,
+			account: accounts[i],
+			mix:     options.Mix,
+			retries: options.Retries,
+			rand:    rand.New(rand.NewSource(options.Seed + int64(i))),
+			lp:      before.lp[accounts[i].Address],
+			stake:   before.stakes[accounts[i].Address],
+		}
+		workers[i] = w
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = w.run(options.Transactions, deadline)
+		}(i)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	after, err := read(c, t.poolID, accounts)
+	if err != nil {
+		return Report{}, err
+	}
+	report := summarize(results, elapsed)
+	report.Accounts = len(accounts)
+	report.PoolID = t.poolID
+	report.Invariants, err = check(c, t.poolID, workers, before, after, results)
+	return report, err
+}
+
+// find looks up the pool of the pair, with the tokens in pool order.
+func find(c *emuswap.Client, symbol1, symbol2 string) (target, error) {
+	t := target{}
+	var err error
+	if t.token1, err = emuswap.LookupToken(symbol1); err != nil {
+		return t, err
+	}
+	if t.token2, err = emuswap.LookupToken(symbol2); err != nil {
+		return t, err
+	}
+	if t.addresses, err = events.AddressesFor(c.O); err != nil {
+		return t, err
+	}
+	identifier1, err := t.token1.VaultIdentifier(t.addresses)
+	if err != nil {
+		return t, err
+	}
+	identifier2, err := t.token2.VaultIdentifier(t.addresses)
+	if err != nil {
+		return t, err
+	}
+	poolID, err := c.GetPoolIDFromTokenIDs(identifier1, identifier2)
+	if err != nil {
+		return t, err
+	}
+	if poolID == nil {
+		return t, fmt.Errorf("no %s/%s pool", symbol1, symbol2)
+	}
+	t.poolID = *poolID
+	meta, err := c.GetPoolMeta(t.poolID)
+	if err != nil {
+		return t, err
+	}
+	// the reserves are read in the order of the pool
+	if meta.Token1Identifier != identifier1 {
+		t.token1, t.token2 = t.token2, t.token1
+	}
+	return t, nil
+}
+
+// worker sends the transactions of one account. It tracks the LP tokens the
+// account holds and stakes from the events of its transactions, since nothing
+// else moves them.
+type worker struct {
+	c         *emuswap.Client
+	target    target
+	account   provision.Account
+	mix       Mix
+	rand      *rand.Rand
+	retries   int
+	lp, stake emuswap.UFix64
+}
+
+func (w *worker) run(transactions int, deadline time.Time) []result {
+	var results []result
+	for transactions <= 0 || len(results) < transactions {
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			break
+		}
+		results = append(results, w.send(w.mix.pick(w.rand)))
+	}
+	return results
+}
+
+// send sends op, or the op providing what op needs when the account holds no
+// LP tokens to remove or stake, or has nothing staked.
+func (w *worker) send(op Op) result {
+	switch {
+	case (op == RemoveLiquidity || op == Stake) && w.lp == 0:
+		op = AddLiquidity
+	case (op == Unstake || op == Claim) && w.stake == 0:
+		return w.send(Stake)
+	}
+
+	c, name, poolID := w.c, w.account.Name, w.target.poolID
+	var tx overflow.FlowTransactionBuilder
+	var unstaking emuswap.UFix64
+	switch op {
+	case Swap, AddLiquidity:
+		meta, err := c.GetPoolMeta(poolID)
+		if err != nil {
+			return result{op: op, err: err}
+		}
+		if op == Swap {
+			from, to, reserve := w.target.token1, w.target.token2, meta.Token1Amount
+			if w.rand.Intn(2) == 1 {
+				from, to, reserve = to, from, meta.Token2Amount
+			}
+			tx = c.Swap(name, from, to, w.fraction(reserve, 0.0005, 0.005))
+			break
+		}
+		amount1 := w.fraction(meta.Token1Amount, 0.001, 0.005)
+		// a little more token2 than the price asks for, in case a swap moves
+		// the price first, as the contract mints for the smaller share
+		amount2 := emuswap.UFix64FromFloat(amount1.Float64() * meta.Token2Amount.Float64() / meta.Token1Amount.Float64() * 1.001)
+		tx = c.AddLiquidity(name, w.target.token1, w.target.token2, amount1, amount2)
+	case RemoveLiquidity:
+		tx = c.RemoveLiquidity(name, w.target.token1, w.target.token2, w.fraction(w.lp, 0.1, 0.5))
+	case Stake:
+		tx = c.StakingUserStake(name, poolID, w.fraction(w.lp, 0.2, 0.6))
+	case Unstake:
+		unstaking = w.fraction(w.stake, 0.2, 0.6)
+		tx = c.StakingUserUnstake(name, poolID, unstaking)
+	case Claim:
+		tx = c.StakingUserClaimRewards(name, poolID)
+	}
+	r := w.submit(op, tx)
+	if r.err == nil && op == Unstake {
+		// StakingRewards declares TokensUnstaked but never emits it
+		r.unstaked = unstaking
+	}
+	w.lp = w.lp + r.minted + r.unstaked - r.burned - r.staked
+	w.stake = w.stake + r.staked - r.unstaked
+	return r
+}
+
+// fraction is a random share of amount between low and high, all of it when
+// the share rounds to nothing.
+func (w *worker) fraction(amount emuswap.UFix64, low, high float64) emuswap.UFix64 {
+	share := emuswap.UFix64FromFloat(amount.Float64() * (low + w.rand.Float64()*(high-low)))
+	if share == 0 {
+		return amount
+	}
+	return share
+}
+
+// submit sends tx, timing it from building to sealing, and sums the LP
+// tokens its events move. Transactions whose reference block expired before
+// they were sent are built again, up to retries times.
+func (w *worker) submit(op Op, tx overflow.FlowTransactionBuilder) result {
+	r := result{op: op}
+	started := time.Now()
+	sent := w.c.Submit(tx)
+	for emuswap.IsExpired(sent.Err) && r.retries < w.retries {
+		r.retries++
+		sent = w.c.Submit(tx)
+	}
+	r.latency = time.Since(started)
+	if sent.Err != nil {
+		r.err = sent.Err
+		return r
+	}
+	decoded, err := w.target.addresses.DecodeAll(sent.RawEvents)
+	if err != nil {
+		r.err = err
+		return r
+	}
+	for _, event := range decoded {
+		switch e := event.(type) {
+		case events.TokensMinted:
+			if e.TokenID == w.target.poolID {
+				r.minted += e.Amount
+			}
+		case events.TokensBurned:
+			if e.TokenID == w.target.poolID {
+				r.burned += e.Amount
+			}
+		case events.StakingRewardsTokensStaked:
+			if e.Address == w.account.Address && e.PoolID == w.target.poolID {
+				r.staked += e.AmountStaked
+			}
+		}
+	}
+	return r
+}
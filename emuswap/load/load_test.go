@@ -0,0 +1,97 @@
+package load
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/internal/emutest"
+)
+
+// TestMain runs the package tests from the repository root, where flow.json
+// and the files it references resolve.
+func TestMain(m *testing.M) {
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(emutest.Main(m))
+}
+
+var ufix = emuswap.UFix64FromFloat
+
+func TestParseMix(t *testing.T) {
+	mix, err := ParseMix("swap:3, claim:1,stake:0")
+	require.NoError(t, err)
+	assert.Equal(t, Mix{Swap: 3, Claim: 1, Stake: 0}, mix)
+
+	for _, list := range []string{"swap", "swap:x", "swap:-1", "mint:1", "swap:0"} {
+		_, err := ParseMix(list)
+		assert.Error(t, err, list)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	var swaps []result
+	for i := 1; i <= 100; i++ {
+		swaps = append(swaps, result{op: Swap, latency: time.Duration(i) * time.Millisecond})
+	}
+	swaps[0].err = errors.New("[Error Code: 1101] cadence runtime error Execution failed:\nerror: pre-condition failed: Exchanged amount too small\n --> 01cf0e2f2f715450.EmuSwap:210:16")
+	swaps[1].err = errors.New("transaction is expired: ref_height=41 final_height=43")
+	swaps[2].err = errors.New("transaction is expired: ref_height=57 final_height=58")
+	swaps[3].retries = 2
+	claims := []result{{op: Claim, latency: time.Second, err: errors.New("error: panic: Insufficient LP Tokens available to withdraw. 0.00100000")}}
+
+	report := summarize([][]result{swaps, claims}, 2*time.Second)
+	assert.Equal(t, 101, report.Sent)
+	assert.Equal(t, 4, report.Failed)
+	assert.Equal(t, 2, report.Retries)
+	assert.Equal(t, 50.5, report.Throughput)
+	assert.Equal(t, []OpStats{
+		{Op: Swap, Sent: 100, Failed: 3, Retries: 2, Latency: Latency{P50: 50 * time.Millisecond, P90: 90 * time.Millisecond, P99: 99 * time.Millisecond, Max: 100 * time.Millisecond}},
+		{Op: Claim, Sent: 1, Failed: 1, Latency: Latency{P50: time.Second, P90: time.Second, P99: time.Second, Max: time.Second}},
+	}, report.Ops)
+	assert.Equal(t, Latency{P50: 51 * time.Millisecond, P90: 91 * time.Millisecond, P99: 100 * time.Millisecond, Max: time.Second}, report.Latency)
+	assert.Equal(t, []Failure{
+		{Op: Swap, Reason: "transaction is expired: ref_height=N final_height=N", Count: 2},
+		{Op: Claim, Reason: "panic: Insufficient LP Tokens available to withdraw. N", Count: 1},
+		{Op: Swap, Reason: "pre-condition failed: Exchanged amount too small", Count: 1},
+	}, report.Failures)
+}
+
+func TestRun(t *testing.T) {
+	o, err := emutest.Start()
+	require.NoError(t, err)
+	c := emuswap.NewClient(o)
+
+	options := DefaultOptions
+	options.Accounts.Count = 8
+	options.Transactions = 25
+	_, err = Run(c, options)
+	assert.EqualError(t, err, "no FLOW/FUSD pool")
+
+	c.FUSDSetup("account").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", ufix(100000.0), c.Address("account")).Test(t).AssertSuccess()
+	// the pool holds FUSD first, the load adds liquidity in pool order
+	c.EmuSwapAdminCreateNewPool("account", "fusdVault", ufix(5000.0), "flowTokenVault", ufix(10000.0)).Test(t).AssertSuccess()
+	c.StakingAdminCreateNewFarm("account", 0).Test(t).AssertSuccess()
+	c.StakingAdminCreateRewardPool("account", "emuTokenVault", ufix(10000.0), []string{}).Test(t).AssertSuccess()
+
+	report, err := Run(c, options)
+	require.NoError(t, err)
+	assert.Equal(t, 8, report.Accounts)
+	assert.Equal(t, 200, report.Sent)
+	assert.Len(t, report.Ops, len(Ops))
+	assert.Greater(t, report.Throughput, 0.0)
+	assert.Less(t, report.Failed, report.Sent/2)
+	names := []string{}
+	for _, invariant := range report.Invariants {
+		names = append(names, invariant.Name)
+		assert.True(t, invariant.Held, "%s: %s", invariant.Name, invariant.Message)
+	}
+	assert.Equal(t, []string{InvariantSequence, InvariantSupply, InvariantAccounts, InvariantFarm, InvariantValue, InvariantHealth}, names)
+	assert.False(t, report.Broken())
+}
@@ -0,0 +1,182 @@
+package load
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Latency summarizes the time from building a transaction to its seal. The
+// JSON durations are in nanoseconds.
+type Latency struct {
+	P50 time.Duration `json:"p50"`
+	P90 time.Duration `json:"p90"`
+	P99 time.Duration `json:"p99"`
+	Max time.Duration `json:"max"`
+}
+
+// latency takes the nearest rank percentiles of latencies.
+func latency(latencies []time.Duration) Latency {
+	if len(latencies) == 0 {
+		return Latency{}
+	}
+	sorted := append([]time.Duration{}, latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := func(p int) time.Duration {
+		// ceil(p% of n), counted from 1
+		return sorted[(p*len(sorted)+99)/100-1]
+	}
+	return Latency{P50: rank(50), P90: rank(90), P99: rank(99), Max: sorted[len(sorted)-1]}
+}
+
+// OpStats are the transactions of one op.
+type OpStats struct {
+	Op     Op  `json:"op"`
+	Sent   int `json:"sent"`
+	Failed int `json:"failed"`
+	// Retries counts the times transactions were built again after their
+	// reference block expired.
+	Retries int     `json:"retries"`
+	Latency Latency `json:"latency"`
+}
+
+// Failure counts the transactions of an op that failed for one reason.
+type Failure struct {
+	Op     Op     `json:"op"`
+	Reason string `json:"reason"`
+	Count  int    `json:"count"`
+}
+
+// Report is the outcome of a run.
+type Report struct {
+	PoolID   uint64        `json:"poolID"`
+	Accounts int           `json:"accounts"`
+	Elapsed  time.Duration `json:"elapsed"`
+	Sent     int           `json:"sent"`
+	Failed   int           `json:"failed"`
+	Retries  int           `json:"retries"`
+	// Throughput is the transactions sealed or rejected per second.
+	Throughput float64     `json:"throughput"`
+	Latency    Latency     `json:"latency"`
+	Ops        []OpStats   `json:"ops"`
+	Failures   []Failure   `json:"failures"`
+	Invariants []Invariant `json:"invariants"`
+}
+
+// Broken reports whether an invariant does not hold.
+func (r Report) Broken() bool {
+	for _, invariant := range r.Invariants {
+		if !invariant.Held {
+			return true
+		}
+	}
+	return false
+}
+
+func summarize(results [][]result, elapsed time.Duration) Report {
+	report := Report{Elapsed: elapsed, Ops: []OpStats{}, Failures: []Failure{}}
+	var all []time.Duration
+	byOp := map[Op][]time.Duration{}
+	stats := map[Op]*OpStats{}
+	failures := map[Failure]int{}
+	for _, sent := range results {
+		for _, r := range sent {
+			all = append(all, r.latency)
+			byOp[r.op] = append(byOp[r.op], r.latency)
+			s, ok := stats[r.op]
+			if !ok {
+				s = &OpStats{Op: r.op}
+				stats[r.op] = s
+			}
+			s.Sent++
+			s.Retries += r.retries
+			report.Sent++
+			report.Retries += r.retries
+			if r.err != nil {
+				s.Failed++
+				report.Failed++
+				failures[Failure{Op: r.op, Reason: reason(r.err)}]++
+			}
+		}
+	}
+	if elapsed > 0 {
+		report.Throughput = float64(report.Sent) / elapsed.Seconds()
+	}
+	report.Latency = latency(all)
+	for _, op := range Ops {
+		if s, ok := stats[op]; ok {
+			s.Latency = latency(byOp[op])
+			report.Ops = append(report.Ops, *s)
+		}
+	}
+	for failure, count := range failures {
+		failure.Count = count
+		report.Failures = append(report.Failures, failure)
+	}
+	sort.Slice(report.Failures, func(i, j int) bool {
+		a, b := report.Failures[i], report.Failures[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Op != b.Op {
+			return a.Op < b.Op
+		}
+		return a.Reason < b.Reason
+	})
+	return report
+}
+
+// numbers are replaced in reasons, so amounts and heights do not tell
+// failures of the same cause apart.
+var numbers = regexp.MustCompile(`\b\d+(\.\d+)?\b`)
+
+// reason is what failures of the same cause have in common: the first line
+// of the Cadence error, or else the first line of err, with numbers as N.
+func reason(err error) string {
+	lines := strings.Split(err.Error(), "\n")
+	first := strings.TrimSpace(lines[0])
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "error: ") {
+			first = strings.TrimPrefix(line, "error: ")
+			break
+		}
+	}
+	return numbers.ReplaceAllString(first, "N")
+}
+
+// Write prints the report.
+func (r Report) Write(w io.Writer) error {
+	fmt.Fprintf(w, "pool %d, %d accounts, %d transactions in %s, %.1f/s, %d failed, %d retries\n",
+		r.PoolID, r.Accounts, r.Sent, r.Elapsed.Round(time.Millisecond), r.Throughput, r.Failed, r.Retries)
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "op\tsent\tfailed\tp50\tp90\tp99\tmax")
+	row := func(name string, sent, failed int, l Latency) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\t%s\n", name, sent, failed,
+			l.P50.Round(time.Millisecond), l.P90.Round(time.Millisecond), l.P99.Round(time.Millisecond), l.Max.Round(time.Millisecond))
+	}
+	for _, s := range r.Ops {
+		row(string(s.Op), s.Sent, s.Failed, s.Latency)
+	}
+	row("all", r.Sent, r.Failed, r.Latency)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, f := range r.Failures {
+		fmt.Fprintf(w, "%d %s failed: %s\n", f.Count, f.Op, f.Reason)
+	}
+	for _, invariant := range r.Invariants {
+		status := "holds"
+		if !invariant.Held {
+			status = "BROKEN"
+		}
+		if _, err := fmt.Fprintf(w, "%s %s: %s\n", invariant.Name, status, invariant.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+// Package metrics exports gauges in the Prometheus text format. Collectors
+// read the chain when /metrics is scraped:
+//
+//	exporter := metrics.NewExporter()
+//	exporter.Register(rewards.Collector(c))
+//	http.ListenAndServe(":9102", exporter)
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Sample is one value of a gauge.
+type Sample struct {
+	Name   string
+	Help   string
+	Labels map[string]string
+	Value  float64
+}
+
+// Collector returns the samples at the time of a scrape.
+type Collector func() ([]Sample, error)
+
+// Exporter serves the samples of its collectors.
+type Exporter struct {
+	// mu serializes scrapes, overflow is not safe for concurrent scripts.
+	mu         sync.Mutex
+	collectors []Collector
+}
+
+// NewExporter returns an exporter without collectors.
+func NewExporter() *Exporter {
+	return &Exporter{}
+}
+
+// Register adds a collector.
+func (e *Exporter) Register(collector Collector) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.collectors = append(e.collectors, collector)
+}
+
+// Collect runs every collector.
+func (e *Exporter) Collect() ([]Sample, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var samples []Sample
+	for _, collector := range e.collectors {
+		collected, err := collector()
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, collected...)
+	}
+	return samples, nil
+}
+
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/metrics" {
+		http.NotFound(w, r)
+		return
+	}
+	samples, err := e.Collect()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	Write(w, samples)
+}
+
+// Write prints samples as gauges in the text format, grouped by name in the
+// order the names first appear.
+func Write(w io.Writer, samples []Sample) error {
+	var names []string
+	byName := map[string][]Sample{}
+	for _, sample := range samples {
+		if _, ok := byName[sample.Name]; !ok {
+			names = append(names, sample.Name)
+		}
+		byName[sample.Name] = append(byName[sample.Name], sample)
+	}
+	for _, name := range names {
+		group := byName[name]
+		if group[0].Help != "" {
+			if _, err := fmt.Fprintf(w, "# HELP %s %s\n", name, escape(group[0].Help, false)); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "# TYPE %s gauge\n", name); err != nil {
+			return err
+		}
+		for _, sample := range group {
+			if _, err := fmt.Fprintf(w, "%s%s %s\n", name, labels(sample.Labels), strconv.FormatFloat(sample.Value, 'g', -1, 64)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func labels(values map[string]string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = fmt.Sprintf("%s=\"%s\"", key, escape(values[key], true))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// escape escapes backslashes and newlines, and quotes in label values.
+func escape(s string, quotes bool) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	if quotes {
+		s = strings.ReplaceAll(s, `"`, `\"`)
+	}
+	return s
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, []Sample{
+		{Name: "a", Help: "first\nline", Labels: map[string]string{"z": "1", "b": `say "hi"\`}, Value: 1.5},
+		{Name: "b", Value: 2},
+		{Name: "a", Labels: map[string]string{"z": "2"}, Value: 3},
+	}))
+	assert.Equal(t, `# HELP a first\nline
+# TYPE a gauge
+a{b="say \"hi\"\\",z="1"} 1.5
+a{z="2"} 3
+# TYPE b gauge
+b 2
+`, buf.String())
+}
+
+func TestExporter(t *testing.T) {
+	exporter := NewExporter()
+	exporter.Register(func() ([]Sample, error) { return []Sample{{Name: "up", Value: 1}}, nil })
+
+	w := httptest.NewRecorder()
+	exporter.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(w.Result().Body)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "# TYPE up gauge\nup 1\n", string(body))
+
+	w = httptest.NewRecorder()
+	exporter.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, 404, w.Code)
+
+	exporter.Register(func() ([]Sample, error) { return nil, errors.New("unreachable") })
+	w = httptest.NewRecorder()
+	exporter.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, 500, w.Code)
+}
@@ -0,0 +1,204 @@
+// Package mev finds sandwiched swaps. EmuSwap swaps take no minimum output, so
+// a trader who sees a swap coming can swap the same way before it, pushing the
+// price against it, and swap back after it at the better price. The detector
+// looks for these patterns in the swaps of each pool, names the attacker and
+// the victims, and estimates what the victims lost by replaying the swaps on
+// the off-chain pool model with and without the attacker's.
+package mev
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/onflow/flow-go-sdk"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/events"
+	"swap.emudao.org/test-overflow/emuswap/feepolicy"
+)
+
+// Swap is one swap of a pool recovered from the chain.
+type Swap struct {
+	Height           uint64
+	TransactionID    flow.Identifier
+	TransactionIndex int
+	EventIndex       int
+	PoolID           uint64
+	Side             emuswap.Side
+	// Priced is the input after fees, the input amount of the Trade event.
+	Priced emuswap.UFix64
+	// AmountOut is paid out to the trader.
+	AmountOut emuswap.UFix64
+	// Trader is the first authorizer of the transaction, its payer when the
+	// transaction has no authorizers.
+	Trader flow.Address
+}
+
+func swapFromEvent(located events.Located, poolID uint64, side emuswap.Side, token1Amount, token2Amount emuswap.UFix64) (Swap, error) {
+	s := Swap{
+		Height:           located.Height,
+		TransactionID:    located.TransactionID,
+		TransactionIndex: located.TransactionIndex,
+		EventIndex:       located.EventIndex,
+		PoolID:           poolID,
+		Side:             side,
+	}
+	switch side {
+	case emuswap.Token1ForToken2:
+		s.Priced, s.AmountOut = token1Amount, token2Amount
+	case emuswap.Token2ForToken1:
+		s.Priced, s.AmountOut = token2Amount, token1Amount
+	default:
+		return Swap{}, fmt.Errorf("invalid swap side %d", side)
+	}
+	return s, nil
+}
+
+// Index is the swaps found between two block heights.
+type Index struct {
+	Swaps []Swap
+	// Unattributed counts Trade events whose pool could not be told.
+	Unattributed int
+}
+
+// FetchSwaps collects the swaps between two block heights, in chain order,
+// from their Trade events, attributed to pools like
+// feepolicy.FetchPoolTrades does.
+func FetchSwaps(c *emuswap.Client, startHeight, endHeight uint64) (Index, error) {
+	found, unattributed, err := feepolicy.FetchPoolTrades(c, startHeight, endHeight)
+	if err != nil {
+		return Index{}, err
+	}
+	index := Index{Unattributed: unattributed}
+	for _, trade := range found {
+		e := trade.Event.(events.Trade)
+		s, err := swapFromEvent(trade.Located, trade.PoolID, emuswap.Side(e.Side), e.Token1Amount, e.Token2Amount)
+		if err != nil {
+			return Index{}, err
+		}
+		s.Trader = trade.Trader
+		index.Swaps = append(index.Swaps, s)
+	}
+	return index, nil
+}
+
+// Sandwich is a front run and a back run by the same trader around at least
+// one victim in the same pool.
+type Sandwich struct {
+	PoolID   uint64
+	Attacker flow.Address
+	// Front is in the direction of the victims, Back in the other.
+	Front Swap
+	Back  Swap
+	// Between are the swaps of the pool between Front and Back, the victims
+	// among them.
+	Between []Swap
+}
+
+// Victims are the swaps of other traders in the direction of the front run.
+func (s Sandwich) Victims() []Swap {
+	var victims []Swap
+	for _, swap := range s.Between {
+		if swap.Trader != s.Attacker && swap.Side == s.Front.Side {
+			victims = append(victims, swap)
+		}
+	}
+	return victims
+}
+
+// Detect finds sandwiches in swaps, which must be in chain order. A sandwich
+// spans at most window blocks after its front run: 0 keeps to one block, as
+// on networks where the attacker needs the transactions ordered within a
+// block. A swap is part of at most one sandwich as front or back run.
+func Detect(swaps []Swap, window uint64) []Sandwich {
+	var poolIDs []uint64
+	byPool := map[uint64][]Swap{}
+	for _, s := range swaps {
+		if _, ok := byPool[s.PoolID]; !ok {
+			poolIDs = append(poolIDs, s.PoolID)
+		}
+		byPool[s.PoolID] = append(byPool[s.PoolID], s)
+	}
+
+	var sandwiches []Sandwich
+	for _, poolID := range poolIDs {
+		pool := byPool[poolID]
+		used := make([]bool, len(pool))
+		for i, front := range pool {
+			if used[i] {
+				continue
+			}
+			victims := 0
+			for k := i + 1; k < len(pool) && pool[k].Height-front.Height <= window; k++ {
+				s := pool[k]
+				if s.Trader != front.Trader {
+					if s.Side == front.Side {
+						victims++
+					}
+					continue
+				}
+				if s.Side == front.Side || used[k] {
+					// the attacker adding to the front run, or closing an earlier sandwich
+					continue
+				}
+				if victims > 0 {
+					used[i], used[k] = true, true
+					sandwiches = append(sandwiches, Sandwich{
+						PoolID:   poolID,
+						Attacker: front.Trader,
+						Front:    front,
+						Back:     s,
+						Between:  append([]Swap(nil), pool[i+1:k]...),
+					})
+				}
+				break
+			}
+		}
+	}
+	return sandwiches
+}
+
+// ErrNoEstimate is returned when the reserves of a pool cannot be recovered
+// from the swaps of a sandwich.
+var ErrNoEstimate = errors.New("cannot recover the pool reserves")
+
+// Reserves recovers the reserves of the pool before the front run. The front
+// run and the swap after it each fix one point of the constant product curve,
+// which is enough to solve for both reserves. Liquidity added or removed
+// between them, or pool fees other than fees, make the result wrong.
+func (s Sandwich) Reserves(fees feepolicy.Fees) (emuswap.Pool, error) {
+	if len(s.Between) == 0 {
+		return emuswap.Pool{}, ErrNoEstimate
+	}
+	front, next := s.Front, s.Between[0]
+	gross, err := feepolicy.GrossAmount(front.Priced, fees)
+	if err != nil {
+		return emuswap.Pool{}, err
+	}
+	daoFee, err := gross.Mul(fees.DAO)
+	if err != nil {
+		return emuswap.Pool{}, err
+	}
+
+	// in and out are the reserves of the front run's input and output token
+	aPriced, aOut, aNet := front.Priced.Float64(), front.AmountOut.Float64(), (gross - daoFee).Float64()
+	vPriced, vOut := next.Priced.Float64(), next.AmountOut.Float64()
+	var in float64
+	if next.Side == front.Side {
+		in = vOut * (aNet + vPriced) / (aOut*vPriced/aPriced - vOut)
+	} else {
+		in = vPriced * (aNet - vOut) / (vOut*aOut/aPriced - vPriced)
+	}
+	out := aOut * (in + aPriced) / aPriced
+	if in <= 0 || out <= aOut || math.IsInf(in, 0) || math.IsNaN(in) {
+		return emuswap.Pool{}, ErrNoEstimate
+	}
+
+	pool := emuswap.Pool{LPFeePercentage: fees.LP, DAOFeePercentage: fees.DAO}
+	if front.Side == emuswap.Token1ForToken2 {
+		pool.Token1Amount, pool.Token2Amount = emuswap.UFix64FromFloat(in), emuswap.UFix64FromFloat(out)
+	} else {
+		pool.Token2Amount, pool.Token1Amount = emuswap.UFix64FromFloat(in), emuswap.UFix64FromFloat(out)
+	}
+	return pool, nil
+}
@@ -0,0 +1,150 @@
+package mev
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/feepolicy"
+	"swap.emudao.org/test-overflow/internal/emutest"
+)
+
+// TestMain runs the package tests from the repository root, where flow.json
+// and the files it references resolve.
+func TestMain(m *testing.M) {
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(emutest.Main(m))
+}
+
+var ufix = emuswap.UFix64FromFloat
+
+func TestDetect(t *testing.T) {
+	a, b, c := flow.HexToAddress("01"), flow.HexToAddress("02"), flow.HexToAddress("03")
+	swap := func(height, pool uint64, side emuswap.Side, trader flow.Address) Swap {
+		return Swap{Height: height, PoolID: pool, Side: side, Trader: trader}
+	}
+	one, two := emuswap.Token1ForToken2, emuswap.Token2ForToken1
+
+	sandwiches := Detect([]Swap{
+		swap(1, 0, one, a),
+		swap(1, 1, one, b), // another pool
+		swap(1, 0, one, b),
+		swap(1, 0, two, c), // not a victim, in between
+		swap(1, 0, two, a),
+		// the victim comes after the back run
+		swap(2, 0, two, b),
+		swap(2, 0, one, b),
+		swap(2, 0, two, b),
+		// too far apart for a window of 1
+		swap(3, 0, two, c),
+		swap(4, 0, two, a),
+		swap(5, 0, one, c),
+		// a swap the other way is no victim
+		swap(6, 1, two, a),
+		swap(6, 1, one, b),
+		swap(6, 1, two, a),
+	}, 1)
+
+	require.Len(t, sandwiches, 1)
+	s := sandwiches[0]
+	assert.Equal(t, a, s.Attacker)
+	assert.Equal(t, uint64(0), s.PoolID)
+	assert.Len(t, s.Between, 2)
+	assert.Equal(t, []Swap{swap(1, 0, one, b)}, s.Victims())
+
+	assert.Len(t, Detect([]Swap{swap(1, 0, one, c), swap(3, 0, one, b), swap(4, 0, two, c)}, 3), 1)
+	assert.Empty(t, Detect([]Swap{swap(1, 0, one, c), swap(3, 0, one, b), swap(4, 0, two, c)}, 2))
+}
+
+// TestSandwich stages a sandwich on the emulator: account provides the
+// liquidity, user1 swaps before and after user2, and user3 trades on its own.
+// account swaps on a second pool in between.
+func TestSandwich(t *testing.T) {
+	o, err := emutest.Start()
+	require.NoError(t, err)
+	c := emuswap.NewClient(o)
+	flowToken, fusd, emu := emuswap.MustLookupToken("FLOW"), emuswap.MustLookupToken("FUSD"), emuswap.MustLookupToken("EMU")
+
+	c.DemoMintFlowTokens("account", ufix(10000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.FUSDSetup("account").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", ufix(10000.0), c.Address("account")).Test(t).AssertSuccess()
+	for _, user := range []string{"user1", "user2", "user3"} {
+		c.DemoMintFlowTokens("account", ufix(1000.0), c.Address(user)).Test(t).AssertSuccess()
+		c.FUSDSetup(user).Test(t).AssertSuccess()
+		c.DemoMintFUSD("account", ufix(1000.0), c.Address(user)).Test(t).AssertSuccess()
+	}
+	c.EmuSwapAdminCreateNewPool("account", flowToken.StoragePath, ufix(1000.0), fusd.StoragePath, ufix(500.0)).Test(t).AssertSuccess()
+	c.EmuSwapAdminCreateNewPool("account", emu.StoragePath, ufix(1000.0), fusd.StoragePath, ufix(500.0)).Test(t).AssertSuccess()
+
+	fees, err := feepolicy.BaselineFees(c)
+	require.NoError(t, err)
+	before, err := c.LoadPool(0)
+	require.NoError(t, err)
+	start, err := o.GetLatestBlock()
+	require.NoError(t, err)
+
+	c.Swap("user1", flowToken, fusd, ufix(100.0)).Test(t).AssertSuccess()
+	c.Swap("account", emu, fusd, ufix(10.0)).Test(t).AssertSuccess()
+	c.Swap("user2", flowToken, fusd, ufix(20.0)).Test(t).AssertSuccess()
+	// user1 sells back all it bought
+	front := before
+	bought, err := front.Swap(emuswap.Token1ForToken2, ufix(100.0))
+	require.NoError(t, err)
+	c.Swap("user1", fusd, flowToken, bought.AmountOut).Test(t).AssertSuccess()
+	c.Swap("user3", flowToken, fusd, ufix(5.0)).Test(t).AssertSuccess()
+	c.Swap("user3", fusd, flowToken, ufix(2.0)).Test(t).AssertSuccess()
+
+	end, err := o.GetLatestBlock()
+	require.NoError(t, err)
+	index, err := FetchSwaps(c, start.Height, end.Height)
+	require.NoError(t, err)
+	assert.Zero(t, index.Unattributed)
+	require.Len(t, index.Swaps, 6)
+	assert.Equal(t, c.Address("user1"), index.Swaps[0].Trader)
+	assert.Equal(t, c.Address("account"), index.Swaps[1].Trader)
+	assert.Equal(t, uint64(1), index.Swaps[1].PoolID)
+	assert.Equal(t, c.Address("user2"), index.Swaps[2].Trader)
+	for _, s := range append(index.Swaps[:1:1], index.Swaps[2:]...) {
+		assert.Equal(t, uint64(0), s.PoolID)
+	}
+
+	// the emulator puts every transaction in its own block
+	assert.Empty(t, Scan(index, 0, fees).Findings)
+	report := Scan(index, 3, fees)
+	require.Len(t, report.Findings, 1)
+	finding := report.Findings[0]
+	require.NoError(t, finding.Err)
+	assert.Equal(t, c.Address("user1"), finding.Attacker)
+	assert.InDelta(t, before.Token1Amount.Float64(), finding.Reserves.Token1Amount.Float64(), 1e-3)
+	assert.InDelta(t, before.Token2Amount.Float64(), finding.Reserves.Token2Amount.Float64(), 1e-3)
+	assert.Equal(t, ufix(100.0), finding.Paid)
+	assert.Greater(t, finding.Profit(), 0.0)
+
+	// the loss is what the victim would have got from the untouched pool
+	require.Len(t, finding.Victims, 1)
+	victim := finding.Victims[0]
+	assert.Equal(t, c.Address("user2"), victim.Trader)
+	assert.Equal(t, ufix(20.0), victim.AmountIn)
+	alone := before
+	expected, err := alone.Swap(emuswap.Token1ForToken2, ufix(20.0))
+	require.NoError(t, err)
+	trueLoss := expected.AmountOut.Float64() - victim.AmountOut.Float64()
+	assert.Greater(t, trueLoss, 1.0)
+	assert.InDelta(t, trueLoss, victim.Loss().Float64(), 1e-4)
+
+	alerts := report.Alerts(ufix(1.0))
+	require.Len(t, alerts, 1)
+	assert.Equal(t, victim.Trader, alerts[0].Victim)
+	assert.Empty(t, report.Alerts(ufix(100.0)))
+
+	var buf bytes.Buffer
+	require.NoError(t, report.Write(&buf))
+	assert.Contains(t, buf.String(), "1 sandwiches")
+	assert.Contains(t, buf.String(), victim.Trader.String())
+}
@@ -0,0 +1,209 @@
+package mev
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/onflow/flow-go-sdk"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/feepolicy"
+)
+
+// Victim is a sandwiched swap and what it would have paid out without the
+// attacker's swaps.
+type Victim struct {
+	Swap
+	// AmountIn is the amount the victim paid.
+	AmountIn emuswap.UFix64
+	// Actual and Expected are the outputs the pool model gives with and
+	// without the attacker's swaps.
+	Actual   emuswap.UFix64
+	Expected emuswap.UFix64
+}
+
+// Loss is the output the victim did not get, in the output token of its side.
+func (v Victim) Loss() emuswap.UFix64 {
+	if v.Expected < v.Actual {
+		return 0
+	}
+	return v.Expected - v.Actual
+}
+
+// Finding is a detected sandwich and its estimated impact.
+type Finding struct {
+	Sandwich
+	// Reserves are the recovered reserves of the pool before the front run.
+	Reserves emuswap.Pool
+	Victims  []Victim
+	// Paid is what the attacker swapped in on the front side, Received what
+	// the back run paid out, both in the input token of the front run.
+	Paid     emuswap.UFix64
+	Received emuswap.UFix64
+	// Err is set when the losses could not be estimated, the sandwich is
+	// reported anyway.
+	Err error
+}
+
+// Profit is what the attacker made in the input token of the front run,
+// negative for a loss. A back run that does not close the whole position
+// understates it.
+func (f Finding) Profit() float64 {
+	return f.Received.Float64() - f.Paid.Float64()
+}
+
+// Estimate replays the sandwich on the recovered reserves, once as it
+// happened and once without the attacker's swaps.
+func (s Sandwich) Estimate(fees feepolicy.Fees) Finding {
+	finding := Finding{Sandwich: s, Received: s.Back.AmountOut}
+	for _, swap := range append([]Swap{s.Front}, s.Between...) {
+		if swap.Trader != s.Attacker || swap.Side != s.Front.Side {
+			continue
+		}
+		gross, err := feepolicy.GrossAmount(swap.Priced, fees)
+		if err != nil {
+			finding.Err = err
+			return finding
+		}
+		finding.Paid += gross
+	}
+
+	reserves, err := s.Reserves(fees)
+	if err != nil {
+		finding.Err = err
+		return finding
+	}
+	finding.Reserves = reserves
+
+	actual, expected := reserves, reserves
+	if _, err := replay(&actual, s.Front, fees); err != nil {
+		finding.Err = err
+		return finding
+	}
+	for _, swap := range s.Between {
+		result, err := replay(&actual, swap, fees)
+		if err != nil {
+			finding.Err = err
+			return finding
+		}
+		if swap.Trader == s.Attacker {
+			continue
+		}
+		alone, err := replay(&expected, swap, fees)
+		if err != nil {
+			finding.Err = err
+			return finding
+		}
+		if swap.Side == s.Front.Side {
+			finding.Victims = append(finding.Victims, Victim{
+				Swap:     swap,
+				AmountIn: result.AmountIn,
+				Actual:   result.AmountOut,
+				Expected: alone.AmountOut,
+			})
+		}
+	}
+	return finding
+}
+
+// replay applies swap to pool with the amount its trader paid.
+func replay(pool *emuswap.Pool, swap Swap, fees feepolicy.Fees) (emuswap.SwapResult, error) {
+	gross, err := feepolicy.GrossAmount(swap.Priced, fees)
+	if err != nil {
+		return emuswap.SwapResult{}, err
+	}
+	return pool.Swap(swap.Side, gross)
+}
+
+// Report is the outcome of a scan.
+type Report struct {
+	// StartHeight and EndHeight are the first and last block with a swap.
+	StartHeight uint64
+	EndHeight   uint64
+	Window      uint64
+	Fees        feepolicy.Fees
+	Swaps       int
+	// Unattributed counts Trade events whose pool could not be told, they
+	// are not scanned.
+	Unattributed int
+	Findings     []Finding
+}
+
+// Scan detects the sandwiches among the swaps of an index and estimates them
+// with the given fees.
+func Scan(index Index, window uint64, fees feepolicy.Fees) Report {
+	report := Report{Window: window, Fees: fees, Swaps: len(index.Swaps), Unattributed: index.Unattributed}
+	if len(index.Swaps) > 0 {
+		report.StartHeight = index.Swaps[0].Height
+		report.EndHeight = index.Swaps[len(index.Swaps)-1].Height
+	}
+	for _, sandwich := range Detect(index.Swaps, window) {
+		report.Findings = append(report.Findings, sandwich.Estimate(fees))
+	}
+	return report
+}
+
+// Alert is one victim losing at least the alert threshold.
+type Alert struct {
+	Height   uint64
+	PoolID   uint64
+	Attacker flow.Address
+	Victim   flow.Address
+	Side     emuswap.Side
+	Loss     emuswap.UFix64
+	// Unestimated is set when the loss could not be estimated.
+	Unestimated bool
+}
+
+func (a Alert) String() string {
+	if a.Unestimated {
+		return fmt.Sprintf("pool %d block %d: %s sandwiched %s (%s), loss unknown", a.PoolID, a.Height, a.Attacker, a.Victim, a.Side)
+	}
+	return fmt.Sprintf("pool %d block %d: %s sandwiched %s (%s), loss %s", a.PoolID, a.Height, a.Attacker, a.Victim, a.Side, a.Loss)
+}
+
+// Alerts lists the victims that lost at least minLoss of their output token.
+// Sandwiches whose losses could not be estimated alert for every victim.
+func (r Report) Alerts(minLoss emuswap.UFix64) []Alert {
+	var alerts []Alert
+	for _, f := range r.Findings {
+		if f.Err != nil {
+			for _, victim := range f.Sandwich.Victims() {
+				alerts = append(alerts, Alert{Height: victim.Height, PoolID: f.PoolID, Attacker: f.Attacker, Victim: victim.Trader, Side: victim.Side, Unestimated: true})
+			}
+			continue
+		}
+		for _, victim := range f.Victims {
+			if victim.Loss() < minLoss {
+				continue
+			}
+			alerts = append(alerts, Alert{Height: victim.Height, PoolID: f.PoolID, Attacker: f.Attacker, Victim: victim.Trader, Side: victim.Side, Loss: victim.Loss()})
+		}
+	}
+	return alerts
+}
+
+// Write prints the report, one line per victim.
+func (r Report) Write(w io.Writer) error {
+	fmt.Fprintf(w, "blocks %d-%d, %d swaps, window %d blocks, fees %s\n", r.StartHeight, r.EndHeight, r.Swaps, r.Window, r.Fees)
+	if r.Unattributed > 0 {
+		fmt.Fprintf(w, "%d Trade events whose pool could not be told were skipped\n", r.Unattributed)
+	}
+	fmt.Fprintf(w, "%d sandwiches\n", len(r.Findings))
+	if len(r.Findings) == 0 {
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "pool\tfront\tback\tattacker\tprofit\tvictim\tblock\tside\tpaid\treceived\texpected\tloss")
+	for _, f := range r.Findings {
+		head := fmt.Sprintf("%d\t%d\t%d\t%s\t%.8f", f.PoolID, f.Front.Height, f.Back.Height, f.Attacker, f.Profit())
+		if f.Err != nil {
+			fmt.Fprintf(tw, "%s\t%s\n", head, f.Err)
+			continue
+		}
+		for _, v := range f.Victims {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\n", head, v.Trader, v.Height, v.Side, v.AmountIn, v.AmountOut, v.Expected, v.Loss())
+		}
+	}
+	return tw.Flush()
+}
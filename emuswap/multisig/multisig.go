@@ -0,0 +1,324 @@
+// Package multisig proposes, co-signs and executes EmuSwap and StakingRewards
+// admin operations through the EmuMultiSig contract. Payloads are signed off
+// chain with the multisig keys and can be sent by any account.
+//
+// The actions cover the fee, freeze, farm, farm weight and access NFT admin
+// operations, the fee sweep, and the treasury: topping up reward pools and
+// sending tokens from the vaults of the admin account. Creating swap pools
+// and reward pools takes tokens and dictionaries a payload cannot hold, they
+// stay with the keys of the admin account.
+package multisig
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+	"swap.emudao.org/test-overflow/emuswap"
+)
+
+// Threshold is the total key weight OnChainMultiSig requires for execution.
+const Threshold = emuswap.UFix64(1000 * emuswap.UFix64Factor)
+
+// Weight is the key weight making any m keys reach Threshold while m-1 keys
+// stay below it.
+func Weight(m int) emuswap.UFix64 {
+	if m < 1 {
+		panic(fmt.Sprintf("multisig threshold %d", m))
+	}
+	// round up so m * weight >= Threshold
+	return (Threshold + emuswap.UFix64(m) - 1) / emuswap.UFix64(m)
+}
+
+// Action is an admin operation, see contracts/EmuMultiSig.cdc for the methods.
+type Action struct {
+	Method string
+	Args   []interface{}
+}
+
+func UpdateLPFeePercentage(poolID uint64, feePercentage emuswap.UFix64) Action {
+	return Action{Method: "updateLPFeePercentage", Args: []interface{}{poolID, feePercentage}}
+}
+
+func UpdateDAOFeePercentage(poolID uint64, feePercentage emuswap.UFix64) Action {
+	return Action{Method: "updateDAOFeePercentage", Args: []interface{}{poolID, feePercentage}}
+}
+
+func TogglePoolFreeze(poolID uint64) Action {
+	return Action{Method: "togglePoolFreeze", Args: []interface{}{poolID}}
+}
+
+// WithdrawFees sends the collected fees to the DAO.
+func WithdrawFees() Action {
+	return Action{Method: "sendEmuFeesToDAO"}
+}
+
+func CreateFarm(poolID uint64) Action {
+	return Action{Method: "createFarm", Args: []interface{}{poolID}}
+}
+
+func UpdateFarmWeight(rewardPoolID, farmID uint64, weight emuswap.UFix64) Action {
+	return Action{Method: "updateFarmWeightForRewardPool", Args: []interface{}{rewardPoolID, farmID, weight}}
+}
+
+func AddAccessNFT(rewardPoolID uint64, nftIdentifier string) Action {
+	return Action{Method: "addNFT", Args: []interface{}{rewardPoolID, nftIdentifier}}
+}
+
+func RemoveAccessNFT(rewardPoolID, index uint64) Action {
+	return Action{Method: "removeNFT", Args: []interface{}{rewardPoolID, index}}
+}
+
+// DepositRewardTokens tops up a reward pool from the vault the admin account
+// stores at storageID.
+func DepositRewardTokens(rewardPoolID uint64, storageID string, amount emuswap.UFix64) Action {
+	return Action{Method: "depositRewardTokens", Args: []interface{}{rewardPoolID, storageID, amount}}
+}
+
+// SendTokens sends amount from the vault the admin account stores at
+// storageID to the receiver to publishes at receiverPath.
+func SendTokens(storageID string, amount emuswap.UFix64, to flow.Address, receiverPath string) Action {
+	return Action{Method: "sendTokens", Args: []interface{}{storageID, amount, to, receiverPath}}
+}
+
+// argumentTypes are the Cadence types of the arguments of every method,
+// after the expiry.
+var argumentTypes = map[string][]string{
+	"updateLPFeePercentage":         {"UInt64", "UFix64"},
+	"updateDAOFeePercentage":        {"UInt64", "UFix64"},
+	"togglePoolFreeze":              {"UInt64"},
+	"sendEmuFeesToDAO":              {},
+	"createFarm":                    {"UInt64"},
+	"updateFarmWeightForRewardPool": {"UInt64", "UInt64", "UFix64"},
+	"addNFT":                        {"UInt64", "String"},
+	"removeNFT":                     {"UInt64", "UInt64"},
+	"depositRewardTokens":           {"UInt64", "String", "UFix64"},
+	"sendTokens":                    {"String", "UFix64", "Address", "String"},
+}
+
+// Methods lists the methods of the actions with their argument types, sorted.
+func Methods() []string {
+	var methods []string
+	for method, types := range argumentTypes {
+		methods = append(methods, fmt.Sprintf("%s(%s)", method, strings.Join(types, ", ")))
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// ParseAction reads the action of method from its arguments as text.
+func ParseAction(method string, args []string) (Action, error) {
+	types, ok := argumentTypes[method]
+	if !ok {
+		return Action{}, fmt.Errorf("unknown method %q", method)
+	}
+	if len(args) != len(types) {
+		return Action{}, fmt.Errorf("%s takes %d arguments, got %d", method, len(types), len(args))
+	}
+	action := Action{Method: method}
+	for i, arg := range args {
+		var value interface{} = arg
+		var err error
+		switch types[i] {
+		case "UInt64":
+			value, err = strconv.ParseUint(arg, 10, 64)
+		case "UFix64":
+			value, err = emuswap.ParseUFix64(arg)
+		case "Address":
+			value = flow.HexToAddress(arg)
+		}
+		if err != nil {
+			return Action{}, fmt.Errorf("argument %d of %s: %w", i+1, method, err)
+		}
+		action.Args = append(action.Args, value)
+	}
+	return action, nil
+}
+
+// Payload is an action bound to its multisig txIndex and expiry, the unit
+// that gets signed. Expiry is the last block height the payload can be
+// proposed, signed and executed at.
+type Payload struct {
+	TxIndex uint64
+	Expiry  uint64
+	Action  Action
+}
+
+// PayloadOf returns the payload of a pending proposal, the one its co-signers
+// sign.
+func PayloadOf(proposal emuswap.Proposal) (Payload, error) {
+	types, ok := argumentTypes[proposal.Method]
+	if !ok {
+		return Payload{}, fmt.Errorf("unknown method %q", proposal.Method)
+	}
+	if len(proposal.Args) != len(types) {
+		return Payload{}, fmt.Errorf("%s takes %d arguments, got %d", proposal.Method, len(types), len(proposal.Args))
+	}
+	action := Action{Method: proposal.Method}
+	for i, arg := range proposal.Args {
+		// scripts return the arguments as the Go values of cadence
+		switch value := arg.(type) {
+		case uint64:
+			if types[i] == "UFix64" {
+				arg = emuswap.UFix64(value)
+			}
+		case [flow.AddressLength]byte:
+			arg = flow.Address(value)
+		}
+		action.Args = append(action.Args, arg)
+	}
+	return Payload{TxIndex: proposal.TxIndex, Expiry: proposal.Expiry, Action: action}, nil
+}
+
+// Args are the payload arguments as stored on chain, expiry first.
+func (p Payload) Args() []interface{} {
+	return append([]interface{}{p.Expiry}, p.Action.Args...)
+}
+
+// SignableData mirrors OnChainMultiSig.PayloadDetails.getSignableData.
+func (p Payload) SignableData() ([]byte, error) {
+	data := appendUint64(nil, p.TxIndex)
+	data = append(data, p.Action.Method...)
+	for i, arg := range p.Args() {
+		switch arg := arg.(type) {
+		case string:
+			data = append(data, arg...)
+		case uint64:
+			data = appendUint64(data, arg)
+		case emuswap.UFix64:
+			data = appendUint64(data, uint64(arg))
+		case uint8:
+			data = append(data, arg)
+		case flow.Address:
+			data = append(data, arg.Bytes()...)
+		default:
+			return nil, fmt.Errorf("argument %d: %T is not supported by OnChainMultiSig", i, arg)
+		}
+	}
+	return data, nil
+}
+
+func appendUint64(data []byte, v uint64) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	return append(data, b[:]...)
+}
+
+// Key is one of the multisig keys.
+type Key struct {
+	PrivateKey crypto.PrivateKey
+}
+
+// PublicKey is the hex encoded public key the contract knows the key by.
+func (k Key) PublicKey() string {
+	return hex.EncodeToString(k.PrivateKey.PublicKey().Encode())
+}
+
+// SignatureAlgorithm is the raw value of the Cadence SignatureAlgorithm,
+// which numbers the algorithms differently than flow-go-sdk.
+func (k Key) SignatureAlgorithm() uint8 {
+	switch k.PrivateKey.Algorithm() {
+	case crypto.ECDSA_P256:
+		return 1
+	case crypto.ECDSA_secp256k1:
+		return 2
+	}
+	panic(fmt.Sprintf("multisig keys must use ECDSA, got %s", k.PrivateKey.Algorithm()))
+}
+
+// Sign returns the hex encoded signature of the payload. Cadence KeyLists
+// verify SHA3-256 hashes with the user domain tag.
+func (k Key) Sign(payload Payload) (string, error) {
+	data, err := payload.SignableData()
+	if err != nil {
+		return "", err
+	}
+	// computing the public key fills in the point crypto/ecdsa needs to sign
+	k.PrivateKey.PublicKey()
+	signer, err := crypto.NewInMemorySigner(k.PrivateKey, crypto.SHA3_256)
+	if err != nil {
+		return "", err
+	}
+	signature, err := flow.SignUserMessage(signer, data)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(signature), nil
+}
+
+// Setup stores an m-of-len(keys) Signer in the admin account.
+func Setup(c *emuswap.Client, admin string, m int, keys []Key) overflow.FlowTransactionBuilder {
+	publicKeys := make([]string, len(keys))
+	weights := make([]emuswap.UFix64, len(keys))
+	algorithms := make([]uint8, len(keys))
+	for i, key := range keys {
+		publicKeys[i] = key.PublicKey()
+		weights[i] = Weight(m)
+		algorithms[i] = key.SignatureAlgorithm()
+	}
+	return c.MultiSigAdminSetup(admin, publicKeys, weights, algorithms)
+}
+
+// NextPayload binds action to the next free txIndex, to be proposed, signed
+// and executed in the next blocks blocks.
+func NextPayload(c *emuswap.Client, action Action, blocks uint64) (Payload, error) {
+	txIndex, err := c.MultiSigGetTxIndex()
+	if err != nil {
+		return Payload{}, err
+	}
+	height, err := c.MultiSigGetBlockHeight()
+	if err != nil {
+		return Payload{}, err
+	}
+	return Payload{
+		TxIndex: txIndex + 1,
+		Expiry:  height + blocks,
+		Action:  action,
+	}, nil
+}
+
+// Propose builds the transaction adding payload with the signature of key,
+// sent by account.
+func Propose(c *emuswap.Client, account string, key Key, payload Payload) (overflow.FlowTransactionBuilder, error) {
+	signature, err := key.Sign(payload)
+	if err != nil {
+		return overflow.FlowTransactionBuilder{}, err
+	}
+	return c.MultiSigPropose(account, payload.TxIndex, payload.Action.Method, payload.Args(), key.PublicKey(), signature), nil
+}
+
+// CoSign builds the transaction adding the signature of key to a proposed
+// payload, sent by account.
+func CoSign(c *emuswap.Client, account string, key Key, payload Payload) (overflow.FlowTransactionBuilder, error) {
+	signature, err := key.Sign(payload)
+	if err != nil {
+		return overflow.FlowTransactionBuilder{}, err
+	}
+	return c.MultiSigSign(account, payload.TxIndex, key.PublicKey(), signature), nil
+}
+
+// Execute builds the transaction running a payload that reached the threshold.
+func Execute(c *emuswap.Client, account string, txIndex uint64) overflow.FlowTransactionBuilder {
+	return c.MultiSigExecute(account, txIndex)
+}
+
+// Pending lists the proposals that were neither executed nor removed, by
+// txIndex.
+func Pending(c *emuswap.Client) ([]emuswap.Proposal, error) {
+	proposals, err := c.MultiSigGetProposals()
+	if err != nil {
+		return nil, err
+	}
+	pending := make([]emuswap.Proposal, 0, len(proposals))
+	for _, proposal := range proposals {
+		pending = append(pending, proposal)
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].TxIndex < pending[j].TxIndex })
+	return pending, nil
+}
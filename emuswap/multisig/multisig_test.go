@@ -0,0 +1,239 @@
+package multisig
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/internal/emutest"
+)
+
+// TestMain runs the package tests from the repository root, where flow.json
+// and the files it references resolve.
+func TestMain(m *testing.M) {
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(emutest.Main(m))
+}
+
+var ufix = emuswap.UFix64FromFloat
+
+func testKey(t *testing.T, seed byte) Key {
+	privateKey, err := crypto.GeneratePrivateKey(crypto.ECDSA_P256, bytes.Repeat([]byte{seed}, crypto.MinSeedLength))
+	require.NoError(t, err)
+	return Key{PrivateKey: privateKey}
+}
+
+// newTestMultiSig starts an emulator with a FLOW/FUSD pool and a 2-of-3
+// multisig over the admin account.
+func newTestMultiSig(t *testing.T) (*emuswap.Client, []Key) {
+	o, err := emutest.Start()
+	require.NoError(t, err)
+	c := emuswap.NewClient(o)
+
+	c.DemoMintFlowTokens("account", ufix(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.FUSDSetup("account").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", ufix(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.EmuSwapAdminCreateNewPool("account", "flowTokenVault", ufix(100.0), "fusdVault", ufix(50.0)).Test(t).AssertSuccess()
+
+	keys := []Key{testKey(t, 1), testKey(t, 2), testKey(t, 3)}
+	Setup(c, "account", 2, keys).Test(t).AssertSuccess()
+	return c, keys
+}
+
+func propose(t *testing.T, c *emuswap.Client, key Key, payload Payload) overflow.TransactionResult {
+	tx, err := Propose(c, "user1", key, payload)
+	require.NoError(t, err)
+	return tx.Test(t)
+}
+
+func coSign(t *testing.T, c *emuswap.Client, key Key, payload Payload) overflow.TransactionResult {
+	tx, err := CoSign(c, "user2", key, payload)
+	require.NoError(t, err)
+	return tx.Test(t)
+}
+
+func TestWeight(t *testing.T) {
+	for m := 1; m <= 7; m++ {
+		assert.GreaterOrEqual(t, Weight(m)*emuswap.UFix64(m), Threshold, m)
+		assert.Less(t, Weight(m)*emuswap.UFix64(m-1), Threshold, m)
+	}
+}
+
+func TestThreshold(t *testing.T) {
+	c, keys := newTestMultiSig(t)
+
+	payload, err := NextPayload(c, UpdateLPFeePercentage(0, ufix(0.005)), 100)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), payload.TxIndex)
+
+	propose(t, c, testKey(t, 9), payload).AssertFailure("Public key is not a registered signer")
+	propose(t, c, keys[0], payload).AssertSuccess()
+
+	pending, err := Pending(c)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, "updateLPFeePercentage", pending[0].Method)
+	assert.Equal(t, payload.Expiry, pending[0].Expiry)
+	assert.Equal(t, []string{keys[0].PublicKey()}, pending[0].Signers)
+
+	// one of two signatures
+	Execute(c, "user3", 1).Test(t).AssertFailure("Not enough signatures to execute payload")
+
+	coSign(t, c, keys[2], payload).AssertSuccess()
+	Execute(c, "user3", 1).Test(t).
+		AssertSuccess().
+		AssertEmitEvent(overflow.NewTestEvent("A.f8d6e0586b0a20c7.EmuSwap.LPFeeUpdated", map[string]interface{}{
+			"poolID":        "0",
+			"feePercentage": "0.00500000",
+		}))
+
+	pending, err = Pending(c)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	// StakingRewards admin operations go through the same signer
+	payload, err = NextPayload(c, CreateFarm(0), 100)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), payload.TxIndex)
+	propose(t, c, keys[1], payload).AssertSuccess()
+	coSign(t, c, keys[0], payload).AssertSuccess()
+	Execute(c, "user3", 2).Test(t).AssertSuccess()
+
+	farm, err := c.StakingGetFarmMeta(0)
+	require.NoError(t, err)
+	assert.NotNil(t, farm)
+}
+
+func TestReplay(t *testing.T) {
+	c, keys := newTestMultiSig(t)
+
+	payload, err := NextPayload(c, TogglePoolFreeze(0), 100)
+	require.NoError(t, err)
+	propose(t, c, keys[0], payload).AssertSuccess()
+
+	// the same key cannot sign twice
+	coSign(t, c, keys[0], payload).AssertFailure("Signature already added for this txIndex")
+
+	coSign(t, c, keys[1], payload).AssertSuccess()
+	Execute(c, "user3", payload.TxIndex).Test(t).AssertSuccess()
+
+	// executed payloads are gone, neither execution nor proposal can be repeated
+	Execute(c, "user3", payload.TxIndex).Test(t).AssertFailure("No payload for such index")
+	propose(t, c, keys[0], payload).AssertFailure("Incorrect txIndex provided in payload")
+
+	// signatures are bound to the txIndex, the method and the arguments
+	next, err := NextPayload(c, TogglePoolFreeze(0), 100)
+	require.NoError(t, err)
+	signature, err := keys[0].Sign(payload)
+	require.NoError(t, err)
+	c.MultiSigPropose("user1", next.TxIndex, next.Action.Method, next.Args(), keys[0].PublicKey(), signature).
+		Test(t).
+		AssertFailure("Invalid signer")
+
+	other := next
+	other.Action = UpdateDAOFeePercentage(0, ufix(0.5))
+	propose(t, c, keys[0], next).AssertSuccess()
+	signature, err = keys[1].Sign(other)
+	require.NoError(t, err)
+	c.MultiSigSign("user2", next.TxIndex, keys[1].PublicKey(), signature).Test(t).AssertFailure("Invalid signer")
+}
+
+func TestExpiry(t *testing.T) {
+	c, keys := newTestMultiSig(t)
+
+	// every transaction takes a block, expiries are counted in them
+	payload, err := NextPayload(c, WithdrawFees(), 0)
+	require.NoError(t, err)
+	propose(t, c, keys[0], payload).AssertFailure("Proposal expired")
+
+	payload, err = NextPayload(c, WithdrawFees(), 3)
+	require.NoError(t, err)
+	height, err := c.MultiSigGetBlockHeight()
+	require.NoError(t, err)
+	assert.Equal(t, height+3, payload.Expiry)
+	propose(t, c, keys[0], payload).AssertSuccess()
+	c.MultiSigRemoveExpired("user3", payload.TxIndex).Test(t).AssertFailure("Proposal has not expired")
+	coSign(t, c, keys[1], payload).AssertSuccess()
+	height, err = c.MultiSigGetBlockHeight()
+	require.NoError(t, err)
+	assert.Equal(t, payload.Expiry, height)
+
+	coSign(t, c, keys[2], payload).AssertFailure("Proposal expired")
+	Execute(c, "user3", payload.TxIndex).Test(t).AssertFailure("Proposal expired")
+
+	c.MultiSigRemoveExpired("user3", payload.TxIndex).Test(t).AssertSuccess()
+	pending, err := Pending(c)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+// TestActions runs the farm, access NFT and treasury actions, co-signing the
+// payloads read back from the pending proposals.
+func TestActions(t *testing.T) {
+	c, keys := newTestMultiSig(t)
+	emu, fusd := emuswap.MustLookupToken("EMU"), emuswap.MustLookupToken("FUSD")
+	c.FUSDSetup("user1").Test(t).AssertSuccess()
+
+	run := func(action Action) overflow.TransactionResult {
+		payload, err := NextPayload(c, action, 100)
+		require.NoError(t, err)
+		propose(t, c, keys[0], payload).AssertSuccess()
+		pending, err := Pending(c)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		proposed, err := PayloadOf(pending[0])
+		require.NoError(t, err)
+		assert.Equal(t, payload, proposed)
+		coSign(t, c, keys[1], proposed).AssertSuccess()
+		return Execute(c, "user3", payload.TxIndex).Test(t).AssertSuccess()
+	}
+
+	run(CreateFarm(0))
+	run(UpdateFarmWeight(0, 0, ufix(2.0)))
+	run(AddAccessNFT(0, "A.f8d6e0586b0a20c7.ExampleNFT.NFT"))
+	before, err := c.StakingGetRewardPoolsMeta()
+	require.NoError(t, err)
+	assert.Equal(t, ufix(2.0), before[0].FarmWeightsByID[0])
+	assert.Equal(t, []string{"A.f8d6e0586b0a20c7.ExampleNFT.NFT"}, before[0].AccessNFTsAccepted)
+
+	run(RemoveAccessNFT(0, 0))
+	run(DepositRewardTokens(0, emu.StoragePath, ufix(10.0)))
+	after, err := c.StakingGetRewardPoolsMeta()
+	require.NoError(t, err)
+	assert.Empty(t, after[0].AccessNFTsAccepted)
+	assert.Equal(t, before[0].Balance+ufix(10.0), after[0].Balance)
+
+	run(SendTokens(fusd.StoragePath, ufix(5.0), c.Address("user1"), fusd.ReceiverPath)).
+		AssertEmitEvent(overflow.NewTestEvent("A.f8d6e0586b0a20c7.FUSD.TokensDeposited", map[string]interface{}{
+			"amount": "5.00000000",
+			"to":     "0x" + c.Address("user1").Hex(),
+		}))
+}
+
+func TestParseAction(t *testing.T) {
+	action, err := ParseAction("sendTokens", []string{"fusdVault", "5.0", "0x01cf0e2f2f715450", "fusdReceiver"})
+	require.NoError(t, err)
+	assert.Equal(t, SendTokens("fusdVault", ufix(5.0), flow.HexToAddress("01cf0e2f2f715450"), "fusdReceiver"), action)
+	action, err = ParseAction("updateLPFeePercentage", []string{"0", "0.005"})
+	require.NoError(t, err)
+	assert.Equal(t, UpdateLPFeePercentage(0, ufix(0.005)), action)
+	action, err = ParseAction("sendEmuFeesToDAO", nil)
+	require.NoError(t, err)
+	assert.Equal(t, WithdrawFees(), action)
+
+	_, err = ParseAction("destroy", nil)
+	assert.EqualError(t, err, `unknown method "destroy"`)
+	_, err = ParseAction("createFarm", nil)
+	assert.EqualError(t, err, "createFarm takes 1 arguments, got 0")
+	_, err = ParseAction("createFarm", []string{"-1"})
+	assert.Error(t, err)
+	assert.Contains(t, Methods(), "sendTokens(String, UFix64, Address, String)")
+}
@@ -0,0 +1,220 @@
+package emuswap
+
+import (
+	"testing"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newNFTFarm sets up farm 0 on a FLOW/FUSD pool with the ungated reward pool
+// 0 and reward pool 1 accepting the NFTs listed by nftPaths. users get LP
+// tokens of the pool and two ExampleNFTs each, mock time is on.
+func newNFTFarm(t *testing.T, nftPaths []string, users ...string) *Client {
+	c := newTestClient(t)
+	flowToken, fusd := MustLookupToken("FLOW"), MustLookupToken("FUSD")
+
+	c.DemoMintFlowTokens("account", UFix64FromFloat(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.FUSDSetup("account").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", UFix64FromFloat(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.StakingAdminToggleMockTime("account").Test(t).AssertSuccess()
+	c.EmuSwapAdminCreateNewPool("account", flowToken.StoragePath, UFix64FromFloat(100.0), fusd.StoragePath, UFix64FromFloat(50.0)).Test(t).AssertSuccess()
+	c.StakingAdminCreateNewFarm("account", 0).Test(t).AssertSuccess()
+	c.StakingAdminCreateRewardPool("account", MustLookupToken("EMU").StoragePath, UFix64FromFloat(1000.0), nftPaths).Test(t).AssertSuccess()
+
+	for _, user := range users {
+		c.DemoMintFlowTokens("account", UFix64FromFloat(100.0), c.Address(user)).Test(t).AssertSuccess()
+		c.FUSDSetup(user).Test(t).AssertSuccess()
+		c.DemoMintFUSD("account", UFix64FromFloat(100.0), c.Address(user)).Test(t).AssertSuccess()
+		c.EmuTokenSetup(user).Test(t).AssertSuccess()
+		c.AddLiquidity(user, flowToken, fusd, UFix64FromFloat(10.0), UFix64FromFloat(5.0)).Test(t).AssertSuccess()
+		c.ExampleNFTSetup(user).Test(t).AssertSuccess()
+		c.ExampleNFTMint("account", c.Address(user)).Test(t).AssertSuccess()
+		c.ExampleNFTMint("account", c.Address(user)).Test(t).AssertSuccess()
+	}
+	return c
+}
+
+func nftIDs(t *testing.T, c *Client, user string) []uint64 {
+	ids, err := c.ExampleNFTGetIDs(c.Address(user))
+	require.NoError(t, err)
+	return ids
+}
+
+func stakeInfo(t *testing.T, c *Client, user string) StakeInfo {
+	stakes, err := c.StakingReadStakesInfo(0)
+	require.NoError(t, err)
+	stake, ok := stakes[c.Address(user)]
+	require.True(t, ok, "%s has no stake", user)
+	return stake
+}
+
+func TestNFTGatedRewardPool(t *testing.T) {
+	c := newNFTFarm(t, []string{}, "user1", "user2")
+	exampleNFT := MustLookupNFT("ExampleNFT")
+	identifier, err := c.NFTIdentifier(exampleNFT)
+	require.NoError(t, err)
+	assert.Equal(t, "A.f8d6e0586b0a20c7.ExampleNFT.NFT", identifier)
+
+	c.GateRewardPool("account", 1, exampleNFT).Test(t).AssertSuccess()
+	pools, err := c.StakingGetRewardPoolsMeta()
+	require.NoError(t, err)
+	assert.Empty(t, pools[0].AccessNFTsAccepted)
+	assert.Equal(t, []string{identifier}, pools[1].AccessNFTsAccepted)
+
+	// user1 stakes an NFT, user2 does not
+	ids := nftIDs(t, c, "user1")
+	c.StakeWithNFTs("user1", 0, UFix64FromFloat(0.05), exampleNFT, ids[:1]).Test(t).AssertSuccess()
+	c.StakingUserStake("user2", 0, UFix64FromFloat(0.05)).Test(t).AssertSuccess()
+	assert.Len(t, nftIDs(t, c, "user1"), 1)
+	assert.Equal(t, []string{identifier}, stakeInfo(t, c, "user1").StakedNFTs)
+	assert.Empty(t, stakeInfo(t, c, "user2").StakedNFTs)
+
+	c.StakingAdminUpdateMockTimestamp("account", UFix64FromFloat(100.0)).Test(t).AssertSuccess()
+
+	// both earn from reward pool 0, only user1 from the gated reward pool 1,
+	// the share of user2 stays in its vault
+	gated := stakeInfo(t, c, "user1")
+	ungated := stakeInfo(t, c, "user2")
+	assert.Contains(t, gated.PendingRewards, uint64(0))
+	assert.Contains(t, ungated.PendingRewards, uint64(0))
+	assert.Equal(t, gated.PendingRewards[0], ungated.PendingRewards[0])
+	assert.Equal(t, Fix64FromFloat(50.0), gated.PendingRewards[1])
+	assert.NotContains(t, ungated.PendingRewards, uint64(1))
+	assert.NotContains(t, ungated.RewardDebtByID, uint64(1))
+
+	// claiming skips the reward pools a stake is not eligible for
+	c.StakingUserClaimRewards("user2", 0).Test(t).AssertSuccess()
+
+	// without access NFTs the reward pool pays every staker again
+	c.StakingAdminRemoveAccessNFT("account", 1, 0).Test(t).AssertSuccess()
+	pools, err = c.StakingGetRewardPoolsMeta()
+	require.NoError(t, err)
+	assert.Empty(t, pools[1].AccessNFTsAccepted)
+}
+
+func TestNFTWrongType(t *testing.T) {
+	c := newNFTFarm(t, []string{"A.f8d6e0586b0a20c7.OtherNFT.NFT"}, "user1")
+	exampleNFT := MustLookupNFT("ExampleNFT")
+	ids := nftIDs(t, c, "user1")
+
+	c.StakeWithNFTs("user1", 0, UFix64FromFloat(0.05), exampleNFT, ids[:1]).
+		Test(t).
+		AssertFailure("NFT provided is not of required type for this farm!")
+	assert.Equal(t, ids, nftIDs(t, c, "user1"), "the NFT stays with the signer")
+
+	c.StakingUserStake("user1", 0, UFix64FromFloat(0.05)).Test(t).AssertSuccess()
+	c.StakeNFT("user1", 0, exampleNFT, ids[0]).
+		Test(t).
+		AssertFailure("NFT provided is not of required type for this farm!")
+	c.UnstakeNFT("user1", 0, exampleNFT).Test(t).AssertFailure("No NFT of this type staked!")
+
+	// once accepted it can be staked
+	c.GateRewardPool("account", 1, exampleNFT).Test(t).AssertSuccess()
+	c.StakeNFT("user1", 0, exampleNFT, ids[0]).Test(t).AssertSuccess()
+	assert.Len(t, stakeInfo(t, c, "user1").StakedNFTs, 1)
+}
+
+func TestNFTNotRequired(t *testing.T) {
+	c := newNFTFarm(t, []string{}, "user1")
+	ids := nftIDs(t, c, "user1")
+
+	c.StakeWithNFTs("user1", 0, UFix64FromFloat(0.05), MustLookupNFT("ExampleNFT"), ids[:1]).
+		Test(t).
+		AssertFailure("NFT not required for this Farm.")
+	assert.Equal(t, ids, nftIDs(t, c, "user1"))
+}
+
+func TestNFTDuplicateType(t *testing.T) {
+	c := newNFTFarm(t, []string{"A.f8d6e0586b0a20c7.ExampleNFT.NFT"}, "user1")
+	exampleNFT := MustLookupNFT("ExampleNFT")
+	ids := nftIDs(t, c, "user1")
+	require.Len(t, ids, 2)
+
+	c.StakeWithNFTs("user1", 0, UFix64FromFloat(0.05), exampleNFT, ids).
+		Test(t).
+		AssertFailure("Duplicate NFT type detected")
+
+	c.StakeWithNFTs("user1", 0, UFix64FromFloat(0.05), exampleNFT, ids[:1]).Test(t).AssertSuccess()
+	// adding to the stake
+	c.StakeWithNFTs("user1", 0, UFix64FromFloat(0.01), exampleNFT, ids[1:]).
+		Test(t).
+		AssertFailure("Duplicate NFT type detected")
+	c.StakeNFT("user1", 0, exampleNFT, ids[1]).
+		Test(t).
+		AssertFailure("NFT of this collection already staked!")
+
+	assert.Equal(t, ids[1:], nftIDs(t, c, "user1"))
+	stake := stakeInfo(t, c, "user1")
+	assert.Equal(t, UFix64FromFloat(0.05), stake.Balance)
+	assert.Len(t, stake.StakedNFTs, 1)
+}
+
+func TestNFTUnstake(t *testing.T) {
+	c := newNFTFarm(t, []string{"A.f8d6e0586b0a20c7.ExampleNFT.NFT"}, "user1")
+	exampleNFT := MustLookupNFT("ExampleNFT")
+	ids := nftIDs(t, c, "user1")
+
+	// the NFT stays staked until the whole stake is
+	c.StakeWithNFTs("user1", 0, UFix64FromFloat(0.05), exampleNFT, ids[:1]).Test(t).AssertSuccess()
+	c.StakingUserUnstake("user1", 0, UFix64FromFloat(0.02)).Test(t).AssertSuccess()
+	assert.Len(t, stakeInfo(t, c, "user1").StakedNFTs, 1)
+	assert.Equal(t, ids[1:], nftIDs(t, c, "user1"))
+
+	c.StakingUserUnstake("user1", 0, UFix64FromFloat(0.03)).Test(t).AssertSuccess()
+	assert.ElementsMatch(t, ids, nftIDs(t, c, "user1"))
+	stake := stakeInfo(t, c, "user1")
+	assert.Empty(t, stake.StakedNFTs)
+	assert.NotContains(t, stake.RewardDebtByID, uint64(1))
+
+	// staking an NFT later earns from the gated reward pool from then on,
+	// unstaking it pays out what is pending
+	c.StakingUserStake("user1", 0, UFix64FromFloat(0.05)).Test(t).AssertSuccess()
+	c.StakingAdminUpdateMockTimestamp("account", UFix64FromFloat(100.0)).Test(t).AssertSuccess()
+	assert.NotContains(t, stakeInfo(t, c, "user1").PendingRewards, uint64(1))
+
+	c.StakeNFT("user1", 0, exampleNFT, ids[0]).Test(t).AssertSuccess()
+	assert.Equal(t, Fix64(0), stakeInfo(t, c, "user1").PendingRewards[1])
+	c.StakingAdminUpdateMockTimestamp("account", UFix64FromFloat(100.0)).Test(t).AssertSuccess()
+	assert.Equal(t, Fix64FromFloat(100.0), stakeInfo(t, c, "user1").PendingRewards[1])
+
+	c.UnstakeNFT("user1", 0, exampleNFT).
+		Test(t).
+		AssertFailure("No receiver for the pending rewards of reward pool 1, add one first!")
+	c.AddRewardReceiver("user1", 0, 1, MustLookupToken("EMU")).Test(t).AssertSuccess()
+	c.UnstakeNFT("user1", 0, exampleNFT).
+		Test(t).
+		AssertSuccess().
+		AssertEmitEvent(overflow.NewTestEvent("A.f8d6e0586b0a20c7.StakingRewards.RewardsClaimed", map[string]interface{}{
+			"address":        "0x" + c.Address("user1").Hex(),
+			"tokenType":      "A.f8d6e0586b0a20c7.EmuToken.Vault",
+			"amountClaimed":  "100.00000000",
+			"rewardDebt":     "200.00000000",
+			"totalRemaining": "900.00000000",
+		}))
+	stake = stakeInfo(t, c, "user1")
+	assert.NotContains(t, stake.PendingRewards, uint64(1))
+	assert.Empty(t, stake.StakedNFTs)
+	assert.Equal(t, UFix64FromFloat(0.05), stake.Balance)
+	assert.ElementsMatch(t, ids, nftIDs(t, c, "user1"))
+}
+
+func TestNFTAcceptedLater(t *testing.T) {
+	c := newNFTFarm(t, []string{"A.f8d6e0586b0a20c7.ExampleNFT.NFT"}, "user1")
+	exampleNFT := MustLookupNFT("ExampleNFT")
+	ids := nftIDs(t, c, "user1")
+	c.StakeWithNFTs("user1", 0, UFix64FromFloat(0.05), exampleNFT, ids[:1]).Test(t).AssertSuccess()
+
+	// reward pool 2 comes to accept the NFT the stake holds
+	c.AddRewardPool("account", MustLookupToken("EMU"), UFix64FromFloat(1000.0), []string{"A.f8d6e0586b0a20c7.OtherNFT.NFT"}).Test(t).AssertSuccess()
+	assert.NotContains(t, stakeInfo(t, c, "user1").RewardDebtByID, uint64(2))
+	c.GateRewardPool("account", 2, exampleNFT).Test(t).AssertSuccess()
+
+	// unstaking makes the stake eligible from then on
+	c.StakingUserUnstake("user1", 0, UFix64FromFloat(0.02)).Test(t).AssertSuccess()
+	assert.Equal(t, Fix64(0), stakeInfo(t, c, "user1").PendingRewards[2])
+	c.StakingAdminUpdateMockTimestamp("account", UFix64FromFloat(100.0)).Test(t).AssertSuccess()
+	assert.Greater(t, int64(stakeInfo(t, c, "user1").PendingRewards[2]), int64(0))
+	c.StakingUserUnstake("user1", 0, UFix64FromFloat(0.01)).Test(t).AssertSuccess()
+}
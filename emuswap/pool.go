@@ -0,0 +1,169 @@
+package emuswap
+
+import "fmt"
+
+// Side is the direction of a swap, as in the side field of EmuSwap.Trade.
+type Side uint8
+
+const (
+	Token1ForToken2 Side = 1
+	Token2ForToken1 Side = 2
+)
+
+func (s Side) String() string {
+	switch s {
+	case Token1ForToken2:
+		return "token1->token2"
+	case Token2ForToken1:
+		return "token2->token1"
+	}
+	return fmt.Sprintf("Side(%d)", uint8(s))
+}
+
+// Pool models an EmuSwap pool off chain. Quotes and swaps compute exactly what
+// EmuSwap.Pool computes on chain, including the truncation of UFix64 arithmetic.
+type Pool struct {
+	Token1Amount     UFix64
+	Token2Amount     UFix64
+	LPFeePercentage  UFix64
+	DAOFeePercentage UFix64
+}
+
+// NewPool models the pool described by meta. Pools start with the contract
+// wide GetLPFeePercentage and GetDAOFeePercentage, GetPoolFees reads the fees
+// of every pool.
+func NewPool(meta PoolMeta, lpFeePercentage, daoFeePercentage UFix64) Pool {
+	return Pool{
+		Token1Amount:     meta.Token1Amount,
+		Token2Amount:     meta.Token2Amount,
+		LPFeePercentage:  lpFeePercentage,
+		DAOFeePercentage: daoFeePercentage,
+	}
+}
+
+// LoadPool models an on chain pool with the fees it charges.
+func (c *Client) LoadPool(poolID uint64) (Pool, error) {
+	meta, err := c.GetPoolMeta(poolID)
+	if err != nil {
+		return Pool{}, err
+	}
+	fees, err := c.GetPoolFees()
+	if err != nil {
+		return Pool{}, err
+	}
+	poolFees, ok := fees[poolID]
+	if !ok {
+		return Pool{}, fmt.Errorf("no fees for pool %d", poolID)
+	}
+	return NewPool(meta, poolFees["LPFeePercentage"], poolFees["DAOFeePercentage"]), nil
+}
+
+// reserves returns the reserves of the input and output token of side.
+func (p Pool) reserves(side Side) (UFix64, UFix64) {
+	if side == Token2ForToken1 {
+		return p.Token2Amount, p.Token1Amount
+	}
+	return p.Token1Amount, p.Token2Amount
+}
+
+// QuoteExactIn is the output for an input of amount on the curve, fees excluded.
+// It matches quoteSwapExactToken1ForToken2 and quoteSwapExactToken2ForToken1.
+func (p Pool) QuoteExactIn(side Side, amount UFix64) (UFix64, error) {
+	in, out := p.reserves(side)
+	numerator, err := out.Mul(amount)
+	if err != nil {
+		return 0, err
+	}
+	denominator, err := in.Add(amount)
+	if err != nil {
+		return 0, err
+	}
+	return numerator.Div(denominator)
+}
+
+// QuoteExactOut is the input needed for an output of amount on the curve, fees
+// excluded. It matches quoteSwapToken1ForExactToken2 and
+// quoteSwapToken2ForExactToken1.
+func (p Pool) QuoteExactOut(side Side, amount UFix64) (UFix64, error) {
+	in, out := p.reserves(side)
+	if out <= amount {
+		return 0, ErrInsufficientToken
+	}
+	numerator, err := in.Mul(amount)
+	if err != nil {
+		return 0, err
+	}
+	return numerator.Div(out - amount)
+}
+
+// SwapResult splits the input of a swap into the fees and the amount priced on
+// the curve.
+type SwapResult struct {
+	Side Side
+	// AmountIn is the amount paid by the trader.
+	AmountIn UFix64
+	// DAOFee is withdrawn from the input and stored with EmuSwap.storeFees.
+	DAOFee UFix64
+	// Priced is the input after both fees, the input amount of the Trade event.
+	Priced UFix64
+	// AmountOut is paid out to the trader.
+	AmountOut UFix64
+}
+
+// LPFee is the part of the input that stays in the pool without being priced.
+func (r SwapResult) LPFee() UFix64 {
+	return r.AmountIn - r.DAOFee - r.Priced
+}
+
+// Swap applies a swap of amount input tokens to the pool like swapToken1ForToken2
+// and swapToken2ForToken1 do. The pool is unchanged when an error is returned.
+func (p *Pool) Swap(side Side, amount UFix64) (SwapResult, error) {
+	if side != Token1ForToken2 && side != Token2ForToken1 {
+		return SwapResult{}, fmt.Errorf("invalid swap side %d", side)
+	}
+	if amount == 0 {
+		return SwapResult{}, ErrEmptyVault
+	}
+	daoFee, err := amount.Mul(p.DAOFeePercentage)
+	if err != nil {
+		return SwapResult{}, err
+	}
+	netPercentage, err := UFix64(UFix64Factor).Sub(p.LPFeePercentage)
+	if err == nil {
+		netPercentage, err = netPercentage.Sub(p.DAOFeePercentage)
+	}
+	if err != nil {
+		return SwapResult{}, err
+	}
+	priced, err := amount.Mul(netPercentage)
+	if err != nil {
+		return SwapResult{}, err
+	}
+	out, err := p.QuoteExactIn(side, priced)
+	if err != nil {
+		return SwapResult{}, err
+	}
+	if out == 0 {
+		return SwapResult{}, ErrAmountTooSmall
+	}
+
+	in, _ := p.reserves(side)
+	in, err = in.Add(amount - daoFee)
+	if err != nil {
+		return SwapResult{}, err
+	}
+	if side == Token1ForToken2 {
+		p.Token1Amount, p.Token2Amount = in, p.Token2Amount-out
+	} else {
+		p.Token2Amount, p.Token1Amount = in, p.Token1Amount-out
+	}
+	return SwapResult{Side: side, AmountIn: amount, DAOFee: daoFee, Priced: priced, AmountOut: out}, nil
+}
+
+// Price is the amount of token2 per token1 at the current reserves.
+func (p Pool) Price() float64 {
+	if p.Token1Amount == 0 {
+		return 0
+	}
+	return p.Token2Amount.Float64() / p.Token1Amount.Float64()
+}
@@ -0,0 +1,88 @@
+package emuswap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUFix64Arithmetic(t *testing.T) {
+	third, err := UFix64FromFloat(1.0).Div(UFix64FromFloat(3.0))
+	require.NoError(t, err)
+	assert.Equal(t, "0.33333333", third.String())
+
+	product, err := third.Mul(UFix64FromFloat(3.0))
+	require.NoError(t, err)
+	assert.Equal(t, "0.99999999", product.String())
+
+	_, err = MaxUFix64.Mul(UFix64FromFloat(2.0))
+	assert.ErrorIs(t, err, ErrUFix64Range)
+	_, err = MaxUFix64.Add(1)
+	assert.ErrorIs(t, err, ErrUFix64Range)
+	_, err = UFix64(0).Sub(1)
+	assert.ErrorIs(t, err, ErrUFix64Range)
+	_, err = third.Div(0)
+	assert.ErrorIs(t, err, ErrUFix64Range)
+}
+
+func TestPoolMatchesContract(t *testing.T) {
+	c := newTestClient(t)
+	flowToken, fusd := MustLookupToken("FLOW"), MustLookupToken("FUSD")
+
+	c.DemoMintFlowTokens("account", UFix64FromFloat(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.FUSDSetup("account").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", UFix64FromFloat(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.EmuSwapAdminCreateNewPool("account", "flowTokenVault", UFix64FromFloat(100.0), "fusdVault", UFix64FromFloat(50.0)).
+		Test(t).
+		AssertSuccess()
+	c.EmuSwapAdminUpdateDAOFeePercentage("account", 0, UFix64FromFloat(0.0005)).Test(t).AssertSuccess()
+
+	pool, err := c.LoadPool(0)
+	require.NoError(t, err)
+	assert.Equal(t, UFix64FromFloat(0.0005), pool.DAOFeePercentage)
+
+	for _, amount := range []UFix64{UFix64FromFloat(0.3), UFix64FromFloat(7.77777777)} {
+		quotes, err := c.PoolGetQuotes(0, amount)
+		require.NoError(t, err)
+		for name, quote := range map[string]func() (UFix64, error){
+			"exact A for B": func() (UFix64, error) { return pool.QuoteExactIn(Token1ForToken2, amount) },
+			"exact B for A": func() (UFix64, error) { return pool.QuoteExactIn(Token2ForToken1, amount) },
+			"A for exact B": func() (UFix64, error) { return pool.QuoteExactOut(Token1ForToken2, amount) },
+			"B for exact A": func() (UFix64, error) { return pool.QuoteExactOut(Token2ForToken1, amount) },
+		} {
+			value, err := quote()
+			require.NoError(t, err)
+			assert.Equal(t, quotes[name], value, "%s %s", name, amount)
+		}
+	}
+
+	swap := func(from, to Token, side Side, amount UFix64) {
+		result, err := pool.Swap(side, amount)
+		require.NoError(t, err)
+		token1Amount, token2Amount := result.Priced, result.AmountOut
+		if side == Token2ForToken1 {
+			token1Amount, token2Amount = result.AmountOut, result.Priced
+		}
+		c.Swap("account", from, to, amount).Test(t).
+			AssertSuccess().
+			AssertEmitEvent(overflow.NewTestEvent("A.f8d6e0586b0a20c7.EmuSwap.Trade", map[string]interface{}{
+				"token1Amount": token1Amount.String(),
+				"token2Amount": token2Amount.String(),
+				"side":         fmt.Sprint(uint8(side)),
+			}))
+	}
+	swap(flowToken, fusd, Token1ForToken2, UFix64FromFloat(10.0))
+	swap(fusd, flowToken, Token2ForToken1, UFix64FromFloat(3.33333333))
+	swap(flowToken, fusd, Token1ForToken2, UFix64FromFloat(0.00012345))
+
+	meta, err := c.GetPoolMeta(0)
+	require.NoError(t, err)
+	assert.Equal(t, meta.Token1Amount, pool.Token1Amount)
+	assert.Equal(t, meta.Token2Amount, pool.Token2Amount)
+
+	_, err = pool.Swap(Token1ForToken2, 1)
+	assert.ErrorIs(t, err, ErrAmountTooSmall)
+}
@@ -0,0 +1,292 @@
+// Package portfolio gathers what an address holds across EmuSwap: wallet
+// vaults of the registered tokens, LP tokens, farm stakes and their pending
+// rewards, xEMU, the vesting allowance and airdrop claims. Everything is read
+// with one script and valued in a quote token at the spot prices of the pools,
+// routing through other tokens where no pool pairs a token with the quote.
+//
+// The JSON encoding of Portfolio is the schema served by cmd/api and printed
+// by cmd/portfolio. Fields are only added, a breaking change bumps Version.
+package portfolio
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/onflow/flow-go-sdk"
+	"swap.emudao.org/test-overflow/emuswap"
+)
+
+// Version is the version of the JSON schema.
+const Version = 1
+
+// Kind is the kind of a position.
+type Kind string
+
+const (
+	Wallet  Kind = "wallet"  // a token vault of the address
+	LP      Kind = "lp"      // LP tokens held, worth a share of the pool
+	Stake   Kind = "stake"   // LP tokens staked in a farm
+	Reward  Kind = "reward"  // rewards pending in a farm
+	XEmu    Kind = "xemu"    // xEMU, worth EMU from the xEMU pool
+	Vesting Kind = "vesting" // EMU Vesting allows the address to withdraw
+	Airdrop Kind = "airdrop" // tokens claimable from an FTAirdrop drop
+)
+
+// Amount is an amount of a token.
+type Amount struct {
+	Token  string         `json:"token"`
+	Amount emuswap.UFix64 `json:"amount"`
+}
+
+// Position is one holding. Token and Amount are what is held, LP and staked
+// LP tokens have no token identifier and are held in the pool PoolID. The
+// tokens LP and xEMU are worth are listed in Underlying.
+type Position struct {
+	Kind         Kind           `json:"kind"`
+	Token        string         `json:"token,omitempty"`
+	Amount       emuswap.UFix64 `json:"amount"`
+	PoolID       *uint64        `json:"poolID,omitempty"`
+	FarmID       *uint64        `json:"farmID,omitempty"`
+	RewardPoolID *uint64        `json:"rewardPoolID,omitempty"`
+	DropID       *uint64        `json:"dropID,omitempty"`
+	Underlying   []Amount       `json:"underlying,omitempty"`
+	// Value is in the quote token, null when a token has no price.
+	Value *emuswap.UFix64 `json:"value"`
+}
+
+// Portfolio is everything an address holds, valued in Quote.
+type Portfolio struct {
+	Version int    `json:"version"`
+	Address string `json:"address"`
+	Quote   string `json:"quote"`
+	// Total is the value of the positions with a price.
+	Total     emuswap.UFix64 `json:"total"`
+	Positions []Position     `json:"positions"`
+	// Prices are the quote token per token of every token with a route to
+	// the quote token.
+	Prices map[string]float64 `json:"prices"`
+	// Unpriced are the tokens held without a route to the quote token.
+	Unpriced []string `json:"unpriced"`
+}
+
+// Prices values the tokens of pools in quote. A token paired with a priced
+// token gets the spot price of the pool, the fewest hops from quote win and
+// among those the deepest pool.
+func Prices(pools map[uint64]emuswap.PoolMeta, quote string) map[string]float64 {
+	prices := map[string]float64{quote: 1}
+	var ids []uint64
+	for id := range pools {
+		ids = append(ids, id)
+	}
+	sortIDs(ids)
+	for {
+		type candidate struct{ price, depth float64 }
+		found := map[string]candidate{}
+		for _, id := range ids {
+			pool := pools[id]
+			sides := [2][2]string{{pool.Token1Identifier, pool.Token2Identifier}, {pool.Token2Identifier, pool.Token1Identifier}}
+			reserves := [2][2]emuswap.UFix64{{pool.Token1Amount, pool.Token2Amount}, {pool.Token2Amount, pool.Token1Amount}}
+			for i, side := range sides {
+				known, ok := prices[side[0]]
+				if _, priced := prices[side[1]]; !ok || priced || reserves[i][0] == 0 || reserves[i][1] == 0 {
+					continue
+				}
+				depth := reserves[i][0].Float64() * known
+				if depth > found[side[1]].depth {
+					found[side[1]] = candidate{price: depth / reserves[i][1].Float64(), depth: depth}
+				}
+			}
+		}
+		if len(found) == 0 {
+			return prices
+		}
+		for token, c := range found {
+			prices[token] = c.price
+		}
+	}
+}
+
+// Load reads the holdings of address and values them in the token with the
+// symbol quote.
+func Load(c *emuswap.Client, address flow.Address, quote string) (Portfolio, error) {
+	addresses, err := emuswap.ContractAddresses(c.O.State, c.O.Network)
+	if err != nil {
+		return Portfolio{}, err
+	}
+	quoteToken, err := emuswap.LookupToken(quote)
+	if err != nil {
+		return Portfolio{}, err
+	}
+	quoteIdentifier, err := quoteToken.VaultIdentifier(addresses)
+	if err != nil {
+		return Portfolio{}, err
+	}
+	holdings, err := c.ReadHoldings(address)
+	if err != nil {
+		return Portfolio{}, err
+	}
+	identifiers := map[string]string{}
+	for _, token := range emuswap.Tokens {
+		if identifiers[token.Symbol], err = token.VaultIdentifier(addresses); err != nil {
+			return Portfolio{}, err
+		}
+	}
+	return Value(holdings, "0x"+address.Hex(), identifiers, quoteIdentifier), nil
+}
+
+// Value values holdings in the token quote, identifiers are the vault
+// identifiers of the registered tokens by symbol.
+func Value(h emuswap.Holdings, address string, identifiers map[string]string, quote string) Portfolio {
+	p := Portfolio{
+		Version:   Version,
+		Address:   address,
+		Quote:     quote,
+		Positions: []Position{},
+		Prices:    Prices(h.Pools, quote),
+		Unpriced:  []string{},
+	}
+	unpriced := map[string]bool{}
+	value := func(amounts ...Amount) *emuswap.UFix64 {
+		total := 0.0
+		for _, a := range amounts {
+			price, ok := p.Prices[a.Token]
+			if !ok {
+				unpriced[a.Token] = true
+				return nil
+			}
+			total += a.Amount.Float64() * price
+		}
+		v := emuswap.UFix64FromFloat(total)
+		return &v
+	}
+	add := func(position Position) {
+		amounts := position.Underlying
+		if amounts == nil {
+			amounts = []Amount{{Token: position.Token, Amount: position.Amount}}
+		}
+		position.Value = value(amounts...)
+		if position.Value != nil {
+			p.Total += *position.Value
+		}
+		p.Positions = append(p.Positions, position)
+	}
+	underlying := func(poolID uint64, amount emuswap.UFix64) []Amount {
+		pool, ok := h.Pools[poolID]
+		if !ok || pool.TotalSupply == 0 {
+			return []Amount{}
+		}
+		share := amount.Float64() / pool.TotalSupply.Float64()
+		return []Amount{
+			{Token: pool.Token1Identifier, Amount: emuswap.UFix64FromFloat(pool.Token1Amount.Float64() * share)},
+			{Token: pool.Token2Identifier, Amount: emuswap.UFix64FromFloat(pool.Token2Amount.Float64() * share)},
+		}
+	}
+	id := func(n uint64) *uint64 { return &n }
+
+	for _, token := range emuswap.Tokens {
+		balance := h.Balances[token.BalancePath]
+		if balance == 0 {
+			continue
+		}
+		if token.Symbol == "xEMU" {
+			emu := emuswap.UFix64FromFloat(balance.Float64() * h.EmuPerXEmu.Float64())
+			add(Position{Kind: XEmu, Token: identifiers[token.Symbol], Amount: balance, Underlying: []Amount{{Token: identifiers["EMU"], Amount: emu}}})
+			continue
+		}
+		add(Position{Kind: Wallet, Token: identifiers[token.Symbol], Amount: balance})
+	}
+	var poolIDs, farmIDs []uint64
+	for poolID := range h.LPBalances {
+		poolIDs = append(poolIDs, poolID)
+	}
+	for farmID := range h.Stakes {
+		farmIDs = append(farmIDs, farmID)
+	}
+	for _, poolID := range sortIDs(poolIDs) {
+		if balance := h.LPBalances[poolID]; balance > 0 {
+			add(Position{Kind: LP, Amount: balance, PoolID: id(poolID), Underlying: underlying(poolID, balance)})
+		}
+	}
+	for _, farmID := range sortIDs(farmIDs) {
+		stake := h.Stakes[farmID]
+		if stake.Balance > 0 {
+			// farms are created for the pool of the same ID
+			add(Position{Kind: Stake, Amount: stake.Balance, PoolID: id(farmID), FarmID: id(farmID), Underlying: underlying(farmID, stake.Balance)})
+		}
+		var rewardPoolIDs []uint64
+		for poolID := range stake.PendingRewards {
+			rewardPoolIDs = append(rewardPoolIDs, poolID)
+		}
+		for _, poolID := range sortIDs(rewardPoolIDs) {
+			if pending := stake.PendingRewards[poolID]; pending > 0 {
+				add(Position{Kind: Reward, Token: h.RewardPoolTokens[poolID], Amount: emuswap.UFix64(pending), FarmID: id(farmID), RewardPoolID: id(poolID)})
+			}
+		}
+	}
+	if h.VestingAllowance > 0 {
+		add(Position{Kind: Vesting, Token: identifiers["EMU"], Amount: h.VestingAllowance})
+	}
+	claims := append([]emuswap.AirdropClaim{}, h.AirdropClaims...)
+	sort.Slice(claims, func(i, j int) bool { return claims[i].ID < claims[j].ID })
+	for _, claim := range claims {
+		add(Position{Kind: Airdrop, Token: claim.TokenIdentifier, Amount: claim.Amount, DropID: id(claim.ID)})
+	}
+
+	for token := range unpriced {
+		p.Unpriced = append(p.Unpriced, token)
+	}
+	sort.Strings(p.Unpriced)
+	return p
+}
+
+func sortIDs(ids []uint64) []uint64 {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// Write prints a table of the positions.
+func (p Portfolio) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s in %s\n", p.Address, p.Quote)
+	fmt.Fprintln(tw, "kind\tid\ttoken\tamount\tvalue")
+	for _, position := range p.Positions {
+		value := "-"
+		if position.Value != nil {
+			value = position.Value.String()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", position.Kind, position.ids(), position.token(), position.Amount, value)
+	}
+	fmt.Fprintf(tw, "total\t\t\t\t%s\n", p.Total)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, token := range p.Unpriced {
+		if _, err := fmt.Fprintf(w, "WARNING no route from %s to %s, left out of the total\n", token, p.Quote); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p Position) ids() string {
+	switch {
+	case p.RewardPoolID != nil:
+		return fmt.Sprintf("farm %d pool %d", *p.FarmID, *p.RewardPoolID)
+	case p.FarmID != nil:
+		return fmt.Sprintf("farm %d", *p.FarmID)
+	case p.PoolID != nil:
+		return fmt.Sprintf("pool %d", *p.PoolID)
+	case p.DropID != nil:
+		return fmt.Sprintf("drop %d", *p.DropID)
+	}
+	return "-"
+}
+
+func (p Position) token() string {
+	if p.Token == "" && len(p.Underlying) == 2 {
+		return p.Underlying[0].Token + "/" + p.Underlying[1].Token
+	}
+	return p.Token
+}
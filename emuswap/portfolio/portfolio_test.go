@@ -0,0 +1,152 @@
+package portfolio
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/internal/emutest"
+)
+
+// TestMain runs the package tests from the repository root, where flow.json
+// and the files it references resolve.
+func TestMain(m *testing.M) {
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(emutest.Main(m))
+}
+
+var ufix = emuswap.UFix64FromFloat
+
+func TestPrices(t *testing.T) {
+	pools := map[uint64]emuswap.PoolMeta{
+		0: {Token1Identifier: "A", Token1Amount: ufix(100), Token2Identifier: "Q", Token2Amount: ufix(50)},
+		// B is priced through A, by the deeper of the two pools
+		1: {Token1Identifier: "B", Token1Amount: ufix(10), Token2Identifier: "A", Token2Amount: ufix(10)},
+		2: {Token1Identifier: "A", Token1Amount: ufix(100), Token2Identifier: "B", Token2Amount: ufix(50)},
+		// one hop beats a deeper route
+		3: {Token1Identifier: "C", Token1Amount: ufix(1), Token2Identifier: "Q", Token2Amount: ufix(1)},
+		4: {Token1Identifier: "C", Token1Amount: ufix(1000), Token2Identifier: "A", Token2Amount: ufix(1000)},
+		// no route
+		5: {Token1Identifier: "D", Token1Amount: ufix(1), Token2Identifier: "E", Token2Amount: ufix(1)},
+		// empty pools price nothing
+		6: {Token1Identifier: "F", Token2Identifier: "Q"},
+	}
+	assert.Equal(t, map[string]float64{"Q": 1, "A": 0.5, "B": 1, "C": 1}, Prices(pools, "Q"))
+}
+
+func TestValue(t *testing.T) {
+	identifiers := map[string]string{"FLOW": "FLOW", "FUSD": "Q", "EMU": "EMU", "xEMU": "xEMU"}
+	holdings := emuswap.Holdings{
+		Balances:   map[string]emuswap.UFix64{"flowTokenBalance": ufix(4), "xEmuTokenBalance": ufix(2)},
+		LPBalances: map[uint64]emuswap.UFix64{0: ufix(0.5)},
+		Stakes: map[uint64]emuswap.StakeInfo{0: {
+			Balance:        ufix(0.25),
+			PendingRewards: map[uint64]emuswap.Fix64{0: emuswap.Fix64FromFloat(8), 1: emuswap.Fix64FromFloat(-1)},
+		}},
+		AirdropClaims:    []emuswap.AirdropClaim{{ID: 2, Amount: ufix(1), TokenIdentifier: "OTHER"}},
+		Pools:            map[uint64]emuswap.PoolMeta{0: {Token1Identifier: "FLOW", Token1Amount: ufix(100), Token2Identifier: "Q", Token2Amount: ufix(50), TotalSupply: ufix(1)}, 1: {Token1Identifier: "EMU", Token1Amount: ufix(40), Token2Identifier: "Q", Token2Amount: ufix(10)}},
+		RewardPoolTokens: map[uint64]string{0: "EMU"},
+		EmuPerXEmu:       ufix(1.5),
+	}
+	p := Value(holdings, "0x01", identifiers, "Q")
+	value := func(f float64) *emuswap.UFix64 { v := ufix(f); return &v }
+	id := func(n uint64) *uint64 { return &n }
+	assert.Equal(t, []Position{
+		{Kind: Wallet, Token: "FLOW", Amount: ufix(4), Value: value(2)},
+		{Kind: XEmu, Token: "xEMU", Amount: ufix(2), Underlying: []Amount{{Token: "EMU", Amount: ufix(3)}}, Value: value(0.75)},
+		{Kind: LP, Amount: ufix(0.5), PoolID: id(0), Underlying: []Amount{{Token: "FLOW", Amount: ufix(50)}, {Token: "Q", Amount: ufix(25)}}, Value: value(50)},
+		{Kind: Stake, Amount: ufix(0.25), PoolID: id(0), FarmID: id(0), Underlying: []Amount{{Token: "FLOW", Amount: ufix(25)}, {Token: "Q", Amount: ufix(12.5)}}, Value: value(25)},
+		{Kind: Reward, Token: "EMU", Amount: ufix(8), FarmID: id(0), RewardPoolID: id(0), Value: value(2)},
+		{Kind: Airdrop, Token: "OTHER", Amount: ufix(1), DropID: id(2)},
+	}, p.Positions)
+	assert.Equal(t, ufix(79.75), p.Total)
+	assert.Equal(t, []string{"OTHER"}, p.Unpriced)
+
+	var buf bytes.Buffer
+	require.NoError(t, p.Write(&buf))
+	assert.Contains(t, buf.String(), "WARNING no route from OTHER to Q")
+}
+
+func TestLoad(t *testing.T) {
+	o, err := emutest.Start()
+	require.NoError(t, err)
+	c := emuswap.NewClient(o)
+	flowToken, fusd, emu := emuswap.MustLookupToken("FLOW"), emuswap.MustLookupToken("FUSD"), emuswap.MustLookupToken("EMU")
+
+	c.DemoMintFlowTokens("account", ufix(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.FUSDSetup("account").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", ufix(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.EmuSwapAdminCreateNewPool("account", flowToken.StoragePath, ufix(100.0), fusd.StoragePath, ufix(50.0)).Test(t).AssertSuccess()
+	c.EmuSwapAdminCreateNewPool("account", emu.StoragePath, ufix(100.0), flowToken.StoragePath, ufix(50.0)).Test(t).AssertSuccess()
+	c.StakingAdminToggleMockTime("account").Test(t).AssertSuccess()
+	c.StakingAdminCreateNewFarm("account", 0).Test(t).AssertSuccess()
+	c.StakingUserStake("account", 0, ufix(0.5)).Test(t).AssertSuccess()
+	c.StakingAdminUpdateMockTimestamp("account", ufix(100.0)).Test(t).AssertSuccess()
+	c.XEmuSetup("account").Test(t).AssertSuccess()
+	c.XEmuEnterPool("account", ufix(10.0)).Test(t).AssertSuccess()
+	// a drop of FLOW the signer can claim 10 of
+	c.FTAirdropCreateDrop("account", ufix(20.0)).Test(t).AssertSuccess()
+
+	p, err := Load(c, c.Address("account"), "FUSD")
+	require.NoError(t, err)
+	assert.Equal(t, Version, p.Version)
+	assert.Equal(t, "A.f8d6e0586b0a20c7.FUSD.Vault", p.Quote)
+	assert.Equal(t, map[string]float64{
+		"A.f8d6e0586b0a20c7.FUSD.Vault":      1,
+		"A.0ae53cb6e3f42a79.FlowToken.Vault": 0.5,
+		"A.f8d6e0586b0a20c7.EmuToken.Vault":  0.25,
+	}, p.Prices)
+	assert.Empty(t, p.Unpriced)
+
+	byKind := map[Kind][]Position{}
+	total := emuswap.UFix64(0)
+	for _, position := range p.Positions {
+		byKind[position.Kind] = append(byKind[position.Kind], position)
+		require.NotNil(t, position.Value, position.Kind)
+		total += *position.Value
+	}
+	assert.Equal(t, total, p.Total)
+	assert.Len(t, byKind[Wallet], 3, "FLOW, FUSD and EMU")
+
+	require.Len(t, byKind[LP], 2)
+	assert.Equal(t, ufix(0.5), byKind[LP][0].Amount, "half of the LP of pool 0 is staked")
+	require.Len(t, byKind[Stake], 1)
+	stake := byKind[Stake][0]
+	assert.Equal(t, ufix(0.5), stake.Amount)
+	assert.Equal(t, []Amount{{Token: "A.0ae53cb6e3f42a79.FlowToken.Vault", Amount: ufix(50.0)}, {Token: "A.f8d6e0586b0a20c7.FUSD.Vault", Amount: ufix(25.0)}}, stake.Underlying)
+	assert.Equal(t, ufix(50.0), *stake.Value)
+
+	require.Len(t, byKind[Reward], 1)
+	reward := byKind[Reward][0]
+	assert.Equal(t, "A.f8d6e0586b0a20c7.EmuToken.Vault", reward.Token)
+	assert.Equal(t, ufix(100.0), reward.Amount)
+	assert.Equal(t, ufix(25.0), *reward.Value)
+
+	require.Len(t, byKind[XEmu], 1)
+	assert.Equal(t, ufix(2.5), *byKind[XEmu][0].Value)
+
+	require.Len(t, byKind[Airdrop], 1)
+	assert.Equal(t, ufix(10.0), byKind[Airdrop][0].Amount)
+	assert.Equal(t, ufix(5.0), *byKind[Airdrop][0].Value)
+
+	// the service account vests EMU
+	allowance, err := c.VestingGetUnlockAllowance(c.Address("account"))
+	require.NoError(t, err)
+	if allowance > 0 {
+		require.Len(t, byKind[Vesting], 1)
+		assert.Equal(t, allowance, byKind[Vesting][0].Amount)
+	}
+
+	// an address holding nothing but the FLOW accounts are created with,
+	// without vesting or stakes
+	empty, err := Load(c, c.Address("user3"), "FUSD")
+	require.NoError(t, err)
+	require.Len(t, empty.Positions, 1)
+	assert.Equal(t, Wallet, empty.Positions[0].Kind)
+	assert.Equal(t, "A.0ae53cb6e3f42a79.FlowToken.Vault", empty.Positions[0].Token)
+}
@@ -0,0 +1,185 @@
+// Package profile records the computation and the number of events of
+// transactions run on the in-memory emulator, compares them against budgets
+// kept in a baselines file and sweeps the cost of the main transactions
+// against the number of pools, reward pools and stakers.
+//
+// Only the in-memory emulator reports computation, the numbers come from its
+// log.
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bjartek/overflow/overflow"
+)
+
+// Sample is one execution of a transaction.
+type Sample struct {
+	// Name is the transaction file relative to transactions/, e.g.
+	// EmuSwap/user/swap, or templates/swap for rendered transactions.
+	Name        string
+	Computation int
+	Events      int
+	Failed      bool
+}
+
+// Recorder collects samples of the transactions sent through it.
+type Recorder struct {
+	mu      sync.Mutex
+	samples []Sample
+}
+
+// Send sends tx and records its cost.
+func (r *Recorder) Send(tx overflow.FlowTransactionBuilder) *overflow.OverflowResult {
+	result := tx.Send()
+	r.mu.Lock()
+	r.samples = append(r.samples, Sample{
+		Name:        tx.FileName,
+		Computation: result.ComputationUsed,
+		Events:      len(result.RawEvents),
+		Failed:      result.Err != nil,
+	})
+	r.mu.Unlock()
+	return result
+}
+
+// Add records samples measured elsewhere, like those of suite.ReadSamples.
+func (r *Recorder) Add(samples ...Sample) {
+	r.mu.Lock()
+	r.samples = append(r.samples, samples...)
+	r.mu.Unlock()
+}
+
+// Test is tx.Test(t) recording the cost of tx.
+func (r *Recorder) Test(t *testing.T, tx overflow.FlowTransactionBuilder) overflow.TransactionResult {
+	result := r.Send(tx)
+	var events []*overflow.FormatedEvent
+	for _, event := range result.RawEvents {
+		events = append(events, overflow.ParseEvent(event, 0, zeroTime, nil))
+	}
+	return overflow.TransactionResult{Err: result.Err, Events: events, Result: result, Testing: t}
+}
+
+var zeroTime = time.Unix(0, 0)
+
+// Samples returns the recorded samples in the order they were sent.
+func (r *Recorder) Samples() []Sample {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Sample(nil), r.samples...)
+}
+
+// Costliest returns the most expensive successful sample of every transaction.
+func (r *Recorder) Costliest() map[string]Sample {
+	costliest := map[string]Sample{}
+	for _, sample := range r.Samples() {
+		if sample.Failed {
+			continue
+		}
+		if current, ok := costliest[sample.Name]; !ok || sample.Computation > current.Computation {
+			costliest[sample.Name] = sample
+		}
+	}
+	return costliest
+}
+
+// Baseline is the recorded cost of a transaction and the computation it may
+// use before Check fails. A pinned budget was set by hand and is kept by
+// Update.
+type Baseline struct {
+	Computation int  `json:"computation"`
+	Events      int  `json:"events"`
+	Budget      int  `json:"budget"`
+	Pinned      bool `json:"pinned,omitempty"`
+}
+
+// Baselines are keyed by transaction name.
+type Baselines map[string]Baseline
+
+// LoadBaselines reads a baselines file, a missing file gives no baselines.
+func LoadBaselines(path string) (Baselines, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Baselines{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var baselines Baselines
+	if err := json.Unmarshal(data, &baselines); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return baselines, nil
+}
+
+// Save writes the baselines, sorted by name.
+func (b Baselines) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Update returns the baselines with the costliest samples recorded, budgets
+// allowing headroom more computation, e.g. 0.1 for 10%. Pinned budgets are
+// kept, every other budget follows the sample.
+func (b Baselines) Update(costliest map[string]Sample, headroom float64) Baselines {
+	updated := Baselines{}
+	for name, baseline := range b {
+		updated[name] = baseline
+	}
+	for name, sample := range costliest {
+		baseline := Baseline{
+			Computation: sample.Computation,
+			Events:      sample.Events,
+			Budget:      int(math.Ceil(float64(sample.Computation) * (1 + headroom))),
+		}
+		if previous := b[name]; previous.Pinned {
+			baseline.Budget, baseline.Pinned = previous.Budget, true
+		}
+		updated[name] = baseline
+	}
+	return updated
+}
+
+// Violation is a transaction that exceeded its budget or changed its events.
+type Violation struct {
+	Name     string
+	Sample   Sample
+	Baseline Baseline
+	Reason   string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Name, v.Reason)
+}
+
+// Check compares the costliest samples with the baselines. Transactions
+// without a baseline are violations too, so new transactions get a budget.
+func (b Baselines) Check(costliest map[string]Sample) []Violation {
+	var violations []Violation
+	for name, sample := range costliest {
+		baseline, ok := b[name]
+		switch {
+		case !ok:
+			violations = append(violations, Violation{name, sample, baseline, "no baseline"})
+		case sample.Computation > baseline.Budget:
+			violations = append(violations, Violation{name, sample, baseline,
+				fmt.Sprintf("computation %d exceeds budget %d (baseline %d)", sample.Computation, baseline.Budget, baseline.Computation)})
+		case sample.Events != baseline.Events:
+			violations = append(violations, Violation{name, sample, baseline,
+				fmt.Sprintf("%d events, baseline %d", sample.Events, baseline.Events)})
+		}
+	}
+	sort.Slice(violations, func(i, j int) bool { return violations[i].Name < violations[j].Name })
+	return violations
+}
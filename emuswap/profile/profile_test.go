@@ -0,0 +1,121 @@
+package profile
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/internal/emutest"
+)
+
+var update = flag.Bool("update", false, "rewrite baselines.json with the recorded costs")
+
+// BaselinesFile is relative to the repository root the tests run from.
+const baselinesFile = "emuswap/profile/baselines.json"
+
+// TestMain runs the package tests from the repository root, where flow.json
+// and the files it references resolve.
+func TestMain(m *testing.M) {
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(emutest.Main(m))
+}
+
+// TestBudgets fails when a transaction of the scenario uses more computation
+// than its budget or emits a different number of events. After an intended
+// change run
+//
+//	go test ./emuswap/profile -run TestBudgets -update
+func TestBudgets(t *testing.T) {
+	o, err := emutest.Start()
+	require.NoError(t, err)
+	var r Recorder
+	require.NoError(t, Scenario(emuswap.NewClient(o), &r))
+
+	baselines, err := LoadBaselines(baselinesFile)
+	require.NoError(t, err)
+	costliest := r.Costliest()
+	for _, name := range []string{"templates/swap", "templates/add_liquidity_and_stake", "Staking/user/claim_rewards", "EmuSwap/admin/withdraw_fees"} {
+		assert.Contains(t, costliest, name)
+		assert.NotZero(t, costliest[name].Computation, name)
+	}
+
+	if *update {
+		require.NoError(t, baselines.Update(costliest, 0.1).Save(baselinesFile))
+		return
+	}
+	for _, violation := range baselines.Check(costliest) {
+		t.Errorf("%s, run go test ./emuswap/profile -run TestBudgets -update if intended", violation)
+	}
+}
+
+func TestBaselines(t *testing.T) {
+	baselines := Baselines{
+		"kept":    {Computation: 10, Events: 1, Budget: 11},
+		"raised":  {Computation: 10, Events: 1, Budget: 50},
+		"pinned":  {Computation: 10, Events: 1, Budget: 50, Pinned: true},
+		"changed": {Computation: 10, Events: 1, Budget: 11},
+	}
+	costliest := map[string]Sample{
+		"kept":    {Name: "kept", Computation: 11, Events: 1},
+		"raised":  {Name: "raised", Computation: 40, Events: 1},
+		"pinned":  {Name: "pinned", Computation: 40, Events: 1},
+		"changed": {Name: "changed", Computation: 10, Events: 2},
+		"new":     {Name: "new", Computation: 5, Events: 0},
+	}
+	var reasons []string
+	for _, violation := range baselines.Check(costliest) {
+		reasons = append(reasons, violation.String())
+	}
+	assert.Equal(t, []string{"changed: 2 events, baseline 1", "new: no baseline"}, reasons)
+
+	costliest["kept"] = Sample{Name: "kept", Computation: 12, Events: 1}
+	assert.Len(t, baselines.Check(costliest), 3)
+
+	updated := baselines.Update(costliest, 0.1)
+	assert.Empty(t, updated.Check(costliest))
+	assert.Equal(t, Baseline{Computation: 12, Events: 1, Budget: 14}, updated["kept"])
+	assert.Equal(t, Baseline{Computation: 40, Events: 1, Budget: 44}, updated["raised"])
+	assert.Equal(t, Baseline{Computation: 40, Events: 1, Budget: 50, Pinned: true}, updated["pinned"])
+	assert.Equal(t, 6, updated["new"].Budget)
+
+	path := filepath.Join(t.TempDir(), "baselines.json")
+	require.NoError(t, updated.Save(path))
+	loaded, err := LoadBaselines(path)
+	require.NoError(t, err)
+	assert.Equal(t, updated, loaded)
+
+	missing, err := LoadBaselines(filepath.Join(t.TempDir(), "missing.json"))
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+}
+
+func TestSweepRewardPools(t *testing.T) {
+	points, err := Sweeps[1].Run(3)
+	require.NoError(t, err)
+
+	claims := map[int]int{}
+	for _, p := range points {
+		assert.Equal(t, "rewardPools", p.Dimension)
+		if p.Sample.Name == "Staking/user/claim_rewards" {
+			claims[p.Size] = p.Sample.Computation
+		}
+	}
+	// claimRewards loops over every reward pool
+	require.Len(t, claims, 3)
+	assert.Less(t, claims[1], claims[2])
+	assert.Less(t, claims[2], claims[3])
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteSweep(&buf, points))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, "dimension,size,transaction,computation,events", lines[0])
+	assert.Len(t, lines, len(points)+1)
+}
@@ -0,0 +1,76 @@
+package profile
+
+import (
+	"fmt"
+
+	"github.com/bjartek/overflow/overflow"
+	"swap.emudao.org/test-overflow/emuswap"
+)
+
+var ufix = emuswap.UFix64FromFloat
+
+// run sends every transaction through r and stops at the first failure.
+func run(r *Recorder, txs ...overflow.FlowTransactionBuilder) error {
+	for _, tx := range txs {
+		if result := r.Send(tx); result.Err != nil {
+			return fmt.Errorf("%s: %w", tx.FileName, result.Err)
+		}
+	}
+	return nil
+}
+
+// fund gives account a FLOW, FUSD and EMU balance.
+func fund(c *emuswap.Client, r *Recorder, account string) error {
+	if account == "account" {
+		return run(r,
+			c.DemoMintFlowTokens("account", ufix(100000.0), c.Address("account")),
+			c.FUSDSetup("account"),
+			c.DemoMintFUSD("account", ufix(100000.0), c.Address("account")),
+		)
+	}
+	return run(r,
+		c.DemoMintFlowTokens("account", ufix(1000.0), c.Address(account)),
+		c.FUSDSetup(account),
+		c.DemoMintFUSD("account", ufix(1000.0), c.Address(account)),
+		c.EmuTokenSetup(account),
+		c.EmuTokenTransfer("account", ufix(1000.0), c.Address(account)),
+	)
+}
+
+// Scenario runs the transactions of a pool's life on an emulator with the
+// contracts deployed: pool and farm creation, liquidity, staking, swaps in
+// both directions, reward claims and the fee sweep to the DAO.
+func Scenario(c *emuswap.Client, r *Recorder) error {
+	flowToken, fusd, emu := emuswap.MustLookupToken("FLOW"), emuswap.MustLookupToken("FUSD"), emuswap.MustLookupToken("EMU")
+
+	if err := fund(c, r, "account"); err != nil {
+		return err
+	}
+	if err := fund(c, r, "user1"); err != nil {
+		return err
+	}
+	return run(r,
+		c.EmuSwapAdminCreateNewPool("account", flowToken.StoragePath, ufix(1000.0), fusd.StoragePath, ufix(500.0)),
+		c.EmuSwapAdminCreateNewPool("account", flowToken.StoragePath, ufix(1000.0), emu.StoragePath, ufix(1000.0)),
+		c.EmuSwapAdminUpdateLPFeePercentage("account", 0, ufix(0.0025)),
+		c.EmuSwapAdminUpdateDAOFeePercentage("account", 0, ufix(0.0005)),
+		c.StakingAdminCreateNewFarm("account", 0),
+		c.StakingAdminCreateRewardPool("account", emu.StoragePath, ufix(10000.0), []string{}),
+		c.StakingAdminToggleMockTime("account"),
+
+		c.AddLiquidityAndStake("user1", 0, flowToken, fusd, ufix(10.0), ufix(5.0)),
+		c.AddLiquidityAndStake("user1", 0, flowToken, fusd, ufix(10.0), ufix(5.0)),
+		c.Swap("user1", flowToken, fusd, ufix(10.0)),
+		c.Swap("user1", fusd, flowToken, ufix(5.0)),
+		c.Swap("user1", flowToken, emu, ufix(1.0)),
+		// sendEmuFeesToDAO needs EMU fees to deposit into
+		c.Swap("user1", emu, flowToken, ufix(1.0)),
+		c.AddLiquidity("user1", flowToken, fusd, ufix(2.0), ufix(1.0)),
+		c.RemoveLiquidity("user1", flowToken, fusd, ufix(0.001)),
+
+		c.StakingAdminUpdateMockTimestamp("account", ufix(3600.0)),
+		c.StakingUserClaimRewards("user1", 0),
+		c.StakingUserUnstake("user1", 0, ufix(0.001)),
+		c.EmuSwapAdminWithdrawFees("account"),
+	)
+}
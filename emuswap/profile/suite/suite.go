@@ -0,0 +1,251 @@
+// Package suite records the computation and the number of events of the
+// transactions the Go tests run on the in-memory emulator, so the budgets of
+// cmd/profile -suite cover the whole test suite and not only the profiling
+// scenario.
+//
+// The tests record when EMUSWAP_PROFILE names a directory: Collect, called by
+// the test helper starting an emulator, wraps its runtime, and Run, called
+// from TestMain, writes the samples of the test binary to a file in the
+// directory. The runtime reports the computation of the transaction body, the
+// number the emulator logs for overflow.
+package suite
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/cadence/runtime"
+	"github.com/onflow/flow-go/model/flow"
+	"swap.emudao.org/test-overflow/internal/emulator"
+)
+
+// EnvDir is the environment variable naming the directory Run writes samples
+// to. A relative path is relative to the directory the tests run in, the
+// repository root for the tests of this module.
+const EnvDir = "EMUSWAP_PROFILE"
+
+// TransactionsDir is where the transaction files samples are named after
+// live, relative to the directory the tests run in.
+const TransactionsDir = "transactions"
+
+// Sample is one execution of a transaction, named like profile.Sample.
+type Sample struct {
+	Name        string
+	Computation int
+	Events      int
+	Failed      bool
+}
+
+// Recorder records the transactions the runtime of one emulator executes.
+type Recorder struct {
+	mu      sync.Mutex
+	samples []Sample
+}
+
+// Samples returns the recorded samples in the order they were executed.
+func (r *Recorder) Samples() []Sample {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Sample(nil), r.samples...)
+}
+
+// transactionEnv is what the FVM passes the runtime for a transaction.
+type transactionEnv interface {
+	ComputationUsed() uint64
+	Events() []flow.Event
+}
+
+type recordingRuntime struct {
+	runtime.Runtime
+	recorder *Recorder
+}
+
+func (r recordingRuntime) Unwrap() runtime.Runtime { return r.Runtime }
+
+func (r recordingRuntime) ExecuteTransaction(script runtime.Script, context runtime.Context) error {
+	err := r.Runtime.ExecuteTransaction(script, context)
+	env, ok := context.Interface.(transactionEnv)
+	name := transactionName(script.Source)
+	if !ok || name == "" {
+		return err
+	}
+	r.recorder.mu.Lock()
+	r.recorder.samples = append(r.recorder.samples, Sample{
+		Name:        name,
+		Computation: int(env.ComputationUsed()),
+		Events:      len(env.Events()),
+		Failed:      err != nil,
+	})
+	r.recorder.mu.Unlock()
+	return err
+}
+
+// Attach records the transactions the emulator of o executes from now on.
+// Attaching to an emulator again returns the recorder it already has.
+func Attach(o *overflow.Overflow) (*Recorder, error) {
+	vm, err := emulator.VirtualMachine(o)
+	if err != nil {
+		return nil, err
+	}
+	for rt := vm.Runtime; rt != nil; rt = emulator.Unwrap(rt) {
+		if attached, ok := rt.(recordingRuntime); ok {
+			return attached.recorder, nil
+		}
+	}
+	r := &Recorder{}
+	vm.Runtime = recordingRuntime{Runtime: vm.Runtime, recorder: r}
+	return r, nil
+}
+
+var generated = regexp.MustCompile(`(?m)^// Generated from emuswap/(templates/\w+)\.cdc\.tmpl$`)
+
+// transactionName names code rendered from a template after the template,
+// e.g. templates/swap, and code of a transaction file after the file relative
+// to TransactionsDir, e.g. EmuSwap/user/swap. Other code, like the
+// transactions overflow sends to set up the emulator, gets no name.
+func transactionName(code []byte) string {
+	if match := generated.FindSubmatch(code); match != nil {
+		return string(match[1])
+	}
+	files.Do(indexFiles)
+	return files.names[normalize(code)]
+}
+
+var files struct {
+	sync.Once
+	names map[string]string
+}
+
+func indexFiles() {
+	files.names = map[string]string{}
+	filepath.WalkDir(TransactionsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".cdc" {
+			return nil
+		}
+		code, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		name, err := filepath.Rel(TransactionsDir, strings.TrimSuffix(path, ".cdc"))
+		if err != nil {
+			return nil
+		}
+		key := normalize(code)
+		if _, ok := files.names[key]; !ok {
+			files.names[key] = filepath.ToSlash(name)
+		}
+		return nil
+	})
+}
+
+// normalize drops the imports, whose locations overflow replaces with
+// addresses, and blank lines and indentation.
+func normalize(code []byte) string {
+	var b strings.Builder
+	for _, line := range strings.Split(string(code), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "import ") {
+			continue
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+var collected struct {
+	sync.Mutex
+	recorders []*Recorder
+}
+
+// Collect attaches to the emulator of o for Run when EnvDir is set, and does
+// nothing otherwise or when o runs no in-memory emulator.
+func Collect(o *overflow.Overflow) error {
+	if os.Getenv(EnvDir) == "" {
+		return nil
+	}
+	r, err := Attach(o)
+	if errors.Is(err, emulator.ErrNotEmulator) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	collected.Lock()
+	defer collected.Unlock()
+	for _, c := range collected.recorders {
+		if c == r {
+			return nil
+		}
+	}
+	collected.recorders = append(collected.recorders, r)
+	return nil
+}
+
+// Run runs the tests and, when EnvDir is set, writes the samples of the
+// emulators Collect attached to to a new file in the directory. It returns
+// the exit code for os.Exit.
+func Run(m interface{ Run() int }) int {
+	code := m.Run()
+	dir := os.Getenv(EnvDir)
+	if dir == "" {
+		return code
+	}
+	var samples []Sample
+	collected.Lock()
+	for _, r := range collected.recorders {
+		samples = append(samples, r.Samples()...)
+	}
+	collected.Unlock()
+	if err := write(dir, samples); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if code == 0 {
+			code = 1
+		}
+	}
+	return code
+}
+
+func write(dir string, samples []Sample) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, "samples-*.json")
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(samples); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadSamples returns the samples Run wrote to dir.
+func ReadSamples(dir string) ([]Sample, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "samples-*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var samples []Sample
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var s []Sample
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		samples = append(samples, s...)
+	}
+	return samples, nil
+}
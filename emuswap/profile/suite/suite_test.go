@@ -0,0 +1,84 @@
+package suite_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/coverage"
+	. "swap.emudao.org/test-overflow/emuswap/profile/suite"
+	"swap.emudao.org/test-overflow/internal/emutest"
+)
+
+// TestMain runs the package tests from the repository root, where flow.json
+// and the files it references resolve.
+func TestMain(m *testing.M) {
+	if err := os.Chdir("../../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(emutest.Main(m))
+}
+
+var ufix = emuswap.UFix64FromFloat
+
+func TestAttach(t *testing.T) {
+	o, err := overflow.NewTestingEmulator().StartE()
+	require.NoError(t, err)
+	r, err := Attach(o)
+	require.NoError(t, err)
+	// coverage wraps the runtime on top of the recorder
+	_, err = coverage.Attach(o)
+	require.NoError(t, err)
+	again, err := Attach(o)
+	require.NoError(t, err)
+	assert.Same(t, r, again)
+
+	c := emuswap.NewClient(o)
+	flowToken, fusd := emuswap.MustLookupToken("FLOW"), emuswap.MustLookupToken("FUSD")
+	setup := c.FUSDSetup("account").Send()
+	require.NoError(t, setup.Err)
+	c.DemoMintFUSD("account", ufix(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.EmuSwapAdminCreateNewPool("account", flowToken.StoragePath, ufix(100.0), fusd.StoragePath, ufix(50.0)).Test(t).AssertSuccess()
+	swap := c.Swap("account", flowToken, fusd, ufix(10.0)).Send()
+	require.NoError(t, swap.Err)
+	c.Swap("account", fusd, flowToken, ufix(5000.0)).Test(t).AssertFailure("Amount withdrawn must be less than or equal than the balance of the Vault")
+
+	samples := r.Samples()
+	require.Len(t, samples, 5)
+	assert.Equal(t, Sample{Name: "FUSD/setup", Computation: setup.ComputationUsed, Events: len(setup.RawEvents)}, samples[0])
+	assert.Equal(t, "EmuSwap/admin/create_new_pool", samples[2].Name)
+	assert.Equal(t, Sample{Name: "templates/swap", Computation: swap.ComputationUsed, Events: len(swap.RawEvents)}, samples[3])
+	assert.Equal(t, "templates/swap", samples[4].Name)
+	assert.True(t, samples[4].Failed)
+}
+
+type tests func() int
+
+func (run tests) Run() int { return run() }
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(EnvDir, dir)
+	code := Run(tests(func() int {
+		o, err := overflow.NewTestingEmulator().StartE()
+		require.NoError(t, err)
+		require.NoError(t, Collect(o))
+		require.NoError(t, Collect(o))
+		emuswap.NewClient(o).FUSDSetup("user1").Test(t).AssertSuccess()
+		return 3
+	}))
+	assert.Equal(t, 3, code)
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+	assert.Len(t, paths, 1)
+	samples, err := ReadSamples(dir)
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	assert.Equal(t, "FUSD/setup", samples[0].Name)
+	assert.NotZero(t, samples[0].Computation)
+}
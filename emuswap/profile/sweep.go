@@ -0,0 +1,189 @@
+package profile
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/bjartek/overflow/overflow"
+	"swap.emudao.org/test-overflow/emuswap"
+)
+
+// Point is the cost of a transaction at one size of a swept dimension.
+type Point struct {
+	Dimension string
+	Size      int
+	Sample    Sample
+}
+
+// Sweep measures transactions on a fresh emulator for every size from 1 to
+// max. Setup transactions go to setup, the measured ones to measure.
+type Sweep struct {
+	Dimension string
+	// Max is the largest size the emulator accounts and tokens allow.
+	Max int
+	run func(c *emuswap.Client, setup, measure *Recorder, n int) error
+}
+
+// Run sweeps sizes 1 to max, capped at s.Max.
+func (s Sweep) Run(max int) ([]Point, error) {
+	if max > s.Max {
+		max = s.Max
+	}
+	var points []Point
+	for n := 1; n <= max; n++ {
+		o, err := overflow.NewTestingEmulator().StartE()
+		if err != nil {
+			return nil, err
+		}
+		var setup, measure Recorder
+		if err := s.run(emuswap.NewClient(o), &setup, &measure, n); err != nil {
+			return nil, fmt.Errorf("%s %d: %w", s.Dimension, n, err)
+		}
+		costliest := measure.Costliest()
+		names := make([]string, 0, len(costliest))
+		for name := range costliest {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			points = append(points, Point{Dimension: s.Dimension, Size: n, Sample: costliest[name]})
+		}
+	}
+	return points, nil
+}
+
+// Sweeps are the dimensions the contracts loop over.
+var Sweeps = []Sweep{
+	{Dimension: "pools", Max: len(sweepPairs), run: sweepPools},
+	{Dimension: "rewardPools", Max: 8, run: sweepRewardPools},
+	{Dimension: "stakers", Max: len(stakerAccounts), run: sweepStakers},
+}
+
+// sweepPairs are the pools the pools sweep creates, in order.
+var sweepPairs = [][2]string{
+	{"FLOW", "FUSD"},
+	{"FLOW", "EMU"},
+	{"FUSD", "EMU"},
+	{"xEMU", "EMU"},
+	{"FLOW", "xEMU"},
+	{"FUSD", "xEMU"},
+}
+
+// sweepPools creates n pools and swaps both ways in each, so every token of
+// the pools has collected fees, then swaps in the first pool and sends the
+// fees to the DAO.
+func sweepPools(c *emuswap.Client, setup, measure *Recorder, n int) error {
+	if err := fund(c, setup, "account"); err != nil {
+		return err
+	}
+	if err := run(setup, c.XEmuEnterPool("account", ufix(10000.0))); err != nil {
+		return err
+	}
+	for _, pair := range sweepPairs[:n] {
+		token1, token2 := emuswap.MustLookupToken(pair[0]), emuswap.MustLookupToken(pair[1])
+		if err := run(setup,
+			c.EmuSwapAdminCreateNewPool("account", token1.StoragePath, ufix(1000.0), token2.StoragePath, ufix(1000.0)),
+			c.Swap("account", token1, token2, ufix(1.0)),
+			c.Swap("account", token2, token1, ufix(1.0)),
+		); err != nil {
+			return err
+		}
+	}
+	txs := []overflow.FlowTransactionBuilder{
+		c.Swap("account", emuswap.MustLookupToken("FLOW"), emuswap.MustLookupToken("FUSD"), ufix(1.0)),
+	}
+	// without an EMU pool there are no EMU fees to send
+	if n > 1 {
+		txs = append(txs, c.EmuSwapAdminWithdrawFees("account"))
+	}
+	return run(measure, txs...)
+}
+
+// sweepRewardPools creates n EMU reward pools for the FLOW/FUSD farm and stakes,
+// claims and unstakes once.
+func sweepRewardPools(c *emuswap.Client, setup, measure *Recorder, n int) error {
+	flowToken, fusd, emu := emuswap.MustLookupToken("FLOW"), emuswap.MustLookupToken("FUSD"), emuswap.MustLookupToken("EMU")
+	if err := fund(c, setup, "account"); err != nil {
+		return err
+	}
+	if err := fund(c, setup, "user1"); err != nil {
+		return err
+	}
+	if err := run(setup,
+		c.EmuSwapAdminCreateNewPool("account", flowToken.StoragePath, ufix(1000.0), fusd.StoragePath, ufix(500.0)),
+		c.StakingAdminCreateNewFarm("account", 0),
+		c.StakingAdminToggleMockTime("account"),
+	); err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		if err := run(setup, c.StakingAdminCreateRewardPool("account", emu.StoragePath, ufix(1000.0), []string{})); err != nil {
+			return err
+		}
+	}
+	if err := run(measure, c.AddLiquidityAndStake("user1", 0, flowToken, fusd, ufix(10.0), ufix(5.0))); err != nil {
+		return err
+	}
+	if err := run(setup, c.StakingAdminUpdateMockTimestamp("account", ufix(3600.0))); err != nil {
+		return err
+	}
+	return run(measure,
+		c.StakingUserClaimRewards("user1", 0),
+		c.StakingUserUnstake("user1", 0, ufix(0.001)),
+	)
+}
+
+// stakerAccounts are the emulator accounts that can stake.
+var stakerAccounts = []string{"user1", "user2", "user3", "admin-account"}
+
+// sweepStakers stakes with n accounts, then the last one claims and unstakes.
+func sweepStakers(c *emuswap.Client, setup, measure *Recorder, n int) error {
+	flowToken, fusd, emu := emuswap.MustLookupToken("FLOW"), emuswap.MustLookupToken("FUSD"), emuswap.MustLookupToken("EMU")
+	if err := fund(c, setup, "account"); err != nil {
+		return err
+	}
+	if err := run(setup,
+		c.EmuSwapAdminCreateNewPool("account", flowToken.StoragePath, ufix(1000.0), fusd.StoragePath, ufix(500.0)),
+		c.StakingAdminCreateNewFarm("account", 0),
+		c.StakingAdminCreateRewardPool("account", emu.StoragePath, ufix(1000.0), []string{}),
+		c.StakingAdminToggleMockTime("account"),
+	); err != nil {
+		return err
+	}
+	stakers := stakerAccounts[:n]
+	for i, staker := range stakers {
+		if err := fund(c, setup, staker); err != nil {
+			return err
+		}
+		recorder := setup
+		if i == n-1 {
+			recorder = measure
+		}
+		if err := run(recorder, c.AddLiquidityAndStake(staker, 0, flowToken, fusd, ufix(10.0), ufix(5.0))); err != nil {
+			return err
+		}
+	}
+	if err := run(setup, c.StakingAdminUpdateMockTimestamp("account", ufix(3600.0))); err != nil {
+		return err
+	}
+	last := stakers[n-1]
+	return run(measure,
+		c.StakingUserClaimRewards(last, 0),
+		c.StakingUserUnstake(last, 0, ufix(0.001)),
+	)
+}
+
+// WriteSweep writes the points as CSV, one row per dimension, size and
+// transaction.
+func WriteSweep(w io.Writer, points []Point) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"dimension", "size", "transaction", "computation", "events"})
+	for _, p := range points {
+		out.Write([]string{p.Dimension, strconv.Itoa(p.Size), p.Sample.Name, strconv.Itoa(p.Sample.Computation), strconv.Itoa(p.Sample.Events)})
+	}
+	out.Flush()
+	return out.Error()
+}
@@ -0,0 +1,135 @@
+package provision
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// member is a member of a JSON object, objects are read as lists of members
+// to keep the order of flow.json.
+type member struct {
+	key   string
+	value json.RawMessage
+}
+
+func readObject(data []byte) ([]member, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, fmt.Errorf("not a JSON object")
+	}
+	var members []member
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		members = append(members, member{key: token.(string), value: value})
+	}
+	return members, nil
+}
+
+func writeObject(members []member) ([]byte, error) {
+	var compact bytes.Buffer
+	compact.WriteByte('{')
+	for i, m := range members {
+		if i > 0 {
+			compact.WriteByte(',')
+		}
+		key, err := json.Marshal(m.key)
+		if err != nil {
+			return nil, err
+		}
+		compact.Write(key)
+		compact.WriteByte(':')
+		if err := json.Compact(&compact, m.value); err != nil {
+			return nil, err
+		}
+	}
+	compact.WriteByte('}')
+	return compact.Bytes(), nil
+}
+
+// configured is an account of flow.json in the simple format.
+type configured struct {
+	Address string `json:"address"`
+	Key     string `json:"key,omitempty"`
+	Keys    string `json:"keys,omitempty"`
+}
+
+// WriteAccounts adds the accounts to the accounts of the flow.json at path,
+// named after network like emulator-user4, in the simple format of the other
+// emulator accounts. Accounts already there with the same address and key are
+// left as they are, the rest of the file is kept.
+func WriteAccounts(path, network string, accounts []Account) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	members, err := readObject(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	index := -1
+	for i, m := range members {
+		if m.key == "accounts" {
+			index = i
+		}
+	}
+	if index < 0 {
+		members = append(members, member{key: "accounts", value: json.RawMessage("{}")})
+		index = len(members) - 1
+	}
+	entries, err := readObject(members[index].value)
+	if err != nil {
+		return fmt.Errorf("%s accounts: %w", path, err)
+	}
+
+	for _, account := range accounts {
+		entry := configured{
+			Address: account.Address.Hex(),
+			Key:     strings.TrimPrefix(account.Key.String(), "0x"),
+		}
+		value, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		name := network + "-" + account.Name
+		found := false
+		for i, e := range entries {
+			if e.key != name {
+				continue
+			}
+			found = true
+			var current configured
+			if json.Unmarshal(e.value, &current) == nil && current.Address == entry.Address &&
+				(current.Key == entry.Key || current.Keys == entry.Key) {
+				break
+			}
+			entries[i].value = value
+		}
+		if !found {
+			entries = append(entries, member{key: name, value: value})
+		}
+	}
+
+	if members[index].value, err = writeObject(entries); err != nil {
+		return err
+	}
+	compact, err := writeObject(members)
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, compact, "", "  "); err != nil {
+		return err
+	}
+	out.WriteByte('\n')
+	return os.WriteFile(path, out.Bytes(), 0o644)
+}
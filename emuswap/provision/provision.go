@@ -0,0 +1,235 @@
+// Package provision creates test accounts from deterministic seeds, sets them
+// up to hold FLOW, FUSD, EMU, xEMU and EmuSwap LP tokens and tops up their
+// balances, a batch of accounts per transaction. The accounts are added to the
+// overflow state so transactions can be signed by their names, and
+// WriteAccounts adds them to flow.json for later runs.
+//
+// Provisioned accounts are not added to the deployments of flow.json.
+// Overflow creates the accounts listed there in the order of their names, and
+// the emulator assigns addresses in creation order, so user10 would take the
+// address of user2.
+package provision
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/onflow/flow-cli/pkg/flowkit"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+	"swap.emudao.org/test-overflow/emuswap"
+)
+
+// DefaultSeed is the seed format the keys of user1, user2 and user3 in
+// flow.json were generated from, see the README.
+const DefaultSeed = "Test_User_Account_seed_phrase_%06d"
+
+// Spec describes the accounts to provision.
+type Spec struct {
+	// Count accounts are provisioned, named with the Name format and keyed
+	// from the Seed format, both formatted with 1 to Count.
+	Count int
+	Name  string
+	Seed  string
+	// Balances are topped up to by token symbol. xEMU is bought by entering
+	// the xEMU pool with EMU of the service account.
+	Balances map[string]emuswap.UFix64
+	// BatchSize is the number of accounts created or funded per transaction.
+	BatchSize int
+}
+
+// DefaultSpec provisions user1 and up from DefaultSeed, 50 per transaction.
+var DefaultSpec = Spec{Name: "user%d", Seed: DefaultSeed, BatchSize: 50}
+
+// Account is a provisioned account.
+type Account struct {
+	// Name is the name transactions are signed with, without the network.
+	Name    string
+	Address flow.Address
+	Key     crypto.PrivateKey
+	// Created is set for accounts created by Provision rather than found on
+	// chain with their key.
+	Created bool
+}
+
+// Key generates the ECDSA_P256 key of seed like `flow keys generate --seed`.
+// Seeds are at least 32 bytes.
+func Key(seed string) (crypto.PrivateKey, error) {
+	return crypto.GeneratePrivateKey(crypto.ECDSA_P256, []byte(seed))
+}
+
+// Provision makes sure the accounts of spec exist, are set up and hold at
+// least their balances. Accounts of the state holding their configured key on
+// chain are set up in place, the others are created with the key of their
+// seed, paid by the service account, which also funds them.
+func Provision(c *emuswap.Client, spec Spec) ([]Account, error) {
+	if spec.BatchSize <= 0 {
+		return nil, fmt.Errorf("batch size %d", spec.BatchSize)
+	}
+	for symbol := range spec.Balances {
+		if _, ok := topUps[symbol]; !ok {
+			return nil, fmt.Errorf("no balance of %s can be provisioned", symbol)
+		}
+	}
+	accounts := make([]Account, spec.Count)
+	var create []int
+	for i := range accounts {
+		account := &accounts[i]
+		account.Name = fmt.Sprintf(spec.Name, i+1)
+		key, err := Key(fmt.Sprintf(spec.Seed, i+1))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", account.Name, err)
+		}
+		account.Key = key
+		address, configuredKey, ok := existing(c, account.Name)
+		if !ok {
+			create = append(create, i)
+			continue
+		}
+		account.Address, account.Key = address, configuredKey
+		if err := c.Submit(c.DemoSetupAccount(account.Name)).Err; err != nil {
+			return nil, fmt.Errorf("setting up %s: %w", account.Name, err)
+		}
+	}
+
+	service := c.O.ServiceAccountSuffix
+	for start := 0; start < len(create); start += spec.BatchSize {
+		batch := create[start:min(start+spec.BatchSize, len(create))]
+		publicKeys := make([]string, len(batch))
+		for j, i := range batch {
+			publicKeys[j] = hex.EncodeToString(accounts[i].Key.PublicKey().Encode())
+		}
+		result := c.Submit(c.DemoCreateAccounts(service, publicKeys))
+		if result.Err != nil {
+			return nil, fmt.Errorf("creating %s: %w", accounts[batch[0]].Name, result.Err)
+		}
+		var created []flow.Address
+		for _, event := range result.RawEvents {
+			if event.Type == flow.EventAccountCreated {
+				created = append(created, flow.AccountCreatedEvent(event).Address())
+			}
+		}
+		if len(created) != len(batch) {
+			return nil, fmt.Errorf("%d accounts created for %d keys", len(created), len(batch))
+		}
+		for j, i := range batch {
+			accounts[i].Address = created[j]
+			accounts[i].Created = true
+		}
+	}
+	for _, account := range accounts {
+		state := &flowkit.Account{}
+		state.SetName(stateName(c, account.Name))
+		state.SetAddress(account.Address)
+		state.SetKey(flowkit.NewHexAccountKeyFromPrivateKey(0, crypto.SHA3_256, account.Key))
+		c.O.State.Accounts().AddOrUpdate(state)
+	}
+
+	if len(spec.Balances) == 0 {
+		return accounts, nil
+	}
+	for start := 0; start < len(accounts); start += spec.BatchSize {
+		if err := fund(c, accounts[start:min(start+spec.BatchSize, len(accounts))], spec.Balances); err != nil {
+			return nil, err
+		}
+	}
+	return accounts, nil
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func stateName(c *emuswap.Client, name string) string {
+	if c.O.PrependNetworkToAccountNames {
+		return c.O.Network + "-" + name
+	}
+	return name
+}
+
+// existing returns the address and key of the account name in the state when
+// the account holds the key on chain. Accounts keep the key they are
+// configured with, user3 in flow.json was not generated from DefaultSeed.
+func existing(c *emuswap.Client, name string) (flow.Address, crypto.PrivateKey, bool) {
+	configured, err := c.O.State.Accounts().ByName(stateName(c, name))
+	if err != nil {
+		return flow.EmptyAddress, nil, false
+	}
+	key, err := configured.Key().PrivateKey()
+	if err != nil {
+		return flow.EmptyAddress, nil, false
+	}
+	onChain, err := c.O.Services.Accounts.Get(configured.Address())
+	if err != nil {
+		// not created on this chain yet
+		return flow.EmptyAddress, nil, false
+	}
+	for _, accountKey := range onChain.Keys {
+		if !accountKey.Revoked && accountKey.PublicKey.Equals((*key).PublicKey()) {
+			return configured.Address(), *key, true
+		}
+	}
+	// the address went to another account, as after restarting the emulator
+	return flow.EmptyAddress, nil, false
+}
+
+// topUps are the amounts of fund_accounts.cdc by symbol.
+var topUps = map[string]int{"FLOW": 0, "FUSD": 1, "EMU": 2, "xEMU": 3}
+
+// fund tops the balances of accounts up in one transaction.
+func fund(c *emuswap.Client, accounts []Account, balances map[string]emuswap.UFix64) error {
+	addresses := make([]flow.Address, len(accounts))
+	for i, account := range accounts {
+		addresses[i] = account.Address
+	}
+	paths := map[string]string{}
+	var balancePaths []string
+	for symbol := range balances {
+		token := emuswap.MustLookupToken(symbol)
+		paths[symbol] = token.BalancePath
+		balancePaths = append(balancePaths, token.BalancePath)
+	}
+	held, err := c.GetBalances(addresses, balancePaths)
+	if err != nil {
+		return err
+	}
+	emuPerXEmu := 1.0
+	if _, ok := balances["xEMU"]; ok {
+		holdings, err := c.ReadHoldings(c.Address(c.O.ServiceAccountSuffix))
+		if err != nil {
+			return err
+		}
+		emuPerXEmu = holdings.EmuPerXEmu.Float64()
+	}
+
+	amounts := [4][]emuswap.UFix64{}
+	for i := range amounts {
+		amounts[i] = make([]emuswap.UFix64, len(accounts))
+	}
+	needed := false
+	for i, address := range addresses {
+		for symbol, target := range balances {
+			balance := held[address][paths[symbol]]
+			if balance >= target {
+				continue
+			}
+			amount := target - balance
+			if symbol == "xEMU" {
+				amount = emuswap.UFix64FromFloat(amount.Float64() * emuPerXEmu)
+			}
+			amounts[topUps[symbol]][i] = amount
+			needed = true
+		}
+	}
+	if !needed {
+		return nil
+	}
+	err = c.Submit(c.DemoFundAccounts(c.O.ServiceAccountSuffix, addresses, amounts[0], amounts[1], amounts[2], amounts[3])).Err
+	if err != nil {
+		return fmt.Errorf("funding %s: %w", accounts[0].Name, err)
+	}
+	return nil
+}
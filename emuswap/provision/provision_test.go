@@ -0,0 +1,150 @@
+package provision
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/internal/emutest"
+)
+
+// TestMain runs the package tests from the repository root, where flow.json
+// and the files it references resolve.
+func TestMain(m *testing.M) {
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(emutest.Main(m))
+}
+
+var ufix = emuswap.UFix64FromFloat
+
+func TestKey(t *testing.T) {
+	// the keys of user1 and user2 in flow.json
+	for i, expected := range []string{
+		"0xc3c402f4b5ac76dd16b9d60de899c01e2d3c5ae153efdd2ca7fe91ad754abd73",
+		"0xc89af7e50eb5c927e66d040a93e02a7a6ffbcd950ab9d6fcbea235f9217b4836",
+	} {
+		key, err := Key(fmt.Sprintf(DefaultSeed, i+1))
+		require.NoError(t, err)
+		assert.Equal(t, expected, key.String(), "user%d", i+1)
+	}
+	_, err := Key("too short")
+	assert.Error(t, err)
+}
+
+func TestProvision(t *testing.T) {
+	o, err := emutest.Start()
+	require.NoError(t, err)
+	c := emuswap.NewClient(o)
+
+	spec := DefaultSpec
+	spec.Count = 5
+	spec.BatchSize = 2
+	spec.Balances = map[string]emuswap.UFix64{"FLOW": ufix(100), "FUSD": ufix(50), "EMU": ufix(10), "xEMU": ufix(5)}
+	accounts, err := Provision(c, spec)
+	require.NoError(t, err)
+	require.Len(t, accounts, 5)
+	for i, account := range accounts {
+		assert.Equal(t, fmt.Sprintf("user%d", i+1), account.Name)
+		assert.Equal(t, i >= 3, account.Created, account.Name)
+		assert.Equal(t, c.Address(account.Name), account.Address, "signs by name")
+	}
+	assert.Equal(t, c.Address("user1"), flow.HexToAddress("179b6b1cb6755e31"))
+
+	addresses := []flow.Address{accounts[0].Address, accounts[4].Address}
+	paths := []string{"flowTokenBalance", "fusdBalance", "emuTokenBalance", "xEmuTokenBalance"}
+	held, err := c.GetBalances(addresses, paths)
+	require.NoError(t, err)
+	for _, address := range addresses {
+		assert.Equal(t, map[string]emuswap.UFix64{
+			"flowTokenBalance": ufix(100),
+			"fusdBalance":      ufix(50),
+			"emuTokenBalance":  ufix(10),
+			"xEmuTokenBalance": ufix(5),
+		}, held[address], address)
+	}
+
+	// user3 keeps the key of flow.json
+	assert.Equal(t, "0x835aa2f42658d9752208e71879dff94ba6c13fdf7020c76dbb44b28c5786341e", accounts[2].Key.String())
+
+	// the created accounts sign with their keys and hold LP tokens
+	c.XEmuEnterPool("user5", ufix(4.0)).Test(t).AssertSuccess()
+	c.FUSDSetup("account").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", ufix(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.EmuSwapAdminCreateNewPool("account", "flowTokenVault", ufix(100.0), "fusdVault", ufix(50.0)).Test(t).AssertSuccess()
+	c.EmuSwapUserAddLiquidity("user5", "flowTokenVault", ufix(10.0), "fusdVault", ufix(5.0)).Test(t).AssertSuccess()
+
+	// provisioning again tops up what was spent and creates nothing
+	again, err := Provision(c, spec)
+	require.NoError(t, err)
+	for i, account := range again {
+		assert.False(t, account.Created, account.Name)
+		assert.Equal(t, accounts[i].Address, account.Address)
+	}
+	held, err = c.GetBalances(addresses[1:], paths)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]emuswap.UFix64{
+		"flowTokenBalance": ufix(100),
+		"fusdBalance":      ufix(50),
+		"emuTokenBalance":  ufix(10),
+		"xEmuTokenBalance": ufix(9),
+	}, held[addresses[1]])
+
+	spec.Balances = map[string]emuswap.UFix64{"USDC": ufix(1)}
+	_, err = Provision(c, spec)
+	assert.Error(t, err)
+}
+
+func TestWriteAccounts(t *testing.T) {
+	original, err := os.ReadFile("flow.json")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "flow.json")
+	require.NoError(t, os.WriteFile(path, original, 0o644))
+
+	user1, err := Key(fmt.Sprintf(DefaultSeed, 1))
+	require.NoError(t, err)
+	user4, err := Key(fmt.Sprintf(DefaultSeed, 4))
+	require.NoError(t, err)
+
+	// user1 is there already
+	require.NoError(t, WriteAccounts(path, "emulator", []Account{{Name: "user1", Address: flow.HexToAddress("179b6b1cb6755e31"), Key: user1}}))
+	written, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(original), string(written))
+
+	require.NoError(t, WriteAccounts(path, "emulator", []Account{
+		{Name: "user1", Address: flow.HexToAddress("179b6b1cb6755e31"), Key: user1},
+		{Name: "user4", Address: flow.HexToAddress("045a1763c93006ca"), Key: user4},
+	}))
+	written, err = os.ReadFile(path)
+	require.NoError(t, err)
+	members, err := readObject(written)
+	require.NoError(t, err)
+	var keys []string
+	for _, m := range members {
+		keys = append(keys, m.key)
+	}
+	assert.Equal(t, []string{"emulators", "contracts", "networks", "accounts", "deployments"}, keys)
+	accounts, err := readObject(members[3].value)
+	require.NoError(t, err)
+	last := accounts[len(accounts)-1]
+	assert.Equal(t, "emulator-user4", last.key)
+	var entry configured
+	require.NoError(t, json.Unmarshal(last.value, &entry))
+	assert.Equal(t, configured{Address: "045a1763c93006ca", Key: user4.String()[2:]}, entry)
+
+	// the new account is the only change
+	require.NoError(t, os.WriteFile(path, original, 0o644))
+	require.NoError(t, WriteAccounts(path, "emulator", []Account{{Name: "user4", Address: flow.HexToAddress("045a1763c93006ca"), Key: user4}}))
+	again, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(written), string(again))
+	assert.Len(t, again, len(original)+len(",\n    \"emulator-user4\": {\n      \"address\": \"045a1763c93006ca\",\n      \"key\": \"\"\n    }")+64)
+}
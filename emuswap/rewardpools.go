@@ -0,0 +1,83 @@
+package emuswap
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/flow-go-sdk"
+)
+
+// AddRewardPool builds a transaction creating a reward pool of amount of the
+// signer's token, weighted for farm 0 and gated on nftPaths when not empty.
+// Stakes already in a farm earn from it from then on.
+func (c *Client) AddRewardPool(signer string, token Token, amount UFix64, nftPaths []string) overflow.FlowTransactionBuilder {
+	return c.StakingAdminCreateRewardPool(signer, token.StoragePath, amount, nftPaths)
+}
+
+// AddRewardReceiver builds a transaction registering the signer's token
+// receiver for the rewards of rewardPoolID claimed from the stake in farmID.
+// Stakes get a receiver for reward pool 0 only, claims skip pools without.
+func (c *Client) AddRewardReceiver(signer string, farmID uint64, rewardPoolID uint64, token Token) overflow.FlowTransactionBuilder {
+	return c.StakingUserAddRewardReceiver(signer, farmID, rewardPoolID, token.ReceiverPath, token.StoragePath)
+}
+
+// RewardPoolTokens maps the reward pools to the registered token they pay,
+// pools of unregistered tokens are left out.
+func (c *Client) RewardPoolTokens() (map[uint64]Token, error) {
+	pools, err := c.StakingGetRewardPoolsMeta()
+	if err != nil {
+		return nil, err
+	}
+	addresses, err := ContractAddresses(c.O.State, c.O.Network)
+	if err != nil {
+		return nil, err
+	}
+	tokens := map[uint64]Token{}
+	for id, pool := range pools {
+		for _, token := range Tokens {
+			if identifier, err := token.VaultIdentifier(addresses); err == nil && identifier == pool.TokenIdentifier {
+				tokens[id] = token
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// Unclaimable is a pending reward a staker cannot claim, as the stake has no
+// receiver for its reward pool.
+type Unclaimable struct {
+	FarmID       uint64
+	Address      flow.Address
+	RewardPoolID uint64
+	Pending      Fix64
+}
+
+// UnclaimableRewards lists the pending rewards of the stakes in farmID that
+// claims skip for want of a receiver, ordered by address and reward pool.
+func (c *Client) UnclaimableRewards(farmID uint64) ([]Unclaimable, error) {
+	stakes, err := c.StakingReadStakesInfo(farmID)
+	if err != nil {
+		return nil, fmt.Errorf("farm %d: %w", farmID, err)
+	}
+	unclaimable := []Unclaimable{}
+	for address, stake := range stakes {
+		receivers := map[uint64]bool{}
+		for _, id := range stake.RewardReceiverIDs {
+			receivers[id] = true
+		}
+		for poolID, pending := range stake.PendingRewards {
+			if pending > 0 && !receivers[poolID] {
+				unclaimable = append(unclaimable, Unclaimable{FarmID: farmID, Address: address, RewardPoolID: poolID, Pending: pending})
+			}
+		}
+	}
+	sort.Slice(unclaimable, func(i, j int) bool {
+		a, b := unclaimable[i], unclaimable[j]
+		if a.Address != b.Address {
+			return a.Address.Hex() < b.Address.Hex()
+		}
+		return a.RewardPoolID < b.RewardPoolID
+	})
+	return unclaimable, nil
+}
@@ -0,0 +1,96 @@
+package emuswap
+
+import (
+	"testing"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecondRewardPool(t *testing.T) {
+	c := newTestClient(t)
+	flowToken, fusd, emu := MustLookupToken("FLOW"), MustLookupToken("FUSD"), MustLookupToken("EMU")
+
+	c.DemoMintFlowTokens("account", UFix64FromFloat(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.FUSDSetup("account").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", UFix64FromFloat(2000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.StakingAdminToggleMockTime("account").Test(t).AssertSuccess()
+	c.EmuSwapAdminCreateNewPool("account", flowToken.StoragePath, UFix64FromFloat(100.0), fusd.StoragePath, UFix64FromFloat(50.0)).Test(t).AssertSuccess()
+	c.StakingAdminCreateNewFarm("account", 0).Test(t).AssertSuccess()
+	for _, user := range []string{"user1", "user2"} {
+		c.DemoMintFlowTokens("account", UFix64FromFloat(100.0), c.Address(user)).Test(t).AssertSuccess()
+		c.FUSDSetup(user).Test(t).AssertSuccess()
+		c.DemoMintFUSD("account", UFix64FromFloat(100.0), c.Address(user)).Test(t).AssertSuccess()
+		c.EmuTokenSetup(user).Test(t).AssertSuccess()
+		c.AddLiquidity(user, flowToken, fusd, UFix64FromFloat(10.0), UFix64FromFloat(5.0)).Test(t).AssertSuccess()
+		c.StakingUserStake(user, 0, UFix64FromFloat(0.05)).Test(t).AssertSuccess()
+	}
+	c.StakingAdminUpdateMockTimestamp("account", UFix64FromFloat(100.0)).Test(t).AssertSuccess()
+
+	// a FUSD reward pool added mid-stream pays the existing stakes from now on
+	c.StakingAdminCreateRewardPoolFusd("account", UFix64FromFloat(1000.0)).Test(t).AssertSuccess()
+	tokens, err := c.RewardPoolTokens()
+	require.NoError(t, err)
+	assert.Equal(t, map[uint64]Token{0: emu, 1: fusd}, tokens)
+	for _, user := range []string{"user1", "user2"} {
+		stake := stakeInfo(t, c, user)
+		assert.Equal(t, Fix64(0), stake.RewardDebtByID[1], user)
+		assert.Equal(t, Fix64(0), stake.PendingRewards[1], user)
+		assert.Equal(t, []uint64{0}, stake.RewardReceiverIDs, user)
+	}
+
+	// reward pool 1 emits 1 token per second shared by the two stakes
+	c.StakingAdminUpdateMockTimestamp("account", UFix64FromFloat(100.0)).Test(t).AssertSuccess()
+	first, second := stakeInfo(t, c, "user1"), stakeInfo(t, c, "user2")
+	assert.Equal(t, Fix64FromFloat(50.0), first.PendingRewards[1])
+	assert.Equal(t, Fix64FromFloat(50.0), second.PendingRewards[1])
+	assert.Equal(t, first.PendingRewards[0], second.PendingRewards[0])
+	assert.Equal(t, Fix64FromFloat(100.0), first.PendingRewards[0], "reward pool 0 accrued from the start")
+
+	// without a receiver for reward pool 1 its rewards cannot be claimed
+	unclaimable, err := c.UnclaimableRewards(0)
+	require.NoError(t, err)
+	require.Len(t, unclaimable, 2)
+	for _, u := range unclaimable {
+		assert.Equal(t, uint64(1), u.RewardPoolID)
+		assert.Equal(t, Fix64FromFloat(50.0), u.Pending)
+	}
+
+	c.AddRewardReceiver("user1", 0, 1, fusd).Test(t).AssertSuccess()
+	unclaimable, err = c.UnclaimableRewards(0)
+	require.NoError(t, err)
+	assert.Equal(t, []Unclaimable{{FarmID: 0, Address: c.Address("user2"), RewardPoolID: 1, Pending: Fix64FromFloat(50.0)}}, unclaimable)
+	assert.ElementsMatch(t, []uint64{0, 1}, stakeInfo(t, c, "user1").RewardReceiverIDs)
+
+	// user1 claims both reward pools, the debts of user2 are untouched
+	c.StakingUserClaimRewards("user1", 0).
+		Test(t).
+		AssertSuccess().
+		AssertEmitEvent(overflow.NewTestEvent("A.f8d6e0586b0a20c7.StakingRewards.RewardsClaimed", map[string]interface{}{
+			"address":        "0x" + c.Address("user1").Hex(),
+			"tokenType":      "A.f8d6e0586b0a20c7.FUSD.Vault",
+			"amountClaimed":  "50.00000000",
+			"rewardDebt":     "50.00000000",
+			"totalRemaining": "950.00000000",
+		}))
+	first = stakeInfo(t, c, "user1")
+	assert.Equal(t, Fix64(0), first.PendingRewards[0])
+	assert.Equal(t, Fix64(0), first.PendingRewards[1])
+	assert.Equal(t, second.RewardDebtByID, stakeInfo(t, c, "user2").RewardDebtByID)
+
+	// user2 claims reward pool 0 only, reward pool 1 keeps accruing for it
+	c.StakingUserClaimRewards("user2", 0).Test(t).AssertSuccess()
+	second = stakeInfo(t, c, "user2")
+	assert.Equal(t, Fix64(0), second.PendingRewards[0])
+	assert.Equal(t, Fix64FromFloat(50.0), second.PendingRewards[1])
+	c.StakingAdminUpdateMockTimestamp("account", UFix64FromFloat(10.0)).Test(t).AssertSuccess()
+	assert.Equal(t, Fix64FromFloat(55.0), stakeInfo(t, c, "user2").PendingRewards[1])
+
+	c.AddRewardReceiver("user2", 0, 1, fusd).Test(t).AssertSuccess()
+	c.StakingUserClaimRewards("user2", 0).Test(t).AssertSuccess()
+	assert.Equal(t, Fix64(0), stakeInfo(t, c, "user2").PendingRewards[1])
+	unclaimable, err = c.UnclaimableRewards(0)
+	require.NoError(t, err)
+	assert.Empty(t, unclaimable)
+}
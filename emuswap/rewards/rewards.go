@@ -0,0 +1,383 @@
+// Package rewards forecasts when the StakingRewards reward pools run out. A
+// reward pool pays the rewards its farms accrue from its vault when stakers
+// claim them, and claimRewards fails once the vault holds less than a staker
+// is owed. The forecaster takes the tokens remaining in the vault, subtracts
+// the pending rewards already owed to stakers, and projects the time the
+// rest is accrued by the farms with stake under the DecayingEmission of the
+// pool.
+//
+// The projection accrues every second at the rate of its epoch. The contract
+// applies the rate at the time of each farm update to the whole period since
+// the update before, so farms updated rarely across an epoch boundary accrue
+// a little more or less.
+package rewards
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/metrics"
+)
+
+// Rate returns the emission rate of a reward pool at now, as
+// DecayingEmission.getCurrentEmissionRate computes it. A pool whose genesis
+// is not set, which the contract does on the first stake for reward pool 0
+// only, emits at the full rate.
+func Rate(emission emuswap.DecayingEmission, genesis, now emuswap.UFix64) (emuswap.UFix64, error) {
+	one := emuswap.UFix64(emuswap.UFix64Factor)
+	if genesis == 0 {
+		genesis = now
+	}
+	elapsed, err := now.Sub(genesis)
+	if err != nil {
+		return 0, err
+	}
+	epoch, err := elapsed.Div(emission.EpochLength)
+	if err != nil {
+		return 0, err
+	}
+	// the contract returns to the full rate after the last epoch
+	if epoch > emission.TotalEpochs {
+		return one, nil
+	}
+	factor, err := one.Sub(emission.Decay)
+	if err != nil {
+		return 0, err
+	}
+	rate := one
+	for ; epoch > one; epoch -= one {
+		if rate, err = rate.Mul(factor); err != nil {
+			return 0, err
+		}
+	}
+	return rate, nil
+}
+
+// segment is a period of constant emission rate, End is +Inf for the last.
+type segment struct {
+	Start, End float64
+	Rate       float64
+}
+
+// schedule returns the emission rates from now on.
+func schedule(emission emuswap.DecayingEmission, genesis, now emuswap.UFix64) ([]segment, error) {
+	rate := func(t float64) (float64, error) {
+		r, err := Rate(emission, genesis, emuswap.UFix64FromFloat(t))
+		return r.Float64(), err
+	}
+	start := now.Float64()
+	if genesis == 0 {
+		r, err := rate(start)
+		return []segment{{Start: start, End: math.Inf(1), Rate: r}}, err
+	}
+	// the rate changes after every whole epoch and returns to the full rate
+	// after the last one
+	length, total := emission.EpochLength.Float64(), emission.TotalEpochs.Float64()
+	var ends []float64
+	for n := 1.0; n < total; n++ {
+		ends = append(ends, genesis.Float64()+n*length)
+	}
+	ends = append(ends, genesis.Float64()+total*length)
+	var segments []segment
+	for _, end := range ends {
+		if end <= start {
+			continue
+		}
+		r, err := rate((start + end) / 2)
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, segment{Start: start, End: end, Rate: r})
+		start = end
+	}
+	r, err := rate(start + length)
+	if err != nil {
+		return nil, err
+	}
+	return append(segments, segment{Start: start, End: math.Inf(1), Rate: r}), nil
+}
+
+// exhaustion returns the time at which share of the emission of segments
+// accrues budget tokens, +Inf when it never does.
+func exhaustion(segments []segment, share, budget float64) float64 {
+	for _, s := range segments {
+		perSecond := s.Rate * share
+		if perSecond <= 0 {
+			continue
+		}
+		accrued := perSecond * (s.End - s.Start)
+		if accrued >= budget {
+			return s.Start + budget/perSecond
+		}
+		budget -= accrued
+	}
+	return math.Inf(1)
+}
+
+// Farm is a farm accruing rewards of a reward pool.
+type Farm struct {
+	FarmID uint64 `json:"farmID"`
+	// Weight is the weight of the farm over the total weight of the pool.
+	Weight float64        `json:"weight"`
+	Staked emuswap.UFix64 `json:"staked"`
+	// Owed is the pending rewards of the stakers of the farm.
+	Owed emuswap.UFix64 `json:"owed"`
+	// Unclaimable is the part of Owed to stakers without a receiver for the
+	// reward pool, claims skip it.
+	Unclaimable emuswap.UFix64 `json:"unclaimable"`
+}
+
+// Forecast is the outlook of one reward pool.
+type Forecast struct {
+	RewardPoolID    uint64         `json:"rewardPoolID"`
+	TokenIdentifier string         `json:"tokenIdentifier"`
+	Remaining       emuswap.UFix64 `json:"remaining"`
+	Owed            emuswap.UFix64 `json:"owed"`
+	Unclaimable     emuswap.UFix64 `json:"unclaimable"`
+	// Shortfall is the owed rewards the remaining tokens cannot pay.
+	Shortfall emuswap.UFix64 `json:"shortfall"`
+	// Rate is the current emission in tokens per second, Share the part of
+	// it accruing to farms with stake.
+	Rate  emuswap.UFix64 `json:"rate"`
+	Share float64        `json:"share"`
+	Farms []Farm         `json:"farms"`
+	// ExhaustedAt is the time the remaining tokens are owed, nil when the
+	// pool never runs out at the current stake.
+	ExhaustedAt *emuswap.UFix64 `json:"exhaustedAt"`
+	Warnings    []string        `json:"warnings"`
+}
+
+// Report is the forecast of every reward pool.
+type Report struct {
+	Now       emuswap.UFix64 `json:"now"`
+	Forecasts []Forecast     `json:"forecasts"`
+}
+
+// Failed reports whether a pool cannot pay what it owes.
+func (r Report) Failed() bool {
+	for _, f := range r.Forecasts {
+		if f.Shortfall > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Load reads the reward pools, farms and stakes and projects when each reward
+// pool runs out.
+func Load(c *emuswap.Client) (Report, error) {
+	now, err := c.StakingGetNow()
+	if err != nil {
+		return Report{}, err
+	}
+	report := Report{Now: now, Forecasts: []Forecast{}}
+	pools, err := c.StakingGetRewardPoolsMeta()
+	if err != nil {
+		return report, err
+	}
+	staked, err := c.StakingGetTotalStaked()
+	if err != nil {
+		return report, err
+	}
+
+	farmIDs := make([]uint64, 0, len(staked))
+	for farmID := range staked {
+		farmIDs = append(farmIDs, farmID)
+	}
+	sort.Slice(farmIDs, func(i, j int) bool { return farmIDs[i] < farmIDs[j] })
+	// owed by farm and reward pool, nil for farms whose stakes cannot be read
+	owed := map[uint64]map[uint64]emuswap.UFix64{}
+	unclaimable := map[uint64]map[uint64]emuswap.UFix64{}
+	for _, farmID := range farmIDs {
+		stakes, err := c.StakingReadStakesInfo(farmID)
+		if err != nil {
+			continue
+		}
+		owed[farmID] = map[uint64]emuswap.UFix64{}
+		unclaimable[farmID] = map[uint64]emuswap.UFix64{}
+		for _, stake := range stakes {
+			receivers := map[uint64]bool{}
+			for _, id := range stake.RewardReceiverIDs {
+				receivers[id] = true
+			}
+			for poolID, pending := range stake.PendingRewards {
+				if pending > 0 {
+					owed[farmID][poolID] += emuswap.UFix64(pending)
+					if !receivers[poolID] {
+						unclaimable[farmID][poolID] += emuswap.UFix64(pending)
+					}
+				}
+			}
+		}
+	}
+
+	poolIDs := make([]uint64, 0, len(pools))
+	for poolID := range pools {
+		poolIDs = append(poolIDs, poolID)
+	}
+	sort.Slice(poolIDs, func(i, j int) bool { return poolIDs[i] < poolIDs[j] })
+	for _, poolID := range poolIDs {
+		forecast, err := forecastPool(pools[poolID], now, farmIDs, staked, owed, unclaimable)
+		if err != nil {
+			return report, fmt.Errorf("reward pool %d: %w", poolID, err)
+		}
+		report.Forecasts = append(report.Forecasts, forecast)
+	}
+	return report, nil
+}
+
+func forecastPool(meta emuswap.RewardPoolMeta, now emuswap.UFix64, farmIDs []uint64, staked map[uint64]emuswap.UFix64, owed, unclaimable map[uint64]map[uint64]emuswap.UFix64) (Forecast, error) {
+	f := Forecast{
+		RewardPoolID:    meta.ID,
+		TokenIdentifier: meta.TokenIdentifier,
+		Remaining:       meta.Balance,
+		Rate:            meta.CurrentEmissionRate,
+		Farms:           []Farm{},
+		Warnings:        []string{},
+	}
+	warn := func(format string, args ...interface{}) {
+		f.Warnings = append(f.Warnings, fmt.Sprintf(format, args...))
+	}
+
+	for _, farmID := range farmIDs {
+		weight, weighted := meta.FarmWeightsByID[farmID]
+		farm := Farm{FarmID: farmID, Staked: staked[farmID]}
+		switch {
+		case !weighted:
+			if farm.Staked > 0 {
+				warn("farm %d has stake but no weight, its updates fail", farmID)
+			}
+			continue
+		case meta.TotalWeight == 0:
+			warn("total weight is 0, farm updates fail")
+		default:
+			farm.Weight = weight.Float64() / meta.TotalWeight.Float64()
+		}
+		if owed[farmID] == nil {
+			warn("pending rewards of farm %d cannot be read", farmID)
+		} else {
+			farm.Owed = owed[farmID][meta.ID]
+			farm.Unclaimable = unclaimable[farmID][meta.ID]
+		}
+		if farm.Staked > 0 {
+			f.Share += farm.Weight
+		}
+		f.Owed += farm.Owed
+		f.Unclaimable += farm.Unclaimable
+		f.Farms = append(f.Farms, farm)
+	}
+	if f.Unclaimable > 0 {
+		warn("%s owed to stakers without a receiver, claims skip it", f.Unclaimable)
+	}
+
+	if f.Owed > f.Remaining {
+		f.Shortfall = f.Owed - f.Remaining
+		warn("owes %s but holds %s, claims fail", f.Owed, f.Remaining)
+		exhausted := now
+		f.ExhaustedAt = &exhausted
+		return f, nil
+	}
+
+	var segments []segment
+	if meta.Emission == nil {
+		warn("emission is not DecayingEmission, projected at the current rate")
+		segments = []segment{{Start: now.Float64(), End: math.Inf(1), Rate: meta.CurrentEmissionRate.Float64()}}
+	} else {
+		var err error
+		if segments, err = schedule(*meta.Emission, meta.RewardsGenesisTimestamp, now); err != nil {
+			return f, err
+		}
+		if meta.RewardsGenesisTimestamp == 0 {
+			warn("genesis is not set, emits at the full rate")
+		}
+	}
+	if at := exhaustion(segments, f.Share, (f.Remaining - f.Owed).Float64()); !math.IsInf(at, 1) {
+		exhausted := emuswap.UFix64FromFloat(at)
+		f.ExhaustedAt = &exhausted
+	}
+	return f, nil
+}
+
+// SecondsLeft returns the seconds from now until the pool runs out, +Inf when
+// it does not.
+func (f Forecast) SecondsLeft(now emuswap.UFix64) float64 {
+	if f.ExhaustedAt == nil {
+		return math.Inf(1)
+	}
+	return math.Max(0, f.ExhaustedAt.Float64()-now.Float64())
+}
+
+// Samples are the gauges of the report.
+func (r Report) Samples() []metrics.Sample {
+	var samples []metrics.Sample
+	gauges := []struct {
+		name, help string
+		value      func(Forecast) float64
+	}{
+		{"emuswap_reward_pool_remaining", "Reward tokens in the vault of the reward pool.", func(f Forecast) float64 { return f.Remaining.Float64() }},
+		{"emuswap_reward_pool_owed", "Pending rewards owed to stakers.", func(f Forecast) float64 { return f.Owed.Float64() }},
+		{"emuswap_reward_pool_unclaimable", "Owed rewards of stakers without a receiver for the reward pool.", func(f Forecast) float64 { return f.Unclaimable.Float64() }},
+		{"emuswap_reward_pool_shortfall", "Owed rewards the vault cannot pay.", func(f Forecast) float64 { return f.Shortfall.Float64() }},
+		{"emuswap_reward_pool_emission_rate", "Current emission in tokens per second.", func(f Forecast) float64 { return f.Rate.Float64() }},
+		{"emuswap_reward_pool_seconds_left", "Seconds until the remaining tokens are owed, +Inf if never.", func(f Forecast) float64 { return f.SecondsLeft(r.Now) }},
+	}
+	for _, gauge := range gauges {
+		for _, f := range r.Forecasts {
+			samples = append(samples, metrics.Sample{
+				Name:   gauge.name,
+				Help:   gauge.help,
+				Labels: map[string]string{"reward_pool": strconv.FormatUint(f.RewardPoolID, 10), "token": f.TokenIdentifier},
+				Value:  gauge.value(f),
+			})
+		}
+	}
+	return samples
+}
+
+// Collector exports the forecast of every scrape.
+func Collector(c *emuswap.Client) metrics.Collector {
+	return func() ([]metrics.Sample, error) {
+		report, err := Load(c)
+		if err != nil {
+			return nil, err
+		}
+		return report.Samples(), nil
+	}
+}
+
+// Write prints a table of the forecasts and their warnings.
+func (r Report) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "pool\ttoken\tremaining\towed\trate/s\tshare\texhausted at\tin")
+	for _, f := range r.Forecasts {
+		at, in := "never", "-"
+		if f.ExhaustedAt != nil {
+			at = f.ExhaustedAt.String()
+			in = duration(f.SecondsLeft(r.Now))
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%.4f\t%s\t%s\n", f.RewardPoolID, f.TokenIdentifier, f.Remaining, f.Owed, f.Rate, f.Share, at, in)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, f := range r.Forecasts {
+		for _, warning := range f.Warnings {
+			if _, err := fmt.Fprintf(w, "WARNING reward pool %d: %s\n", f.RewardPoolID, warning); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// duration prints seconds in days and hours.
+func duration(seconds float64) string {
+	days := math.Floor(seconds / 86400)
+	hours := (seconds - days*86400) / 3600
+	return fmt.Sprintf("%.0fd %.1fh", days, hours)
+}
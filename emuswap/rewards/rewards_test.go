@@ -0,0 +1,156 @@
+package rewards
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/metrics"
+	"swap.emudao.org/test-overflow/internal/emutest"
+)
+
+// TestMain runs the package tests from the repository root, where flow.json
+// and the files it references resolve.
+func TestMain(m *testing.M) {
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(emutest.Main(m))
+}
+
+var ufix = emuswap.UFix64FromFloat
+
+var emission = emuswap.DecayingEmission{EpochLength: ufix(100.0), TotalEpochs: ufix(3.0), Decay: ufix(0.5)}
+
+func TestRate(t *testing.T) {
+	genesis := ufix(1000.0)
+	for at, expected := range map[float64]float64{
+		1000: 1, 1050: 1, 1100: 1,
+		// the epoch keeps 8 decimals, 1100.0000001 is still the first
+		1100.0000001: 1, 1100.000001: 0.5, 1200: 0.5,
+		1250: 0.25, 1300: 0.25,
+		// the contract returns to the full rate after the last epoch
+		1300.000001: 1, 5000: 1,
+	} {
+		rate, err := Rate(emission, genesis, ufix(at))
+		require.NoError(t, err)
+		assert.Equal(t, ufix(expected), rate, "%v", at)
+	}
+	// without a genesis the rate stays full
+	rate, err := Rate(emission, 0, ufix(5000.0))
+	require.NoError(t, err)
+	assert.Equal(t, ufix(1.0), rate)
+}
+
+func TestExhaustion(t *testing.T) {
+	segments, err := schedule(emission, ufix(1000.0), ufix(1050.0))
+	require.NoError(t, err)
+	require.Len(t, segments, 4)
+	assert.Equal(t, segment{Start: 1050, End: 1100, Rate: 1}, segments[0])
+	assert.Equal(t, segment{Start: 1300, End: math.Inf(1), Rate: 1}, segments[3])
+
+	// 50 at 1/s, 50 at 0.5/s, 25 at 0.25/s, then 1/s
+	for budget, expected := range map[float64]float64{
+		10: 1060, 50: 1100, 75: 1150, 100: 1200, 112.5: 1250, 125: 1300, 135: 1310,
+	} {
+		assert.InDelta(t, expected, exhaustion(segments, 1, budget), 1e-9, "%v", budget)
+	}
+	// half the weight takes twice as long within the first epoch
+	assert.InDelta(t, 1090, exhaustion(segments, 0.5, 20), 1e-9)
+	assert.True(t, math.IsInf(exhaustion(segments, 0, 1), 1))
+}
+
+func TestForecast(t *testing.T) {
+	o, err := emutest.Start()
+	require.NoError(t, err)
+	c := emuswap.NewClient(o)
+	flowToken, fusd, emu := emuswap.MustLookupToken("FLOW"), emuswap.MustLookupToken("FUSD"), emuswap.MustLookupToken("EMU")
+
+	c.DemoMintFlowTokens("account", ufix(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.FUSDSetup("account").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", ufix(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.DemoMintFlowTokens("account", ufix(100.0), c.Address("user1")).Test(t).AssertSuccess()
+	c.FUSDSetup("user1").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", ufix(100.0), c.Address("user1")).Test(t).AssertSuccess()
+	c.StakingAdminToggleMockTime("account").Test(t).AssertSuccess()
+
+	c.EmuSwapAdminCreateNewPool("account", flowToken.StoragePath, ufix(100.0), fusd.StoragePath, ufix(50.0)).Test(t).AssertSuccess()
+	c.StakingAdminCreateNewFarm("account", 0).Test(t).AssertSuccess()
+	c.StakingAdminCreateRewardPool("account", emu.StoragePath, ufix(1000.0), []string{}).Test(t).AssertSuccess()
+
+	// nothing staked, nothing accrues
+	report, err := Load(c)
+	require.NoError(t, err)
+	require.Len(t, report.Forecasts, 2)
+	for _, f := range report.Forecasts {
+		assert.Nil(t, f.ExhaustedAt, f.RewardPoolID)
+		assert.Zero(t, f.Share)
+	}
+
+	c.AddLiquidityAndStake("user1", 0, flowToken, fusd, ufix(10.0), ufix(5.0)).Test(t).AssertSuccess()
+	report, err = Load(c)
+	require.NoError(t, err)
+	start := report.Now
+	second := report.Forecasts[1]
+	assert.Equal(t, ufix(1000.0), second.Remaining)
+	assert.Zero(t, second.Owed)
+	assert.Equal(t, 1.0, second.Share)
+	// reward pool 1 never gets a genesis and pays 1 token per second
+	assert.Contains(t, second.Warnings, "genesis is not set, emits at the full rate")
+	require.NotNil(t, second.ExhaustedAt)
+	assert.Equal(t, start+ufix(1000.0), *second.ExhaustedAt)
+	// reward pool 0 decays from its genesis at the first stake
+	first := report.Forecasts[0]
+	assert.Empty(t, first.Warnings)
+	require.NotNil(t, first.ExhaustedAt)
+	assert.Greater(t, first.SecondsLeft(report.Now), 40*28*24*3600.0)
+
+	// the forecast holds as rewards accrue
+	c.StakingAdminUpdateMockTimestamp("account", ufix(400.0)).Test(t).AssertSuccess()
+	report, err = Load(c)
+	require.NoError(t, err)
+	second = report.Forecasts[1]
+	assert.Equal(t, ufix(400.0), second.Owed)
+	// user1 has a receiver for reward pool 0 only
+	assert.Equal(t, second.Owed, second.Unclaimable)
+	assert.Contains(t, second.Warnings, "400.00000000 owed to stakers without a receiver, claims skip it")
+	assert.Zero(t, report.Forecasts[0].Unclaimable)
+	assert.Equal(t, start+ufix(1000.0), *second.ExhaustedAt)
+	assert.Equal(t, 600.0, second.SecondsLeft(report.Now))
+	assert.False(t, report.Failed())
+
+	// the contract rate matches the model across epochs
+	pools, err := c.StakingGetRewardPoolsMeta()
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		c.StakingAdminUpdateMockTimestamp("account", ufix(28*24*3600.0)).Test(t).AssertSuccess()
+		now, err := c.StakingGetNow()
+		require.NoError(t, err)
+		current, err := c.StakingGetRewardPoolsMeta()
+		require.NoError(t, err)
+		rate, err := Rate(*pools[0].Emission, pools[0].RewardsGenesisTimestamp, now)
+		require.NoError(t, err)
+		assert.Equal(t, current[0].CurrentEmissionRate, rate)
+	}
+
+	// more is owed than the vault holds
+	report, err = Load(c)
+	require.NoError(t, err)
+	second = report.Forecasts[1]
+	assert.Greater(t, second.Owed, second.Remaining)
+	assert.Equal(t, second.Owed-second.Remaining, second.Shortfall)
+	assert.Zero(t, second.SecondsLeft(report.Now))
+	assert.True(t, report.Failed())
+
+	var buf bytes.Buffer
+	require.NoError(t, report.Write(&buf))
+	assert.Contains(t, buf.String(), "WARNING reward pool 1: owes")
+	buf.Reset()
+	require.NoError(t, metrics.Write(&buf, report.Samples()))
+	assert.Contains(t, buf.String(), `emuswap_reward_pool_seconds_left{reward_pool="1",token="A.f8d6e0586b0a20c7.EmuToken.Vault"} 0`)
+	assert.Contains(t, buf.String(), "# TYPE emuswap_reward_pool_shortfall gauge")
+}
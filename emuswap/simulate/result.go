@@ -0,0 +1,281 @@
+package simulate
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/onflow/flow-go-sdk"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/audit"
+)
+
+// Balance is a balance a dry run changes, of a registered token or of the LP
+// tokens of a pool.
+type Balance struct {
+	Address flow.Address `json:"address"`
+	// Token is the symbol of the token, LP for LP tokens.
+	Token  string         `json:"token"`
+	PoolID *uint64        `json:"poolID,omitempty"`
+	Before emuswap.UFix64 `json:"before"`
+	After  emuswap.UFix64 `json:"after"`
+}
+
+// Delta is what the balance gains, negative when it loses.
+func (b Balance) Delta() emuswap.Fix64 {
+	return emuswap.Fix64(int64(b.After) - int64(b.Before))
+}
+
+// Fee is the treasury of a token that a dry run changes.
+type Fee struct {
+	// Token is the symbol of the token, or its vault identifier when it is
+	// not registered.
+	Token  string         `json:"token"`
+	Before emuswap.UFix64 `json:"before"`
+	After  emuswap.UFix64 `json:"after"`
+}
+
+// PoolChange is a pool a dry run changes. Before is nil for a pool it
+// creates.
+type PoolChange struct {
+	PoolID uint64           `json:"poolID"`
+	Before *audit.PoolState `json:"before"`
+	After  *audit.PoolState `json:"after"`
+}
+
+// Farm is the admin state of a farm with the LP tokens staked in it. The
+// rewards pending are left out, they change with every block.
+type Farm struct {
+	audit.FarmState
+	TotalStaked emuswap.UFix64 `json:"totalStaked"`
+	// Stakes are keyed by address in hex, as flow.Address is in JSON.
+	Stakes map[string]emuswap.UFix64 `json:"stakes"`
+}
+
+// FarmChange is a farm a dry run changes. Before is nil for a farm it
+// creates.
+type FarmChange struct {
+	FarmID uint64 `json:"farmID"`
+	Before *Farm  `json:"before"`
+	After  *Farm  `json:"after"`
+}
+
+// Result is what a dry run would have done. Only what changes is listed.
+type Result struct {
+	// Err is the error the transactions were sent with.
+	Err          error         `json:"-"`
+	Error        string        `json:"error,omitempty"`
+	Transactions []Transaction `json:"transactions"`
+	Balances     []Balance     `json:"balances"`
+	Fees         []Fee         `json:"fees"`
+	Pools        []PoolChange  `json:"pools"`
+	Farms        []FarmChange  `json:"farms"`
+}
+
+// state is what a dry run compares before and after.
+type state struct {
+	admin    audit.State
+	balances map[flow.Address]map[string]emuswap.UFix64
+	lp       map[flow.Address]map[uint64]emuswap.UFix64
+	farms    map[uint64]Farm
+}
+
+func read(c *emuswap.Client, addresses []flow.Address) (state, error) {
+	var s state
+	var err error
+	if s.admin, err = audit.ReadState(c); err != nil {
+		return s, err
+	}
+	paths := make([]string, len(emuswap.Tokens))
+	for i, token := range emuswap.Tokens {
+		paths[i] = token.BalancePath
+	}
+	if s.balances, err = c.GetBalances(addresses, paths); err != nil {
+		return s, err
+	}
+	if s.lp, err = c.GetLPBalances(addresses); err != nil {
+		return s, err
+	}
+	stakes, err := c.StakingReadAllStakes()
+	if err != nil {
+		return s, err
+	}
+	s.farms = map[uint64]Farm{}
+	for id, farm := range s.admin.Farms {
+		s.farms[id] = Farm{FarmState: farm, Stakes: map[string]emuswap.UFix64{}}
+	}
+	for id, byAddress := range stakes {
+		farm, ok := s.farms[id]
+		if !ok {
+			farm = Farm{Stakes: map[string]emuswap.UFix64{}}
+		}
+		for address, stake := range byAddress {
+			farm.Stakes[address.Hex()] = stake.Balance
+			farm.TotalStaked += stake.Balance
+		}
+		s.farms[id] = farm
+	}
+	return s, nil
+}
+
+func (r *Result) compare(c *emuswap.Client, addresses []flow.Address, before, after state) error {
+	r.Balances = []Balance{}
+	sorted := append([]flow.Address{}, addresses...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Hex() < sorted[j].Hex() })
+	for _, address := range sorted {
+		for _, token := range emuswap.Tokens {
+			b := Balance{
+				Address: address,
+				Token:   token.Symbol,
+				Before:  before.balances[address][token.BalancePath],
+				After:   after.balances[address][token.BalancePath],
+			}
+			if b.Before != b.After {
+				r.Balances = append(r.Balances, b)
+			}
+		}
+		for _, id := range keys(before.lp[address], after.lp[address]) {
+			id := id
+			b := Balance{Address: address, Token: "LP", PoolID: &id, Before: before.lp[address][id], After: after.lp[address][id]}
+			if b.Before != b.After {
+				r.Balances = append(r.Balances, b)
+			}
+		}
+	}
+
+	contracts, err := emuswap.ContractAddresses(c.O.State, c.O.Network)
+	if err != nil {
+		return err
+	}
+	symbols := map[string]string{}
+	for _, token := range emuswap.Tokens {
+		if identifier, err := token.VaultIdentifier(contracts); err == nil {
+			symbols[identifier] = token.Symbol
+		}
+	}
+	r.Fees = []Fee{}
+	for _, identifier := range keys(before.admin.Treasury, after.admin.Treasury) {
+		fee := Fee{Token: identifier, Before: before.admin.Treasury[identifier], After: after.admin.Treasury[identifier]}
+		if symbol, ok := symbols[identifier]; ok {
+			fee.Token = symbol
+		}
+		if fee.Before != fee.After {
+			r.Fees = append(r.Fees, fee)
+		}
+	}
+
+	r.Pools = []PoolChange{}
+	for _, id := range keys(before.admin.Pools, after.admin.Pools) {
+		change := PoolChange{PoolID: id}
+		if pool, ok := before.admin.Pools[id]; ok {
+			change.Before = &pool
+		}
+		if pool, ok := after.admin.Pools[id]; ok {
+			change.After = &pool
+		}
+		if !reflect.DeepEqual(change.Before, change.After) {
+			r.Pools = append(r.Pools, change)
+		}
+	}
+
+	r.Farms = []FarmChange{}
+	for _, id := range keys(before.farms, after.farms) {
+		change := FarmChange{FarmID: id}
+		if farm, ok := before.farms[id]; ok {
+			change.Before = &farm
+		}
+		if farm, ok := after.farms[id]; ok {
+			change.After = &farm
+		}
+		if !reflect.DeepEqual(change.Before, change.After) {
+			r.Farms = append(r.Farms, change)
+		}
+	}
+	return nil
+}
+
+// keys are the keys of both maps, sorted.
+func keys[K uint64 | string, V any](a, b map[K]V) []K {
+	var sorted []K
+	for k := range a {
+		sorted = append(sorted, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			sorted = append(sorted, k)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+// Write prints the result.
+func (r Result) Write(w io.Writer) error {
+	for _, tx := range r.Transactions {
+		status := "sealed"
+		if tx.Err != nil {
+			status = "failed: " + firstLine(tx.Err)
+		}
+		fmt.Fprintf(w, "transaction %s %s\n", tx.ID, status)
+		for _, event := range tx.Events {
+			fmt.Fprintf(w, "  %s\n", event)
+		}
+	}
+	if r.Err != nil {
+		fmt.Fprintf(w, "error: %s\n", firstLine(r.Err))
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	if len(r.Balances) > 0 {
+		fmt.Fprintln(tw, "address\ttoken\tbefore\tafter\tdelta\t")
+		for _, b := range r.Balances {
+			token := b.Token
+			if b.PoolID != nil {
+				token = fmt.Sprintf("LP %d", *b.PoolID)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t\n", "0x"+b.Address.Hex(), token, b.Before, b.After, b.Delta())
+		}
+	}
+	for _, fee := range r.Fees {
+		fmt.Fprintf(tw, "treasury\t%s\t%s\t%s\t%s\t\n", fee.Token, fee.Before, fee.After, emuswap.Fix64(int64(fee.After)-int64(fee.Before)))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, change := range r.Pools {
+		switch {
+		case change.Before == nil:
+			fmt.Fprintf(w, "pool %d created: %s\n", change.PoolID, pool(*change.After))
+		case change.After == nil:
+			fmt.Fprintf(w, "pool %d removed\n", change.PoolID)
+		default:
+			fmt.Fprintf(w, "pool %d: %s -> %s\n", change.PoolID, pool(*change.Before), pool(*change.After))
+		}
+	}
+	for _, change := range r.Farms {
+		switch {
+		case change.Before == nil:
+			fmt.Fprintf(w, "farm %d created, %s staked\n", change.FarmID, change.After.TotalStaked)
+		case change.After == nil:
+			fmt.Fprintf(w, "farm %d removed\n", change.FarmID)
+		default:
+			fmt.Fprintf(w, "farm %d: %s -> %s staked", change.FarmID, change.Before.TotalStaked, change.After.TotalStaked)
+			if !reflect.DeepEqual(change.Before.FarmState, change.After.FarmState) {
+				fmt.Fprint(w, ", reward pools changed")
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	return nil
+}
+
+func pool(p audit.PoolState) string {
+	s := fmt.Sprintf("%s/%s, %s LP tokens, fees %s/%s", p.Meta.Token1Amount, p.Meta.Token2Amount, p.Meta.TotalSupply, p.LPFeePercentage, p.DAOFeePercentage)
+	if p.Frozen {
+		s += ", frozen"
+	}
+	return s
+}
@@ -0,0 +1,328 @@
+// Package simulate dry-runs transactions on a running emulator. It reports
+// the events the transactions emit, the balances they move and how they
+// change pools and farms, then puts the emulator back the way it was.
+//
+// The emulator keeps snapshots of its state when started with --persist. A
+// dry run names a snapshot of the current state, sends the transactions,
+// reads what changed and jumps back to the snapshot. Until then other clients
+// of the emulator see the transactions. A dry run that finds a transaction it
+// did not send in the blocks after the snapshot does not jump back, as that
+// would undo the transaction too.
+package simulate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/flow-go-sdk"
+	"swap.emudao.org/test-overflow/emuswap"
+)
+
+// Options configure a dry run.
+type Options struct {
+	// AdminURL is the admin API of the emulator.
+	AdminURL string
+	// Accounts are read for balance changes besides the signers of the
+	// transactions and the addresses in their events.
+	Accounts []flow.Address
+}
+
+// DefaultOptions use the admin API where `flow emulator` serves it.
+var DefaultOptions = Options{AdminURL: "http://127.0.0.1:8080"}
+
+// Field is a field of an event, formatted like Cadence does.
+type Field struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Event is an event a transaction emitted.
+type Event struct {
+	Type   string  `json:"type"`
+	Fields []Field `json:"fields"`
+}
+
+func (e Event) String() string {
+	fields := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		fields[i] = f.Name + ": " + f.Value
+	}
+	return fmt.Sprintf("%s(%s)", e.Type, strings.Join(fields, ", "))
+}
+
+// Transaction is a transaction sent during the dry run.
+type Transaction struct {
+	ID string `json:"id"`
+	// Err is the failure of the transaction, classified by emuswap.Classify.
+	Err    error   `json:"-"`
+	Error  string  `json:"error,omitempty"`
+	Events []Event `json:"events"`
+
+	signers []flow.Address
+}
+
+// Run sends the transactions of send with a copy of c and reports what they
+// changed once the emulator is back at the state before. The transactions are
+// the ones send submits with Client.Submit, and the ones creating accounts or
+// deploying contracts any other way. The error of send is the Err of the
+// result; the error returned is the failure of the dry run itself. When
+// another client sent transactions meanwhile, Run leaves the emulator as it is
+// and returns an error naming them and the snapshot to restore by hand. What
+// send changes of the overflow state, as accounts it adds, is kept.
+func Run(c *emuswap.Client, options Options, send func(c *emuswap.Client) error) (Result, error) {
+	if c.O.Network != "emulator" {
+		return Result{}, fmt.Errorf("dry runs need the emulator, %s keeps no snapshots", c.O.Network)
+	}
+	latest, _, _, err := c.O.Services.Blocks.GetBlock("latest", "", false)
+	if err != nil {
+		return Result{}, err
+	}
+	name := fmt.Sprintf("dry-run-%d", time.Now().UnixNano())
+	snapshot, err := jump(options.AdminURL, name)
+	if err != nil {
+		return Result{}, err
+	}
+	if snapshot.BlockID != latest.ID.String() {
+		return Result{}, fmt.Errorf("the emulator at %s is at block %s, the %s network at %s", options.AdminURL, snapshot.BlockID, c.O.Network, latest.ID)
+	}
+
+	var mu sync.Mutex
+	var submitted []*overflow.OverflowResult
+	sim := *c
+	sim.OnSubmit = func(result *overflow.OverflowResult) {
+		if c.OnSubmit != nil {
+			c.OnSubmit(result)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		submitted = append(submitted, result)
+	}
+	var result Result
+	if result.Err = emuswap.Classify(send(&sim)); result.Err != nil {
+		result.Error = result.Err.Error()
+	}
+
+	transactions, err := collect(c, submitted, snapshot.Height)
+	var after state
+	var addresses []flow.Address
+	if err == nil {
+		addresses = accounts(options.Accounts, transactions)
+		after, err = read(c, addresses)
+	}
+	if err == nil {
+		var others []string
+		if others, err = foreign(c, transactions, snapshot.Height); err == nil && len(others) > 0 {
+			return Result{}, fmt.Errorf("transactions %s were sent to the emulator during the dry run, restore snapshot %s to undo the dry run", strings.Join(others, ", "), name)
+		}
+	}
+	if _, restoreErr := jump(options.AdminURL, name); restoreErr != nil {
+		return Result{}, fmt.Errorf("restoring snapshot %s: %w", name, restoreErr)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	before, err := read(c, addresses)
+	if err != nil {
+		return Result{}, err
+	}
+	result.Transactions = transactions
+	if err := result.compare(c, addresses, before, after); err != nil {
+		return Result{}, err
+	}
+	return result, nil
+}
+
+// Send dry-runs tx.
+func Send(c *emuswap.Client, tx overflow.FlowTransactionBuilder, options Options) (Result, error) {
+	return Run(c, options, func(c *emuswap.Client) error {
+		return c.Submit(tx).Err
+	})
+}
+
+// snapshot is what the admin API answers.
+type snapshot struct {
+	Height  uint64 `json:"height"`
+	BlockID string `json:"blockId"`
+}
+
+// jump creates the snapshot name of the current state, or restores it when
+// it exists.
+func jump(adminURL, name string) (snapshot, error) {
+	response, err := http.Get(strings.TrimSuffix(adminURL, "/") + "/emulator/snapshot/" + url.PathEscape(name))
+	if err != nil {
+		return snapshot{}, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return snapshot{}, fmt.Errorf("snapshot %s: %s, is the emulator started with --persist?", name, response.Status)
+	}
+	var s snapshot
+	if err := json.NewDecoder(response.Body).Decode(&s); err != nil {
+		return snapshot{}, fmt.Errorf("snapshot %s: %w", name, err)
+	}
+	return s, nil
+}
+
+// accountEvents are emitted by the transactions sent without Client.Submit
+// that a dry run lists.
+var accountEvents = []string{
+	flow.EventAccountCreated,
+	flow.EventAccountContractAdded,
+	flow.EventAccountContractUpdated,
+}
+
+// collect lists the submitted transactions and the ones found by their
+// account events in the blocks after height.
+func collect(c *emuswap.Client, submitted []*overflow.OverflowResult, height uint64) ([]Transaction, error) {
+	var transactions []Transaction
+	seen := map[flow.Identifier]bool{}
+	for _, result := range submitted {
+		if result.Transaction == nil {
+			// failed before it was sent
+			continue
+		}
+		tx := Transaction{ID: result.Id.String(), Err: result.Err, Events: convert(result.RawEvents)}
+		if tx.Err != nil {
+			tx.Error = tx.Err.Error()
+		}
+		tx.signers = append([]flow.Address{result.Transaction.Payer}, result.Transaction.Authorizers...)
+		transactions = append(transactions, tx)
+		seen[result.Id] = true
+	}
+
+	latest, err := c.O.Services.Blocks.GetLatestBlockHeight()
+	if err != nil || latest <= height {
+		return transactions, err
+	}
+	blocks, err := c.O.Services.Events.Get(accountEvents, height+1, latest, 250, 1)
+	if err != nil {
+		return nil, err
+	}
+	for _, block := range blocks {
+		for _, event := range block.Events {
+			if seen[event.TransactionID] {
+				continue
+			}
+			seen[event.TransactionID] = true
+			sent, status, err := c.O.Services.Transactions.GetStatus(event.TransactionID, false)
+			if err != nil {
+				return nil, fmt.Errorf("transaction %s: %w", event.TransactionID, err)
+			}
+			tx := Transaction{
+				ID:      event.TransactionID.String(),
+				Err:     emuswap.Classify(status.Error),
+				Events:  convert(status.Events),
+				signers: append([]flow.Address{sent.Payer}, sent.Authorizers...),
+			}
+			if tx.Err != nil {
+				tx.Error = tx.Err.Error()
+			}
+			transactions = append(transactions, tx)
+		}
+	}
+	return transactions, nil
+}
+
+// foreign lists the transactions in the blocks after height that are not
+// among transactions.
+func foreign(c *emuswap.Client, transactions []Transaction, height uint64) ([]string, error) {
+	latest, err := c.O.Services.Blocks.GetLatestBlockHeight()
+	if err != nil {
+		return nil, err
+	}
+	ours := map[string]bool{}
+	for _, tx := range transactions {
+		ours[tx.ID] = true
+	}
+	var others []string
+	for h := height + 1; h <= latest; h++ {
+		_, _, collections, err := c.O.Services.Blocks.GetBlock(strconv.FormatUint(h, 10), "", true)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %w", h, err)
+		}
+		for _, collection := range collections {
+			for _, id := range collection.TransactionIDs {
+				if !ours[id.String()] {
+					others = append(others, id.String())
+				}
+			}
+		}
+	}
+	return others, nil
+}
+
+func convert(flowEvents []flow.Event) []Event {
+	converted := []Event{}
+	for _, e := range flowEvents {
+		event := Event{Type: e.Type, Fields: []Field{}}
+		for i, field := range e.Value.EventType.Fields {
+			event.Fields = append(event.Fields, Field{Name: field.Identifier, Value: e.Value.Fields[i].String()})
+		}
+		converted = append(converted, event)
+	}
+	return converted
+}
+
+// accounts are the accounts whose balances are compared: the ones asked
+// for, the signers of the transactions and the addresses in their events.
+func accounts(asked []flow.Address, transactions []Transaction) []flow.Address {
+	var addresses []flow.Address
+	seen := map[flow.Address]bool{}
+	add := func(address flow.Address) {
+		if !seen[address] {
+			seen[address] = true
+			addresses = append(addresses, address)
+		}
+	}
+	for _, address := range asked {
+		add(address)
+	}
+	for _, tx := range transactions {
+		for _, signer := range tx.signers {
+			add(signer)
+		}
+	}
+	for _, tx := range transactions {
+		for _, event := range tx.Events {
+			for _, field := range event.Fields {
+				if address, ok := parseAddress(field.Value); ok {
+					add(address)
+				}
+			}
+		}
+	}
+	return addresses
+}
+
+// parseAddress reads an address field, which Cadence formats as 0x followed
+// by 16 hex digits.
+func parseAddress(value string) (flow.Address, bool) {
+	if len(value) != 18 || !strings.HasPrefix(value, "0x") {
+		return flow.EmptyAddress, false
+	}
+	for _, r := range value[2:] {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return flow.EmptyAddress, false
+		}
+	}
+	return flow.HexToAddress(value), true
+}
+
+// firstLine is the message of a classified error or the first line of any
+// other.
+func firstLine(err error) string {
+	var classified *emuswap.Error
+	if errors.As(err, &classified) && classified.Message != "" {
+		return classified.Message
+	}
+	line, _, _ := strings.Cut(err.Error(), "\n")
+	return line
+}
@@ -0,0 +1,280 @@
+package simulate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/flow-cli/pkg/flowkit/output"
+	"github.com/onflow/flow-emulator/server"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/provision"
+	"swap.emudao.org/test-overflow/internal/emutest"
+)
+
+// TestMain runs the package tests from the repository root, where flow.json
+// and the files it references resolve.
+func TestMain(m *testing.M) {
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(emutest.Main(m))
+}
+
+var ufix = emuswap.UFix64FromFloat
+
+// freePort is a port nothing listens on.
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// waitListening waits until something listens on the ports.
+func waitListening(t *testing.T, ports ...int) {
+	deadline := time.Now().Add(10 * time.Second)
+	for _, port := range ports {
+		for {
+			conn, err := net.Dial("tcp", "127.0.0.1:"+strconv.Itoa(port))
+			if err == nil {
+				conn.Close()
+				break
+			}
+			require.True(t, time.Now().Before(deadline), "nothing listens on port %d: %v", port, err)
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+// startEmulator serves an emulator persisted in a temporary directory, the
+// in-memory one keeps no snapshots, and returns a client of it with the
+// contracts deployed and the options to dry-run with it.
+func startEmulator(t *testing.T) (*emuswap.Client, Options) {
+	grpcPort, adminPort, restPort := freePort(t), freePort(t), freePort(t)
+	dir := t.TempDir()
+	config, err := os.ReadFile("flow.json")
+	require.NoError(t, err)
+	config = bytes.ReplaceAll(config, []byte("127.0.0.1:3569"), []byte("127.0.0.1:"+strconv.Itoa(grpcPort)))
+	configPath := filepath.Join(dir, "flow.json")
+	require.NoError(t, os.WriteFile(configPath, config, 0o600))
+
+	builder := overflow.NewOverflowBuilder("emulator", true, output.NoneLog).Config(configPath).ExistingEmulator()
+	o, err := builder.StartE()
+	require.NoError(t, err)
+	service, err := o.State.EmulatorServiceAccount()
+	require.NoError(t, err)
+	key, err := service.Key().PrivateKey()
+	require.NoError(t, err)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	s := server.NewEmulatorServer(logger, &server.Config{
+		GRPCPort:           grpcPort,
+		AdminPort:          adminPort,
+		RESTPort:           restPort,
+		ServicePublicKey:   (*key).PublicKey(),
+		ServicePrivateKey:  *key,
+		ServiceKeySigAlgo:  service.Key().SigAlgo(),
+		ServiceKeyHashAlgo: service.Key().HashAlgo(),
+		Persist:            true,
+		DBPath:             filepath.Join(dir, "db"),
+		GenesisTokenSupply: 100000000000000000,
+		// the defaults of flow emulator
+		TransactionMaxGasLimit: 9999,
+		ScriptGasLimit:         100000,
+	})
+	require.NotNil(t, s)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		s.Start()
+	}()
+	// Start returns once the store is closed and committed, which must
+	// happen before the temporary directory is removed
+	t.Cleanup(func() {
+		s.Stop()
+		<-stopped
+	})
+	waitListening(t, adminPort, grpcPort)
+
+	// the connection of the first client failed while nothing listened and
+	// backs off, a new one connects right away
+	o, err = builder.StartE()
+	require.NoError(t, err)
+
+	// signing with a key whose public key was never derived panics
+	for _, account := range *o.State.Accounts() {
+		if k, err := account.Key().PrivateKey(); err == nil {
+			(*k).PublicKey()
+		}
+	}
+	o = o.InitializeContracts()
+	o, err = o.CreateAccountsE()
+	require.NoError(t, err)
+	return emuswap.NewClient(o), Options{AdminURL: "http://127.0.0.1:" + strconv.Itoa(adminPort)}
+}
+
+func TestSend(t *testing.T) {
+	c, options := startEmulator(t)
+	c.FUSDSetup("account").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", ufix(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.EmuSwapAdminCreateNewPool("account", "flowTokenVault", ufix(100.0), "fusdVault", ufix(50.0)).Test(t).AssertSuccess()
+	account := c.Address("account")
+	before, err := c.GetBalances([]flow.Address{account}, []string{"flowTokenBalance", "fusdBalance"})
+	require.NoError(t, err)
+	pools, err := c.GetPoolsMeta()
+	require.NoError(t, err)
+
+	result, err := Send(c, c.EmuSwapUserSwap("account", "flowTokenVault", "fusdVault", ufix(10.0)), options)
+	require.NoError(t, err)
+	require.NoError(t, result.Err)
+	require.Len(t, result.Transactions, 1)
+	var types []string
+	for _, event := range result.Transactions[0].Events {
+		types = append(types, event.Type)
+	}
+	assert.Contains(t, types, "A.f8d6e0586b0a20c7.EmuSwap.Trade")
+
+	deltas := map[string]emuswap.Fix64{}
+	for _, b := range result.Balances {
+		if b.Address == account {
+			deltas[b.Token] = b.Delta()
+		}
+	}
+	assert.Equal(t, emuswap.Fix64FromFloat(-10.0), deltas["FLOW"])
+	assert.Greater(t, int64(deltas["FUSD"]), int64(0))
+	require.Len(t, result.Pools, 1)
+	assert.Equal(t, ufix(100.0), result.Pools[0].Before.Meta.Token1Amount)
+	assert.Greater(t, uint64(result.Pools[0].After.Meta.Token1Amount), uint64(ufix(100.0)))
+	assert.Empty(t, result.Farms)
+
+	var out strings.Builder
+	require.NoError(t, result.Write(&out))
+	assert.Contains(t, out.String(), "EmuSwap.Trade(")
+	assert.Contains(t, out.String(), "-10.00000000")
+
+	// nothing was committed
+	after, err := c.GetBalances([]flow.Address{account}, []string{"flowTokenBalance", "fusdBalance"})
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+	poolsAfter, err := c.GetPoolsMeta()
+	require.NoError(t, err)
+	assert.Equal(t, pools, poolsAfter)
+	// and the emulator goes on from the snapshot
+	c.EmuSwapUserSwap("account", "flowTokenVault", "fusdVault", ufix(1.0)).Test(t).AssertSuccess()
+}
+
+func TestRunFailure(t *testing.T) {
+	c, options := startEmulator(t)
+	c.FUSDSetup("account").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", ufix(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.EmuSwapAdminCreateNewPool("account", "flowTokenVault", ufix(100.0), "fusdVault", ufix(50.0)).Test(t).AssertSuccess()
+	c.StakingAdminCreateNewFarm("account", 0).Test(t).AssertSuccess()
+
+	result, err := Run(c, options, func(c *emuswap.Client) error {
+		if err := c.Submit(c.StakingUserStake("account", 0, ufix(0.5))).Err; err != nil {
+			return err
+		}
+		if err := c.Submit(c.EmuSwapAdminTogglePoolFreeze("account", 0)).Err; err != nil {
+			return err
+		}
+		return c.Submit(c.EmuSwapUserSwap("account", "flowTokenVault", "fusdVault", ufix(1.0))).Err
+	})
+	require.NoError(t, err)
+	assert.True(t, errors.Is(result.Err, emuswap.ErrPoolFrozen), "%v", result.Err)
+	require.Len(t, result.Transactions, 3)
+	assert.NoError(t, result.Transactions[1].Err)
+	assert.ErrorIs(t, result.Transactions[2].Err, emuswap.ErrPoolFrozen)
+	require.Len(t, result.Pools, 1)
+	assert.False(t, result.Pools[0].Before.Frozen)
+	assert.True(t, result.Pools[0].After.Frozen)
+	require.Len(t, result.Balances, 1)
+	assert.Equal(t, "LP", result.Balances[0].Token)
+	assert.Equal(t, emuswap.Fix64FromFloat(-0.5), result.Balances[0].Delta())
+	require.Len(t, result.Farms, 1)
+	assert.Equal(t, ufix(0.5), result.Farms[0].After.TotalStaked)
+	assert.Equal(t, ufix(0.5), result.Farms[0].After.Stakes[c.Address("account").Hex()])
+	_, err = json.Marshal(result)
+	assert.NoError(t, err)
+
+	var out strings.Builder
+	require.NoError(t, result.Write(&out))
+	assert.Contains(t, out.String(), "failed: EmuSwap is frozen")
+
+	frozen, err := c.GetFrozenPools()
+	require.NoError(t, err)
+	assert.False(t, frozen[0])
+}
+
+func TestRunForeign(t *testing.T) {
+	c, options := startEmulator(t)
+	c.FUSDSetup("account").Test(t).AssertSuccess()
+
+	// the outer client stands for another client of the emulator
+	_, err := Run(c, options, func(sim *emuswap.Client) error {
+		if err := sim.Submit(sim.DemoMintFUSD("account", ufix(10.0), sim.Address("account"))).Err; err != nil {
+			return err
+		}
+		c.DemoMintFUSD("account", ufix(1.0), c.Address("account")).Test(t).AssertSuccess()
+		return nil
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "restore snapshot dry-run-")
+
+	// the emulator was left as it is
+	balances, err := c.GetBalances([]flow.Address{c.Address("account")}, []string{"fusdBalance"})
+	require.NoError(t, err)
+	assert.Equal(t, ufix(11.0), balances[c.Address("account")]["fusdBalance"])
+}
+
+func TestRunProvision(t *testing.T) {
+	c, options := startEmulator(t)
+	spec := provision.DefaultSpec
+	spec.Count = 5
+	spec.Balances = map[string]emuswap.UFix64{"FUSD": ufix(100.0)}
+	result, err := Run(c, options, func(c *emuswap.Client) error {
+		_, err := provision.Provision(c, spec)
+		return err
+	})
+	require.NoError(t, err)
+	require.NoError(t, result.Err)
+
+	created := 0
+	for _, tx := range result.Transactions {
+		for _, event := range tx.Events {
+			if event.Type == "flow.AccountCreated" {
+				created++
+			}
+		}
+	}
+	assert.Equal(t, 2, created)
+	funded := map[flow.Address]bool{}
+	for _, b := range result.Balances {
+		if b.Token == "FUSD" && b.Delta() > 0 {
+			funded[b.Address] = true
+		}
+	}
+	assert.Len(t, funded, 5)
+}
+
+func TestRunInMemory(t *testing.T) {
+	o, err := emutest.Start()
+	require.NoError(t, err)
+	options := Options{AdminURL: "http://127.0.0.1:" + strconv.Itoa(freePort(t))}
+	_, err = Run(emuswap.NewClient(o), options, func(c *emuswap.Client) error { return nil })
+	assert.Error(t, err)
+}
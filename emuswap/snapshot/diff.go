@@ -0,0 +1,156 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Options configure a Diff.
+type Options struct {
+	// Ignore are the paths left out, with * matching any one segment, e.g.
+	// farms.*.stakes.*.pendingRewards. Everything under a path is left out
+	// with it.
+	Ignore []string
+}
+
+// DefaultOptions leave out where and when the states were taken and the
+// rewards pending, which grow with the block timestamp.
+var DefaultOptions = Options{Ignore: []string{"network", "height", "farms.*.stakes.*.pendingRewards"}}
+
+// Difference is a value that differs between two states. Path names the value
+// by the JSON keys leading to it, e.g. pools.0.token1Amount. Before is empty
+// for a value only after has, After for one only before has.
+type Difference struct {
+	Path   string `json:"path"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+func (d Difference) String() string {
+	switch {
+	case d.Before == "":
+		return fmt.Sprintf("%s: added %s", d.Path, d.After)
+	case d.After == "":
+		return fmt.Sprintf("%s: removed %s", d.Path, d.Before)
+	default:
+		return fmt.Sprintf("%s: %s -> %s", d.Path, d.Before, d.After)
+	}
+}
+
+// Diff lists the values that differ between before and after, in the order of
+// the fields of State and of sorted map keys. A pool, farm or other entry
+// only one state has is one difference, with the entry as JSON.
+func Diff(before, after State, options Options) []Difference {
+	d := differ{ignore: make([][]string, len(options.Ignore))}
+	for i, pattern := range options.Ignore {
+		d.ignore[i] = strings.Split(pattern, ".")
+	}
+	d.compare(nil, reflect.ValueOf(before), reflect.ValueOf(after))
+	return d.differences
+}
+
+type differ struct {
+	ignore      [][]string
+	differences []Difference
+}
+
+func (d *differ) ignored(path []string) bool {
+	for _, pattern := range d.ignore {
+		if len(pattern) > len(path) {
+			continue
+		}
+		matched := true
+		for i, segment := range pattern {
+			if segment != "*" && segment != path[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *differ) compare(path []string, before, after reflect.Value) {
+	if d.ignored(path) {
+		return
+	}
+	switch before.Kind() {
+	case reflect.Struct:
+		for i := 0; i < before.NumField(); i++ {
+			name, _, _ := strings.Cut(before.Type().Field(i).Tag.Get("json"), ",")
+			d.compare(append(path, name), before.Field(i), after.Field(i))
+		}
+	case reflect.Map:
+		keys := before.MapKeys()
+		for _, key := range after.MapKeys() {
+			if !before.MapIndex(key).IsValid() {
+				keys = append(keys, key)
+			}
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if keys[i].CanUint() {
+				return keys[i].Uint() < keys[j].Uint()
+			}
+			return keys[i].String() < keys[j].String()
+		})
+		for _, key := range keys {
+			keyPath := append(append([]string{}, path...), fmt.Sprint(key.Interface()))
+			b, a := before.MapIndex(key), after.MapIndex(key)
+			switch {
+			case !b.IsValid():
+				d.report(keyPath, "", format(a))
+			case !a.IsValid():
+				d.report(keyPath, format(b), "")
+			default:
+				d.compare(keyPath, b, a)
+			}
+		}
+	case reflect.Ptr:
+		switch {
+		case before.IsNil() && after.IsNil():
+		case before.IsNil():
+			d.report(path, "", format(after))
+		case after.IsNil():
+			d.report(path, format(before), "")
+		default:
+			d.compare(path, before.Elem(), after.Elem())
+		}
+	case reflect.Slice:
+		// nil and empty slices alike, JSON has null for one and [] for the other
+		if before.Len() != 0 || after.Len() != 0 {
+			if !reflect.DeepEqual(before.Interface(), after.Interface()) {
+				d.report(path, format(before), format(after))
+			}
+		}
+	default:
+		if before.Interface() != after.Interface() {
+			d.report(path, format(before), format(after))
+		}
+	}
+}
+
+func (d *differ) report(path []string, before, after string) {
+	if d.ignored(path) {
+		return
+	}
+	d.differences = append(d.differences, Difference{Path: strings.Join(path, "."), Before: before, After: after})
+}
+
+// format is the value as in the JSON of the state, strings unquoted.
+func format(v reflect.Value) string {
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return fmt.Sprint(v.Interface())
+	}
+	var s string
+	if json.Unmarshal(data, &s) == nil {
+		return s
+	}
+	return string(data)
+}
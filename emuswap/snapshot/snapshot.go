@@ -0,0 +1,310 @@
+// Package snapshot exports the whole protocol state to one canonical JSON
+// document and compares two of them field by field: the pools with their fees
+// and frozen flags, the fees collected, every farm with its stakes, the reward
+// pools, the xEMU pool, and the airdrops and vesting records on networks that
+// deploy FTAirdrop and Vesting.
+//
+// Take a snapshot before and after an upgrade, after two test runs or while
+// investigating an incident, and Diff them:
+//
+//	before, err := snapshot.Take(c)
+//	...
+//	for _, difference := range snapshot.Diff(before, after, snapshot.DefaultOptions) {
+//		fmt.Println(difference)
+//	}
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/emuswap/audit"
+)
+
+// State is the protocol state. Maps are keyed by ID, by token identifier, or
+// by address in hex as flow.Address is in JSON.
+type State struct {
+	Network string `json:"network"`
+	// Height is the latest block when the state was taken. The scripts reading
+	// it run at the latest block each, so blocks committed meanwhile show.
+	Height uint64 `json:"height"`
+	// LPFeePercentage and DAOFeePercentage are the fees new pools start with.
+	LPFeePercentage  emuswap.UFix64  `json:"lpFeePercentage"`
+	DAOFeePercentage emuswap.UFix64  `json:"daoFeePercentage"`
+	Pools            map[uint64]Pool `json:"pools"`
+	// FeesCollected is the treasury by token identifier.
+	FeesCollected map[string]emuswap.UFix64 `json:"feesCollected"`
+	Farms         map[uint64]Farm           `json:"farms"`
+	RewardPools   map[uint64]RewardPool     `json:"rewardPools"`
+	XEmu          XEmu                      `json:"xEmu"`
+	// Airdrops and Vesting are nil on networks without FTAirdrop and Vesting.
+	Airdrops map[uint64]Airdrop `json:"airdrops"`
+	Vesting  map[string]Vesting `json:"vesting"`
+}
+
+// Pool is the PoolMeta, fees and frozen flag of a pool.
+type Pool struct {
+	Token1Identifier string         `json:"token1Identifier"`
+	Token2Identifier string         `json:"token2Identifier"`
+	Token1Amount     emuswap.UFix64 `json:"token1Amount"`
+	Token2Amount     emuswap.UFix64 `json:"token2Amount"`
+	TotalSupply      emuswap.UFix64 `json:"totalSupply"`
+	LPFeePercentage  emuswap.UFix64 `json:"lpFeePercentage"`
+	DAOFeePercentage emuswap.UFix64 `json:"daoFeePercentage"`
+	Frozen           bool           `json:"frozen"`
+}
+
+// Farm is the farm of a pool with the stakes read_stakes_info reads. Meta is
+// nil when get_farm_meta cannot read it, as when a reward pool does not weight
+// the farm.
+type Farm struct {
+	Meta   *FarmMeta        `json:"meta"`
+	Stakes map[string]Stake `json:"stakes"`
+}
+
+// FarmMeta is StakingRewards.FarmMeta without its stakes.
+type FarmMeta struct {
+	TotalStaked                        emuswap.UFix64            `json:"totalStaked"`
+	LastRewardTimestamp                emuswap.UFix64            `json:"lastRewardTimestamp"`
+	FarmWeightsByID                    map[uint64]emuswap.UFix64 `json:"farmWeightsByID"`
+	RewardTokensPerSecondByID          map[uint64]emuswap.UFix64 `json:"rewardTokensPerSecondByID"`
+	TotalAccumulatedTokensPerShareByID map[uint64]emuswap.UFix64 `json:"totalAccumulatedTokensPerShareByID"`
+	RewardsRemainingByID               map[uint64]emuswap.UFix64 `json:"rewardsRemainingByID"`
+}
+
+// Stake is StakingRewards.StakeInfo. The rewards pending grow with the block
+// timestamp, DefaultOptions leave them out of a Diff.
+type Stake struct {
+	Balance           emuswap.UFix64           `json:"balance"`
+	RewardDebtByID    map[uint64]emuswap.Fix64 `json:"rewardDebtByID"`
+	PendingRewards    map[uint64]emuswap.Fix64 `json:"pendingRewards"`
+	StakedNFTs        []string                 `json:"stakedNFTs"`
+	RewardReceiverIDs []uint64                 `json:"rewardReceiverIDs"`
+}
+
+// RewardPool is StakingRewards.RewardPoolMeta.
+type RewardPool struct {
+	TokenIdentifier         string                    `json:"tokenIdentifier"`
+	Balance                 emuswap.UFix64            `json:"balance"`
+	FarmWeightsByID         map[uint64]emuswap.UFix64 `json:"farmWeightsByID"`
+	TotalWeight             emuswap.UFix64            `json:"totalWeight"`
+	RewardsGenesisTimestamp emuswap.UFix64            `json:"rewardsGenesisTimestamp"`
+	CurrentEmissionRate     emuswap.UFix64            `json:"currentEmissionRate"`
+	// Emission is nil for reward pools emitting at a constant rate.
+	Emission           *emuswap.DecayingEmission `json:"emission"`
+	AccessNFTsAccepted []string                  `json:"accessNFTsAccepted"`
+}
+
+// XEmu is the EMU pool backing xEMU. EmuPerXEmu is 1 while no xEMU exists,
+// the rate the first EMU enters at.
+type XEmu struct {
+	TotalSupply    emuswap.UFix64 `json:"totalSupply"`
+	EmuPoolBalance emuswap.UFix64 `json:"emuPoolBalance"`
+	EmuPerXEmu     emuswap.UFix64 `json:"emuPerXEmu"`
+}
+
+// Airdrop is FTAirdrop.DropMeta, Address is the owner's and Claims are what
+// is left to claim.
+type Airdrop struct {
+	TokenIdentifier string                    `json:"tokenIdentifier"`
+	Balance         emuswap.UFix64            `json:"balance"`
+	StartTime       emuswap.UFix64            `json:"startTime"`
+	EndTime         emuswap.UFix64            `json:"endTime"`
+	Address         string                    `json:"address"`
+	Claims          map[string]emuswap.UFix64 `json:"claims"`
+}
+
+// Vesting is Vesting.VestingMeta.
+type Vesting struct {
+	InitialBalance  emuswap.UFix64 `json:"initialBalance"`
+	LockedFrom      emuswap.UFix64 `json:"lockedFrom"`
+	Duration        emuswap.UFix64 `json:"duration"`
+	Balance         emuswap.UFix64 `json:"balance"`
+	TokensWithdrawn emuswap.UFix64 `json:"tokensWithdrawn"`
+}
+
+// Take reads the state at the latest block.
+func Take(c *emuswap.Client) (State, error) {
+	s := State{Network: c.O.Network}
+	var err error
+	if s.Height, err = c.O.Services.Blocks.GetLatestBlockHeight(); err != nil {
+		return s, err
+	}
+	admin, err := audit.ReadState(c)
+	if err != nil {
+		return s, err
+	}
+	s.LPFeePercentage, s.DAOFeePercentage = admin.LPFeePercentage, admin.DAOFeePercentage
+	s.FeesCollected = admin.Treasury
+	s.Pools = map[uint64]Pool{}
+	for id, pool := range admin.Pools {
+		s.Pools[id] = Pool{
+			Token1Identifier: pool.Meta.Token1Identifier,
+			Token2Identifier: pool.Meta.Token2Identifier,
+			Token1Amount:     pool.Meta.Token1Amount,
+			Token2Amount:     pool.Meta.Token2Amount,
+			TotalSupply:      pool.Meta.TotalSupply,
+			LPFeePercentage:  pool.LPFeePercentage,
+			DAOFeePercentage: pool.DAOFeePercentage,
+			Frozen:           pool.Frozen,
+		}
+	}
+
+	if s.Farms, err = farms(c); err != nil {
+		return s, err
+	}
+	metas, err := c.StakingGetRewardPoolsMeta()
+	if err != nil {
+		return s, err
+	}
+	s.RewardPools = map[uint64]RewardPool{}
+	for id, meta := range metas {
+		s.RewardPools[id] = RewardPool{
+			TokenIdentifier:         meta.TokenIdentifier,
+			Balance:                 meta.Balance,
+			FarmWeightsByID:         meta.FarmWeightsByID,
+			TotalWeight:             meta.TotalWeight,
+			RewardsGenesisTimestamp: meta.RewardsGenesisTimestamp,
+			CurrentEmissionRate:     meta.CurrentEmissionRate,
+			Emission:                meta.Emission,
+			AccessNFTsAccepted:      meta.AccessNFTsAccepted,
+		}
+	}
+
+	xEmu, err := c.XEmuGetPool()
+	if err != nil {
+		return s, err
+	}
+	s.XEmu = XEmu{TotalSupply: xEmu["totalSupply"], EmuPoolBalance: xEmu["emuPoolBalance"], EmuPerXEmu: emuswap.UFix64FromFloat(1)}
+	if s.XEmu.TotalSupply > 0 {
+		if s.XEmu.EmuPerXEmu, err = s.XEmu.EmuPoolBalance.Div(s.XEmu.TotalSupply); err != nil {
+			return s, fmt.Errorf("xEMU rate: %w", err)
+		}
+	}
+
+	contracts, err := emuswap.ContractAddresses(c.O.State, c.O.Network)
+	if err != nil {
+		return s, err
+	}
+	if _, ok := contracts["FTAirdrop"]; ok {
+		if s.Airdrops, err = airdrops(c); err != nil {
+			return s, err
+		}
+	}
+	if _, ok := contracts["Vesting"]; ok {
+		if s.Vesting, err = vesting(c); err != nil {
+			return s, err
+		}
+	}
+	return s, nil
+}
+
+func farms(c *emuswap.Client) (map[uint64]Farm, error) {
+	staked, err := c.StakingGetTotalStaked()
+	if err != nil {
+		return nil, err
+	}
+	farms := map[uint64]Farm{}
+	for id := range staked {
+		farm := Farm{Stakes: map[string]Stake{}}
+		if meta, err := c.StakingGetFarmMeta(id); err == nil && meta != nil {
+			farm.Meta = &FarmMeta{
+				TotalStaked:                        meta.TotalStaked,
+				LastRewardTimestamp:                meta.LastRewardTimestamp,
+				FarmWeightsByID:                    meta.FarmWeightsByID,
+				RewardTokensPerSecondByID:          meta.RewardTokensPerSecondByID,
+				TotalAccumulatedTokensPerShareByID: meta.TotalAccumulatedTokensPerShareByID,
+				RewardsRemainingByID:               meta.RewardsRemainingByID,
+			}
+		}
+		stakes, err := c.StakingReadStakesInfo(id)
+		if err != nil {
+			return nil, fmt.Errorf("farm %d: %w", id, err)
+		}
+		for address, stake := range stakes {
+			farm.Stakes[address.Hex()] = Stake{
+				Balance:           stake.Balance,
+				RewardDebtByID:    stake.RewardDebtByID,
+				PendingRewards:    stake.PendingRewards,
+				StakedNFTs:        stake.StakedNFTs,
+				RewardReceiverIDs: stake.RewardReceiverIDs,
+			}
+		}
+		farms[id] = farm
+	}
+	return farms, nil
+}
+
+func airdrops(c *emuswap.Client) (map[uint64]Airdrop, error) {
+	drops, err := c.FTAirdropGetDropsMeta()
+	if err != nil {
+		return nil, err
+	}
+	airdrops := map[uint64]Airdrop{}
+	for id, drop := range drops {
+		airdrop := Airdrop{
+			TokenIdentifier: drop.TokenIdentifier,
+			Balance:         drop.Balance,
+			StartTime:       drop.StartTime,
+			EndTime:         drop.EndTime,
+			Address:         drop.Address.Hex(),
+			Claims:          map[string]emuswap.UFix64{},
+		}
+		for address, amount := range drop.Claims {
+			airdrop.Claims[address.Hex()] = amount
+		}
+		airdrops[id] = airdrop
+	}
+	return airdrops, nil
+}
+
+func vesting(c *emuswap.Client) (map[string]Vesting, error) {
+	metas, err := c.VestingGetVestingMeta()
+	if err != nil {
+		return nil, err
+	}
+	vesting := map[string]Vesting{}
+	for address, meta := range metas {
+		vesting[address.Hex()] = Vesting{
+			InitialBalance:  meta.InitalBalance,
+			LockedFrom:      meta.LockedFrom,
+			Duration:        meta.Duration,
+			Balance:         meta.Balance,
+			TokensWithdrawn: meta.TokensWithdrawn,
+		}
+	}
+	return vesting, nil
+}
+
+// Write encodes the state as canonical JSON: indented, object keys sorted and
+// numbers of the fixed point types as decimal strings, so equal states are
+// equal bytes.
+func (s State) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+// Read decodes a state written by Write.
+func Read(r io.Reader) (State, error) {
+	var s State
+	err := json.NewDecoder(r).Decode(&s)
+	return s, err
+}
+
+// ReadFile reads the state written to path.
+func ReadFile(path string) (State, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return State{}, err
+	}
+	defer f.Close()
+	s, err := Read(f)
+	if err != nil {
+		return State{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
@@ -0,0 +1,131 @@
+package snapshot
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"swap.emudao.org/test-overflow/emuswap"
+	"swap.emudao.org/test-overflow/internal/emutest"
+)
+
+// TestMain runs the package tests from the repository root, where flow.json
+// and the files it references resolve.
+func TestMain(m *testing.M) {
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(emutest.Main(m))
+}
+
+var ufix = emuswap.UFix64FromFloat
+
+func paths(differences []Difference) []string {
+	var paths []string
+	for _, d := range differences {
+		paths = append(paths, d.Path)
+	}
+	return paths
+}
+
+func TestTake(t *testing.T) {
+	o, err := emutest.Start()
+	require.NoError(t, err)
+	c := emuswap.NewClient(o)
+	account := c.Address("account").Hex()
+	c.FUSDSetup("account").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", ufix(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.EmuSwapAdminCreateNewPool("account", "flowTokenVault", ufix(100.0), "fusdVault", ufix(50.0)).Test(t).AssertSuccess()
+	c.StakingAdminCreateNewFarm("account", 0).Test(t).AssertSuccess()
+	c.StakingUserStake("account", 0, ufix(0.5)).Test(t).AssertSuccess()
+	c.XEmuEnterPool("account", ufix(10.0)).Test(t).AssertSuccess()
+	c.FTAirdropCreateDrop("account", ufix(100.0)).Test(t).AssertSuccess()
+
+	before, err := Take(c)
+	require.NoError(t, err)
+	assert.Equal(t, "emulator", before.Network)
+	assert.NotZero(t, before.Height)
+	require.Contains(t, before.Pools, uint64(0))
+	pool := before.Pools[0]
+	assert.Equal(t, ufix(100.0), pool.Token1Amount)
+	assert.Equal(t, ufix(50.0), pool.Token2Amount)
+	assert.False(t, pool.Frozen)
+	assert.Equal(t, before.LPFeePercentage, pool.LPFeePercentage)
+	require.Contains(t, before.Farms, uint64(0))
+	require.NotNil(t, before.Farms[0].Meta)
+	assert.Equal(t, ufix(0.5), before.Farms[0].Meta.TotalStaked)
+	assert.Equal(t, ufix(0.5), before.Farms[0].Stakes[account].Balance)
+	assert.NotEmpty(t, before.RewardPools)
+	assert.Equal(t, XEmu{TotalSupply: ufix(10.0), EmuPoolBalance: ufix(10.0), EmuPerXEmu: ufix(1.0)}, before.XEmu)
+	require.Len(t, before.Airdrops, 1)
+	assert.Equal(t, ufix(100.0), before.Airdrops[0].Balance)
+	assert.Equal(t, account, before.Airdrops[0].Address)
+	assert.Equal(t, map[string]emuswap.UFix64{account: ufix(10.0)}, before.Airdrops[0].Claims)
+	require.Len(t, before.Vesting, 4)
+	assert.NotZero(t, before.Vesting[account].InitialBalance)
+	assert.Equal(t, before.Vesting[account].InitialBalance, before.Vesting[account].Balance)
+
+	// the document reads back to the same state and bytes
+	var written bytes.Buffer
+	require.NoError(t, before.Write(&written))
+	read, err := Read(bytes.NewReader(written.Bytes()))
+	require.NoError(t, err)
+	assert.Empty(t, Diff(before, read, Options{}))
+	var rewritten bytes.Buffer
+	require.NoError(t, read.Write(&rewritten))
+	assert.Equal(t, written.String(), rewritten.String())
+
+	c.EmuSwapUserSwap("account", "flowTokenVault", "fusdVault", ufix(10.0)).Test(t).AssertSuccess()
+	c.EmuSwapAdminTogglePoolFreeze("account", 0).Test(t).AssertSuccess()
+	after, err := Take(c)
+	require.NoError(t, err)
+	differences := Diff(before, after, DefaultOptions)
+	assert.Subset(t, paths(differences), []string{"pools.0.token1Amount", "pools.0.token2Amount", "pools.0.frozen"})
+	assert.Contains(t, differences, Difference{Path: "pools.0.frozen", Before: "false", After: "true"})
+	for _, d := range differences {
+		assert.NotContains(t, d.Path, "pendingRewards")
+		assert.NotEqual(t, "height", d.Path)
+	}
+	assert.Contains(t, paths(Diff(before, after, Options{})), "height")
+}
+
+func TestDiff(t *testing.T) {
+	before := State{
+		Height: 10,
+		Pools:  map[uint64]Pool{0: {Token1Amount: ufix(1.0)}},
+		Farms: map[uint64]Farm{0: {Stakes: map[string]Stake{
+			"01": {Balance: ufix(1.0), PendingRewards: map[uint64]emuswap.Fix64{0: 1}},
+			"02": {Balance: ufix(2.0), StakedNFTs: []string{}},
+		}}},
+		XEmu: XEmu{EmuPerXEmu: ufix(1.0)},
+	}
+	after := State{
+		Height: 11,
+		Pools:  map[uint64]Pool{0: {Token1Amount: ufix(1.5)}, 2: {Frozen: true}},
+		Farms: map[uint64]Farm{0: {Stakes: map[string]Stake{
+			"01": {Balance: ufix(1.0), PendingRewards: map[uint64]emuswap.Fix64{0: 2}},
+		}}},
+		XEmu: XEmu{EmuPerXEmu: ufix(1.0)},
+	}
+	differences := Diff(before, after, DefaultOptions)
+	require.Len(t, differences, 3)
+	assert.Equal(t, "pools.0.token1Amount: 1.00000000 -> 1.50000000", differences[0].String())
+	assert.Equal(t, "pools.2", differences[1].Path)
+	assert.Contains(t, differences[1].String(), `added {"token1Identifier":""`)
+	assert.Equal(t, "farms.0.stakes.02", differences[2].Path)
+	assert.Empty(t, differences[2].After)
+
+	ignored := Diff(before, after, Options{Ignore: []string{"pools", "farms.*.stakes.02"}})
+	assert.Equal(t, []string{"height", "farms.0.stakes.01.pendingRewards.0"}, paths(ignored))
+
+	// nil and empty slices and maps are alike
+	before.Farms[0].Stakes["02"] = Stake{Balance: ufix(2.0)}
+	after = before
+	after.Farms = map[uint64]Farm{0: {Stakes: map[string]Stake{
+		"01": before.Farms[0].Stakes["01"],
+		"02": {Balance: ufix(2.0), StakedNFTs: []string{}, RewardDebtByID: map[uint64]emuswap.Fix64{}},
+	}}}
+	assert.Empty(t, Diff(before, after, Options{}))
+}
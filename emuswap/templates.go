@@ -0,0 +1,122 @@
+package emuswap
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/flow-go-sdk"
+)
+
+// The transaction templates render the swap, liquidity and stake transactions
+// for any pair of registered tokens. Contracts are imported by address so the
+// rendered code can be sent inline.
+//
+//go:embed templates/*.cdc.tmpl
+var templateFS embed.FS
+
+var templates = template.Must(template.New("").
+	Funcs(template.FuncMap{"imports": func(...string) (string, error) { return "", nil }}).
+	ParseFS(templateFS, "templates/*.cdc.tmpl"))
+
+// PairData is passed to the liquidity and stake templates.
+type PairData struct {
+	Token1 Token
+	Token2 Token
+	// Reward is the token farms pay out, EMU unless set otherwise
+	Reward Token
+}
+
+// SwapData is passed to the swap template.
+type SwapData struct {
+	From Token
+	To   Token
+}
+
+// RenderTransaction renders one of the templates in emuswap/templates, e.g.
+// "swap.cdc.tmpl", importing contracts from addresses.
+func RenderTransaction(name string, addresses map[string]flow.Address, data interface{}) (string, error) {
+	t, err := templates.Clone()
+	if err != nil {
+		return "", err
+	}
+	t.Funcs(template.FuncMap{"imports": importsFunc(addresses)})
+
+	var out bytes.Buffer
+	if err := t.ExecuteTemplate(&out, name, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// importsFunc returns the import lines for the given contracts, skipping
+// duplicates such as a pair where both tokens share a contract.
+func importsFunc(addresses map[string]flow.Address) func(...string) (string, error) {
+	return func(contracts ...string) (string, error) {
+		seen := map[string]bool{}
+		var lines []string
+		for _, contract := range contracts {
+			if seen[contract] {
+				continue
+			}
+			seen[contract] = true
+			address, ok := addresses[contract]
+			if !ok {
+				return "", fmt.Errorf("no address for contract %s", contract)
+			}
+			lines = append(lines, fmt.Sprintf("import %s from 0x%s", contract, address.Hex()))
+		}
+		return strings.Join(lines, "\n"), nil
+	}
+}
+
+// Swap builds a transaction swapping amount of from for to. The to vault is
+// created if the signer does not have one.
+func (c *Client) Swap(signer string, from, to Token, amount UFix64) overflow.FlowTransactionBuilder {
+	return c.templated("swap.cdc.tmpl", SwapData{From: from, To: to}, signer, amount)
+}
+
+// AddLiquidity builds a transaction adding amount1 of token1 and amount2 of
+// token2 to their pool, in whichever order the pool holds them.
+func (c *Client) AddLiquidity(signer string, token1, token2 Token, amount1, amount2 UFix64) overflow.FlowTransactionBuilder {
+	return c.templated("add_liquidity.cdc.tmpl", c.pair(token1, token2), signer, amount1, amount2)
+}
+
+// RemoveLiquidity builds a transaction returning amount of LP tokens of the
+// token1/token2 pool. Missing token vaults are created.
+func (c *Client) RemoveLiquidity(signer string, token1, token2 Token, amount UFix64) overflow.FlowTransactionBuilder {
+	return c.templated("remove_liquidity.cdc.tmpl", c.pair(token1, token2), signer, amount)
+}
+
+// AddLiquidityAndStake builds a transaction adding liquidity to the
+// token1/token2 pool and staking the LP tokens in farmID. The EMU reward vault
+// and the stake controller collection are created if missing.
+func (c *Client) AddLiquidityAndStake(signer string, farmID uint64, token1, token2 Token, amount1, amount2 UFix64) overflow.FlowTransactionBuilder {
+	return c.templated("add_liquidity_and_stake.cdc.tmpl", c.pair(token1, token2), signer, farmID, amount1, amount2)
+}
+
+func (c *Client) pair(token1, token2 Token) PairData {
+	return PairData{Token1: token1, Token2: token2, Reward: MustLookupToken("EMU")}
+}
+
+// templated panics when rendering fails, like encodeArgs, since the tokens
+// come from the registry and the addresses from flow.json. The builder is named
+// after the template, e.g. templates/swap, instead of overflow's "inline".
+func (c *Client) templated(name string, data interface{}, signer string, args ...interface{}) overflow.FlowTransactionBuilder {
+	addresses, err := ContractAddresses(c.O.State, c.O.Network)
+	if err != nil {
+		panic(fmt.Sprintf("%s: %v", name, err))
+	}
+	code, err := RenderTransaction(name, addresses, data)
+	if err != nil {
+		panic(fmt.Sprintf("%s: %v", name, err))
+	}
+	tx := c.O.Transaction(code).
+		SignProposeAndPayAs(signer).
+		ArgsV(encodeArgs(name, args))
+	tx.FileName = "templates/" + strings.TrimSuffix(name, ".cdc.tmpl")
+	return tx
+}
@@ -0,0 +1,45 @@
+package emuswap
+
+import (
+	"testing"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderTransactionMissingAddress(t *testing.T) {
+	_, err := RenderTransaction("swap.cdc.tmpl", map[string]flow.Address{}, SwapData{
+		From: MustLookupToken("FLOW"),
+		To:   MustLookupToken("FUSD"),
+	})
+	assert.ErrorContains(t, err, "no address for contract FungibleToken")
+}
+
+func TestTemplatedTransactions(t *testing.T) {
+	c := newTestClient(t)
+	flowToken, fusd := MustLookupToken("FLOW"), MustLookupToken("FUSD")
+
+	c.DemoMintFlowTokens("account", UFix64FromFloat(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.FUSDSetup("account").Test(t).AssertSuccess()
+	c.DemoMintFUSD("account", UFix64FromFloat(1000.0), c.Address("account")).Test(t).AssertSuccess()
+	c.EmuSwapAdminCreateNewPool("account", "flowTokenVault", UFix64FromFloat(100.0), "fusdVault", UFix64FromFloat(50.0)).
+		Test(t).
+		AssertSuccess()
+
+	// user1 has no FUSD vault, the swap creates it
+	c.DemoMintFlowTokens("account", UFix64FromFloat(100.0), c.Address("user1")).Test(t).AssertSuccess()
+	c.Swap("user1", flowToken, fusd, UFix64FromFloat(10.0)).Test(t).AssertSuccess()
+
+	meta, err := c.GetPoolMeta(0)
+	assert.NoError(t, err)
+	assert.Less(t, meta.Token2Amount, UFix64FromFloat(50.0))
+
+	// and the other direction spends from the vault created above
+	c.Swap("user1", fusd, flowToken, UFix64FromFloat(1.0)).Test(t).AssertSuccess()
+
+	// liquidity is added in pool order whatever order the pair is given in
+	c.AddLiquidity("user1", fusd, flowToken, UFix64FromFloat(2.0), UFix64FromFloat(4.0)).Test(t).AssertSuccess()
+	c.RemoveLiquidity("user1", flowToken, fusd, UFix64FromFloat(0.01)).Test(t).AssertSuccess()
+
+	c.Swap("user1", flowToken, MustLookupToken("EMU"), UFix64FromFloat(1.0)).Test(t).AssertFailure("Can't find swap pool")
+}
@@ -0,0 +1,54 @@
+package emuswap
+
+import (
+	"fmt"
+
+	"github.com/onflow/flow-go-sdk"
+)
+
+// Token describes a fungible token that can be held in EmuSwap pools. Paths
+// are identifiers without their /storage or /public domain.
+type Token struct {
+	Symbol       string
+	Contract     string // contract declaring the Vault resource
+	StoragePath  string
+	ReceiverPath string
+	BalancePath  string
+}
+
+// Tokens is the registry of known tokens, looked up with LookupToken.
+var Tokens = []Token{
+	{Symbol: "FLOW", Contract: "FlowToken", StoragePath: "flowTokenVault", ReceiverPath: "flowTokenReceiver", BalancePath: "flowTokenBalance"},
+	{Symbol: "FUSD", Contract: "FUSD", StoragePath: "fusdVault", ReceiverPath: "fusdReceiver", BalancePath: "fusdBalance"},
+	{Symbol: "EMU", Contract: "EmuToken", StoragePath: "emuTokenVault", ReceiverPath: "emuTokenReceiver", BalancePath: "emuTokenBalance"},
+	{Symbol: "xEMU", Contract: "xEmuToken", StoragePath: "xEmuTokenVault", ReceiverPath: "xEmuTokenReceiver", BalancePath: "xEmuTokenBalance"},
+}
+
+// LookupToken finds a registered token by symbol or storage path identifier.
+func LookupToken(key string) (Token, error) {
+	for _, token := range Tokens {
+		if token.Symbol == key || token.StoragePath == key {
+			return token, nil
+		}
+	}
+	return Token{}, fmt.Errorf("unknown token %q", key)
+}
+
+// MustLookupToken is LookupToken for tokens known to be registered.
+func MustLookupToken(key string) Token {
+	token, err := LookupToken(key)
+	if err != nil {
+		panic(err)
+	}
+	return token
+}
+
+// VaultIdentifier is the Vault type identifier EmuSwap uses to key pools and
+// fees, e.g. A.0ae53cb6e3f42a79.FlowToken.Vault.
+func (t Token) VaultIdentifier(addresses map[string]flow.Address) (string, error) {
+	address, ok := addresses[t.Contract]
+	if !ok {
+		return "", fmt.Errorf("no address for contract %s", t.Contract)
+	}
+	return fmt.Sprintf("A.%s.%s.Vault", address.Hex(), t.Contract), nil
+}
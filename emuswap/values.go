@@ -0,0 +1,427 @@
+package emuswap
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+)
+
+// UFix64Factor is the fixed point scale used by Cadence for UFix64 and Fix64.
+const UFix64Factor = 100000000
+
+// UFix64 mirrors the Cadence UFix64 type. The value is stored as the raw fixed
+// point integer so no precision is lost on the way to and from the chain.
+type UFix64 uint64
+
+// MaxUFix64 is the largest value representable by a Cadence UFix64.
+const MaxUFix64 = UFix64(math.MaxUint64)
+
+// UFix64FromFloat converts a float to the closest UFix64, panicking on negative input.
+func UFix64FromFloat(f float64) UFix64 {
+	if f < 0 {
+		panic(fmt.Sprintf("negative UFix64 %v", f))
+	}
+	return UFix64(math.Round(f * UFix64Factor))
+}
+
+// ParseUFix64 parses a decimal string with at most 8 fractional digits.
+func ParseUFix64(s string) (UFix64, error) {
+	integer, fraction, err := splitFixed(s)
+	if err != nil {
+		return 0, err
+	}
+	raw := new(big.Int).Mul(integer, big.NewInt(UFix64Factor))
+	raw.Add(raw, fraction)
+	if !raw.IsUint64() {
+		return 0, fmt.Errorf("UFix64 %q out of range", s)
+	}
+	return UFix64(raw.Uint64()), nil
+}
+
+// Float64 returns the (possibly rounded) float value.
+func (u UFix64) Float64() float64 {
+	return float64(u) / UFix64Factor
+}
+
+// String formats the value the same way Cadence does, e.g. 1.50000000.
+func (u UFix64) String() string {
+	return fmt.Sprintf("%d.%08d", uint64(u)/UFix64Factor, uint64(u)%UFix64Factor)
+}
+
+// MarshalText encodes the value as its decimal string, so JSON keeps every
+// digit.
+func (u UFix64) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText parses a decimal string.
+func (u *UFix64) UnmarshalText(text []byte) error {
+	value, err := ParseUFix64(string(text))
+	if err != nil {
+		return err
+	}
+	*u = value
+	return nil
+}
+
+// ErrUFix64Range is returned by UFix64 arithmetic that would abort a Cadence
+// program with an overflow, underflow or division by zero.
+var ErrUFix64Range = errors.New("UFix64 out of range")
+
+// The arithmetic below follows the Cadence interpreter, truncating the results
+// of multiplications and divisions, so off-chain models match the contracts to
+// the last digit.
+
+// Add returns u + v.
+func (u UFix64) Add(v UFix64) (UFix64, error) {
+	sum := u + v
+	if sum < u {
+		return 0, fmt.Errorf("%w: %s + %s", ErrUFix64Range, u, v)
+	}
+	return sum, nil
+}
+
+// Sub returns u - v.
+func (u UFix64) Sub(v UFix64) (UFix64, error) {
+	if v > u {
+		return 0, fmt.Errorf("%w: %s - %s", ErrUFix64Range, u, v)
+	}
+	return u - v, nil
+}
+
+// Mul returns u * v.
+func (u UFix64) Mul(v UFix64) (UFix64, error) {
+	hi, lo := bits.Mul64(uint64(u), uint64(v))
+	if hi >= UFix64Factor {
+		return 0, fmt.Errorf("%w: %s * %s", ErrUFix64Range, u, v)
+	}
+	quo, _ := bits.Div64(hi, lo, UFix64Factor)
+	return UFix64(quo), nil
+}
+
+// Div returns u / v.
+func (u UFix64) Div(v UFix64) (UFix64, error) {
+	if v == 0 {
+		return 0, fmt.Errorf("%w: %s / 0", ErrUFix64Range, u)
+	}
+	hi, lo := bits.Mul64(uint64(u), UFix64Factor)
+	if hi >= uint64(v) {
+		return 0, fmt.Errorf("%w: %s / %s", ErrUFix64Range, u, v)
+	}
+	quo, _ := bits.Div64(hi, lo, uint64(v))
+	return UFix64(quo), nil
+}
+
+// Fix64 mirrors the Cadence Fix64 type as a raw fixed point integer.
+type Fix64 int64
+
+// Fix64FromFloat converts a float to the closest Fix64.
+func Fix64FromFloat(f float64) Fix64 {
+	return Fix64(math.Round(f * UFix64Factor))
+}
+
+// Float64 returns the (possibly rounded) float value.
+func (f Fix64) Float64() float64 {
+	return float64(f) / UFix64Factor
+}
+
+func (f Fix64) String() string {
+	sign := ""
+	abs := uint64(f)
+	if f < 0 {
+		sign = "-"
+		abs = uint64(-f)
+	}
+	return fmt.Sprintf("%s%d.%08d", sign, abs/UFix64Factor, abs%UFix64Factor)
+}
+
+// ParseFix64 parses a decimal string with at most 8 fractional digits and an
+// optional minus sign.
+func ParseFix64(s string) (Fix64, error) {
+	digits := strings.TrimPrefix(s, "-")
+	integer, fraction, err := splitFixed(digits)
+	if err != nil {
+		return 0, err
+	}
+	raw := new(big.Int).Mul(integer, big.NewInt(UFix64Factor))
+	raw.Add(raw, fraction)
+	if digits != s {
+		raw.Neg(raw)
+	}
+	if !raw.IsInt64() {
+		return 0, fmt.Errorf("Fix64 %q out of range", s)
+	}
+	return Fix64(raw.Int64()), nil
+}
+
+// MarshalText encodes the value as its decimal string like UFix64.
+func (f Fix64) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText parses a decimal string.
+func (f *Fix64) UnmarshalText(text []byte) error {
+	value, err := ParseFix64(string(text))
+	if err != nil {
+		return err
+	}
+	*f = value
+	return nil
+}
+
+func splitFixed(s string) (*big.Int, *big.Int, error) {
+	parts := strings.SplitN(s, ".", 2)
+	integer, ok := new(big.Int).SetString(parts[0], 10)
+	if !ok || integer.Sign() < 0 {
+		return nil, nil, fmt.Errorf("invalid fixed point number %q", s)
+	}
+	fraction := big.NewInt(0)
+	if len(parts) == 2 {
+		digits := parts[1]
+		if len(digits) == 0 || len(digits) > 8 {
+			return nil, nil, fmt.Errorf("invalid fixed point number %q", s)
+		}
+		digits += strings.Repeat("0", 8-len(digits))
+		value, err := strconv.ParseUint(digits, 10, 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid fixed point number %q", s)
+		}
+		fraction.SetUint64(value)
+	}
+	return integer, fraction, nil
+}
+
+var (
+	ufix64Type  = reflect.TypeOf(UFix64(0))
+	fix64Type   = reflect.TypeOf(Fix64(0))
+	addressType = reflect.TypeOf(flow.Address{})
+)
+
+// Encode converts a Go value of one of the binding types into a cadence value.
+func Encode(value interface{}) (cadence.Value, error) {
+	return encode(reflect.ValueOf(value))
+}
+
+func encode(v reflect.Value) (cadence.Value, error) {
+	switch v.Type() {
+	case ufix64Type:
+		return cadence.UFix64(v.Uint()), nil
+	case fix64Type:
+		return cadence.Fix64(v.Int()), nil
+	case addressType:
+		return cadence.Address(v.Interface().(flow.Address)), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return cadence.String(v.String()), nil
+	case reflect.Bool:
+		return cadence.Bool(v.Bool()), nil
+	case reflect.Uint8:
+		return cadence.UInt8(v.Uint()), nil
+	case reflect.Uint16:
+		return cadence.UInt16(v.Uint()), nil
+	case reflect.Uint32:
+		return cadence.UInt32(v.Uint()), nil
+	case reflect.Uint64:
+		return cadence.UInt64(v.Uint()), nil
+	case reflect.Interface:
+		// elements of []interface{}, e.g. [AnyStruct] arguments
+		if v.IsNil() {
+			return nil, fmt.Errorf("cannot encode nil as a cadence value")
+		}
+		return encode(v.Elem())
+	case reflect.Slice:
+		values := make([]cadence.Value, v.Len())
+		for i := range values {
+			value, err := encode(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return cadence.NewArray(values), nil
+	case reflect.Map:
+		// sorted, so the same map always encodes to the same arguments
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			switch keys[i].Kind() {
+			case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				return keys[i].Uint() < keys[j].Uint()
+			}
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		pairs := make([]cadence.KeyValuePair, len(keys))
+		for i, key := range keys {
+			k, err := encode(key)
+			if err != nil {
+				return nil, err
+			}
+			value, err := encode(v.MapIndex(key))
+			if err != nil {
+				return nil, err
+			}
+			pairs[i] = cadence.KeyValuePair{Key: k, Value: value}
+		}
+		return cadence.NewDictionary(pairs), nil
+	}
+	return nil, fmt.Errorf("cannot encode %s as a cadence value", v.Type())
+}
+
+// Decode copies a cadence value into target, which must be a pointer to a
+// binding type. Structs and events are matched on their `cadence` field tags.
+func Decode(value cadence.Value, target interface{}) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("decode target must be a non nil pointer, got %T", target)
+	}
+	return decode(value, v.Elem())
+}
+
+func decode(value cadence.Value, v reflect.Value) error {
+	if optional, ok := value.(cadence.Optional); ok {
+		if optional.Value == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		value = optional.Value
+	}
+	if value == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	if v.Kind() == reflect.Ptr {
+		elem := reflect.New(v.Type().Elem())
+		if err := decode(value, elem.Elem()); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+
+	if v.Kind() == reflect.Interface {
+		v.Set(reflect.ValueOf(value.ToGoValue()))
+		return nil
+	}
+
+	switch val := value.(type) {
+	case cadence.UFix64:
+		if v.Type() == ufix64Type {
+			v.SetUint(uint64(val))
+			return nil
+		}
+	case cadence.Fix64:
+		if v.Type() == fix64Type {
+			v.SetInt(int64(val))
+			return nil
+		}
+	case cadence.Address:
+		if v.Type() == addressType {
+			v.Set(reflect.ValueOf(flow.Address(val)))
+			return nil
+		}
+	case cadence.String:
+		if v.Kind() == reflect.String {
+			v.SetString(string(val))
+			return nil
+		}
+	case cadence.Bool:
+		if v.Kind() == reflect.Bool {
+			v.SetBool(bool(val))
+			return nil
+		}
+	case cadence.UInt8, cadence.UInt16, cadence.UInt32, cadence.UInt64:
+		switch v.Kind() {
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n := reflect.ValueOf(val).Uint()
+			if v.OverflowUint(n) {
+				return fmt.Errorf("%v overflows %s", val, v.Type())
+			}
+			v.SetUint(n)
+			return nil
+		}
+	case cadence.Array:
+		if v.Kind() == reflect.Slice {
+			slice := reflect.MakeSlice(v.Type(), len(val.Values), len(val.Values))
+			for i, item := range val.Values {
+				if err := decode(item, slice.Index(i)); err != nil {
+					return err
+				}
+			}
+			v.Set(slice)
+			return nil
+		}
+	case cadence.Dictionary:
+		if v.Kind() == reflect.Map {
+			m := reflect.MakeMapWithSize(v.Type(), len(val.Pairs))
+			for _, pair := range val.Pairs {
+				key := reflect.New(v.Type().Key()).Elem()
+				if err := decode(pair.Key, key); err != nil {
+					return err
+				}
+				item := reflect.New(v.Type().Elem()).Elem()
+				if err := decode(pair.Value, item); err != nil {
+					return err
+				}
+				m.SetMapIndex(key, item)
+			}
+			v.Set(m)
+			return nil
+		}
+		// a dictionary keyed by strings, such as a script returning several
+		// values, decodes into a struct like a Cadence struct
+		if v.Kind() == reflect.Struct {
+			fields := make([]cadence.Field, len(val.Pairs))
+			values := make([]cadence.Value, len(val.Pairs))
+			for i, pair := range val.Pairs {
+				key, ok := pair.Key.(cadence.String)
+				if !ok {
+					return fmt.Errorf("cannot decode cadence %s into %s", value.Type().ID(), v.Type())
+				}
+				fields[i] = cadence.Field{Identifier: string(key)}
+				values[i] = pair.Value
+			}
+			return decodeFields(fields, values, v)
+		}
+	case cadence.Struct:
+		if v.Kind() == reflect.Struct {
+			return decodeFields(val.StructType.Fields, val.Fields, v)
+		}
+	case cadence.Event:
+		if v.Kind() == reflect.Struct {
+			return decodeFields(val.EventType.Fields, val.Fields, v)
+		}
+	}
+	return fmt.Errorf("cannot decode cadence %s into %s", value.Type().ID(), v.Type())
+}
+
+func decodeFields(fields []cadence.Field, values []cadence.Value, v reflect.Value) error {
+	byName := map[string]cadence.Value{}
+	for i, field := range fields {
+		byName[field.Identifier] = values[i]
+	}
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Tag.Get("cadence")
+		if name == "" {
+			continue
+		}
+		value, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("cadence value has no field %q for %s", name, v.Type())
+		}
+		if err := decode(value, v.Field(i)); err != nil {
+			return fmt.Errorf("field %s: %w", name, err)
+		}
+	}
+	return nil
+}
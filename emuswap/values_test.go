@@ -0,0 +1,90 @@
+package emuswap
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/onflow/cadence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUFix64ParseAndFormat(t *testing.T) {
+	value, err := ParseUFix64("184467440737.09551615")
+	assert.NoError(t, err)
+	assert.Equal(t, MaxUFix64, value)
+	assert.Equal(t, "184467440737.09551615", value.String())
+
+	value, err = ParseUFix64("0.5")
+	assert.NoError(t, err)
+	assert.Equal(t, UFix64FromFloat(0.5), value)
+
+	_, err = ParseUFix64("184467440737.09551616")
+	assert.Error(t, err)
+	_, err = ParseUFix64("0.000000001")
+	assert.Error(t, err)
+
+	assert.Equal(t, "-1.50000000", Fix64FromFloat(-1.5).String())
+	signed, err := ParseFix64("-1.5")
+	assert.NoError(t, err)
+	assert.Equal(t, Fix64FromFloat(-1.5), signed)
+	signed, err = ParseFix64("-92233720368.54775808")
+	assert.NoError(t, err)
+	assert.Equal(t, "-92233720368.54775808", signed.String())
+	_, err = ParseFix64("92233720368.54775808")
+	assert.Error(t, err)
+	_, err = ParseFix64("--1.0")
+	assert.Error(t, err)
+
+	data, err := json.Marshal(map[string]UFix64{"amount": UFix64FromFloat(12.5)})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"amount": "12.50000000"}`, string(data))
+	var decoded map[string]UFix64
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount": "0.00000001"}`), &decoded))
+	assert.Equal(t, UFix64(1), decoded["amount"])
+
+	data, err = json.Marshal(map[string]Fix64{"delta": Fix64FromFloat(-0.25)})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"delta": "-0.25000000"}`, string(data))
+	var deltas map[string]Fix64
+	assert.NoError(t, json.Unmarshal(data, &deltas))
+	assert.Equal(t, Fix64FromFloat(-0.25), deltas["delta"])
+}
+
+func TestDecodeOptionalDictionary(t *testing.T) {
+	value := cadence.NewOptional(cadence.NewDictionary([]cadence.KeyValuePair{
+		{Key: cadence.UInt64(0), Value: cadence.Fix64(-150000000)},
+	}))
+
+	var result map[uint64]Fix64
+	assert.NoError(t, Decode(value, &result))
+	assert.Equal(t, map[uint64]Fix64{0: Fix64FromFloat(-1.5)}, result)
+
+	var missing *PoolMeta
+	assert.NoError(t, Decode(cadence.NewOptional(nil), &missing))
+	assert.Nil(t, missing)
+}
+
+func TestEncodeDictionary(t *testing.T) {
+	value, err := Encode(map[uint64]UFix64{10: UFix64FromFloat(0.5), 2: UFix64FromFloat(1)})
+	assert.NoError(t, err)
+	assert.Equal(t, "{2: 1.00000000, 10: 0.50000000}", value.String())
+
+	var result map[uint64]UFix64
+	assert.NoError(t, Decode(value, &result))
+	assert.Equal(t, map[uint64]UFix64{10: UFix64FromFloat(0.5), 2: UFix64FromFloat(1)}, result)
+}
+
+func TestDecodeDictionaryStruct(t *testing.T) {
+	value := cadence.NewDictionary([]cadence.KeyValuePair{
+		{Key: cadence.String("id"), Value: cadence.UInt64(3)},
+		{Key: cadence.String("amount"), Value: cadence.NewOptional(cadence.UFix64(250000000))},
+		{Key: cadence.String("type"), Value: cadence.String("A.f8d6e0586b0a20c7.EmuToken.Vault")},
+	})
+
+	var claim AirdropClaim
+	assert.NoError(t, Decode(value, &claim))
+	assert.Equal(t, AirdropClaim{ID: 3, Amount: UFix64FromFloat(2.5), TokenIdentifier: "A.f8d6e0586b0a20c7.EmuToken.Vault"}, claim)
+
+	var pool PoolMeta
+	assert.Error(t, Decode(value, &pool), "missing fields")
+}
@@ -0,0 +1,252 @@
+// Package bindgen generates typed Go bindings for the Cadence scripts and
+// transactions of this repository.
+package bindgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// BindingsFile is where the generated bindings live, relative to the repo root.
+const BindingsFile = "emuswap/bindings_gen.go"
+
+var basicTypes = map[string]string{
+	"UFix64":    "UFix64",
+	"Fix64":     "Fix64",
+	"UInt8":     "uint8",
+	"UInt16":    "uint16",
+	"UInt32":    "uint32",
+	"UInt64":    "uint64",
+	"String":    "string",
+	"Bool":      "bool",
+	"Address":   "flow.Address",
+	"AnyStruct": "interface{}",
+}
+
+// typeMapper resolves Cadence types to Go types and remembers which contract
+// structs were referenced so they can be emitted as well.
+type typeMapper struct {
+	structs map[string]map[string]Struct
+	used    map[string]Struct
+	// pkg qualifies the emuswap value types when generating outside of emuswap
+	pkg string
+}
+
+func (m *typeMapper) goType(cadenceType string, contract string) (string, error) {
+	t := strings.TrimSpace(cadenceType)
+	if strings.HasSuffix(t, "?") {
+		inner, err := m.goType(strings.TrimSuffix(t, "?"), contract)
+		if err != nil {
+			return "", err
+		}
+		// maps, slices and interfaces are already nilable
+		if strings.HasPrefix(inner, "map[") || strings.HasPrefix(inner, "[]") || inner == "interface{}" {
+			return inner, nil
+		}
+		return "*" + inner, nil
+	}
+	if strings.HasPrefix(t, "[") && strings.HasSuffix(t, "]") {
+		inner, err := m.goType(t[1:len(t)-1], contract)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + inner, nil
+	}
+	if strings.HasPrefix(t, "{") && strings.HasSuffix(t, "}") {
+		key, value, ok := strings.Cut(t[1:len(t)-1], ":")
+		if !ok {
+			return "", fmt.Errorf("malformed dictionary type %q", t)
+		}
+		goKey, err := m.goType(key, contract)
+		if err != nil {
+			return "", err
+		}
+		goValue, err := m.goType(value, contract)
+		if err != nil {
+			return "", err
+		}
+		return "map[" + goKey + "]" + goValue, nil
+	}
+	if goType, ok := basicTypes[t]; ok {
+		if goType == "UFix64" || goType == "Fix64" {
+			goType = m.pkg + goType
+		}
+		return goType, nil
+	}
+
+	name := t
+	if qualifier, rest, ok := strings.Cut(t, "."); ok {
+		contract, name = qualifier, rest
+	}
+	s, ok := m.structs[contract][name]
+	if !ok {
+		return "", fmt.Errorf("unsupported cadence type %q", t)
+	}
+	if existing, ok := m.used[name]; ok && existing.Contract != s.Contract {
+		return "", fmt.Errorf("struct %s is declared by both %s and %s", name, existing.Contract, s.Contract)
+	}
+	if _, ok := m.used[name]; !ok {
+		m.used[name] = s
+		for _, field := range s.Fields {
+			if _, err := m.goType(field.Type, s.Contract); err != nil {
+				return "", fmt.Errorf("%s.%s: %w", name, field.Name, err)
+			}
+		}
+	}
+	return name, nil
+}
+
+// Generate returns the formatted source of emuswap/bindings_gen.go for the
+// repository rooted at root.
+func Generate(root string) ([]byte, error) {
+	bindings, err := ParseBindings(root)
+	if err != nil {
+		return nil, err
+	}
+	structs, err := ParseStructs(root)
+	if err != nil {
+		return nil, err
+	}
+	mapper := &typeMapper{structs: structs, used: map[string]Struct{}}
+
+	var body bytes.Buffer
+	names := map[string]string{}
+	for _, binding := range bindings {
+		name := FuncName(binding.Path)
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("%s and %s both generate %s", other, binding.Path, name)
+		}
+		names[name] = binding.Path
+		if err := writeBinding(&body, mapper, name, binding); err != nil {
+			return nil, fmt.Errorf("%s: %w", binding.Path, err)
+		}
+	}
+
+	var types bytes.Buffer
+	if err := writeStructs(&types, mapper); err != nil {
+		return nil, err
+	}
+	code := types.String() + body.String()
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by cmd/bindgen from scripts/ and transactions/. DO NOT EDIT.\n\n")
+	out.WriteString("package emuswap\n\n")
+	out.WriteString("import (\n")
+	if strings.Contains(code, "overflow.") {
+		out.WriteString("\t\"github.com/bjartek/overflow/overflow\"\n")
+	}
+	if strings.Contains(code, "flow.Address") {
+		out.WriteString("\t\"github.com/onflow/flow-go-sdk\"\n")
+	}
+	out.WriteString(")\n\n")
+	out.WriteString(code)
+
+	formatted, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated bindings: %w", err)
+	}
+	return formatted, nil
+}
+
+func writeStructs(out *bytes.Buffer, mapper *typeMapper) error {
+	names := make([]string, 0, len(mapper.used))
+	for name := range mapper.used {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s := mapper.used[name]
+		fmt.Fprintf(out, "// %s mirrors %s.%s.\ntype %s struct {\n", name, s.Contract, name, name)
+		for _, field := range s.Fields {
+			goType, err := mapper.goType(field.Type, s.Contract)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "\t%s %s `cadence:\"%s\"`\n", ExportedName(field.Name), goType, field.Name)
+		}
+		out.WriteString("}\n\n")
+	}
+	return nil
+}
+
+func writeBinding(out *bytes.Buffer, mapper *typeMapper, name string, binding Binding) error {
+	var params, args []string
+	if !binding.Script {
+		params = append(params, "signer string")
+	}
+	for _, param := range binding.Params {
+		goType, err := mapper.goType(param.Type, "")
+		if err != nil {
+			return err
+		}
+		goName := paramName(param.Name)
+		params = append(params, goName+" "+goType)
+		args = append(args, goName)
+	}
+	callArgs := ""
+	if len(args) > 0 {
+		callArgs = ", " + strings.Join(args, ", ")
+	}
+
+	if !binding.Script {
+		fmt.Fprintf(out, "// %s builds transactions/%s.cdc signed by signer.\n", name, binding.Path)
+		fmt.Fprintf(out, "func (c *Client) %s(%s) overflow.FlowTransactionBuilder {\n", name, strings.Join(params, ", "))
+		fmt.Fprintf(out, "\treturn c.transaction(%q, signer%s)\n}\n\n", binding.Path, callArgs)
+		return nil
+	}
+
+	fmt.Fprintf(out, "// %s runs scripts/%s.cdc.\n", name, binding.Path)
+	if binding.Return == "" {
+		fmt.Fprintf(out, "func (c *Client) %s(%s) error {\n", name, strings.Join(params, ", "))
+		fmt.Fprintf(out, "\tvar result interface{}\n\treturn c.script(%q, &result%s)\n}\n\n", binding.Path, callArgs)
+		return nil
+	}
+	result, err := mapper.goType(binding.Return, "")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "func (c *Client) %s(%s) (%s, error) {\n", name, strings.Join(params, ", "), result)
+	fmt.Fprintf(out, "\tvar result %s\n\terr := c.script(%q, &result%s)\n\treturn result, err\n}\n\n", result, binding.Path, callArgs)
+	return nil
+}
+
+// FuncName turns a file path like EmuSwap/user/swap into EmuSwapUserSwap.
+func FuncName(path string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '_' || r == '-' }) {
+		b.WriteString(ExportedName(part))
+	}
+	return b.String()
+}
+
+var initialisms = map[string]string{
+	"id":   "ID",
+	"ids":  "IDs",
+	"dao":  "DAO",
+	"lp":   "LP",
+	"nft":  "NFT",
+	"nfts": "NFTs",
+}
+
+// ExportedName upper cases the first letter, spelling common initialisms like
+// id and dao in capitals.
+func ExportedName(name string) string {
+	if initialism, ok := initialisms[name]; ok {
+		return initialism
+	}
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+func paramName(name string) string {
+	if token.IsKeyword(name) || name == "signer" || name == "result" || name == "err" || name == "c" {
+		return name + "_"
+	}
+	return name
+}
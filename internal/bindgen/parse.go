@@ -0,0 +1,183 @@
+package bindgen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Param is a single name: Type pair from a Cadence parameter or field list.
+type Param struct {
+	Name string
+	Type string
+}
+
+// Binding describes one script or transaction file.
+type Binding struct {
+	Script bool
+	Path   string // relative to scripts/ or transactions/, without .cdc
+	Params []Param
+	Return string // scripts only, empty when main returns nothing
+}
+
+// Struct is a `pub struct` declared in a contract.
+type Struct struct {
+	Contract string
+	Name     string
+	Fields   []Param
+}
+
+var (
+	lineComment  = regexp.MustCompile(`//[^\n]*`)
+	blockComment = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	scriptMain   = regexp.MustCompile(`pub\s+fun\s+main\s*\(([^)]*)\)\s*(?::\s*(.*?))?\s*\{[ \t]*\r?\n`)
+	transaction  = regexp.MustCompile(`(?m)^\s*transaction\s*(?:\(([^)]*)\))?\s*\{`)
+	structDecl   = regexp.MustCompile(`pub\s+struct\s+(\w+)\s*(?::\s*[\w,\s]+)?\{`)
+	fieldDecl    = regexp.MustCompile(`^\s*pub\s+(?:let|var)\s+(\w+)\s*:\s*(.+?)\s*;?\s*$`)
+)
+
+func stripComments(code string) string {
+	return lineComment.ReplaceAllString(blockComment.ReplaceAllString(code, ""), "")
+}
+
+// ParseBindings reads every .cdc file below root/scripts and root/transactions.
+// Files without a transaction or main declaration are skipped.
+func ParseBindings(root string) ([]Binding, error) {
+	var bindings []Binding
+	for _, dir := range []string{"scripts", "transactions"} {
+		base := filepath.Join(root, dir)
+		err := filepath.Walk(base, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || filepath.Ext(path) != ".cdc" {
+				return err
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(base, path)
+			if err != nil {
+				return err
+			}
+			binding, ok, err := parseBinding(dir == "scripts", strings.TrimSuffix(filepath.ToSlash(rel), ".cdc"), string(content))
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			if ok {
+				bindings = append(bindings, binding)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(bindings, func(i, j int) bool {
+		if bindings[i].Script != bindings[j].Script {
+			return bindings[i].Script
+		}
+		return bindings[i].Path < bindings[j].Path
+	})
+	return bindings, nil
+}
+
+func parseBinding(script bool, path string, code string) (Binding, bool, error) {
+	code = stripComments(code)
+	binding := Binding{Script: script, Path: path}
+
+	var match []string
+	if script {
+		match = scriptMain.FindStringSubmatch(code)
+	} else {
+		match = transaction.FindStringSubmatch(code)
+	}
+	if match == nil {
+		return binding, false, nil
+	}
+	params, err := splitParams(match[1])
+	if err != nil {
+		return binding, false, err
+	}
+	binding.Params = params
+	if script {
+		binding.Return = strings.TrimSpace(match[2])
+	}
+	return binding, true, nil
+}
+
+// splitParams splits `a: T, b: {K: V}` on top level commas.
+func splitParams(list string) ([]Param, error) {
+	var params []Param
+	depth := 0
+	start := 0
+	list = strings.TrimSpace(list)
+	if list == "" {
+		return nil, nil
+	}
+	flush := func(part string) error {
+		name, typ, ok := strings.Cut(part, ":")
+		if !ok {
+			return fmt.Errorf("malformed parameter %q", part)
+		}
+		params = append(params, Param{Name: strings.TrimSpace(name), Type: strings.TrimSpace(typ)})
+		return nil
+	}
+	for i, r := range list {
+		switch r {
+		case '{', '[', '<', '(':
+			depth++
+		case '}', ']', '>', ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				if err := flush(list[start:i]); err != nil {
+					return nil, err
+				}
+				start = i + 1
+			}
+		}
+	}
+	if err := flush(list[start:]); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// ParseStructs collects the public fields of every `pub struct` in root/contracts.
+func ParseStructs(root string) (map[string]map[string]Struct, error) {
+	files, err := filepath.Glob(filepath.Join(root, "contracts", "*.cdc"))
+	if err != nil {
+		return nil, err
+	}
+	structs := map[string]map[string]Struct{}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		contract := strings.TrimSuffix(filepath.Base(file), ".cdc")
+		structs[contract] = parseStructs(contract, stripComments(string(content)))
+	}
+	return structs, nil
+}
+
+func parseStructs(contract string, code string) map[string]Struct {
+	result := map[string]Struct{}
+	for _, loc := range structDecl.FindAllStringSubmatchIndex(code, -1) {
+		s := Struct{Contract: contract, Name: code[loc[2]:loc[3]]}
+		// fields are declared before the initializer
+		body := code[loc[1]:]
+		if end := strings.Index(body, "init("); end >= 0 {
+			body = body[:end]
+		}
+		for _, line := range strings.Split(body, "\n") {
+			if m := fieldDecl.FindStringSubmatch(line); m != nil {
+				s.Fields = append(s.Fields, Param{Name: m[1], Type: m[2]})
+			}
+		}
+		result[s.Name] = s
+	}
+	return result
+}
@@ -0,0 +1,59 @@
+// Package emulator reaches the FVM of the in-memory emulator overflow starts,
+// for the packages recording what it executes by wrapping its runtime.
+package emulator
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"unsafe"
+
+	"github.com/bjartek/overflow/overflow"
+	"github.com/onflow/cadence/runtime"
+	"github.com/onflow/flow-go/fvm"
+)
+
+// ErrNotEmulator is returned for an Overflow not running the in-memory
+// emulator.
+var ErrNotEmulator = errors.New("not an in-memory emulator")
+
+// VirtualMachine digs the FVM out of the emulator gateway of o, through
+// fields none of flowkit, the emulator and overflow export.
+func VirtualMachine(o *overflow.Overflow) (vm *fvm.VirtualMachine, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			vm, err = nil, fmt.Errorf("reading the emulator: %v", r)
+		}
+	}()
+	if o.Services == nil || o.Services.Scripts == nil {
+		return nil, ErrNotEmulator
+	}
+	gateway := unexported(reflect.ValueOf(o.Services.Scripts).Elem(), "gateway").Elem()
+	if gateway.Type().String() != "*gateway.EmulatorGateway" {
+		return nil, ErrNotEmulator
+	}
+	blockchain := unexported(gateway.Elem(), "emulator")
+	vm, ok := unexported(blockchain.Elem(), "vm").Interface().(*fvm.VirtualMachine)
+	if !ok || vm == nil {
+		return nil, ErrNotEmulator
+	}
+	return vm, nil
+}
+
+func unexported(v reflect.Value, name string) reflect.Value {
+	field := v.FieldByName(name)
+	if !field.IsValid() {
+		panic("no field " + name + " in " + v.Type().String())
+	}
+	return reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem()
+}
+
+// Unwrap returns the runtime rt wraps, nil when rt is not a wrapper. Runtimes
+// set on the FVM around its runtime implement Unwrap, so that each recorder
+// finds its own runtime among the others to attach only once.
+func Unwrap(rt runtime.Runtime) runtime.Runtime {
+	if wrapper, ok := rt.(interface{ Unwrap() runtime.Runtime }); ok {
+		return wrapper.Unwrap()
+	}
+	return nil
+}
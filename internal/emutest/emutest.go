@@ -0,0 +1,42 @@
+// Package emutest starts the in-memory emulators of the tests of this module,
+// recording the Cadence they execute when coverage.EnvDir is set and the cost
+// of the transactions they run when suite.EnvDir is set. Package tests run
+// their tests with Main and start emulators with Start:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(emutest.Main(m))
+//	}
+package emutest
+
+import (
+	"testing"
+
+	"github.com/bjartek/overflow/overflow"
+	"swap.emudao.org/test-overflow/emuswap/coverage"
+	"swap.emudao.org/test-overflow/emuswap/profile/suite"
+)
+
+// Start starts the in-memory emulator with the contracts deployed and the
+// accounts created, attached to for coverage.Run and suite.Run.
+func Start() (*overflow.Overflow, error) {
+	o, err := overflow.NewTestingEmulator().StartE()
+	if err != nil {
+		return nil, err
+	}
+	if err := coverage.Collect(o); err != nil {
+		return nil, err
+	}
+	if err := suite.Collect(o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+type tests func() int
+
+func (run tests) Run() int { return run() }
+
+// Main runs the tests and returns the exit code for os.Exit.
+func Main(m *testing.M) int {
+	return coverage.Run(tests(func() int { return suite.Run(m) }))
+}
@@ -4,8 +4,11 @@ import (
 	"fmt"
 
 	"github.com/bjartek/overflow/overflow"
+	"swap.emudao.org/test-overflow/emuswap"
 )
 
+var ufix = emuswap.UFix64FromFloat
+
 func mintFlowTokens(o *overflow.Overflow, account string, amount float64) {
 	o.TransactionFromFile("demo/mintFlowTokens").
 		SignProposeAndPayAs("account").
@@ -93,42 +96,29 @@ func main() {
 	o.ScriptFromFile("pool/get_quote_exact_b_to_a").Args(o.Arguments().UInt64(0).UFix64(1.0)).Run()
 
 	// Swap
+	c := emuswap.NewClient(o)
+	flow, fusd := emuswap.MustLookupToken("FLOW"), emuswap.MustLookupToken("FUSD")
+
 	fmt.Print("User 1 Swaps 1.0 Flow for FUSD")
-	o.TransactionFromFile("EmuSwap/user/swap_flow_for_fusd").
-		SignProposeAndPayAs("user1").
-		Args(o.Arguments().
-			UFix64(1.0)).
-		RunPrintEventsFull()
+	c.Swap("user1", flow, fusd, ufix(1.0)).RunPrintEventsFull()
 
 	o.ScriptFromFile("get_pools_meta").Run()
 	o.ScriptFromFile("pool/get_quotes").Args(o.Arguments().UInt64(0).UFix64(1.0)).Run()
 
 	fmt.Print("User 2 Swaps 1.0 FUSD for Flow")
-	o.TransactionFromFile("EmuSwap/user/swap_flow_for_fusd").
-		SignProposeAndPayAs("user2").
-		Args(o.Arguments().
-			UFix64(1.0)).
-		RunPrintEventsFull()
+	c.Swap("user2", fusd, flow, ufix(1.0)).RunPrintEventsFull()
 
 	o.ScriptFromFile("get_pools_meta").Run()
 	o.ScriptFromFile("pool/get_quotes").Args(o.Arguments().UInt64(0).UFix64(1.0)).Run()
 
 	fmt.Print("User 1 Swaps 1.0 Flow for FUSD")
-	o.TransactionFromFile("EmuSwap/user/swap_flow_for_fusd").
-		SignProposeAndPayAs("user1").
-		Args(o.Arguments().
-			UFix64(1.0)).
-		RunPrintEventsFull()
+	c.Swap("user1", flow, fusd, ufix(1.0)).RunPrintEventsFull()
 
 	o.ScriptFromFile("get_pools_meta").Run()
 	o.ScriptFromFile("pool/get_quotes").Args(o.Arguments().UInt64(0).UFix64(1.0)).Run()
 
 	fmt.Print("User 2 Swaps 1.0 FUSD for Flow")
-	o.TransactionFromFile("EmuSwap/user/swap_flow_for_fusd").
-		SignProposeAndPayAs("user2").
-		Args(o.Arguments().
-			UFix64(1.0)).
-		RunPrintEventsFull()
+	c.Swap("user2", fusd, flow, ufix(1.0)).RunPrintEventsFull()
 
 	o.ScriptFromFile("get_pools_meta").Run()
 	o.ScriptFromFile("pool/get_quotes").Args(o.Arguments().UInt64(0).UFix64(1.0)).Run()
@@ -259,14 +249,7 @@ func main() {
 	o.ScriptFromFile("Staking/get_pending_rewards").Args(o.Arguments().UInt64(0).Account("user3")).Run()
 
 	/*
-		//# flow transactions send "./transactions/Staking/user/add_liquidity_and_stake.cdc" 10.0 10.0 --signer "user-account1"
-		o.TransactionFromFile("Staking/user/add_liquidity_and_stake").
-			SignProposeAndPayAs("user1").
-			Args(o.
-				Arguments().
-				UFix64(0.01).
-				UFix64(0.01)).
-			RunPrintEventsFull()
+		c.AddLiquidityAndStake("user1", 0, flow, fusd, ufix(0.01), ufix(0.01)).RunPrintEventsFull()
 	*/
 
 	//# flow transactions send "./transactions/Staking/user/unstake.cdc" 0 0.001 --signer "user-account1"
@@ -4,348 +4,213 @@ import (
 	"fmt"
 
 	"github.com/bjartek/overflow/overflow"
+	"swap.emudao.org/test-overflow/emuswap"
 )
 
-func mintFlowTokens(o *overflow.Overflow, account string, amount float64) {
-	o.TransactionFromFile("demo/mintFlowTokens").
-		SignProposeAndPayAs("account").
-		Args(o.Arguments().
-			UFix64(amount).
-			Account(account)).
-		RunPrintEventsFull()
+var ufix = emuswap.UFix64FromFloat
+
+func mintFlowTokens(c *emuswap.Client, account string, amount float64) {
+	c.DemoMintFlowTokens("account", ufix(amount), c.Address(account)).RunPrintEventsFull()
+}
+
+func setupFUSDVaultWithBalance(c *emuswap.Client, account string, amount float64) {
+	c.FUSDSetup(account).RunPrintEventsFull()
+	c.DemoMintFUSD("account", ufix(amount), c.Address(account)).RunPrintEventsFull()
 }
 
-func setupFUSDVaultWithBalance(o *overflow.Overflow, account string, amount float64) {
-	o.TransactionFromFile("FUSD/setup").SignProposeAndPayAs(account).RunPrintEventsFull()
-	o.TransactionFromFile("demo/mintFUSD").SignProposeAndPayAs("account").Args(o.Arguments().UFix64(amount).Address(account)).RunPrintEventsFull()
+func createPoolFlowFUSD(c *emuswap.Client, account string, flowAmount float64, fusdAmount float64) {
+	c.EmuSwapAdminCreateNewPoolFLOWFUSD(account, ufix(flowAmount), ufix(fusdAmount)).RunPrintEventsFull()
 }
 
-func createPoolFlowFUSD(o *overflow.Overflow, account string, flowAmount float64, fusdAmount float64) {
-	o.TransactionFromFile("EmuSwap/admin/create_new_pool_FLOW_FUSD").
-		SignProposeAndPayAs("account").
-		Args(o.
-			Arguments().
-			UFix64(flowAmount).
-			UFix64(fusdAmount)).
-		RunPrintEventsFull()
+// show prints a script result, exiting on error like overflow's Run does.
+func show(label string, value interface{}, err error) {
+	if err != nil {
+		panic(fmt.Errorf("%s: %w", label, err))
+	}
+	fmt.Printf("%s: %+v\n", label, value)
 }
 
 func main() {
 	o := overflow.NewOverflow().Start()
+	c := emuswap.NewClient(o)
 	fmt.Printf("%v", o.State.Accounts())
 
-	// flow transactions send "./transactions/EmuSwap/admin/create_new_pool_FLOW_FUSD.cdc" 100.0 500.0 --signer "admin-account"
-	//o.TransactionFromFile("EmuSwap/admin/create_new_pool_FLOW_FUSD").SignProposeAndPayAs("account").NamedArguments(map[string]string{"token1": "100.0", "token2": "500.0"}).RunPrintEventsFull()
-
 	fmt.Print("Minting Flow Tokens")
 
-	mintFlowTokens(o, "account", 1000.0)
-	mintFlowTokens(o, "user1", 1000.0)
-	mintFlowTokens(o, "user2", 1000.0)
+	mintFlowTokens(c, "account", 1000.0)
+	mintFlowTokens(c, "user1", 1000.0)
+	mintFlowTokens(c, "user2", 1000.0)
 
 	// Setup FUSD Vaults
-	setupFUSDVaultWithBalance(o, "account", 1000.0)
-	setupFUSDVaultWithBalance(o, "user1", 1000.0)
-	setupFUSDVaultWithBalance(o, "user2", 1000.0)
+	setupFUSDVaultWithBalance(c, "account", 1000.0)
+	setupFUSDVaultWithBalance(c, "user1", 1000.0)
+	setupFUSDVaultWithBalance(c, "user2", 1000.0)
 	// EmuSwap tests
 	//
 
 	fmt.Print("Admin creates Flow/FUSD pool")
 	// flow transactions send "./transactions/EmuSwap/admin/create_new_pool_FLOW_FUSD.cdc" 100.0 500.0 --signer "admin-account"
-	o.TransactionFromFile("EmuSwap/admin/create_new_pool_FLOW_FUSD").
-		SignProposeAndPayAs("account").
-		Args(o.
-			Arguments().
-			UFix64(1000.0).
-			UFix64(500.0)).
-		RunPrintEventsFull()
+	createPoolFlowFUSD(c, "account", 1000.0, 500.0)
 
-	o.ScriptFromFile("get_dao_fee_percentage").Run()
-	o.ScriptFromFile("get_lp_fee_percentage").Run()
+	fee, err := c.GetDAOFeePercentage()
+	show("dao fee", fee, err)
+	fee, err = c.GetLPFeePercentage()
+	show("lp fee", fee, err)
 
 	fmt.Print("Admin updates LP fee percentage")
-	o.TransactionFromFile("EmuSwap/admin/update_lp_fee_percentage").
-		SignProposeAndPayAs("account").
-		Args(o.Arguments().
-			UInt64(0).
-			UFix64(0.0025)).
-		RunPrintEventsFull()
+	c.EmuSwapAdminUpdateLPFeePercentage("account", 0, ufix(0.0025)).RunPrintEventsFull()
 
 	fmt.Print("Admin updates DAO fee percentage")
-	o.TransactionFromFile("EmuSwap/admin/update_dao_fee_percentage").
-		SignProposeAndPayAs("account").
-		Args(o.Arguments().
-			UInt64(0).
-			UFix64(0.0025)).
-		RunPrintEventsFull()
+	c.EmuSwapAdminUpdateDAOFeePercentage("account", 0, ufix(0.0025)).RunPrintEventsFull()
 
-	o.ScriptFromFile("get_pool_ids").Run()
-	o.ScriptFromFile("get_pool_meta").Args(o.Arguments().UInt64(0)).Run()
-	o.ScriptFromFile("get_pools_meta").Run()
+	showPools := func() {
+		pools, err := c.GetPoolsMeta()
+		show("pools", pools, err)
+		quotes, err := c.PoolGetQuotes(0, ufix(1.0))
+		show("quotes", quotes, err)
+	}
+
+	ids, err := c.GetPoolIDs()
+	show("pool ids", ids, err)
+	meta, err := c.GetPoolMeta(0)
+	show("pool 0", meta, err)
 
 	// Get quotes
 	fmt.Print("Getting quotes:")
-	o.ScriptFromFile("pool/get_quotes").Args(o.Arguments().UInt64(0).UFix64(1.0)).Run()
-	o.ScriptFromFile("pool/get_quote_a_to_exact_b").Args(o.Arguments().UInt64(0).UFix64(1.0)).Run()
-	o.ScriptFromFile("pool/get_quote_b_to_exact_a").Args(o.Arguments().UInt64(0).UFix64(1.0)).Run()
-	o.ScriptFromFile("pool/get_quote_exact_a_to_b").Args(o.Arguments().UInt64(0).UFix64(1.0)).Run()
-	o.ScriptFromFile("pool/get_quote_exact_b_to_a").Args(o.Arguments().UInt64(0).UFix64(1.0)).Run()
+	showPools()
+	quote, err := c.PoolGetQuoteAToExactB(0, ufix(1.0))
+	show("a to exact b", quote, err)
+	quote, err = c.PoolGetQuoteBToExactA(0, ufix(1.0))
+	show("b to exact a", quote, err)
+	quote, err = c.PoolGetQuoteExactAToB(0, ufix(1.0))
+	show("exact a to b", quote, err)
+	quote, err = c.PoolGetQuoteExactBToA(0, ufix(1.0))
+	show("exact b to a", quote, err)
 
 	// Swap
 	fmt.Print("User 1 Swaps 1.0 Flow for FUSD")
-	o.TransactionFromFile("EmuSwap/exchange/swap_flow_for_fusd").
-		SignProposeAndPayAs("user1").
-		Args(o.Arguments().
-			UFix64(1.0)).
-		RunPrintEventsFull()
-
-	o.ScriptFromFile("get_pools_meta").Run()
-	o.ScriptFromFile("pool/get_quotes").Args(o.Arguments().UInt64(0).UFix64(1.0)).Run()
+	c.EmuSwapUserSwap("user1", "flowTokenVault", "fusdVault", ufix(1.0)).RunPrintEventsFull()
+	showPools()
 
 	fmt.Print("User 2 Swaps 1.0 FUSD for Flow")
-	o.TransactionFromFile("EmuSwap/exchange/swap_flow_for_fusd").
-		SignProposeAndPayAs("user2").
-		Args(o.Arguments().
-			UFix64(1.0)).
-		RunPrintEventsFull()
-
-	o.ScriptFromFile("get_pools_meta").Run()
-	o.ScriptFromFile("pool/get_quotes").Args(o.Arguments().UInt64(0).UFix64(1.0)).Run()
+	c.EmuSwapUserSwap("user2", "fusdVault", "flowTokenVault", ufix(1.0)).RunPrintEventsFull()
+	showPools()
 
 	fmt.Print("User 1 Swaps 1.0 Flow for FUSD")
-	o.TransactionFromFile("EmuSwap/exchange/swap_flow_for_fusd").
-		SignProposeAndPayAs("user1").
-		Args(o.Arguments().
-			UFix64(1.0)).
-		RunPrintEventsFull()
-
-	o.ScriptFromFile("get_pools_meta").Run()
-	o.ScriptFromFile("pool/get_quotes").Args(o.Arguments().UInt64(0).UFix64(1.0)).Run()
+	c.EmuSwapUserSwap("user1", "flowTokenVault", "fusdVault", ufix(1.0)).RunPrintEventsFull()
+	showPools()
 
 	fmt.Print("User 2 Swaps 1.0 FUSD for Flow")
-	o.TransactionFromFile("EmuSwap/exchange/swap_flow_for_fusd").
-		SignProposeAndPayAs("user2").
-		Args(o.Arguments().
-			UFix64(1.0)).
-		RunPrintEventsFull()
-
-	o.ScriptFromFile("get_pools_meta").Run()
-	o.ScriptFromFile("pool/get_quotes").Args(o.Arguments().UInt64(0).UFix64(1.0)).Run()
+	c.EmuSwapUserSwap("user2", "fusdVault", "flowTokenVault", ufix(1.0)).RunPrintEventsFull()
+	showPools()
 
 	// Add Liquidity
-	fmt.Print("User 1 adds liquidity 200 1000")
-	o.TransactionFromFile("EmuSwap/exchange/add_liquidity_FLOW_FUSD").
-		SignProposeAndPayAs("user1").
-		Args(o.
-			Arguments().
-			UFix64(200.0).
-			UFix64(1000.0)).
-		RunPrintEventsFull()
-
-	fmt.Print("User 2 adds liquidity Flow/FUSD 200 1000")
-	o.TransactionFromFile("EmuSwap/exchange/add_liquidity_FLOW_FUSD").
-		SignProposeAndPayAs("user2").
-		Args(o.Arguments().
-			UFix64(200.0).
-			UFix64(1000.0)).
-		RunPrintEventsFull()
+	fmt.Print("User 1 adds liquidity 200 100")
+	c.EmuSwapUserAddLiquidity("user1", "flowTokenVault", ufix(200.0), "fusdVault", ufix(100.0)).RunPrintEventsFull()
+
+	fmt.Print("User 2 adds liquidity Flow/FUSD 200 100")
+	c.EmuSwapUserAddLiquidity("user2", "flowTokenVault", ufix(200.0), "fusdVault", ufix(100.0)).RunPrintEventsFull()
 
 	fmt.Print("User 1 withdraws liquidity")
-	o.TransactionFromFile("EmuSwap/exchange/remove_liquidity_FLOW_FUSD").
-		SignProposeAndPayAs("user1").
-		Args(o.Arguments().
-			UFix64(0.001)).
-		RunPrintEventsFull()
+	c.EmuSwapUserRemoveLiquidity("user1", ufix(0.001), "flowTokenVault", "fusdVault").RunPrintEventsFull()
 
-	o.ScriptFromFile("pool/get_quotes").Args(o.Arguments().UInt64(0).UFix64(1.0)).Run()
-	o.ScriptFromFile("read_fees_collected").Run()
+	quotes, err := c.PoolGetQuotes(0, ufix(1.0))
+	show("quotes", quotes, err)
+	fees, err := c.ReadFeesCollected()
+	show("fees collected", fees, err)
 
 	// Staking
 	//
 
 	// flow transactions send "./transactions/Staking/admin/toggle_mock_time.cdc" --signer "admin-account"
-	o.TransactionFromFile("Staking/admin/toggle_mock_time").
-		SignProposeAndPayAs("account").
-		RunPrintEventsFull()
+	c.StakingAdminToggleMockTime("account").RunPrintEventsFull()
 
 	fmt.Print("Admin creates new farm")
 	// flow transactions send "./transactions/Staking/admin/create_new_farm.cdc" 0 --signer "admin-account"
-	o.TransactionFromFile("Staking/admin/create_new_farm").
-		SignProposeAndPayAs("account").
-		Args(o.
-			Arguments().
-			UInt64(0)).
-		RunPrintEventsFull()
+	c.StakingAdminCreateNewFarm("account", 0).RunPrintEventsFull()
 
 	fmt.Print("Admin creates rewards pool FUSD")
-	// flow transactions send "./transactions/Staking/admin/create_reward_pool_fusd.cdc" 100000.0 --signer admin-account
-	o.TransactionFromFile("Staking/admin/create_reward_pool_fusd").
-		SignProposeAndPayAs("account").
-		Args(o.
-			Arguments().
-			UFix64(100000.0)).
-		RunPrintEventsFull()
+	// flow transactions send "./transactions/Staking/admin/create_reward_pool_fusd.cdc" 100.0 --signer admin-account
+	c.StakingAdminCreateRewardPoolFusd("account", ufix(100.0)).RunPrintEventsFull()
 
 	fmt.Print("Admin creates new farm")
 	// flow transactions send "./transactions/Staking/admin/update_mock_timestamp.cdc" 1.0 --signer "admin-account"
-	o.TransactionFromFile("Staking/admin/update_mock_timestamp").
-		SignProposeAndPayAs("account").
-		Args(o.
-			Arguments().
-			UFix64(1.0)).
-		RunPrintEventsFull()
+	c.StakingAdminUpdateMockTimestamp("account", ufix(1.0)).RunPrintEventsFull()
 
 	fmt.Print("User1 Stakes 0.001")
-	// flow transactions send "./transactions/Staking/user/stake.cdc" 1.0 --signer "user-account1"
-	o.TransactionFromFile("Staking/user/stake").
-		SignProposeAndPayAs("user1").
-		Args(o.
-			Arguments().
-			UFix64(0.001)).
-		RunPrintEventsFull()
+	// flow transactions send "./transactions/Staking/user/stake.cdc" 0 1.0 --signer "user-account1"
+	c.StakingUserStake("user1", 0, ufix(0.001)).RunPrintEventsFull()
 
 	// flow transactions send "./transactions/Staking/admin/update_mock_timestamp.cdc" 100.0 --signer "admin-account"
-	o.TransactionFromFile("Staking/admin/update_mock_timestamp").
-		SignProposeAndPayAs("account").
-		Args(o.
-			Arguments().
-			UFix64(100.0)).
-		RunPrintEventsFull()
-
-		// flow transactions send "./transactions/Staking/user/claim_rewards.cdc" 0 --signer "user-account1"
-	o.TransactionFromFile("Staking/user/claim_rewards").
-		SignProposeAndPayAs("user1").
-		Args(o.
-			Arguments().
-			UInt64(0)).
-		RunPrintEventsFull()
-
-		// flow transactions send "./transactions/Staking/user/stake.cdc" 1.0 --signer "user-account2"
-	o.TransactionFromFile("Staking/user/stake").
-		SignProposeAndPayAs("user2").
-		Args(o.
-			Arguments().
-			UFix64(0.18999999)).
-		RunPrintEventsFull()
+	c.StakingAdminUpdateMockTimestamp("account", ufix(100.0)).RunPrintEventsFull()
+
+	// flow transactions send "./transactions/Staking/user/claim_rewards.cdc" 0 --signer "user-account1"
+	c.StakingUserClaimRewards("user1", 0).RunPrintEventsFull()
+
+	// flow transactions send "./transactions/Staking/user/stake.cdc" 0 1.0 --signer "user-account2"
+	c.StakingUserStake("user2", 0, ufix(0.18999999)).RunPrintEventsFull()
 
 	fmt.Print("User 1 Withdraws half their staked LP Tokens (0.0005) ")
 	// flow transactions send "./transactions/Staking/user/unstake.cdc" 0 0.5 --signer "user-account1"
-	o.TransactionFromFile("Staking/user/unstake").
-		SignProposeAndPayAs("user1").
-		Args(o.
-			Arguments().
-			UInt64(0).
-			UFix64(0.0005)).
-		RunPrintEventsFull()
-
-	//flow scripts execute "./scripts/Staking/get_farm_meta.cdc" 0
-	o.ScriptFromFile("Staking/get_farm_meta").Args(o.Arguments().UInt64(0)).Run()
-
-	//flow scripts execute "./scripts/Staking/get_pending_rewards.cdc" 0 0x179b6b1cb6755e31
-	o.ScriptFromFile("Staking/get_pending_rewards").Args(o.Arguments().UInt64(0).Account("user1")).Run()
-	o.ScriptFromFile("Staking/get_pending_rewards").Args(o.Arguments().UInt64(0).Account("user2")).Run()
-	o.ScriptFromFile("Staking/get_pending_rewards").Args(o.Arguments().UInt64(0).Account("user3")).Run()
+	c.StakingUserUnstake("user1", 0, ufix(0.0005)).RunPrintEventsFull()
+
+	showFarm := func() {
+		//flow scripts execute "./scripts/Staking/get_farm_meta.cdc" 0
+		farm, err := c.StakingGetFarmMeta(0)
+		show("farm 0", farm, err)
+	}
+	showPendingRewards := func(accounts ...string) {
+		//flow scripts execute "./scripts/Staking/get_pending_rewards.cdc" 0 0x179b6b1cb6755e31
+		for _, account := range accounts {
+			rewards, err := c.StakingGetPendingRewards(0, c.Address(account))
+			show("pending rewards "+account, rewards, err)
+		}
+	}
+
+	showFarm()
+	showPendingRewards("user1", "user2", "user3")
 
 	/*
-		//# flow transactions send "./transactions/Staking/user/add_liquidity_and_stake.cdc" 10.0 10.0 --signer "user-account1"
-		o.TransactionFromFile("Staking/user/add_liquidity_and_stake").
-			SignProposeAndPayAs("user1").
-			Args(o.
-				Arguments().
-				UFix64(0.01).
-				UFix64(0.01)).
-			RunPrintEventsFull()
+		c.AddLiquidityAndStake("user1", 0, emuswap.MustLookupToken("FLOW"), emuswap.MustLookupToken("FUSD"), ufix(0.01), ufix(0.01)).RunPrintEventsFull()
 	*/
 
 	//# flow transactions send "./transactions/Staking/user/unstake.cdc" 0 0.001 --signer "user-account1"
-	o.TransactionFromFile("Staking/user/unstake").
-		SignProposeAndPayAs("user1").
-		Args(o.
-			Arguments().
-			UInt64(0).
-			UFix64(0.0001)).
-		RunPrintEventsFull()
+	c.StakingUserUnstake("user1", 0, ufix(0.0001)).RunPrintEventsFull()
 
 	//# flow transactions send "./transactions/Staking/user/unstake.cdc" 0 0.001 --signer "user-account2"
-	o.TransactionFromFile("Staking/user/unstake").
-		SignProposeAndPayAs("user2").
-		Args(o.
-			Arguments().
-			UInt64(0).
-			UFix64(0.00005)).
-		RunPrintEventsFull()
+	c.StakingUserUnstake("user2", 0, ufix(0.00005)).RunPrintEventsFull()
 
 	//#flow scripts execute "./scripts/Staking/get_farm_meta.cdc" 0
-
-	o.ScriptFromFile("Staking/get_farm_meta").Args(o.Arguments().UInt64(0)).Run()
-
-	//# flow scripts execute "./scripts/Staking/get_pending_rewards.cdc" 0 0x01cf0e2f2f715450
-	// #flow scripts execute "./scripts/Staking/get_pending_rewards.cdc" 0 0x179b6b1cb6755e31
-	// #flow scripts execute "./scripts/Staking/get_pending_rewards.cdc" 0 0xf3fcd2c1a78f5eee
-	o.ScriptFromFile("Staking/get_pending_rewards").Args(o.Arguments().UInt64(0).Account("account")).Run()
-	o.ScriptFromFile("Staking/get_pending_rewards").Args(o.Arguments().UInt64(0).Account("user1")).Run()
-	o.ScriptFromFile("Staking/get_pending_rewards").Args(o.Arguments().UInt64(0).Account("user2")).Run()
-	o.ScriptFromFile("Staking/get_pending_rewards").Args(o.Arguments().UInt64(0).Account("user3")).Run()
+	showFarm()
+	showPendingRewards("account", "user1", "user2", "user3")
 
 	// echo "update timestamp +100"
 	// flow transactions send "./transactions/Staking/admin/update_mock_timestamp.cdc" 100.0 --signer "admin-account"
-	o.TransactionFromFile("Staking/admin/update_mock_timestamp").
-		SignProposeAndPayAs("account").
-		Args(o.
-			Arguments().
-			UFix64(100.0)).
-		RunPrintEventsFull()
-
-	o.ScriptFromFile("Staking/get_pending_rewards").Args(o.Arguments().UInt64(0).Account("account")).Run()
-	o.ScriptFromFile("Staking/get_pending_rewards").Args(o.Arguments().UInt64(0).Account("user1")).Run()
-	o.ScriptFromFile("Staking/get_pending_rewards").Args(o.Arguments().UInt64(0).Account("user2")).Run()
-	o.ScriptFromFile("Staking/get_pending_rewards").Args(o.Arguments().UInt64(0).Account("user3")).Run()
+	c.StakingAdminUpdateMockTimestamp("account", ufix(100.0)).RunPrintEventsFull()
 
-	// #flow transactions send "./transactions/tick.cdc"
-	o.TransactionFromFile("tick").
-		SignProposeAndPayAs("account").
-		RunPrintEventsFull()
+	showPendingRewards("account", "user1", "user2", "user3")
 
-		// echo "should be 100 tokens shared between these two"
+	// #flow transactions send "./transactions/tick.cdc"
+	c.Tick("account").RunPrintEventsFull()
 
-	// flow scripts execute "./scripts/Staking/get_farm_meta.cdc" 0
-	o.ScriptFromFile("Staking/get_farm_meta").Args(o.Arguments().UInt64(0)).Run()
+	// echo "should be 100 tokens shared between these two"
+	showFarm()
 
 	// flow transactions send "./transactions/Staking/user/claim_rewards.cdc" 0 --signer "user-account1"
-	o.TransactionFromFile("Staking/user/claim_rewards").
-		SignProposeAndPayAs("user1").
-		Args(o.
-			Arguments().
-			UInt64(0)).
-		RunPrintEventsFull()
+	c.StakingUserClaimRewards("user1", 0).RunPrintEventsFull()
 
 	// # flow transactions send "./transactions/Staking/user/claim_rewards.cdc" 0 --signer "user-account2"
-	o.TransactionFromFile("Staking/user/claim_rewards").
-		SignProposeAndPayAs("user2").
-		Args(o.
-			Arguments().
-			UInt64(0)).
-		RunPrintEventsFull()
+	c.StakingUserClaimRewards("user2", 0).RunPrintEventsFull()
 
 	// flow transactions send "./transactions/Staking/user/claim_rewards.cdc" 0 --signer "user-account1"
-	o.TransactionFromFile("Staking/user/claim_rewards").
-		SignProposeAndPayAs("user1").
-		Args(o.
-			Arguments().
-			UInt64(0)).
-		RunPrintEventsFull()
+	c.StakingUserClaimRewards("user1", 0).RunPrintEventsFull()
 
 	// flow transactions send "./transactions/Staking/user/claim_rewards.cdc" 0 --signer "user-account2"
-	o.TransactionFromFile("Staking/user/claim_rewards").
-		SignProposeAndPayAs("user2").
-		Args(o.
-			Arguments().
-			UInt64(0)).
-		RunPrintEventsFull()
+	c.StakingUserClaimRewards("user2", 0).RunPrintEventsFull()
 
 	// # flow transactions send "./transactions/Staking/admin/create_reward_pool_fusd.cdc" /storage/fusdVault 0.1 --signer admin-account
 	// # flow transactions send "./transactions/Staking/admin/create_reward_pool_fusd.cdc" /storage/flowTokenVault 0.1 --signer admin-account
-	// flow transactions send "./transactions/Staking/admin/create_reward_pool_fusd.cdc" 100000.0 --signer admin-account
+	// flow transactions send "./transactions/Staking/admin/create_reward_pool_fusd.cdc" 100.0 --signer admin-account
 
 	// o.TransactionFromFile("FUSD/setup").SignProposeAndPayAs("user1").RunPrintEventsFull()
 	// o.TransactionFromFile("FUSD/setup").SignProposeAndPayAs("user2").RunPrintEventsFull()